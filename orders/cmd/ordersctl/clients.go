@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	"github.com/wathuta/technical_test/orders/internal/config"
+	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
+	ordersPb "github.com/wathuta/technical_test/protos_gen/orders"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	productsPb "github.com/wathuta/technical_test/protos_gen/products"
+	"google.golang.org/grpc"
+)
+
+// clients connects to the services the first time a command calls them.
+type clients struct {
+	cfg Config
+
+	orders  *grpc.ClientConn
+	payment *grpc.ClientConn
+}
+
+func (c *clients) dial(address string) (*grpc.ClientConn, error) {
+	transportCreds, err := config.ClientCredentials(c.cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(transportCreds)}
+	if c.cfg.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(auth.NewTokenCredentials(c.cfg.Token, c.cfg.TLS.ClientTLS())))
+	}
+	return grpc.Dial(address, opts...)
+}
+
+func (c *clients) ordersConn() (*grpc.ClientConn, error) {
+	if c.orders == nil {
+		conn, err := c.dial(c.cfg.OrdersAddress)
+		if err != nil {
+			return nil, err
+		}
+		c.orders = conn
+	}
+	return c.orders, nil
+}
+
+func (c *clients) Customers() (customersPb.CustomerServiceClient, error) {
+	conn, err := c.ordersConn()
+	if err != nil {
+		return nil, err
+	}
+	return customersPb.NewCustomerServiceClient(conn), nil
+}
+
+func (c *clients) Products() (productsPb.ProductServiceClient, error) {
+	conn, err := c.ordersConn()
+	if err != nil {
+		return nil, err
+	}
+	return productsPb.NewProductServiceClient(conn), nil
+}
+
+func (c *clients) Orders() (ordersPb.OrderServiceClient, error) {
+	conn, err := c.ordersConn()
+	if err != nil {
+		return nil, err
+	}
+	return ordersPb.NewOrderServiceClient(conn), nil
+}
+
+func (c *clients) Payments() (paymentpb.PaymentServiceClient, error) {
+	if c.payment == nil {
+		conn, err := c.dial(c.cfg.PaymentAddress)
+		if err != nil {
+			return nil, err
+		}
+		c.payment = conn
+	}
+	return paymentpb.NewPaymentServiceClient(c.payment), nil
+}
+
+func (c *clients) Close() {
+	for _, conn := range []*grpc.ClientConn{c.orders, c.payment} {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
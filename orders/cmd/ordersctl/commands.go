@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
+	ordersPb "github.com/wathuta/technical_test/protos_gen/orders"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	productsPb "github.com/wathuta/technical_test/protos_gen/products"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// env is what a command runs with.
+type env struct {
+	cfg     Config
+	clients *clients
+	out     *printer
+	// stdin is read by the commands taking a file named "-".
+	stdin io.Reader
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, e *env, args []string) error
+}
+
+// commands maps "<resource> <action>" to the command.
+var commands = map[string]command{
+	"customers create": {"-name NAME -email EMAIL -phone PHONE -address ADDRESS", createCustomer},
+	"customers get":    {"CUSTOMER_ID", getCustomer},
+	"customers list":   {"[-page-size N] [-page-token N]", listCustomers},
+	"products create":  {"-name NAME -sku SKU -category CATEGORY -brand BRAND -model MODEL -price MINOR_UNITS [-currency KES] -stock N", createProduct},
+	"products get":     {"PRODUCT_ID", getProduct},
+	"products list":    {"[-page-size N] [-page-token N]", listProducts},
+	"orders place":     {"-f REQUEST.json [-customer ID] [-product ID] [-quantity N] [-promo CODE]", placeOrder},
+	"orders get":       {"ORDER_ID", getOrder},
+	"orders cancel":    {"ORDER_ID", cancelOrder},
+	"payments get":     {"PAYMENT_ID | -order ORDER_ID", getPayment},
+	"callbacks replay": {"CALLBACK.json", replayCallback},
+}
+
+var errUsage = errors.New("invalid arguments")
+
+// parseFlags parses the flags of a command, the remaining arguments must be exactly nargs.
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() != nargs {
+		return fmt.Errorf("%w: expected %d arguments, got %d", errUsage, nargs, fs.NArg())
+	}
+	return nil
+}
+
+func createCustomer(ctx context.Context, e *env, args []string) error {
+	customer := &customersPb.Customer{}
+	fs := flag.NewFlagSet("customers create", flag.ContinueOnError)
+	fs.StringVar(&customer.Name, "name", "", "")
+	fs.StringVar(&customer.Email, "email", "", "")
+	fs.StringVar(&customer.PhoneNumber, "phone", "", "")
+	fs.StringVar(&customer.Address, "address", "", "")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	client, err := e.clients.Customers()
+	if err != nil {
+		return err
+	}
+	resp, err := client.CreateCustomer(ctx, &customersPb.CreateCustomerRequest{Customer: customer})
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, customersTable(resp.Customer))
+}
+
+func getCustomer(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("customers get", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	client, err := e.clients.Customers()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetCustomerById(ctx, &customersPb.GetCustomerByIdRequest{CustomerId: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, customersTable(resp.Customer))
+}
+
+func listCustomers(ctx context.Context, e *env, args []string) error {
+	req := &customersPb.ListCustomersRequest{}
+	fs := flag.NewFlagSet("customers list", flag.ContinueOnError)
+	pageFlags(fs, &req.PageSize, &req.PageToken)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	client, err := e.clients.Customers()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListCustomers(ctx, req)
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, withNextPage(customersTable(resp.Customers...), resp.NextPageToken))
+}
+
+func createProduct(ctx context.Context, e *env, args []string) error {
+	price := &moneypb.Money{}
+	product := &productsPb.Product{Attributes: &productsPb.ProductAttributes{Price: price}}
+	var category string
+	var stock int
+	fs := flag.NewFlagSet("products create", flag.ContinueOnError)
+	fs.StringVar(&product.Name, "name", "", "")
+	fs.StringVar(&product.Sku, "sku", "", "")
+	fs.StringVar(&category, "category", "", "")
+	fs.StringVar(&product.Attributes.Brand, "brand", "", "")
+	fs.StringVar(&product.Attributes.Model, "model", "", "")
+	fs.Int64Var(&price.Amount, "price", 0, "")
+	fs.StringVar(&price.CurrencyCode, "currency", "KES", "")
+	fs.IntVar(&stock, "stock", 0, "")
+	fs.BoolVar(&product.IsAvailable, "available", true, "")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	value, ok := productsPb.ProductCategory_value[strings.ToUpper(category)]
+	if !ok {
+		return fmt.Errorf("%w: unknown category %q", errUsage, category)
+	}
+	product.Category = productsPb.ProductCategory(value)
+	product.StockQuantity = int32(stock)
+
+	client, err := e.clients.Products()
+	if err != nil {
+		return err
+	}
+	resp, err := client.CreateProduct(ctx, &productsPb.CreateProductRequest{Product: product})
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, productsTable(resp.Product))
+}
+
+func getProduct(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("products get", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	client, err := e.clients.Products()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetProductById(ctx, &productsPb.GetProductByIdRequest{ProductId: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, productsTable(resp.Product))
+}
+
+func listProducts(ctx context.Context, e *env, args []string) error {
+	req := &productsPb.ListProductsRequest{}
+	fs := flag.NewFlagSet("products list", flag.ContinueOnError)
+	pageFlags(fs, &req.PageSize, &req.PageToken)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	client, err := e.clients.Products()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListProducts(ctx, req)
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, withNextPage(productsTable(resp.Products...), resp.NextPageToken))
+}
+
+// placeOrder creates the order in a CreateOrderRequest written as JSON, the flags override the
+// fields in the file so a template can be reused.
+func placeOrder(ctx context.Context, e *env, args []string) error {
+	var file, customerId, productId, promoCode string
+	var quantity int
+	fs := flag.NewFlagSet("orders place", flag.ContinueOnError)
+	fs.StringVar(&file, "f", "", "")
+	fs.StringVar(&customerId, "customer", "", "")
+	fs.StringVar(&productId, "product", "", "")
+	fs.IntVar(&quantity, "quantity", 0, "")
+	fs.StringVar(&promoCode, "promo", "", "")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if file == "" {
+		return fmt.Errorf("%w: -f is required", errUsage)
+	}
+	content, err := e.readFile(file)
+	if err != nil {
+		return err
+	}
+	req := &ordersPb.CreateOrderRequest{}
+	if err := protojson.Unmarshal(content, req); err != nil {
+		return fmt.Errorf("invalid order request in %s: %w", file, err)
+	}
+	if customerId != "" {
+		req.CustomerId = customerId
+	}
+	if productId != "" {
+		req.ProductId = productId
+	}
+	if quantity > 0 {
+		req.ProductQuantity = int32(quantity)
+	}
+	if promoCode != "" {
+		req.PromoCode = promoCode
+	}
+
+	client, err := e.clients.Orders()
+	if err != nil {
+		return err
+	}
+	resp, err := client.CreateOrder(ctx, req)
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, ordersTable(resp.Order))
+}
+
+func getOrder(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("orders get", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	client, err := e.clients.Orders()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetOrderById(ctx, &ordersPb.GetOrderRequest{OrderId: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, ordersTable(resp.Order))
+}
+
+func cancelOrder(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("orders cancel", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	client, err := e.clients.Orders()
+	if err != nil {
+		return err
+	}
+	// updates are only applied to the version of the order they were read from
+	current, err := client.GetOrderById(ctx, &ordersPb.GetOrderRequest{OrderId: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	resp, err := client.UpdateOrder(ctx, &ordersPb.UpdateOrderRequest{
+		Order: &ordersPb.Order{
+			OrderId:     fs.Arg(0),
+			OrderStatus: ordersPb.OrderStatus_ORDER_STATUS_CANCELLED,
+			Version:     current.Order.Version,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"order_status"}},
+	})
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, ordersTable(resp.Order))
+}
+
+func getPayment(ctx context.Context, e *env, args []string) error {
+	var orderId string
+	fs := flag.NewFlagSet("payments get", flag.ContinueOnError)
+	fs.StringVar(&orderId, "order", "", "")
+	nargs := 1
+	if hasFlag(args, "order") {
+		nargs = 0
+	}
+	if err := parseFlags(fs, args, nargs); err != nil {
+		return err
+	}
+	client, err := e.clients.Payments()
+	if err != nil {
+		return err
+	}
+	if orderId != "" {
+		resp, err := client.GetPaymentByOrderId(ctx, &paymentpb.GetPaymentByOrderIdRequest{OrderId: orderId})
+		if err != nil {
+			return err
+		}
+		return e.out.print(resp, paymentsTable(resp.Payment))
+	}
+	resp, err := client.GetPaymentById(ctx, &paymentpb.GetPaymentByIdRequest{Id: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return e.out.print(resp, paymentsTable(resp.Payment))
+}
+
+// replayCallback sends a saved M-Pesa callback to the payment service again, e.g. one that
+// failed while the service was down.
+func replayCallback(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("callbacks replay", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	content, err := e.readFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.CallbackURL, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("callback answered with %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	result := &structpb.Struct{}
+	if err := protojson.Unmarshal(body, result); err != nil {
+		result = nil
+	}
+	return e.out.print(result, table{
+		header: []string{"STATUS", "RESPONSE"},
+		rows:   [][]string{{resp.Status, string(bytes.TrimSpace(body))}},
+	})
+}
+
+func (e *env) readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func pageFlags(fs *flag.FlagSet, pageSize, pageToken *int32) {
+	fs.Func("page-size", "", func(s string) error { return parseInt32(s, pageSize) })
+	fs.Func("page-token", "", func(s string) error { return parseInt32(s, pageToken) })
+}
+
+func parseInt32(s string, v *int32) error {
+	var n int32
+	if _, err := fmt.Sscan(s, &n); err != nil {
+		return err
+	}
+	*v = n
+	return nil
+}
+
+// withNextPage adds the token of the next page under the table.
+func withNextPage(t table, nextPageToken int32) table {
+	if nextPageToken != 0 {
+		t.rows = append(t.rows, nil, []string{fmt.Sprintf("next page: -page-token %d", nextPageToken)})
+	}
+	return t
+}
+
+// hasFlag reports whether args set the flag name.
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		arg = strings.TrimLeft(arg, "-")
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wathuta/technical_test/orders/internal/config"
+)
+
+// The settings of ordersctl are read from these env variables, all of them are optional.
+const (
+	OrdersAddressEnvVar  = "ORDERSCTL_ORDERS_ADDRESS"
+	PaymentAddressEnvVar = "ORDERSCTL_PAYMENT_ADDRESS"
+	CallbackURLEnvVar    = "ORDERSCTL_CALLBACK_URL"
+	// TokenEnvVar is the access token sent with every call, operators need the staff or admin role.
+	TokenEnvVar   = "ORDERSCTL_TOKEN"
+	TimeoutEnvVar = "ORDERSCTL_TIMEOUT"
+	OutputEnvVar  = "ORDERSCTL_OUTPUT"
+	// TLS is used when the CA file is set, the certificate and key are sent when the services
+	// require client certificates.
+	TLSCAFileEnvVar   = "ORDERSCTL_TLS_CA_FILE"
+	TLSCertFileEnvVar = "ORDERSCTL_TLS_CERT_FILE"
+	TLSKeyFileEnvVar  = "ORDERSCTL_TLS_KEY_FILE"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// Config is where ordersctl finds the services and how it calls them.
+type Config struct {
+	OrdersAddress  string
+	PaymentAddress string
+	// CallbackURL is the M-Pesa callback endpoint of the payment service callbacks are replayed to.
+	CallbackURL string
+	Token       string
+	TLS         config.TLSFiles
+	// Timeout bounds every command.
+	Timeout time.Duration
+	// Output is either "table" or "json".
+	Output string
+}
+
+func configFromEnv() (Config, error) {
+	cfg := Config{
+		OrdersAddress:  envOr(OrdersAddressEnvVar, "localhost:5000"),
+		PaymentAddress: envOr(PaymentAddressEnvVar, "localhost:5001"),
+		CallbackURL:    envOr(CallbackURLEnvVar, "http://localhost:5002/callback"),
+		Token:          os.Getenv(TokenEnvVar),
+		TLS: config.TLSFiles{
+			CertFile: os.Getenv(TLSCertFileEnvVar),
+			KeyFile:  os.Getenv(TLSKeyFileEnvVar),
+			CAFile:   os.Getenv(TLSCAFileEnvVar),
+		},
+		Output: envOr(OutputEnvVar, outputTable),
+	}
+	var err error
+	if cfg.Timeout, err = config.DurationFromEnv(TimeoutEnvVar, 10*time.Second); err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.validate()
+}
+
+func (c Config) validate() error {
+	if c.Output != outputTable && c.Output != outputJSON {
+		return fmt.Errorf("unknown output %q, use %s or %s", c.Output, outputTable, outputJSON)
+	}
+	return c.TLS.Validate()
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
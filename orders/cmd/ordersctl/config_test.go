@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	cfg, err := configFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:5000", cfg.OrdersAddress)
+	assert.Equal(t, "localhost:5001", cfg.PaymentAddress)
+	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, outputTable, cfg.Output)
+
+	t.Setenv(OrdersAddressEnvVar, "orders:443")
+	t.Setenv(TimeoutEnvVar, "3s")
+	t.Setenv(OutputEnvVar, outputJSON)
+	cfg, err = configFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "orders:443", cfg.OrdersAddress)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+	assert.Equal(t, outputJSON, cfg.Output)
+
+	t.Setenv(OutputEnvVar, "yaml")
+	_, err = configFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv(OutputEnvVar, "")
+	t.Setenv(TLSCertFileEnvVar, "client.crt")
+	_, err = configFromEnv()
+	assert.Error(t, err, "a certificate without a key is rejected")
+}
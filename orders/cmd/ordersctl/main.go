@@ -0,0 +1,89 @@
+// ordersctl is a command line client of the orders and payment services for operators. It reads
+// where the services are and the access token from ORDERSCTL_* env variables, see config.go.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/status"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command in args and returns the exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := configFromEnv()
+	if err != nil {
+		fmt.Fprintln(stderr, "invalid config:", err)
+		return 2
+	}
+	fs := flag.NewFlagSet("ordersctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(stderr) }
+	fs.StringVar(&cfg.Output, "o", cfg.Output, "output format, table or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(stderr, "invalid config:", err)
+		return 2
+	}
+	if fs.NArg() < 2 {
+		usage(stderr)
+		return 2
+	}
+	name := fs.Arg(0) + " " + fs.Arg(1)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		usage(stderr)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	clients := &clients{cfg: cfg}
+	defer clients.Close()
+	e := &env{cfg: cfg, clients: clients, out: &printer{format: cfg.Output, w: stdout}, stdin: stdin}
+	if err := cmd.run(ctx, e, fs.Args()[2:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "%v\nusage: ordersctl %s %s\n", err, name, cmd.usage)
+			return 2
+		}
+		fmt.Fprintln(stderr, "error:", errorMessage(err))
+		return 1
+	}
+	return 0
+}
+
+// errorMessage returns the code and message of grpc errors, other errors as they are.
+func errorMessage(err error) string {
+	if s, ok := status.FromError(err); ok {
+		return s.Code().String() + ": " + s.Message()
+	}
+	return err.Error()
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("usage: ordersctl [-o table|json] <resource> <action> [arguments]\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprint(w, b.String())
+}
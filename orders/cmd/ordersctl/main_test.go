@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
+	ordersPb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeOrders records the last request of the orders service methods the tests use.
+type fakeOrders struct {
+	customersPb.UnimplementedCustomerServiceServer
+	ordersPb.UnimplementedOrderServiceServer
+
+	authorization []string
+	createOrder   *ordersPb.CreateOrderRequest
+	updateOrder   *ordersPb.UpdateOrderRequest
+}
+
+func (f *fakeOrders) GetCustomerById(ctx context.Context, req *customersPb.GetCustomerByIdRequest) (*customersPb.GetCustomerByIdResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.authorization = md.Get("authorization")
+	if req.CustomerId != "c1" {
+		return nil, status.Error(codes.NotFound, "resource not found")
+	}
+	return &customersPb.GetCustomerByIdResponse{Customer: &customersPb.Customer{CustomerId: "c1", Name: "Jane Doe", Email: "jane@example.com"}}, nil
+}
+
+func (f *fakeOrders) ListCustomers(ctx context.Context, req *customersPb.ListCustomersRequest) (*customersPb.ListCustomersResponse, error) {
+	return &customersPb.ListCustomersResponse{
+		Customers:     []*customersPb.Customer{{CustomerId: "c1", Name: "Jane Doe"}, {CustomerId: "c2", Name: "John Doe"}},
+		NextPageToken: req.PageToken + req.PageSize,
+	}, nil
+}
+
+func (f *fakeOrders) CreateOrder(ctx context.Context, req *ordersPb.CreateOrderRequest) (*ordersPb.CreateOrderResponse, error) {
+	f.createOrder = req
+	return &ordersPb.CreateOrderResponse{Order: &ordersPb.Order{OrderId: "o1", CustomerId: req.CustomerId}}, nil
+}
+
+func (f *fakeOrders) GetOrderById(ctx context.Context, req *ordersPb.GetOrderRequest) (*ordersPb.GetOrderResponse, error) {
+	return &ordersPb.GetOrderResponse{Order: &ordersPb.Order{OrderId: req.OrderId, Version: 4}}, nil
+}
+
+func (f *fakeOrders) UpdateOrder(ctx context.Context, req *ordersPb.UpdateOrderRequest) (*ordersPb.UpdateOrderResponse, error) {
+	f.updateOrder = req
+	return &ordersPb.UpdateOrderResponse{Order: req.Order}, nil
+}
+
+// runCtl serves fake as the orders service and runs ordersctl with args.
+func runCtl(t *testing.T, fake *fakeOrders, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	customersPb.RegisterCustomerServiceServer(srv, fake)
+	ordersPb.RegisterOrderServiceServer(srv, fake)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	t.Setenv(OrdersAddressEnvVar, listener.Addr().String())
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Table(t *testing.T) {
+	t.Setenv(TokenEnvVar, "operator-token")
+	fake := &fakeOrders{}
+	code, stdout, stderr := runCtl(t, fake, "", "customers", "get", "c1")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, []string{"Bearer operator-token"}, fake.authorization)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"ID", "NAME", "EMAIL", "PHONE", "CREATED"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"c1", "Jane", "Doe", "jane@example.com"}, strings.Fields(lines[1]))
+
+	code, stdout, _ = runCtl(t, fake, "", "customers", "list", "-page-size", "2")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "John Doe")
+	assert.Contains(t, stdout, "next page: -page-token 2")
+}
+
+func TestRun_JSON(t *testing.T) {
+	code, stdout, stderr := runCtl(t, &fakeOrders{}, "", "-o", "json", "customers", "get", "c1")
+	require.Equal(t, 0, code, stderr)
+	assert.JSONEq(t, `{"customer":{"customerId":"c1","name":"Jane Doe","email":"jane@example.com"}}`, stdout)
+}
+
+func TestRun_Errors(t *testing.T) {
+	code, _, stderr := runCtl(t, &fakeOrders{}, "", "customers", "get", "missing")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error: NotFound: resource not found\n", stderr)
+
+	code, _, stderr = runCtl(t, &fakeOrders{}, "", "customers", "get")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: ordersctl customers get CUSTOMER_ID")
+
+	code, _, stderr = runCtl(t, &fakeOrders{}, "", "customers", "delete", "c1")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "customers delete"`)
+}
+
+func TestRun_PlaceOrder(t *testing.T) {
+	fake := &fakeOrders{}
+	file := filepath.Join(t.TempDir(), "order.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"customerId":"c1","productId":"p1","productQuantity":1,"shippingMethod":"standard"}`), 0o600))
+
+	code, stdout, stderr := runCtl(t, fake, "", "orders", "place", "-f", file, "-customer", "c2", "-quantity", "3")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "o1")
+	assert.True(t, proto.Equal(&ordersPb.CreateOrderRequest{
+		CustomerId: "c2", ProductId: "p1", ProductQuantity: 3, ShippingMethod: "standard",
+	}, fake.createOrder), fake.createOrder.String())
+
+	// the request can be piped in
+	code, _, stderr = runCtl(t, fake, `{"customerId":"c3"}`, "orders", "place", "-f", "-")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "c3", fake.createOrder.CustomerId)
+}
+
+func TestRun_CancelOrder(t *testing.T) {
+	fake := &fakeOrders{}
+	code, _, stderr := runCtl(t, fake, "", "orders", "cancel", "o1")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "o1", fake.updateOrder.Order.OrderId)
+	assert.Equal(t, ordersPb.OrderStatus_ORDER_STATUS_CANCELLED, fake.updateOrder.Order.OrderStatus)
+	assert.Equal(t, []string{"order_status"}, fake.updateOrder.UpdateMask.Paths)
+	assert.Equal(t, int64(4), fake.updateOrder.Order.Version, "the version read is sent")
+}
+
+func TestRun_ReplayCallback(t *testing.T) {
+	var received []byte
+	status := http.StatusOK
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"ResultCode":0,"ResultDesc":"Accepted"}`))
+	}))
+	defer callback.Close()
+	t.Setenv(CallbackURLEnvVar, callback.URL)
+
+	body := `{"Body":{"stkCallback":{"CheckoutRequestID":"ws_CO_1","ResultCode":0}}}`
+	code, stdout, stderr := runCtl(t, &fakeOrders{}, body, "callbacks", "replay", "-")
+	require.Equal(t, 0, code, stderr)
+	assert.JSONEq(t, body, string(received))
+	assert.Contains(t, stdout, "200 OK")
+
+	status = http.StatusInternalServerError
+	code, _, stderr = runCtl(t, &fakeOrders{}, body, "callbacks", "replay", "-")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "callback answered with 500 Internal Server Error")
+}
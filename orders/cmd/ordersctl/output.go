@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
+	ordersPb "github.com/wathuta/technical_test/protos_gen/orders"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	productsPb "github.com/wathuta/technical_test/protos_gen/products"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// table is the rows a result is printed as in the table output.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// printer writes the results of the commands in the output format of the config.
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+// print writes msg as JSON, or t as a table.
+func (p *printer) print(msg proto.Message, t table) error {
+	if p.format == outputJSON {
+		out, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.w, string(out))
+		return err
+	}
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func customersTable(customers ...*customersPb.Customer) table {
+	t := table{header: []string{"ID", "NAME", "EMAIL", "PHONE", "CREATED"}}
+	for _, c := range customers {
+		t.rows = append(t.rows, []string{c.CustomerId, c.Name, c.Email, c.PhoneNumber, formatTime(c.CreatedAt)})
+	}
+	return t
+}
+
+func productsTable(products ...*productsPb.Product) table {
+	t := table{header: []string{"ID", "NAME", "SKU", "CATEGORY", "PRICE", "STOCK", "AVAILABLE"}}
+	for _, p := range products {
+		t.rows = append(t.rows, []string{
+			p.ProductId, p.Name, p.Sku, p.Category.String(), formatMoney(p.GetAttributes().GetPrice()),
+			strconv.Itoa(int(p.StockQuantity)), strconv.FormatBool(p.IsAvailable),
+		})
+	}
+	return t
+}
+
+func ordersTable(orders ...*ordersPb.Order) table {
+	t := table{header: []string{"ID", "CUSTOMER", "STATUS", "TOTAL", "INVOICE", "CREATED"}}
+	for _, o := range orders {
+		t.rows = append(t.rows, []string{
+			o.OrderId, o.CustomerId, o.OrderStatus.String(), formatMoney(o.GrandTotal), o.InvoiceNumber, formatTime(o.CreatedAt),
+		})
+	}
+	return t
+}
+
+func paymentsTable(payments ...*paymentpb.Payment) table {
+	t := table{header: []string{"ID", "ORDER", "STATUS", "AMOUNT", "RECEIPT", "CREATED"}}
+	for _, p := range payments {
+		t.rows = append(t.rows, []string{
+			p.Id, p.OrderId, p.Status.String(), formatMoney(p.Amount), p.MpesaReceiptNumber, formatTime(p.CreatedAt),
+		})
+	}
+	return t
+}
+
+func formatMoney(m *moneypb.Money) string {
+	if m == nil {
+		return ""
+	}
+	return model.MoneyFromProto(m).String()
+}
+
+func formatTime(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+	return t.AsTime().Format(time.RFC3339)
+}
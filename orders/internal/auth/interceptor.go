@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUnauthenticated  = status.Error(codes.Unauthenticated, "missing or invalid access token")
+	errPermissionDenied = status.Error(codes.PermissionDenied, "permission denied")
+)
+
+// Permission declares who may call an RPC.
+type Permission struct {
+	// Public methods are served without a token.
+	Public bool
+	// Roles that may call the method.
+	Roles []Role
+}
+
+// Allow returns a permission for callers with any of roles.
+func Allow(roles ...Role) Permission {
+	return Permission{Roles: roles}
+}
+
+// Public returns a permission for a method that does not need a token.
+func Public() Permission {
+	return Permission{Public: true}
+}
+
+func (p Permission) allows(principal *Principal) bool {
+	for _, role := range p.Roles {
+		if principal.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy maps full method names such as "/orders.OrderService/CreateOrder" to their permission.
+// Methods that are not in the policy are denied.
+type Policy map[string]Permission
+
+// authorize authenticates the token in the incoming metadata and checks it against the
+// permission of the method. It returns the context handlers run with.
+func (a *Authenticator) authorize(ctx context.Context, policy Policy, fullMethod string) (context.Context, error) {
+	logger := logging.FromContext(ctx)
+	permission, ok := policy[fullMethod]
+	if !ok {
+		logger.Error("no permission declared for method", "method", fullMethod)
+		return nil, errPermissionDenied
+	}
+	if permission.Public {
+		return ctx, nil
+	}
+
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+	principal, err := a.Authenticate(token)
+	if err != nil {
+		logger.Error("failed to authenticate request", "method", fullMethod, "error", err)
+		return nil, errUnauthenticated
+	}
+	if !permission.allows(principal) {
+		logger.Error("caller does not have permission for method", "method", fullMethod, "subject", principal.Subject, "roles", principal.Roles)
+		return nil, errPermissionDenied
+	}
+	return ContextWithPrincipal(ctx, principal), nil
+}
+
+// UnaryServerInterceptor authenticates and authorizes unary RPCs against the policy.
+func UnaryServerInterceptor(a *Authenticator, policy Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, policy, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates and authorizes streaming RPCs against the policy.
+func StreamServerInterceptor(a *Authenticator, policy Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), policy, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorizedStream carries the principal in the context of a stream.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, value := range md.Get("authorization") {
+		scheme, token, found := strings.Cut(value, " ")
+		if found && strings.EqualFold(scheme, "bearer") && len(strings.TrimSpace(token)) > 0 {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testPolicy = Policy{
+	"/test.Service/Public": Public(),
+	"/test.Service/Staff":  Allow(RoleStaff, RoleAdmin),
+}
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	keys := newTestKeys(t)
+	interceptor := UnaryServerInterceptor(keys.authenticator(t), testPolicy)
+
+	var principal *Principal
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		principal, _ = PrincipalFromContext(ctx)
+		return "ok", nil
+	}
+	call := func(ctx context.Context, method string) error {
+		principal = nil
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	staffToken := keys.sign(t, jwt.SigningMethodEdDSA, "ed", testClaims("staff"))
+	require.NoError(t, call(withToken(staffToken), "/test.Service/Staff"))
+	require.NotNil(t, principal)
+	assert.Equal(t, []Role{RoleStaff}, principal.Roles)
+
+	// public methods do not need a token
+	require.NoError(t, call(context.Background(), "/test.Service/Public"))
+	assert.Nil(t, principal)
+
+	err := call(context.Background(), "/test.Service/Staff")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	err = call(withToken("not-a-token"), "/test.Service/Staff")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	customerToken := keys.sign(t, jwt.SigningMethodEdDSA, "ed", testClaims("customer"))
+	err = call(withToken(customerToken), "/test.Service/Staff")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// methods without a declared permission are denied
+	err = call(withToken(staffToken), "/test.Service/Undeclared")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	keys := newTestKeys(t)
+	interceptor := StreamServerInterceptor(keys.authenticator(t), testPolicy)
+
+	var principal *Principal
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		principal, _ = PrincipalFromContext(stream.Context())
+		return nil
+	}
+
+	token := keys.sign(t, jwt.SigningMethodEdDSA, "ed", testClaims("admin"))
+	err := interceptor(nil, &testServerStream{ctx: withToken(token)}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Staff"}, handler)
+	require.NoError(t, err)
+	require.NotNil(t, principal)
+	assert.True(t, principal.HasRole(RoleAdmin))
+
+	err = interceptor(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Staff"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTokenCredentials(t *testing.T) {
+	md, err := NewTokenCredentials("token", false).GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", md["authorization"])
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+var ErrUnknownKey = errors.New("token is not signed by a key in the keyset")
+
+// jwk is a public key in the JSON Web Key format (RFC 7517).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Keyset holds the public keys tokens may be signed with, indexed by key id.
+type Keyset struct {
+	keys map[string]crypto.PublicKey
+}
+
+// LoadKeyset reads a JWK set from a local file.
+func LoadKeyset(path string) (*Keyset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyset(data)
+}
+
+// ParseKeyset parses a JWK set of RSA, P-256 EC or Ed25519 public keys.
+func ParseKeyset(data []byte) (*Keyset, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid keyset: %w", err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, errors.New("invalid keyset: no keys")
+	}
+
+	keyset := &Keyset{keys: make(map[string]crypto.PublicKey, len(set.Keys))}
+	for _, key := range set.Keys {
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", key.Kid, err)
+		}
+		if _, ok := keyset.keys[key.Kid]; ok {
+			return nil, fmt.Errorf("invalid keyset: duplicate key id %q", key.Kid)
+		}
+		keyset.keys[key.Kid] = publicKey
+	}
+	return keyset, nil
+}
+
+// Key returns the key with the given id. A token without a key id can only be verified
+// when the keyset has a single key.
+func (k *Keyset) Key(kid string) (crypto.PublicKey, error) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, nil
+		}
+	}
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("missing key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
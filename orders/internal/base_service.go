@@ -2,21 +2,32 @@ package internal
 
 import (
 	"context"
-	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	"github.com/wathuta/technical_test/orders/internal/carriers"
 	"github.com/wathuta/technical_test/orders/internal/config"
+	"github.com/wathuta/technical_test/orders/internal/gateway"
+	grpcclients "github.com/wathuta/technical_test/orders/internal/grpc_clients"
 	paymentclient "github.com/wathuta/technical_test/orders/internal/grpc_clients/payment_client"
 	handler "github.com/wathuta/technical_test/orders/internal/handler"
+	"github.com/wathuta/technical_test/orders/internal/healthcheck"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/metrics"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
 
 	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
 	ordersPb "github.com/wathuta/technical_test/protos_gen/orders"
 	prductsPb "github.com/wathuta/technical_test/protos_gen/products"
 	"golang.org/x/exp/slog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	"github.com/wathuta/technical_test/orders/internal/repository"
 )
@@ -25,11 +36,30 @@ type Service struct {
 	grpcSrv                 *grpc.Server
 	GracefulShutdownTimeout time.Duration
 
+	// the JSON gateway is nil when it is not enabled
+	gatewaySrv  *http.Server
+	gatewayConn *grpc.ClientConn
+	// the carrier webhook is nil when it is not enabled
+	webhookSrv *http.Server
+
+	health     *healthcheck.Monitor
+	stopHealth context.CancelFunc
+	healthDone chan struct{}
+	metricsSrv *http.Server
+
 	db *sqlx.DB
 }
 type Options struct {
 	ListenAddress           string
+	MetricsListenAddress    string
 	GracefulShutdownTimeout time.Duration
+	// Reflection registers grpc server reflection so tools such as grpcurl can list the services.
+	Reflection bool
+	// GatewayListenAddress serves the services as JSON over HTTP when set.
+	GatewayListenAddress string
+	// WebhookListenAddress serves the webhook of CarrierAdapters when set.
+	WebhookListenAddress string
+	CarrierAdapters      []carriers.CarrierAdapter
 }
 
 func NewService(ctx context.Context, db *sqlx.DB, opts Options) (*Service, error) {
@@ -38,56 +68,249 @@ func NewService(ctx context.Context, db *sqlx.DB, opts Options) (*Service, error
 		return nil, err
 	}
 
-	// Set up gRPC server
-	grpcSrv := grpc.NewServer(grpc.EmptyServerOption{})
+	// every RPC is authenticated with a token signed by a key in the local keyset
+	keyset, err := auth.LoadKeyset(os.Getenv(config.AuthKeysetFileEnvVar))
+	if err != nil {
+		return nil, err
+	}
+	authenticator := auth.NewAuthenticator(keyset, os.Getenv(config.AuthIssuerEnvVar), os.Getenv(config.AuthAudienceEnvVar))
+
+	// TLS is optional, the certificate files are reloaded when they change
+	tlsFiles := config.TLSFilesFromEnv()
+	serverCreds, err := config.ServerCredentials(tlsFiles)
 	if err != nil {
 		return nil, err
 	}
+	clientCreds, err := config.ClientCredentials(tlsFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up gRPC server
+	grpcSrv := grpc.NewServer(
+		grpc.Creds(serverCreds),
+		grpc.ChainUnaryInterceptor(
+			tracing.UnaryServerInterceptor(),
+			logging.UnaryServerInterceptor(),
+			metrics.UnaryServerInterceptor(),
+			auth.UnaryServerInterceptor(authenticator, handler.Permissions()),
+		),
+		grpc.ChainStreamInterceptor(
+			tracing.StreamServerInterceptor(),
+			logging.StreamServerInterceptor(),
+			metrics.StreamServerInterceptor(),
+			auth.StreamServerInterceptor(authenticator, handler.Permissions()),
+		),
+	)
+	if err := metrics.RegisterDB(db.DB, "orders"); err != nil {
+		return nil, err
+	}
 
 	repo := repository.NewRepository(db)
-	clients, err := paymentclient.NewPaymentClient(os.Getenv(config.PaymentServiceListenAddressEnvVar))
+	// creating a payment waits for the Daraja calls, the deadline is passed on to them
+	paymentOpts, err := grpcclients.OptionsFromEnv(grpcclients.EnvVars{
+		Timeout:            config.PaymentServiceTimeoutEnvVar,
+		MaxAttempts:        config.PaymentServiceMaxAttemptsEnvVar,
+		RetryBackoff:       config.PaymentServiceRetryBackoffEnvVar,
+		BreakerThreshold:   config.PaymentServiceBreakerThresholdEnvVar,
+		BreakerCooldown:    config.PaymentServiceBreakerCooldownEnvVar,
+		MaxConcurrentCalls: config.PaymentServiceMaxConcurrentCallsEnvVar,
+	}, grpcclients.DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	clients, err := paymentclient.NewPaymentClient(
+		os.Getenv(config.PaymentServiceListenAddressEnvVar),
+		clientCreds,
+		auth.NewTokenCredentials(os.Getenv(config.ServiceTokenEnvVar), tlsFiles.ClientTLS()),
+		paymentOpts,
+	)
 	if err != nil {
 		return nil, err
 	}
 	handler := handler.New(repo, clients)
 
 	ordersPb.RegisterOrderServiceServer(grpcSrv, handler)
+	ordersPb.RegisterPromotionServiceServer(grpcSrv, handler)
+	ordersPb.RegisterShipmentServiceServer(grpcSrv, handler)
 	customersPb.RegisterCustomerServiceServer(grpcSrv, handler)
 	prductsPb.RegisterProductServiceServer(grpcSrv, handler)
 
+	if opts.Reflection {
+		reflection.Register(grpcSrv)
+	}
+
+	var webhookSrv *http.Server
+	if opts.WebhookListenAddress != "" {
+		webhookSrv = &http.Server{
+			Addr:              opts.WebhookListenAddress,
+			Handler:           carriers.NewWebhookHandler(handler, opts.CarrierAdapters...),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+
+	var gatewaySrv *http.Server
+	var gatewayConn *grpc.ClientConn
+	if opts.GatewayListenAddress != "" {
+		if gatewaySrv, gatewayConn, err = newGatewayServer(ctx, opts, tlsFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	// the health of every service follows the database and, for the services calling it, the
+	// payment service. Only the status of the payment server as a whole is checked so the services
+	// do not take each other out of rotation.
+	healthSrv := health.NewServer()
+	monitor := healthcheck.NewMonitor(healthSrv, healthcheck.DefaultInterval, healthcheck.DefaultTimeout)
+	monitor.AddCheck("postgres", db.PingContext)
+	monitor.AddCheck("payment", clients.CheckHealth)
+	monitor.AddService("", "postgres")
+	monitor.AddService(ordersPb.OrderService_ServiceDesc.ServiceName, "postgres", "payment")
+	monitor.AddService(ordersPb.PromotionService_ServiceDesc.ServiceName, "postgres")
+	monitor.AddService(ordersPb.ShipmentService_ServiceDesc.ServiceName, "postgres")
+	monitor.AddService(customersPb.CustomerService_ServiceDesc.ServiceName, "postgres")
+	monitor.AddService(prductsPb.ProductService_ServiceDesc.ServiceName, "postgres")
+	healthpb.RegisterHealthServer(grpcSrv, healthSrv)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	healthDone := make(chan struct{})
 	go func() {
-		fmt.Println("GRPC Server is running on:", listener.Addr())
+		defer close(healthDone)
+		monitor.Run(healthCtx)
+	}()
+
+	metricsSrv := &http.Server{
+		Addr:              opts.MetricsListenAddress,
+		Handler:           metrics.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		slog.Info("metrics server is running", "address", opts.MetricsListenAddress)
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+	if gatewaySrv != nil {
+		go func() {
+			slog.Info("gateway server is running", "address", opts.GatewayListenAddress)
+			if err := gatewaySrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("gateway server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	if webhookSrv != nil {
+		go func() {
+			slog.Info("carrier webhook server is running", "address", opts.WebhookListenAddress, "carriers", len(opts.CarrierAdapters))
+			if err := webhookSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("carrier webhook server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	go func() {
+		slog.Info("grpc server is running", "address", listener.Addr().String())
 		if err := grpcSrv.Serve(listener); err != nil {
-			slog.Error("error", err)
+			slog.Error("grpc server failed", "error", err)
 			os.Exit(1)
 		}
 	}()
 
-	return &Service{db: db, grpcSrv: grpcSrv}, nil
+	return &Service{
+		db:                      db,
+		grpcSrv:                 grpcSrv,
+		GracefulShutdownTimeout: opts.GracefulShutdownTimeout,
+		gatewaySrv:              gatewaySrv,
+		gatewayConn:             gatewayConn,
+		webhookSrv:              webhookSrv,
+		health:                  monitor,
+		stopHealth:              stopHealth,
+		healthDone:              healthDone,
+		metricsSrv:              metricsSrv,
+	}, nil
+}
+
+// newGatewayServer returns the server of the JSON gateway and its connection to the grpc server.
+func newGatewayServer(ctx context.Context, opts Options, tlsFiles config.TLSFiles) (*http.Server, *grpc.ClientConn, error) {
+	// the gateway dials the grpc server over TLS whenever it is served over TLS
+	loopbackCreds, err := config.LoopbackCredentials(tlsFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := gateway.Dial(opts.ListenAddress, loopbackCreds)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := gateway.NewHandler(ctx, conn,
+		ordersPb.RegisterOrderServiceHandler,
+		ordersPb.RegisterPromotionServiceHandler,
+		ordersPb.RegisterShipmentServiceHandler,
+		customersPb.RegisterCustomerServiceHandler,
+		prductsPb.RegisterProductServiceHandler,
+	)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return &http.Server{
+		Addr:              opts.GatewayListenAddress,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}, conn, nil
 }
 
+// Shutdown lets pending requests finish, for GracefulShutdownTimeout at most, before closing the
+// database pool. It reports whether they all finished in time.
 func (s *Service) Shutdown() bool {
-	c := make(chan struct{})
+	// report NOT_SERVING first so load balancers stop sending requests while pending ones finish
+	s.health.Shutdown()
+	s.stopHealth()
+	// metrics stay available while the servers drain
+	defer s.metricsSrv.Close()
 
-	go func() {
-		defer close(c)
+	ctx, cancel := context.WithTimeout(context.Background(), s.GracefulShutdownTimeout)
+	defer cancel()
 
+	// the gateway stops first, its requests are made to the grpc server
+	completed := shutdownHTTP(ctx, s.gatewaySrv, "gateway")
+	// the webhook records events with the database, it has to stop before it is closed
+	completed = shutdownHTTP(ctx, s.webhookSrv, "carrier webhook") && completed
+	grpcDone := make(chan struct{})
+	go func() {
+		defer close(grpcDone)
 		// Block until all pending RPCs are finished
 		s.grpcSrv.GracefulStop()
 	}()
-
 	select {
-	case <-time.After(s.GracefulShutdownTimeout):
-		// Timeout
+	case <-grpcDone:
+	case <-ctx.Done():
 		s.grpcSrv.Stop()
-		<-c
-		return false
+		<-grpcDone
+		completed = false
+	}
+	if s.gatewayConn != nil {
+		s.gatewayConn.Close()
+	}
+	<-s.healthDone
+
+	// the handlers are done with the database once the server has stopped
+	s.Close()
+	return completed
+}
 
-	case <-c:
-		// Shutdown completed within the timeout
+// shutdownHTTP shuts srv down, closing it when its requests do not finish before ctx is done.
+// It reports whether they did, servers that are not enabled are nil.
+func shutdownHTTP(ctx context.Context, srv *http.Server, name string) bool {
+	if srv == nil {
 		return true
 	}
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error(name+" server did not shut down in time", "error", err)
+		srv.Close()
+		return false
+	}
+	return true
 }
+
 func (s *Service) Close() {
 	s.db.Close()
 }
@@ -0,0 +1,67 @@
+// Package carriers ingests the tracking updates courier partners post to the webhook of the orders
+// service. Every partner is served by a CarrierAdapter that verifies the signature of its requests
+// and normalises its payloads into tracking events, so supporting a partner does not need changes
+// to the webhook.
+package carriers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+)
+
+var (
+	// ErrInvalidSignature is returned by CarrierAdapter.Verify for requests the carrier did not sign.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidPayload is wrapped by the errors of CarrierAdapter.Parse.
+	ErrInvalidPayload = errors.New("invalid payload")
+)
+
+// CarrierAdapter verifies and normalises the webhook requests of a carrier.
+type CarrierAdapter interface {
+	// Name is the carrier in the webhook path, it matches the carrier of its shipments ignoring case.
+	Name() string
+	// Verify returns ErrInvalidSignature unless the request with header and body was signed by the
+	// carrier.
+	Verify(header http.Header, body []byte) error
+	// Parse returns the tracking events in a verified body, it wraps ErrInvalidPayload when the body
+	// cannot be parsed.
+	Parse(body []byte) ([]*orderspb.RecordShipmentEventRequest, error)
+}
+
+// ParseSecrets parses the webhook secrets of the carriers from a comma separated list of
+// name=secret pairs, e.g. "g4s=s3cret,sendy=0ther".
+func ParseSecrets(value string) (map[string]string, error) {
+	secrets := map[string]string{}
+	for i, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		name, secret, ok := strings.Cut(pair, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || len(name) == 0 || len(secret) == 0 {
+			// the pair is not quoted, it may be a secret
+			return nil, fmt.Errorf("carrier secret %d is not a name=secret pair", i+1)
+		}
+		if _, ok := secrets[name]; ok {
+			return nil, fmt.Errorf("carrier %s has more than one secret", name)
+		}
+		secrets[name] = secret
+	}
+	return secrets, nil
+}
+
+// GenericJSONAdapters returns a GenericJSONAdapter for each carrier in secrets, sorted by name.
+func GenericJSONAdapters(secrets map[string]string) []CarrierAdapter {
+	adapters := make([]CarrierAdapter, 0, len(secrets))
+	for name, secret := range secrets {
+		adapters = append(adapters, NewGenericJSONAdapter(name, []byte(secret)))
+	}
+	sort.Slice(adapters, func(i, j int) bool { return adapters[i].Name() < adapters[j].Name() })
+	return adapters
+}
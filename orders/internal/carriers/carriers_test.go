@@ -0,0 +1,33 @@
+package carriers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecrets(t *testing.T) {
+	secrets, err := ParseSecrets(" G4S=s3cret, sendy=a=b ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"g4s": "s3cret", "sendy": "a=b"}, secrets)
+
+	secrets, err = ParseSecrets("")
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	_, err = ParseSecrets("g4s=one,s3cret")
+	assert.EqualError(t, err, "carrier secret 2 is not a name=secret pair")
+	_, err = ParseSecrets("g4s=")
+	assert.Error(t, err)
+	_, err = ParseSecrets("g4s=one,G4S=two")
+	assert.EqualError(t, err, "carrier g4s has more than one secret")
+}
+
+func TestGenericJSONAdapters(t *testing.T) {
+	adapters := GenericJSONAdapters(map[string]string{"sendy": "b", "g4s": "a"})
+
+	require.Len(t, adapters, 2)
+	assert.Equal(t, "g4s", adapters[0].Name())
+	assert.Equal(t, "sendy", adapters[1].Name())
+}
@@ -0,0 +1,130 @@
+package carriers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Headers of the requests of the generic JSON format.
+const (
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// maxTimestampSkew is how old, or far in the future, the timestamp of a signed request may be.
+// Older requests are rejected so a captured request cannot be replayed later.
+const maxTimestampSkew = 5 * time.Minute
+
+// genericStatuses maps the statuses of the generic JSON format to the event types.
+var genericStatuses = map[string]orderspb.ShipmentEventType{
+	"picked_up":        orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_PICKED_UP,
+	"in_transit":       orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_IN_TRANSIT,
+	"out_for_delivery": orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_OUT_FOR_DELIVERY,
+	"delivered":        orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_DELIVERED,
+	"failed_attempt":   orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_FAILED_ATTEMPT,
+}
+
+// GenericJSONAdapter is the adapter of the generic JSON format offered to carriers without a
+// format of their own:
+//
+//	{"events": [{"tracking_number": "TRK-1", "status": "in_transit", "location": "Voi",
+//	  "description": "Arrived at the hub", "occurred_at": "2023-10-01T10:00:00Z"}]}
+//
+// The status is one of picked_up, in_transit, out_for_delivery, delivered and failed_attempt.
+// Requests are signed with the secret shared with the carrier: X-Webhook-Timestamp is the unix
+// time of the request and X-Webhook-Signature is "sha256=" followed by the hex HMAC-SHA256 of the
+// timestamp, a dot and the body.
+type GenericJSONAdapter struct {
+	name   string
+	secret []byte
+	now    func() time.Time
+}
+
+// NewGenericJSONAdapter returns the adapter of the carrier name signing with secret.
+func NewGenericJSONAdapter(name string, secret []byte) *GenericJSONAdapter {
+	return &GenericJSONAdapter{name: strings.ToLower(name), secret: secret, now: time.Now}
+}
+
+func (a *GenericJSONAdapter) Name() string {
+	return a.name
+}
+
+func (a *GenericJSONAdapter) Verify(header http.Header, body []byte) error {
+	timestamp := header.Get(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or invalid %s", ErrInvalidSignature, TimestampHeader)
+	}
+	if skew := a.now().Sub(time.Unix(seconds, 0)); skew > maxTimestampSkew || skew < -maxTimestampSkew {
+		return fmt.Errorf("%w: request timestamp is %s off", ErrInvalidSignature, skew.Round(time.Second))
+	}
+
+	signature, ok := strings.CutPrefix(header.Get(SignatureHeader), "sha256=")
+	if !ok {
+		return fmt.Errorf("%w: missing or invalid %s", ErrInvalidSignature, SignatureHeader)
+	}
+	sent, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(sent, Sign(a.secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 of a request of the generic JSON format sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+type genericPayload struct {
+	Events []genericEvent `json:"events"`
+}
+
+type genericEvent struct {
+	TrackingNumber string    `json:"tracking_number"`
+	Status         string    `json:"status"`
+	Location       string    `json:"location"`
+	Description    string    `json:"description"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+func (a *GenericJSONAdapter) Parse(body []byte) ([]*orderspb.RecordShipmentEventRequest, error) {
+	payload := genericPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	requests := make([]*orderspb.RecordShipmentEventRequest, 0, len(payload.Events))
+	for i, e := range payload.Events {
+		eventType, ok := genericStatuses[strings.ToLower(e.Status)]
+		if !ok {
+			return nil, fmt.Errorf("%w: event %d has unknown status %q", ErrInvalidPayload, i, e.Status)
+		}
+		if len(e.TrackingNumber) == 0 || e.OccurredAt.IsZero() {
+			return nil, fmt.Errorf("%w: event %d needs a tracking_number and occurred_at", ErrInvalidPayload, i)
+		}
+		requests = append(requests, &orderspb.RecordShipmentEventRequest{
+			TrackingNumber: e.TrackingNumber,
+			Event: &orderspb.ShipmentEvent{
+				Type:        eventType,
+				Location:    e.Location,
+				Description: e.Description,
+				OccurredAt:  timestamppb.New(e.OccurredAt),
+			},
+		})
+	}
+	return requests, nil
+}
@@ -0,0 +1,110 @@
+package carriers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+)
+
+// fixture returns a payload recorded from a carrier in testdata.
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return body
+}
+
+// signedHeader returns the headers of a request of the generic JSON format sent at sentAt.
+func signedHeader(secret string, sentAt time.Time, body []byte) http.Header {
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	header := http.Header{}
+	header.Set(TimestampHeader, timestamp)
+	header.Set(SignatureHeader, "sha256="+hex.EncodeToString(Sign([]byte(secret), timestamp, body)))
+	return header
+}
+
+func TestGenericJSONAdapter_Parse(t *testing.T) {
+	adapter := NewGenericJSONAdapter("G4S", []byte("secret"))
+	assert.Equal(t, "g4s", adapter.Name())
+
+	requests, err := adapter.Parse(fixture(t, "generic/events.json"))
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+
+	assert.Equal(t, "TRK-1", requests[0].TrackingNumber)
+	assert.Equal(t, orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_PICKED_UP, requests[0].Event.Type)
+	assert.Equal(t, "Nairobi", requests[0].Event.Location)
+	assert.Equal(t, "Picked up from the sender", requests[0].Event.Description)
+	assert.Equal(t, time.Date(2023, 10, 1, 8, 15, 0, 0, time.UTC), requests[0].Event.OccurredAt.AsTime())
+
+	assert.Equal(t, orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_IN_TRANSIT, requests[1].Event.Type)
+	// times with an offset are normalised
+	assert.Equal(t, time.Date(2023, 10, 1, 11, 40, 0, 0, time.UTC), requests[1].Event.OccurredAt.AsTime())
+
+	// statuses are matched ignoring case
+	assert.Equal(t, "TRK-2", requests[2].TrackingNumber)
+	assert.Equal(t, orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_DELIVERED, requests[2].Event.Type)
+
+	requests, err = adapter.Parse(fixture(t, "generic/failed_attempt.json"))
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_FAILED_ATTEMPT, requests[0].Event.Type)
+}
+
+func TestGenericJSONAdapter_ParseInvalid(t *testing.T) {
+	adapter := NewGenericJSONAdapter("g4s", []byte("secret"))
+	tests := map[string]string{
+		"generic/unknown_status.json":          `invalid payload: event 0 has unknown status "label_created"`,
+		"generic/missing_tracking_number.json": "invalid payload: event 0 needs a tracking_number and occurred_at",
+		"generic/truncated.json":               "invalid payload: unexpected end of JSON input",
+	}
+	for name, message := range tests {
+		requests, err := adapter.Parse(fixture(t, name))
+		assert.Nil(t, requests, name)
+		assert.ErrorIs(t, err, ErrInvalidPayload, name)
+		assert.EqualError(t, err, message, name)
+	}
+}
+
+func TestGenericJSONAdapter_Verify(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	adapter := NewGenericJSONAdapter("g4s", []byte("secret"))
+	adapter.now = func() time.Time { return now }
+	body := fixture(t, "generic/events.json")
+
+	assert.NoError(t, adapter.Verify(signedHeader("secret", now, body), body))
+	assert.NoError(t, adapter.Verify(signedHeader("secret", now.Add(-4*time.Minute), body), body))
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-3] = ' '
+	tests := map[string]struct {
+		header http.Header
+		body   []byte
+	}{
+		"other secret":     {signedHeader("other", now, body), body},
+		"tampered body":    {signedHeader("secret", now, body), tampered},
+		"stale timestamp":  {signedHeader("secret", now.Add(-10*time.Minute), body), body},
+		"future timestamp": {signedHeader("secret", now.Add(10*time.Minute), body), body},
+		"no headers":       {http.Header{}, body},
+	}
+	for name, test := range tests {
+		assert.ErrorIs(t, adapter.Verify(test.header, test.body), ErrInvalidSignature, name)
+	}
+
+	// the timestamp is signed with the body
+	header := signedHeader("secret", now, body)
+	header.Set(TimestampHeader, strconv.FormatInt(now.Add(time.Second).Unix(), 10))
+	assert.ErrorIs(t, adapter.Verify(header, body), ErrInvalidSignature)
+
+	header = signedHeader("secret", now, body)
+	header.Set(SignatureHeader, "sha256=not-hex")
+	assert.ErrorIs(t, adapter.Verify(header, body), ErrInvalidSignature)
+}
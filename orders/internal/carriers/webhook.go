@@ -0,0 +1,134 @@
+package carriers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/metrics"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/grpc/status"
+)
+
+// WebhookPath is the path the carriers post to, followed by the name of their adapter.
+const WebhookPath = "/webhooks/carriers/"
+
+// maxBodyBytes limits the size of webhook requests, carriers send a few events at a time.
+const maxBodyBytes = 1 << 20
+
+// Recorder records the tracking events of the shipments of a carrier, it is the handler of the
+// shipment service.
+type Recorder interface {
+	RecordCarrierEvent(ctx context.Context, carrier string, req *orderspb.RecordShipmentEventRequest) error
+}
+
+// webhookResponse is the body of the answer to a verified request. Events are rejected when they
+// will never be recorded, e.g. for unknown tracking numbers, sending them again does not help.
+type webhookResponse struct {
+	Recorded int             `json:"recorded"`
+	Rejected []rejectedEvent `json:"rejected,omitempty"`
+}
+
+type rejectedEvent struct {
+	Index          int    `json:"index"`
+	TrackingNumber string `json:"tracking_number"`
+	Error          string `json:"error"`
+}
+
+// NewWebhookHandler returns the handler recording the events posted to WebhookPath followed by the
+// name of one of the adapters. Requests are answered with an error status when they should be sent
+// again, recording an event twice has no effect.
+func NewWebhookHandler(recorder Recorder, adapters ...CarrierAdapter) http.Handler {
+	byName := make(map[string]CarrierAdapter, len(adapters))
+	for _, adapter := range adapters {
+		byName[adapter.Name()] = adapter
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(WebhookPath, func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+			return
+		}
+		name := strings.ToLower(strings.TrimPrefix(r.URL.Path, WebhookPath))
+		adapter, ok := byName[name]
+		if !ok {
+			logger.Error("webhook request of an unknown carrier", "carrier", name)
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "unknown carrier"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				logger.Error("webhook request too large", "carrier", name, "limit", tooLarge.Limit)
+				writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"message": "request too large"})
+				return
+			}
+			logger.Error("failed to read webhook request", "carrier", name, "error", err)
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": "failed to read request"})
+			return
+		}
+		if err := adapter.Verify(r.Header, body); err != nil {
+			logger.Error("webhook request signature rejected", "carrier", name, "error", err)
+			metrics.CarrierWebhookRequests.WithLabelValues(name, "unauthenticated").Inc()
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "invalid signature"})
+			return
+		}
+		requests, err := adapter.Parse(body)
+		if err != nil {
+			logger.Error("failed to parse webhook request", "carrier", name, "error", err)
+			metrics.CarrierWebhookRequests.WithLabelValues(name, "invalid").Inc()
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+
+		ctx, span := tracing.Tracer().Start(r.Context(), "RecordCarrierEvents")
+		defer span.End()
+		response := webhookResponse{}
+		retry := false
+		for i, req := range requests {
+			err := recorder.RecordCarrierEvent(ctx, name, req)
+			if err == nil {
+				response.Recorded++
+				continue
+			}
+			if runtime.HTTPStatusFromCode(status.Code(err)) >= http.StatusInternalServerError {
+				logger.Error("failed to record carrier event", "carrier", name, "tracking_number", req.TrackingNumber, "error", err)
+				retry = true
+				break
+			}
+			logger.Warn("carrier event rejected", "carrier", name, "tracking_number", req.TrackingNumber, "error", err)
+			response.Rejected = append(response.Rejected, rejectedEvent{
+				Index:          i,
+				TrackingNumber: req.TrackingNumber,
+				Error:          status.Convert(err).Message(),
+			})
+		}
+		if retry {
+			metrics.CarrierWebhookRequests.WithLabelValues(name, "failed").Inc()
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"message": "failed to record the events, send them again"})
+			return
+		}
+		metrics.CarrierWebhookRequests.WithLabelValues(name, "recorded").Inc()
+		logger.Debug("carrier events recorded", "carrier", name, "recorded", response.Recorded, "rejected", len(response.Rejected))
+		writeJSON(w, http.StatusOK, response)
+	})
+	// the logging middleware runs inside the span so the request logger carries the trace id
+	return tracing.HTTPHandler(logging.HTTPMiddleware(mux), "carrier-webhook")
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,116 @@
+package carriers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/mocks"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// postFixture posts the fixture to the webhook of carrier, signed with secret.
+func postFixture(t *testing.T, handler http.Handler, carrier, secret, name string) *httptest.ResponseRecorder {
+	t.Helper()
+	body := fixture(t, name)
+	request := httptest.NewRequest(http.MethodPost, WebhookPath+carrier, bytes.NewReader(body))
+	request.Header = signedHeader(secret, time.Now(), body)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func trackingNumber(number string) interface{} {
+	return mock.MatchedBy(func(req *orderspb.RecordShipmentEventRequest) bool {
+		return req.TrackingNumber == number
+	})
+}
+
+func TestWebhook_RecordsEvents(t *testing.T) {
+	recorder := mocks.NewRecorder(t)
+	recorder.On("RecordCarrierEvent", mock.Anything, "g4s", trackingNumber("TRK-1")).Return(nil).Twice()
+	recorder.On("RecordCarrierEvent", mock.Anything, "g4s", mock.MatchedBy(func(req *orderspb.RecordShipmentEventRequest) bool {
+		return req.TrackingNumber == "TRK-2" && req.Event.Type == orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_DELIVERED
+	})).Return(nil).Once()
+	handler := NewWebhookHandler(recorder, NewGenericJSONAdapter("g4s", []byte("secret")))
+
+	// carrier names in the path ignore case like the carriers of the shipments
+	response := postFixture(t, handler, "G4S", "secret", "generic/events.json")
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"recorded": 3}`, response.Body.String())
+}
+
+func TestWebhook_RejectsEvents(t *testing.T) {
+	recorder := mocks.NewRecorder(t)
+	recorder.On("RecordCarrierEvent", mock.Anything, "g4s", trackingNumber("TRK-1")).Return(nil).Twice()
+	recorder.On("RecordCarrierEvent", mock.Anything, "g4s", trackingNumber("TRK-2")).Return(status.Error(codes.NotFound, "resource not found"))
+	handler := NewWebhookHandler(recorder, NewGenericJSONAdapter("g4s", []byte("secret")))
+
+	response := postFixture(t, handler, "g4s", "secret", "generic/events.json")
+
+	// sending an unknown tracking number again does not help, the other events are recorded
+	require.Equal(t, http.StatusOK, response.Code)
+	body := webhookResponse{}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+	assert.Equal(t, webhookResponse{
+		Recorded: 2,
+		Rejected: []rejectedEvent{{Index: 2, TrackingNumber: "TRK-2", Error: "resource not found"}},
+	}, body)
+}
+
+func TestWebhook_FailedEvents(t *testing.T) {
+	recorder := mocks.NewRecorder(t)
+	recorder.On("RecordCarrierEvent", mock.Anything, "g4s", trackingNumber("TRK-1")).Return(status.Error(codes.Unavailable, "service unavailable")).Once()
+	handler := NewWebhookHandler(recorder, NewGenericJSONAdapter("g4s", []byte("secret")))
+
+	response := postFixture(t, handler, "g4s", "secret", "generic/events.json")
+
+	// the carrier sends the request again, the events recorded before the failure are ignored then
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+}
+
+func TestWebhook_RejectsRequests(t *testing.T) {
+	recorder := mocks.NewRecorder(t)
+	handler := NewWebhookHandler(recorder, NewGenericJSONAdapter("g4s", []byte("secret")))
+
+	assert.Equal(t, http.StatusNotFound, postFixture(t, handler, "sendy", "secret", "generic/events.json").Code)
+	assert.Equal(t, http.StatusUnauthorized, postFixture(t, handler, "g4s", "other", "generic/events.json").Code)
+	assert.Equal(t, http.StatusBadRequest, postFixture(t, handler, "g4s", "secret", "generic/unknown_status.json").Code)
+	assert.Equal(t, http.StatusBadRequest, postFixture(t, handler, "g4s", "secret", "generic/truncated.json").Code)
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, WebhookPath+"g4s", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+
+	large := bytes.Repeat([]byte(" "), maxBodyBytes+1)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, WebhookPath+"g4s", bytes.NewReader(large)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+}
+
+func TestWebhook_CustomAdapter(t *testing.T) {
+	adapter := mocks.NewCarrierAdapter(t)
+	adapter.On("Name").Return("acme")
+	adapter.On("Verify", mock.Anything, []byte("payload")).Return(nil)
+	adapter.On("Parse", []byte("payload")).Return([]*orderspb.RecordShipmentEventRequest{
+		{TrackingNumber: "ACME-1", Event: &orderspb.ShipmentEvent{Type: orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_IN_TRANSIT}},
+	}, nil)
+	recorder := mocks.NewRecorder(t)
+	recorder.On("RecordCarrierEvent", mock.Anything, "acme", trackingNumber("ACME-1")).Return(nil)
+	handler := NewWebhookHandler(recorder, adapter)
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, WebhookPath+"acme", bytes.NewReader([]byte("payload"))))
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"recorded": 1}`, response.Body.String())
+}
@@ -1,31 +1,65 @@
 package fieldmask
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/iancoleman/strcase"
 	"github.com/wathuta/technical_test/orders/internal/common"
 	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // MaxSize is the hard limit on the number of fields in a single field mask.
 const MaxSize = 96
 
+// Wildcard is the path that selects every field of a resource, it replaces the resource.
+const Wildcard = "*"
+
+// ErrRequired is returned when a request has no field mask.
+var ErrRequired = errors.New("update mask required")
+
 // Mask represents a list of fields in a resource.
 type Mask struct {
-	// Fields included in the mask, in snake case.
+	// Fields included in the mask, in snake case. Nested fields are separated by dots e.g
+	// pickup_address.city.
 	Fields []string
 }
 
-// New returns a new Mask created from a protobuf mask.
-func New(mask *field_mask.FieldMask) (*Mask, error) {
+// New returns a new Mask created from a protobuf mask of the fields of resource. Every path must
+// name a field of resource, or a field of a message field of resource defined in the same proto
+// package, such as pickup_address.city. Fields of other messages such as timestamps and money
+// can only be replaced whole. The Wildcard path selects every field of resource.
+func New(mask *field_mask.FieldMask, resource proto.Message) (*Mask, error) {
+	if mask == nil {
+		return nil, ErrRequired
+	}
 	if len(mask.Paths) > MaxSize {
 		return nil, fmt.Errorf("number of fields is %d, maximum allowed is %d", len(mask.Paths), MaxSize)
 	}
 
+	descriptor := resource.ProtoReflect().Descriptor()
+	for _, path := range mask.Paths {
+		if path == Wildcard {
+			if len(mask.Paths) > 1 {
+				return nil, fmt.Errorf("%q cannot be combined with other paths", Wildcard)
+			}
+			return &Mask{Fields: fieldNames(descriptor)}, nil
+		}
+	}
+
 	fields := make([]string, len(mask.Paths))
-	for i, k := range mask.Paths {
-		fields[i] = strcase.ToSnake(k)
+	for i, path := range mask.Paths {
+		segments := strings.Split(path, ".")
+		for j := range segments {
+			segments[j] = strcase.ToSnake(segments[j])
+		}
+		if err := validate(descriptor, segments); err != nil {
+			return nil, err
+		}
+		fields[i] = strings.Join(segments, ".")
 	}
 
 	return &Mask{
@@ -33,6 +67,36 @@ func New(mask *field_mask.FieldMask) (*Mask, error) {
 	}, nil
 }
 
+// validate returns an error when the path made of segments does not name a field of message.
+func validate(message protoreflect.MessageDescriptor, segments []string) error {
+	path := strings.Join(segments, ".")
+	for i, segment := range segments {
+		field := message.Fields().ByName(protoreflect.Name(segment))
+		if field == nil {
+			return fmt.Errorf("field %q does not exist in %s", path, message.Name())
+		}
+		if i == len(segments)-1 {
+			return nil
+		}
+		if field.Message() == nil || field.IsList() || field.IsMap() ||
+			field.Message().ParentFile().Package() != message.ParentFile().Package() {
+			return fmt.Errorf("field %q cannot be updated, update %q instead", path, strings.Join(segments[:i+1], "."))
+		}
+		message = field.Message()
+	}
+	return nil
+}
+
+// fieldNames returns the names of the fields of message.
+func fieldNames(message protoreflect.MessageDescriptor) []string {
+	fields := message.Fields()
+	names := make([]string, fields.Len())
+	for i := range names {
+		names[i] = string(fields.Get(i).Name())
+	}
+	return names
+}
+
 func (f *Mask) RemoveOutputOnly() {
 	newFields := make([]string, 0, len(f.Fields))
 	for _, k := range f.Fields {
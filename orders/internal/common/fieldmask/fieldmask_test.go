@@ -0,0 +1,75 @@
+package fieldmask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	productspb "github.com/wathuta/technical_test/protos_gen/products"
+	"google.golang.org/genproto/protobuf/field_mask"
+)
+
+func TestNew(t *testing.T) {
+	mask, err := New(&field_mask.FieldMask{Paths: []string{"shippingMethod", "pickup_address.city", "deliveryAddress.postalCode"}}, &orderspb.Order{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shipping_method", "pickup_address.city", "delivery_address.postal_code"}, mask.Fields)
+}
+
+func TestNew_Required(t *testing.T) {
+	_, err := New(nil, &orderspb.Order{})
+
+	assert.ErrorIs(t, err, ErrRequired)
+}
+
+func TestNew_InvalidPaths(t *testing.T) {
+	tests := map[string][]string{
+		"unknown field":          {"shipping_method", "colour"},
+		"unknown nested field":   {"pickup_address.town"},
+		"field of a scalar":      {"shipping_method.length"},
+		"field of another type":  {"scheduled_pickup_datetime.seconds"},
+		"wildcard with a field":  {"*", "shipping_method"},
+		"field after a wildcard": {"shipping_method", "*"},
+	}
+	for name, paths := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(&field_mask.FieldMask{Paths: paths}, &orderspb.Order{})
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNew_Wildcard(t *testing.T) {
+	mask, err := New(&field_mask.FieldMask{Paths: []string{Wildcard}}, &productspb.Product{})
+	assert.NoError(t, err)
+	assert.Contains(t, mask.Fields, "attributes")
+	assert.Contains(t, mask.Fields, "product_id")
+
+	mask.RemoveOutputOnly()
+	assert.NotContains(t, mask.Fields, "product_id")
+	assert.NotContains(t, mask.Fields, "created_at")
+	assert.Contains(t, mask.Fields, "name")
+}
+
+func TestNew_NestedAttributes(t *testing.T) {
+	mask, err := New(&field_mask.FieldMask{Paths: []string{"attributes.price", "attributes.brand"}}, &productspb.Product{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"attributes.price", "attributes.brand"}, mask.Fields)
+
+	// the amount of a price is replaced with its currency
+	_, err = New(&field_mask.FieldMask{Paths: []string{"attributes.price.amount"}}, &productspb.Product{})
+	assert.Error(t, err)
+}
+
+func TestNew_MaxSize(t *testing.T) {
+	paths := make([]string, MaxSize+1)
+	for i := range paths {
+		paths[i] = "name"
+	}
+
+	_, err := New(&field_mask.FieldMask{Paths: paths}, &productspb.Product{})
+
+	assert.Error(t, err)
+}
@@ -31,6 +31,15 @@ func SetPageToken(input int) int {
 	return input
 }
 
+// NextPageToken returns the token of the page after a page of results, 0 when the page was the
+// last one.
+func NextPageToken(token, pageSize, results int) int {
+	if results < pageSize {
+		return 0
+	}
+	return token + results
+}
+
 func IsFieldOutputOnly(field string) bool {
 	list := [...]string{
 		"order_id",
@@ -41,7 +50,24 @@ func IsFieldOutputOnly(field string) bool {
 		"create_time",
 		"update_time",
 		"delete_time",
+		"created_at",
+		"updated_at",
+		"deleted_at",
 		"version",
+		// amounts are priced by the service
+		"shipping_cost",
+		"subtotal",
+		"tax",
+		"grand_total",
+		"currency",
+		"tax_breakdown",
+		// discounts come from the promo code redeemed when the order was created
+		"discount",
+		"promo_code",
+		// invoice numbers come from a gap-free sequence
+		"invoice_number",
+		// the delivery slot is the one the order was scheduled in
+		"delivery_slot_id",
 	}
 
 	for _, curr := range list {
@@ -44,6 +44,15 @@ func TestSetPageToken(t *testing.T) {
 	assert.Equal(t, 0, result)
 }
 
+func TestNextPageToken(t *testing.T) {
+	// Test with a full page
+	assert.Equal(t, 30, NextPageToken(20, 10, 10))
+
+	// Test with the last page
+	assert.Equal(t, 0, NextPageToken(20, 10, 3))
+	assert.Equal(t, 0, NextPageToken(0, 10, 0))
+}
+
 func TestIsFieldOutputOnly(t *testing.T) {
 	// Test with an output-only field
 	result := IsFieldOutputOnly("order_id")
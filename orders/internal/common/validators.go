@@ -3,7 +3,10 @@ package common
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	validator "github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -25,6 +28,8 @@ func MarshalToBytes(input interface{}) (driver.Value, error) {
 func NewValidator() *validator.Validate {
 	// Create a new validator for a Transaction model.
 	validate := validator.New()
+	// name fields after their columns, which are also the names of the fields in the protos
+	validate.RegisterTagNameFunc(fieldName)
 
 	// Custom validation for uuid.UUID fields.
 	_ = validate.RegisterValidation("uuid", func(fl validator.FieldLevel) bool {
@@ -38,14 +43,32 @@ func NewValidator() *validator.Validate {
 	return validate
 }
 
-// ValidatorErrors shows validation errors for each invalid fields.
+// fieldName returns the name of a struct field in validation errors, its db tag when it has one.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"db", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// ValidatorErrors shows validation errors for each invalid fields, keyed by the path of the field
+// e.g. pickup_address.city. It returns nil when err is not a validation error.
 func ValidatorErrors(err error) map[string]string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
 	// Define fields map.
 	fields := make(map[string]string)
 
 	// Make error message for each invalid field.
-	for _, err := range err.(validator.ValidationErrors) {
-		fields[err.Field()] = generateCustomErrorMessage(err)
+	for _, err := range validationErrors {
+		// the namespace starts with the name of the validated struct
+		_, path, _ := strings.Cut(err.Namespace(), ".")
+		fields[path] = generateCustomErrorMessage(err)
 	}
 
 	return fields
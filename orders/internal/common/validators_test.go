@@ -0,0 +1,34 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City string `validate:"required" db:"city"`
+}
+
+type testOrder struct {
+	CustomerID    string      `validate:"required,uuid" db:"customer_id"`
+	Email         string      `validate:"required,email" json:"email,omitempty"`
+	PickupAddress testAddress `validate:"required" db:"pickup_address"`
+	Quantity      int32       `validate:"gt=0"`
+}
+
+func TestValidatorErrors(t *testing.T) {
+	err := NewValidator().Struct(testOrder{CustomerID: "not-a-uuid", Email: "invalid-email", PickupAddress: testAddress{City: ""}})
+	require.Error(t, err)
+
+	assert.Equal(t, map[string]string{
+		"customer_id":         "Invalid customer_id input. Please enter a valid  'uuid'.",
+		"email":               "Invalid email input. Please enter a valid email address.",
+		"pickup_address.city": "city is required.",
+		"Quantity":            "Quantity must be greater than 0.",
+	}, ValidatorErrors(err))
+
+	assert.Nil(t, ValidatorErrors(errors.New("some error")))
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"golang.org/x/exp/slog"
 )
@@ -20,6 +23,24 @@ const (
 	RunMigrationsEnvVar               = "RUN_MIGRATIONS"
 	ListenAddressEnvVar               = "LISTEN_ADDRESS"
 	PaymentServiceListenAddressEnvVar = "PAYMENT_SERVICE_LISTEN_ADDRESS"
+	AuthKeysetFileEnvVar              = "AUTH_KEYSET_FILE"
+	AuthIssuerEnvVar                  = "AUTH_ISSUER"   // optional
+	AuthAudienceEnvVar                = "AUTH_AUDIENCE" // optional
+	ServiceTokenEnvVar                = "SERVICE_TOKEN"
+	MetricsListenAddressEnvVar        = "METRICS_LISTEN_ADDRESS"
+	TracesExporterEnvVar              = "TRACES_EXPORTER"           // optional, "otlp" or "stdout"
+	GracefulShutdownTimeoutEnvVar     = "GRACEFUL_SHUTDOWN_TIMEOUT" // optional, defaults to 30s
+	GatewayListenAddressEnvVar        = "GATEWAY_LISTEN_ADDRESS"    // optional, the JSON gateway is only served when set
+	GRPCReflectionEnvVar              = "GRPC_REFLECTION"           // optional, "true" registers grpc server reflection
+	WebhookListenAddressEnvVar        = "WEBHOOK_LISTEN_ADDRESS"    // optional, the carrier webhook is only served when set
+	CarrierWebhookSecretsEnvVar       = "CARRIER_WEBHOOK_SECRETS"   // name=secret pairs of the carriers posting to the webhook
+	// optional settings of the calls to the payment service, see grpcclients.Options
+	PaymentServiceTimeoutEnvVar            = "PAYMENT_SERVICE_TIMEOUT"
+	PaymentServiceMaxAttemptsEnvVar        = "PAYMENT_SERVICE_MAX_ATTEMPTS"
+	PaymentServiceRetryBackoffEnvVar       = "PAYMENT_SERVICE_RETRY_BACKOFF"
+	PaymentServiceBreakerThresholdEnvVar   = "PAYMENT_SERVICE_BREAKER_THRESHOLD"
+	PaymentServiceBreakerCooldownEnvVar    = "PAYMENT_SERVICE_BREAKER_COOLDOWN"
+	PaymentServiceMaxConcurrentCallsEnvVar = "PAYMENT_SERVICE_MAX_CONCURRENT_CALLS"
 )
 
 func HasAllEnvVariables() bool {
@@ -37,6 +58,9 @@ func HasAllEnvVariables() bool {
 		RunMigrationsEnvVar,
 		ListenAddressEnvVar,
 		PaymentServiceListenAddressEnvVar,
+		AuthKeysetFileEnvVar,
+		ServiceTokenEnvVar,
+		MetricsListenAddressEnvVar,
 	}
 	for _, v := range requiredEnvVars {
 		value, ok := os.LookupEnv(v)
@@ -48,3 +72,37 @@ func HasAllEnvVariables() bool {
 	return true
 
 }
+
+// DurationFromEnv parses the duration, such as "5s", in the env variable key. fallback is
+// returned when the variable is not set.
+func DurationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || len(value) < 1 {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %s: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration", key)
+	}
+	return d, nil
+}
+
+// IntFromEnv parses the positive number in the env variable key. fallback is returned when the
+// variable is not set.
+func IntFromEnv(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || len(value) < 1 {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %s: %w", key, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number", key)
+	}
+	return n, nil
+}
@@ -5,6 +5,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +26,9 @@ func TestHasAllEnvVariables_AllSet(t *testing.T) {
 		RunMigrationsEnvVar,
 		ListenAddressEnvVar,
 		PaymentServiceListenAddressEnvVar,
+		AuthKeysetFileEnvVar,
+		ServiceTokenEnvVar,
+		MetricsListenAddressEnvVar,
 	}
 
 	for _, v := range requiredEnvVars {
@@ -60,3 +64,23 @@ func TestHasAllEnvVariables_MissingVariable(t *testing.T) {
 	result := HasAllEnvVariables()
 	assert.False(t, result, "Expected HasAllEnvVariables to return false")
 }
+
+func TestDurationFromEnv(t *testing.T) {
+	const key = "TEST_DURATION"
+	defer os.Unsetenv(key)
+
+	d, err := DurationFromEnv(key, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, d, "Expected the fallback when the variable is not set")
+
+	os.Setenv(key, "250ms")
+	d, err = DurationFromEnv(key, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, d)
+
+	for _, value := range []string{"5", "soon", "-1s", "0s"} {
+		os.Setenv(key, value)
+		_, err = DurationFromEnv(key, 5*time.Second)
+		assert.Error(t, err, "Expected an error for %q", value)
+	}
+}
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	TLSCertFileEnvVar     = "TLS_CERT_FILE"      // optional
+	TLSKeyFileEnvVar      = "TLS_KEY_FILE"       // optional
+	TLSCAFileEnvVar       = "TLS_CA_FILE"        // optional
+	TLSClientCAFileEnvVar = "TLS_CLIENT_CA_FILE" // optional
+)
+
+// certReloadInterval is how often the certificate files are checked for changes. Checks are
+// made during handshakes so rotated certificates are picked up without a restart.
+var certReloadInterval = 30 * time.Second
+
+// TLSFiles are the PEM files securing the grpc server and the connections to other services.
+// Without any of them the service runs in plaintext.
+type TLSFiles struct {
+	// CertFile and KeyFile are served by the grpc server and presented as client certificate
+	// to the services this service calls.
+	CertFile string
+	KeyFile  string
+	// CAFile verifies the certificates of the services this service calls.
+	CAFile string
+	// ClientCAFile makes the grpc server require client certificates signed by these CAs.
+	ClientCAFile string
+}
+
+func TLSFilesFromEnv() TLSFiles {
+	return TLSFiles{
+		CertFile:     os.Getenv(TLSCertFileEnvVar),
+		KeyFile:      os.Getenv(TLSKeyFileEnvVar),
+		CAFile:       os.Getenv(TLSCAFileEnvVar),
+		ClientCAFile: os.Getenv(TLSClientCAFileEnvVar),
+	}
+}
+
+func (f TLSFiles) Validate() error {
+	if (f.CertFile == "") != (f.KeyFile == "") {
+		return fmt.Errorf("%s and %s must be set together", TLSCertFileEnvVar, TLSKeyFileEnvVar)
+	}
+	if f.ClientCAFile != "" && f.CertFile == "" {
+		return fmt.Errorf("%s requires %s", TLSClientCAFileEnvVar, TLSCertFileEnvVar)
+	}
+	return nil
+}
+
+// ServerTLS reports whether the grpc server is served over TLS.
+func (f TLSFiles) ServerTLS() bool {
+	return f.CertFile != ""
+}
+
+// ClientTLS reports whether the connections to other services use TLS.
+func (f TLSFiles) ClientTLS() bool {
+	return f.CAFile != ""
+}
+
+// ServerCredentials returns the transport credentials of the grpc server.
+func ServerCredentials(files TLSFiles) (credentials.TransportCredentials, error) {
+	if err := files.Validate(); err != nil {
+		return nil, err
+	}
+	if !files.ServerTLS() {
+		return insecure.NewCredentials(), nil
+	}
+	store, err := newCertStore(files)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return store.serverConfig(), nil
+		},
+	}), nil
+}
+
+// ClientCredentials returns the transport credentials used to dial other services.
+func ClientCredentials(files TLSFiles) (credentials.TransportCredentials, error) {
+	if err := files.Validate(); err != nil {
+		return nil, err
+	}
+	if !files.ClientTLS() {
+		return insecure.NewCredentials(), nil
+	}
+	store, err := newCertStore(files)
+	if err != nil {
+		return nil, err
+	}
+	return &clientCredentials{store: store}, nil
+}
+
+// LoopbackCredentials returns the transport credentials the gateway dials the grpc server of this
+// service with. The server is trusted when it presents the certificate of CertFile, so a server
+// only serving TLS needs no CA to be reached by its own gateway.
+func LoopbackCredentials(files TLSFiles) (credentials.TransportCredentials, error) {
+	if err := files.Validate(); err != nil {
+		return nil, err
+	}
+	if !files.ServerTLS() {
+		return insecure.NewCredentials(), nil
+	}
+	store, err := newCertStore(files)
+	if err != nil {
+		return nil, err
+	}
+	return &clientCredentials{store: store, loopback: true}, nil
+}
+
+// certStore holds the certificates loaded from TLSFiles and reloads them when the files change.
+type certStore struct {
+	files TLSFiles
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	roots     *x509.CertPool
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	checkedAt time.Time
+}
+
+func newCertStore(files TLSFiles) (*certStore, error) {
+	s := &certStore{files: files}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads every configured file. The store is only changed when all of them are valid.
+func (s *certStore) load() error {
+	modTimes := map[string]time.Time{}
+	for _, path := range []string{s.files.CertFile, s.files.KeyFile, s.files.CAFile, s.files.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	var cert *tls.Certificate
+	if s.files.CertFile != "" {
+		keyPair, err := tls.LoadX509KeyPair(s.files.CertFile, s.files.KeyFile)
+		if err != nil {
+			return err
+		}
+		cert = &keyPair
+	}
+	roots, err := loadCertPool(s.files.CAFile)
+	if err != nil {
+		return err
+	}
+	clientCAs, err := loadCertPool(s.files.ClientCAFile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cert, s.roots, s.clientCAs = cert, roots, clientCAs
+	s.modTimes = modTimes
+	s.checkedAt = time.Now()
+	return nil
+}
+
+// reloadIfChanged reloads the files when one of them was modified since they were loaded. A
+// failed reload keeps the certificates in use, a half written file is picked up on a later check.
+func (s *certStore) reloadIfChanged() {
+	s.mu.Lock()
+	if time.Since(s.checkedAt) < certReloadInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.checkedAt = time.Now()
+	changed := false
+	for path, modTime := range s.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			changed = true
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := s.load(); err != nil {
+		slog.Error("failed to reload tls certificates", "error", err)
+		return
+	}
+	slog.Info("reloaded tls certificates")
+}
+
+func (s *certStore) serverConfig() *tls.Config {
+	s.reloadIfChanged()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2"},
+		Certificates: []tls.Certificate{*s.cert},
+	}
+	if s.clientCAs != nil {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = s.clientCAs
+	}
+	return config
+}
+
+func (s *certStore) clientConfig() *tls.Config {
+	s.reloadIfChanged()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    s.roots,
+	}
+	if s.cert != nil {
+		config.Certificates = []tls.Certificate{*s.cert}
+	}
+	return config
+}
+
+// loopbackConfig trusts the server presenting the certificate loaded from CertFile, whatever the
+// name it is dialed at.
+func (s *certStore) loopbackConfig() *tls.Config {
+	config := s.clientConfig()
+	config.RootCAs = nil
+	// the certificate of the server is compared with CertFile instead of verified against a CA
+	config.InsecureSkipVerify = true
+	config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("the grpc server presented no certificate")
+		}
+		// the server may have picked up a rotated certificate before this store
+		if s.serves(rawCerts[0]) || s.load() == nil && s.serves(rawCerts[0]) {
+			return nil
+		}
+		return fmt.Errorf("the grpc server does not present the certificate of %s", TLSCertFileEnvVar)
+	}
+	return config
+}
+
+// serves reports whether raw is the certificate loaded from CertFile.
+func (s *certStore) serves(raw []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cert != nil && bytes.Equal(s.cert.Certificate[0], raw)
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// clientCredentials builds the tls config of every connection from the store so reloaded
+// certificates are used by new connections. Loopback credentials trust the certificate of the
+// store instead of its CA.
+type clientCredentials struct {
+	store      *certStore
+	serverName string
+	loopback   bool
+}
+
+var _ credentials.TransportCredentials = (*clientCredentials)(nil)
+
+func (c *clientCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	config := c.store.clientConfig()
+	if c.loopback {
+		config = c.store.loopbackConfig()
+	}
+	config.ServerName = c.serverName
+	return credentials.NewTLS(config).ClientHandshake(ctx, authority, conn)
+}
+
+func (c *clientCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("client credentials cannot be used by a server")
+}
+
+func (c *clientCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2"}
+}
+
+func (c *clientCredentials) Clone() credentials.TransportCredentials {
+	return &clientCredentials{store: c.store, serverName: c.serverName, loopback: c.loopback}
+}
+
+func (c *clientCredentials) OverrideServerName(serverName string) error {
+	c.serverName = serverName
+	return nil
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/config/tlstest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// serveHealth starts a grpc server with the health service and returns its address.
+func serveHealth(t *testing.T, creds credentials.TransportCredentials) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.Creds(creds))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+	return listener.Addr().String()
+}
+
+func checkHealth(t *testing.T, address string, creds credentials.TransportCredentials) error {
+	t.Helper()
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+// mutualTLS issues a server and a client certificate from ca and returns the files of both sides.
+func mutualTLS(t *testing.T, ca *tlstest.CA) (server, client TLSFiles) {
+	t.Helper()
+	dir := t.TempDir()
+	caFile := ca.WriteCert(t, dir)
+	serverCert, serverKey := ca.Issue(t, dir, "payment", "127.0.0.1", "localhost")
+	clientCert, clientKey := ca.Issue(t, dir, "orders")
+	server = TLSFiles{CertFile: serverCert, KeyFile: serverKey, ClientCAFile: caFile}
+	client = TLSFiles{CertFile: clientCert, KeyFile: clientKey, CAFile: caFile}
+	return server, client
+}
+
+func TestTLSFiles_Validate(t *testing.T) {
+	assert.NoError(t, TLSFiles{}.Validate())
+	assert.NoError(t, TLSFiles{CAFile: "ca.pem"}.Validate())
+	assert.NoError(t, TLSFiles{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem"}.Validate())
+	assert.Error(t, TLSFiles{CertFile: "cert.pem"}.Validate())
+	assert.Error(t, TLSFiles{KeyFile: "key.pem"}.Validate())
+	assert.Error(t, TLSFiles{ClientCAFile: "ca.pem"}.Validate())
+}
+
+func TestTLSFilesFromEnv(t *testing.T) {
+	t.Setenv(TLSCertFileEnvVar, "cert.pem")
+	t.Setenv(TLSKeyFileEnvVar, "key.pem")
+	t.Setenv(TLSCAFileEnvVar, "ca.pem")
+	t.Setenv(TLSClientCAFileEnvVar, "client-ca.pem")
+
+	assert.Equal(t, TLSFiles{CertFile: "cert.pem", KeyFile: "key.pem", CAFile: "ca.pem", ClientCAFile: "client-ca.pem"}, TLSFilesFromEnv())
+}
+
+func TestCredentials_Plaintext(t *testing.T) {
+	serverCreds, err := ServerCredentials(TLSFiles{})
+	require.NoError(t, err)
+	clientCreds, err := ClientCredentials(TLSFiles{})
+	require.NoError(t, err)
+	assert.Equal(t, "insecure", serverCreds.Info().SecurityProtocol)
+	assert.Equal(t, "insecure", clientCreds.Info().SecurityProtocol)
+
+	assert.NoError(t, checkHealth(t, serveHealth(t, serverCreds), clientCreds))
+}
+
+func TestCredentials_MissingFile(t *testing.T) {
+	_, err := ServerCredentials(TLSFiles{CertFile: "missing.pem", KeyFile: "missing-key.pem"})
+	assert.Error(t, err)
+	_, err = ClientCredentials(TLSFiles{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+}
+
+func TestCredentials_MutualTLS(t *testing.T) {
+	server, client := mutualTLS(t, tlstest.NewCA(t))
+	serverCreds, err := ServerCredentials(server)
+	require.NoError(t, err)
+	clientCreds, err := ClientCredentials(client)
+	require.NoError(t, err)
+
+	assert.NoError(t, checkHealth(t, serveHealth(t, serverCreds), clientCreds))
+}
+
+func TestCredentials_ClientCertificateRequired(t *testing.T) {
+	server, client := mutualTLS(t, tlstest.NewCA(t))
+	serverCreds, err := ServerCredentials(server)
+	require.NoError(t, err)
+	address := serveHealth(t, serverCreds)
+
+	// no client certificate
+	clientCreds, err := ClientCredentials(TLSFiles{CAFile: client.CAFile})
+	require.NoError(t, err)
+	assert.Error(t, checkHealth(t, address, clientCreds))
+
+	// client certificate of another CA
+	_, other := mutualTLS(t, tlstest.NewCA(t))
+	clientCreds, err = ClientCredentials(TLSFiles{CertFile: other.CertFile, KeyFile: other.KeyFile, CAFile: client.CAFile})
+	require.NoError(t, err)
+	assert.Error(t, checkHealth(t, address, clientCreds))
+}
+
+func TestCredentials_UntrustedServer(t *testing.T) {
+	server, _ := mutualTLS(t, tlstest.NewCA(t))
+	_, client := mutualTLS(t, tlstest.NewCA(t))
+	serverCreds, err := ServerCredentials(TLSFiles{CertFile: server.CertFile, KeyFile: server.KeyFile})
+	require.NoError(t, err)
+	clientCreds, err := ClientCredentials(client)
+	require.NoError(t, err)
+
+	assert.Error(t, checkHealth(t, serveHealth(t, serverCreds), clientCreds))
+}
+
+func TestLoopbackCredentials(t *testing.T) {
+	ca := tlstest.NewCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.Issue(t, dir, "server")
+	files := TLSFiles{CertFile: certFile, KeyFile: keyFile}
+	serverCreds, err := ServerCredentials(files)
+	require.NoError(t, err)
+	loopbackCreds, err := LoopbackCredentials(files)
+	require.NoError(t, err)
+
+	// the server is trusted without a CA file and whatever name it is dialed at
+	assert.NoError(t, checkHealth(t, serveHealth(t, serverCreds), loopbackCreds))
+
+	// another server of the same CA is not
+	otherCert, otherKey := ca.Issue(t, dir, "other", "127.0.0.1")
+	otherCreds, err := ServerCredentials(TLSFiles{CertFile: otherCert, KeyFile: otherKey})
+	require.NoError(t, err)
+	assert.Error(t, checkHealth(t, serveHealth(t, otherCreds), loopbackCreds))
+}
+
+func TestLoopbackCredentials_Plaintext(t *testing.T) {
+	loopbackCreds, err := LoopbackCredentials(TLSFiles{CAFile: "ca.pem"})
+	require.NoError(t, err)
+	assert.Equal(t, "insecure", loopbackCreds.Info().SecurityProtocol)
+}
+
+func TestCredentials_Reload(t *testing.T) {
+	defer func(interval time.Duration) { certReloadInterval = interval }(certReloadInterval)
+	certReloadInterval = 0
+
+	server, client := mutualTLS(t, tlstest.NewCA(t))
+	serverCreds, err := ServerCredentials(server)
+	require.NoError(t, err)
+	clientCreds, err := ClientCredentials(client)
+	require.NoError(t, err)
+	address := serveHealth(t, serverCreds)
+	require.NoError(t, checkHealth(t, address, clientCreds))
+
+	// rotate both sides to certificates of a new CA while the server keeps running
+	rotated := tlstest.NewCA(t)
+	for _, files := range []TLSFiles{server, client} {
+		dir := filepath.Dir(files.CertFile)
+		name := filepath.Base(files.CertFile)
+		name = name[:len(name)-len(".pem")]
+		rotated.Issue(t, dir, name, "127.0.0.1", "localhost")
+		rotated.WriteCert(t, dir)
+		touch(t, files.CertFile, files.KeyFile, filepath.Join(dir, "ca.pem"))
+	}
+	assert.NoError(t, checkHealth(t, address, clientCreds))
+
+	// a client that still trusts the old CA is rejected
+	_, stale := mutualTLS(t, tlstest.NewCA(t))
+	staleCreds, err := ClientCredentials(stale)
+	require.NoError(t, err)
+	assert.Error(t, checkHealth(t, address, staleCreds))
+}
+
+func TestCredentials_FailedReloadKeepsCertificates(t *testing.T) {
+	defer func(interval time.Duration) { certReloadInterval = interval }(certReloadInterval)
+	certReloadInterval = 0
+
+	server, client := mutualTLS(t, tlstest.NewCA(t))
+	serverCreds, err := ServerCredentials(server)
+	require.NoError(t, err)
+	clientCreds, err := ClientCredentials(client)
+	require.NoError(t, err)
+	address := serveHealth(t, serverCreds)
+
+	require.NoError(t, os.WriteFile(server.CertFile, []byte("not a certificate"), 0o600))
+	touch(t, server.CertFile)
+	assert.NoError(t, checkHealth(t, address, clientCreds))
+}
+
+// touch moves the modification time of files forward so a reload does not depend on the
+// resolution of the file system clock.
+func touch(t *testing.T, files ...string) {
+	t.Helper()
+	future := time.Now().Add(time.Minute)
+	for _, file := range files {
+		require.NoError(t, os.Chtimes(file, future, future))
+	}
+}
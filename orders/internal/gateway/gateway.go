@@ -0,0 +1,53 @@
+// Package gateway serves the grpc services as JSON over HTTP for clients that cannot call grpc.
+// Requests are forwarded to the grpc server of the service, so they are authenticated, validated
+// and answered with the same errors as grpc calls.
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// RegisterFunc registers the routes of a grpc service, e.g. ordersPb.RegisterOrderServiceHandler.
+type RegisterFunc func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// Dial connects the gateway to the grpc server listening on listenAddress. The Authorization
+// header of every request is sent on as the token of its call.
+func Dial(listenAddress string, creds credentials.TransportCredentials) (*grpc.ClientConn, error) {
+	return grpc.Dial(loopback(listenAddress),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), logging.UnaryClientInterceptor()),
+	)
+}
+
+// NewHandler returns the handler serving the routes of the services registered by register.
+func NewHandler(ctx context.Context, conn *grpc.ClientConn, register ...RegisterFunc) (http.Handler, error) {
+	mux := runtime.NewServeMux()
+	for _, r := range register {
+		if err := r(ctx, mux, conn); err != nil {
+			return nil, err
+		}
+	}
+	// the logging middleware runs inside the span so the request logger carries the trace id
+	return tracing.HTTPHandler(logging.HTTPMiddleware(mux), "gateway"), nil
+}
+
+// loopback returns the address the grpc server listening on listenAddress is dialed at. Servers
+// listening on every interface are dialed on localhost.
+func loopback(listenAddress string) string {
+	host, port, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return listenAddress
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
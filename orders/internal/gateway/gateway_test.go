@@ -0,0 +1,155 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/config"
+	"github.com/wathuta/technical_test/orders/internal/config/tlstest"
+	ordersPb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeOrderService keeps the metadata and requests of the calls it answers.
+type fakeOrderService struct {
+	ordersPb.UnimplementedOrderServiceServer
+	md     metadata.MD
+	update *ordersPb.UpdateOrderRequest
+}
+
+func (s *fakeOrderService) GetOrderById(ctx context.Context, req *ordersPb.GetOrderRequest) (*ordersPb.GetOrderResponse, error) {
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	if req.OrderId == "missing" {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	return &ordersPb.GetOrderResponse{Order: &ordersPb.Order{OrderId: req.OrderId, OrderStatus: ordersPb.OrderStatus_ORDER_STATUS_PENDING}}, nil
+}
+
+func (s *fakeOrderService) UpdateOrder(ctx context.Context, req *ordersPb.UpdateOrderRequest) (*ordersPb.UpdateOrderResponse, error) {
+	s.update = req
+	return &ordersPb.UpdateOrderResponse{Order: req.Order}, nil
+}
+
+// newTestGateway serves fake over grpc, secured by files, and returns the URL of a gateway in
+// front of it.
+func newTestGateway(t *testing.T, fake *fakeOrderService, files config.TLSFiles) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serverCreds, err := config.ServerCredentials(files)
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.Creds(serverCreds))
+	ordersPb.RegisterOrderServiceServer(srv, fake)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	loopbackCreds, err := config.LoopbackCredentials(files)
+	require.NoError(t, err)
+	conn, err := Dial(listener.Addr().String(), loopbackCreds)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	handler, err := NewHandler(context.Background(), conn, ordersPb.RegisterOrderServiceHandler)
+	require.NoError(t, err)
+	gateway := httptest.NewServer(handler)
+	t.Cleanup(gateway.Close)
+	return gateway.URL
+}
+
+func TestGateway_ForwardsRequests(t *testing.T) {
+	fake := &fakeOrderService{}
+	url := newTestGateway(t, fake, config.TLSFiles{})
+
+	req, err := http.NewRequest(http.MethodGet, url+"/v1/orders/order-1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Request-Id", "request-1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Order struct {
+			OrderID     string `json:"orderId"`
+			OrderStatus string `json:"orderStatus"`
+		} `json:"order"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "order-1", body.Order.OrderID)
+	assert.Equal(t, "ORDER_STATUS_PENDING", body.Order.OrderStatus)
+
+	// the grpc server authenticates the caller and logs the request as usual
+	assert.Equal(t, []string{"Bearer token"}, fake.md.Get("authorization"))
+	assert.Equal(t, []string{"request-1"}, fake.md.Get("x-request-id"))
+	assert.Equal(t, "request-1", resp.Header.Get("X-Request-Id"))
+}
+
+func TestGateway_ServerOnlyTLS(t *testing.T) {
+	// the certificate is not valid for the loopback address and no CA file is configured
+	dir := t.TempDir()
+	certFile, keyFile := tlstest.NewCA(t).Issue(t, dir, "orders")
+	url := newTestGateway(t, &fakeOrderService{}, config.TLSFiles{CertFile: certFile, KeyFile: keyFile})
+
+	resp, err := http.Get(url + "/v1/orders/order-1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGateway_MapsErrors(t *testing.T) {
+	url := newTestGateway(t, &fakeOrderService{}, config.TLSFiles{})
+
+	resp, err := http.Get(url + "/v1/orders/missing")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	var body struct {
+		Code    codes.Code `json:"code"`
+		Message string     `json:"message"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, codes.NotFound, body.Code)
+	assert.Equal(t, "order not found", body.Message)
+}
+
+func TestGateway_PatchSetsUpdateMask(t *testing.T) {
+	fake := &fakeOrderService{}
+	url := newTestGateway(t, fake, config.TLSFiles{})
+
+	req, err := http.NewRequest(http.MethodPatch, url+"/v1/orders/order-1", strings.NewReader(`{"specialInstructions":"leave at the gate"}`))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotNil(t, fake.update)
+	assert.Equal(t, "order-1", fake.update.Order.OrderId)
+	assert.Equal(t, "leave at the gate", fake.update.Order.SpecialInstructions)
+	assert.Equal(t, []string{"special_instructions"}, fake.update.UpdateMask.GetPaths())
+}
+
+func TestLoopback(t *testing.T) {
+	for addr, want := range map[string]string{
+		":5000":          "localhost:5000",
+		"0.0.0.0:5000":   "localhost:5000",
+		"[::]:5000":      "localhost:5000",
+		"10.0.0.1:5000":  "10.0.0.1:5000",
+		"orders:5000":    "orders:5000",
+		"not an address": "not an address",
+	} {
+		assert.Equal(t, want, loopback(addr), addr)
+	}
+}
@@ -14,4 +14,8 @@ type ServiceResult struct {
 
 type PaymentServiceClient interface {
 	CreatePaymentRequest(ctx context.Context, status *paymentpb.CreatePaymentRequest) chan ServiceResult
+	GetPaymentByOrderId(ctx context.Context, orderId string) chan ServiceResult
+	// CheckHealth reports an error when the service cannot be reached, is not serving or calls to it
+	// are failing fast because its circuit breaker is open.
+	CheckHealth(ctx context.Context) error
 }
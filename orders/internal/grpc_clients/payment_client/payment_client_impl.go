@@ -2,26 +2,47 @@ package paymentclient
 
 import (
 	"context"
+	"fmt"
 
 	grpcclients "github.com/wathuta/technical_test/orders/internal/grpc_clients"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// idempotentMethods are retried when the payment service is unavailable.
+var idempotentMethods = []string{
+	"/ecommerce.PaymentService/GetPaymentByOrderId",
+}
+
 type orderClient struct {
 	client paymentpb.PaymentServiceClient
+	health healthpb.HealthClient
+	// resilience holds the circuit breaker reported by CheckHealth
+	resilience *grpcclients.Resilience
 }
 
-func NewPaymentClient(host string) (grpcclients.PaymentServiceClient, error) {
-	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewPaymentClient connects to the payment service over transportCreds. Every call is made with the service token in creds
+// and is retried, broken and limited as opts say.
+func NewPaymentClient(host string, transportCreds credentials.TransportCredentials, creds credentials.PerRPCCredentials, opts grpcclients.Options) (grpcclients.PaymentServiceClient, error) {
+	resilience := grpcclients.NewResilience(opts, idempotentMethods...)
+	conn, err := grpc.Dial(host,
+		grpc.WithTransportCredentials(transportCreds),
+		grpc.WithPerRPCCredentials(creds),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), logging.UnaryClientInterceptor(), resilience.UnaryClientInterceptor()),
+	)
 	if err != nil {
 		return nil, err
 	}
 
 	client := paymentpb.NewPaymentServiceClient(conn)
 	return &orderClient{
-		client: client,
+		client:     client,
+		health:     healthpb.NewHealthClient(conn),
+		resilience: resilience,
 	}, nil
 }
 func (oc *orderClient) CreatePaymentRequest(ctx context.Context, args *paymentpb.CreatePaymentRequest) chan grpcclients.ServiceResult {
@@ -29,7 +50,7 @@ func (oc *orderClient) CreatePaymentRequest(ctx context.Context, args *paymentpb
 
 	go func() {
 		defer close(output)
-		res, err := oc.client.CreatePayment(context.Background(), args)
+		res, err := oc.client.CreatePayment(ctx, args)
 		if err != nil {
 			output <- grpcclients.ServiceResult{Error: err}
 		} else {
@@ -38,3 +59,32 @@ func (oc *orderClient) CreatePaymentRequest(ctx context.Context, args *paymentpb
 	}()
 	return output
 }
+
+func (oc *orderClient) GetPaymentByOrderId(ctx context.Context, orderId string) chan grpcclients.ServiceResult {
+	output := make(chan grpcclients.ServiceResult)
+
+	go func() {
+		defer close(output)
+		res, err := oc.client.GetPaymentByOrderId(ctx, &paymentpb.GetPaymentByOrderIdRequest{OrderId: orderId})
+		if err != nil {
+			output <- grpcclients.ServiceResult{Error: err}
+		} else {
+			output <- grpcclients.ServiceResult{Result: res.Payment, Error: nil}
+		}
+	}()
+	return output
+}
+
+func (oc *orderClient) CheckHealth(ctx context.Context) error {
+	if state := oc.resilience.BreakerState(); state == grpcclients.BreakerOpen {
+		return fmt.Errorf("circuit breaker is %s", state)
+	}
+	res, err := oc.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service is %s", res.Status)
+	}
+	return nil
+}
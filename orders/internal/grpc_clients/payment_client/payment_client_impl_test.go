@@ -0,0 +1,122 @@
+package paymentclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	grpcclients "github.com/wathuta/technical_test/orders/internal/grpc_clients"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// fakePaymentServer blocks CreatePayment until the call is abandoned and reports how it ended.
+type fakePaymentServer struct {
+	paymentpb.UnimplementedPaymentServiceServer
+
+	calls     chan time.Time
+	abandoned chan error
+}
+
+func (s *fakePaymentServer) CreatePayment(ctx context.Context, req *paymentpb.CreatePaymentRequest) (*paymentpb.CreatePaymentResponse, error) {
+	deadline, _ := ctx.Deadline()
+	s.calls <- deadline
+	select {
+	case <-ctx.Done():
+		s.abandoned <- ctx.Err()
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return &paymentpb.CreatePaymentResponse{Payment: &paymentpb.Payment{}}, nil
+	}
+}
+
+func withTimeout(timeout time.Duration) grpcclients.Options {
+	opts := grpcclients.DefaultOptions()
+	opts.Timeout = timeout
+	return opts
+}
+
+func newTestClient(t *testing.T, opts grpcclients.Options) (*orderClient, *fakePaymentServer) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	fake := &fakePaymentServer{calls: make(chan time.Time, 1), abandoned: make(chan error, 1)}
+	srv := grpc.NewServer()
+	paymentpb.RegisterPaymentServiceServer(srv, fake)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	client, err := NewPaymentClient(listener.Addr().String(), insecure.NewCredentials(), auth.NewTokenCredentials("token", false), opts)
+	require.NoError(t, err)
+	return client.(*orderClient), fake
+}
+
+func TestCreatePaymentRequest_CanceledBeforeCall(t *testing.T) {
+	client, fake := newTestClient(t, withTimeout(time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := <-client.CreatePaymentRequest(ctx, &paymentpb.CreatePaymentRequest{})
+
+	assert.Equal(t, codes.Canceled, status.Code(result.Error))
+	assert.Empty(t, fake.calls, "a cancelled request does not reach the payment service")
+}
+
+func TestCreatePaymentRequest_CanceledDuringCall(t *testing.T) {
+	client, fake := newTestClient(t, withTimeout(time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := client.CreatePaymentRequest(ctx, &paymentpb.CreatePaymentRequest{})
+	<-fake.calls
+	cancel()
+
+	assert.Equal(t, codes.Canceled, status.Code((<-results).Error))
+	select {
+	case err := <-fake.abandoned:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("the payment service kept working on a cancelled request")
+	}
+}
+
+func TestCreatePaymentRequest_Deadline(t *testing.T) {
+	client, fake := newTestClient(t, withTimeout(100*time.Millisecond))
+
+	start := time.Now()
+	result := <-client.CreatePaymentRequest(context.Background(), &paymentpb.CreatePaymentRequest{})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(result.Error))
+	assert.WithinDuration(t, start.Add(100*time.Millisecond), <-fake.calls, 50*time.Millisecond, "the deadline is sent to the payment service")
+	assert.Equal(t, context.DeadlineExceeded, <-fake.abandoned)
+}
+
+func TestCreatePaymentRequest_KeepsEarlierCallerDeadline(t *testing.T) {
+	client, fake := newTestClient(t, withTimeout(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	callerDeadline, _ := ctx.Deadline()
+	result := <-client.CreatePaymentRequest(ctx, &paymentpb.CreatePaymentRequest{})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(result.Error))
+	assert.WithinDuration(t, callerDeadline, <-fake.calls, 50*time.Millisecond)
+}
+
+func TestCheckHealth_BreakerOpen(t *testing.T) {
+	opts := withTimeout(50 * time.Millisecond)
+	opts.BreakerThreshold = 1
+	client, _ := newTestClient(t, opts)
+
+	result := <-client.CreatePaymentRequest(context.Background(), &paymentpb.CreatePaymentRequest{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(result.Error))
+
+	err := client.CheckHealth(context.Background())
+	assert.EqualError(t, err, "circuit breaker is open")
+}
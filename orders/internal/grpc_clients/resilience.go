@@ -0,0 +1,275 @@
+package grpcclients
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wathuta/technical_test/orders/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errBreakerOpen  = status.Error(codes.Unavailable, "circuit breaker is open")
+	errTooManyCalls = status.Error(codes.ResourceExhausted, "too many concurrent calls")
+)
+
+// Options configure how calls to another service are made.
+type Options struct {
+	// Timeout bounds every call including its retries, callers with an earlier deadline keep theirs.
+	Timeout time.Duration
+	// MaxAttempts is the number of times an idempotent call is made while the service is unavailable.
+	MaxAttempts int
+	// RetryBackoff is the wait before the first retry. It doubles with every retry up to MaxBackoff
+	// and is jittered so clients do not retry in step.
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	// BreakerThreshold consecutive failures open the circuit breaker, calls then fail straight
+	// away for BreakerCooldown before a single trial call is let through.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// MaxConcurrentCalls limits the calls in flight, further calls are rejected so a slow service
+	// cannot tie up every request. Calls are not limited when it is 0 or less.
+	MaxConcurrentCalls int
+}
+
+// DefaultOptions returns the options used for settings not given in the environment.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:            30 * time.Second,
+		MaxAttempts:        3,
+		RetryBackoff:       100 * time.Millisecond,
+		MaxBackoff:         2 * time.Second,
+		BreakerThreshold:   5,
+		BreakerCooldown:    30 * time.Second,
+		MaxConcurrentCalls: 100,
+	}
+}
+
+// EnvVars names the env variables overriding the default options of the calls to a service.
+type EnvVars struct {
+	Timeout            string
+	MaxAttempts        string
+	RetryBackoff       string
+	BreakerThreshold   string
+	BreakerCooldown    string
+	MaxConcurrentCalls string
+}
+
+// OptionsFromEnv returns defaults with the settings given in the env variables named by vars.
+func OptionsFromEnv(vars EnvVars, defaults Options) (Options, error) {
+	opts := defaults
+	var err error
+	if opts.Timeout, err = config.DurationFromEnv(vars.Timeout, defaults.Timeout); err != nil {
+		return Options{}, err
+	}
+	if opts.MaxAttempts, err = config.IntFromEnv(vars.MaxAttempts, defaults.MaxAttempts); err != nil {
+		return Options{}, err
+	}
+	if opts.RetryBackoff, err = config.DurationFromEnv(vars.RetryBackoff, defaults.RetryBackoff); err != nil {
+		return Options{}, err
+	}
+	if opts.BreakerThreshold, err = config.IntFromEnv(vars.BreakerThreshold, defaults.BreakerThreshold); err != nil {
+		return Options{}, err
+	}
+	if opts.BreakerCooldown, err = config.DurationFromEnv(vars.BreakerCooldown, defaults.BreakerCooldown); err != nil {
+		return Options{}, err
+	}
+	if opts.MaxConcurrentCalls, err = config.IntFromEnv(vars.MaxConcurrentCalls, defaults.MaxConcurrentCalls); err != nil {
+		return Options{}, err
+	}
+	if opts.MaxBackoff < opts.RetryBackoff {
+		opts.MaxBackoff = opts.RetryBackoff
+	}
+	return opts, nil
+}
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is a consecutive failures circuit breaker.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// allow reports whether a call may be made. Once the cooldown has passed an open breaker lets a
+// single trial call through.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.trial = true
+		return true
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// ignore lets another trial call through after a call whose outcome says nothing about the service.
+func (b *breaker) ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// record updates the breaker with the outcome of a call it allowed.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+			b.state = BreakerOpen
+			b.openedAt = b.now()
+		}
+	case codes.Canceled:
+		// the caller gave up, this says nothing about the service
+	default:
+		// any answer, errors included, shows the service is up
+		b.state = BreakerClosed
+		b.failures = 0
+	}
+	b.trial = false
+}
+
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Resilience makes calls to another service with a timeout, retries of idempotent calls, a
+// circuit breaker and a concurrency limit.
+type Resilience struct {
+	opts       Options
+	idempotent map[string]bool
+	breaker    *breaker
+	// slots holds a value for every call in flight, it is nil when calls are not limited.
+	slots chan struct{}
+}
+
+// NewResilience returns a Resilience for the calls made with opts. Only the idempotent methods,
+// given as full method names, are retried.
+func NewResilience(opts Options, idempotent ...string) *Resilience {
+	r := &Resilience{
+		opts:       opts,
+		idempotent: make(map[string]bool, len(idempotent)),
+		breaker:    &breaker{threshold: opts.BreakerThreshold, cooldown: opts.BreakerCooldown, now: time.Now},
+	}
+	if opts.MaxConcurrentCalls > 0 {
+		r.slots = make(chan struct{}, opts.MaxConcurrentCalls)
+	}
+	for _, method := range idempotent {
+		r.idempotent[method] = true
+	}
+	return r
+}
+
+// BreakerState returns the state of the circuit breaker.
+func (r *Resilience) BreakerState() BreakerState {
+	return r.breaker.State()
+}
+
+// backoff returns the jittered wait before retry number retry, starting at 1.
+func (r *Resilience) backoff(retry int) time.Duration {
+	backoff := r.opts.RetryBackoff << (retry - 1)
+	if backoff > r.opts.MaxBackoff || backoff <= 0 {
+		backoff = r.opts.MaxBackoff
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// UnaryClientInterceptor applies the options to the calls made on a connection. Health checks go
+// straight through so they report on the service rather than on the breaker.
+func (r *Resilience) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if r.slots != nil {
+			select {
+			case r.slots <- struct{}{}:
+				defer func() { <-r.slots }()
+			default:
+				return errTooManyCalls
+			}
+		}
+
+		caller := ctx
+		ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+
+		for attempt := 1; ; attempt++ {
+			if !r.breaker.allow() {
+				return errBreakerOpen
+			}
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if caller.Err() != nil {
+				// the caller gave up or ran out of its own time, the service may well be up
+				r.breaker.ignore()
+				return err
+			}
+			r.breaker.record(err)
+			if status.Code(err) != codes.Unavailable || !r.idempotent[method] || attempt >= r.opts.MaxAttempts {
+				return err
+			}
+			if sleep(ctx, r.backoff(attempt)) != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package grpcclients
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	getMethod    = "/test.Flaky/Get"
+	createMethod = "/test.Flaky/Create"
+)
+
+// flakyServer answers the calls with the codes it is given in turn, then with OK. Calls are held
+// while block is set.
+type flakyServer struct {
+	mu    sync.Mutex
+	codes []codes.Code
+	calls int
+	block chan struct{}
+}
+
+func (s *flakyServer) fail(c ...codes.Code) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes = append(s.codes, c...)
+}
+
+func (s *flakyServer) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *flakyServer) handle(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+	if err := dec(&emptypb.Empty{}); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.calls++
+	code := codes.OK
+	if len(s.codes) > 0 {
+		code, s.codes = s.codes[0], s.codes[1:]
+	}
+	block := s.block
+	s.mu.Unlock()
+
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if code != codes.OK {
+		return nil, status.Error(code, "injected failure")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func testOptions() Options {
+	return Options{
+		Timeout:            time.Second,
+		MaxAttempts:        3,
+		RetryBackoff:       time.Millisecond,
+		MaxBackoff:         5 * time.Millisecond,
+		BreakerThreshold:   100,
+		BreakerCooldown:    time.Minute,
+		MaxConcurrentCalls: 10,
+	}
+}
+
+// newFlakyConn serves a flakyServer and connects to it through a Resilience made with opts.
+func newFlakyConn(t *testing.T, opts Options) (*flakyServer, *Resilience, *grpc.ClientConn) {
+	t.Helper()
+	fake := &flakyServer{}
+	desc := &grpc.ServiceDesc{
+		ServiceName: "test.Flaky",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Get", Handler: fake.handle},
+			{MethodName: "Create", Handler: fake.handle},
+		},
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	srv.RegisterService(desc, fake)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	resilience := NewResilience(opts, getMethod)
+	conn, err := grpc.Dial(listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(resilience.UnaryClientInterceptor()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return fake, resilience, conn
+}
+
+func call(conn *grpc.ClientConn, method string) error {
+	return conn.Invoke(context.Background(), method, &emptypb.Empty{}, &emptypb.Empty{})
+}
+
+func TestResilience_RetriesIdempotentCalls(t *testing.T) {
+	fake, _, conn := newFlakyConn(t, testOptions())
+
+	fake.fail(codes.Unavailable, codes.Unavailable)
+	assert.NoError(t, call(conn, getMethod))
+	assert.Equal(t, 3, fake.callCount())
+
+	// calls are made MaxAttempts times at most
+	fake.fail(codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.Unavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(call(conn, getMethod)))
+	assert.Equal(t, 6, fake.callCount())
+}
+
+func TestResilience_DoesNotRetry(t *testing.T) {
+	fake, _, conn := newFlakyConn(t, testOptions())
+
+	// creating twice could charge a customer twice
+	fake.fail(codes.Unavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(call(conn, createMethod)))
+	assert.Equal(t, 1, fake.callCount())
+
+	// only unavailable services are retried
+	fake.fail(codes.Internal)
+	assert.Equal(t, codes.Internal, status.Code(call(conn, getMethod)))
+	assert.Equal(t, 2, fake.callCount())
+}
+
+func TestResilience_Timeout(t *testing.T) {
+	opts := testOptions()
+	opts.Timeout = 50 * time.Millisecond
+	fake, _, conn := newFlakyConn(t, opts)
+	fake.block = make(chan struct{})
+	defer close(fake.block)
+
+	start := time.Now()
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(call(conn, getMethod)))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestResilience_Breaker(t *testing.T) {
+	opts := testOptions()
+	opts.MaxAttempts = 1
+	opts.BreakerThreshold = 2
+	fake, resilience, conn := newFlakyConn(t, opts)
+	now := time.Now()
+	resilience.breaker.now = func() time.Time { return now }
+
+	// answers, even errors, keep the breaker closed
+	fake.fail(codes.NotFound, codes.Unavailable, codes.NotFound)
+	for i := 0; i < 3; i++ {
+		_ = call(conn, createMethod)
+	}
+	assert.Equal(t, BreakerClosed, resilience.BreakerState())
+
+	fake.fail(codes.Unavailable, codes.Unavailable)
+	_ = call(conn, createMethod)
+	_ = call(conn, createMethod)
+	assert.Equal(t, BreakerOpen, resilience.BreakerState())
+
+	// calls fail fast without reaching the service
+	assert.Equal(t, errBreakerOpen, call(conn, createMethod))
+	assert.Equal(t, 5, fake.callCount())
+
+	// after the cooldown a failed trial call opens the breaker again
+	now = now.Add(opts.BreakerCooldown)
+	fake.fail(codes.Unavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(call(conn, createMethod)))
+	assert.Equal(t, BreakerOpen, resilience.BreakerState())
+	assert.Equal(t, errBreakerOpen, call(conn, createMethod))
+
+	// and a successful one closes it
+	now = now.Add(opts.BreakerCooldown)
+	assert.NoError(t, call(conn, createMethod))
+	assert.Equal(t, BreakerClosed, resilience.BreakerState())
+	assert.Equal(t, 7, fake.callCount())
+}
+
+func TestResilience_HalfOpenAllowsOneTrial(t *testing.T) {
+	b := &breaker{threshold: 1, cooldown: time.Minute, now: time.Now}
+	b.record(status.Error(codes.Unavailable, ""))
+	b.openedAt = time.Now().Add(-time.Minute)
+
+	assert.True(t, b.allow())
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.False(t, b.allow(), "only one trial call is made at a time")
+
+	// a trial cancelled by its caller lets another one through
+	b.record(status.Error(codes.Canceled, ""))
+	assert.True(t, b.allow())
+}
+
+func TestResilience_ConcurrencyLimit(t *testing.T) {
+	opts := testOptions()
+	opts.MaxConcurrentCalls = 1
+	fake, _, conn := newFlakyConn(t, opts)
+	fake.block = make(chan struct{})
+
+	done := make(chan error)
+	go func() { done <- call(conn, getMethod) }()
+	require.Eventually(t, func() bool { return fake.callCount() == 1 }, time.Second, time.Millisecond)
+
+	assert.Equal(t, errTooManyCalls, call(conn, getMethod))
+
+	close(fake.block)
+	assert.NoError(t, <-done)
+	assert.NoError(t, call(conn, getMethod))
+}
+
+func TestResilience_NoConcurrencyLimit(t *testing.T) {
+	opts := testOptions()
+	opts.MaxConcurrentCalls = 0
+	fake, _, conn := newFlakyConn(t, opts)
+	fake.block = make(chan struct{})
+
+	done := make(chan error)
+	go func() { done <- call(conn, getMethod) }()
+	require.Eventually(t, func() bool { return fake.callCount() == 1 }, time.Second, time.Millisecond)
+
+	go func() { done <- call(conn, getMethod) }()
+	require.Eventually(t, func() bool { return fake.callCount() == 2 }, time.Second, time.Millisecond)
+
+	close(fake.block)
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+}
+
+func TestResilience_CallerDeadline(t *testing.T) {
+	opts := testOptions()
+	opts.BreakerThreshold = 1
+	fake, resilience, conn := newFlakyConn(t, opts)
+	fake.block = make(chan struct{})
+	defer close(fake.block)
+
+	// a caller with a short deadline does not open the breaker for every other caller
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := conn.Invoke(ctx, getMethod, &emptypb.Empty{}, &emptypb.Empty{})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, BreakerClosed, resilience.BreakerState())
+}
+
+func TestResilience_Backoff(t *testing.T) {
+	r := NewResilience(Options{RetryBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+
+	for retry, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second, 64: time.Second} {
+		for i := 0; i < 20; i++ {
+			backoff := r.backoff(retry)
+			assert.GreaterOrEqual(t, backoff, max/2)
+			assert.LessOrEqual(t, backoff, max)
+		}
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	vars := EnvVars{
+		Timeout:            "TEST_CLIENT_TIMEOUT",
+		MaxAttempts:        "TEST_CLIENT_MAX_ATTEMPTS",
+		RetryBackoff:       "TEST_CLIENT_RETRY_BACKOFF",
+		BreakerThreshold:   "TEST_CLIENT_BREAKER_THRESHOLD",
+		BreakerCooldown:    "TEST_CLIENT_BREAKER_COOLDOWN",
+		MaxConcurrentCalls: "TEST_CLIENT_MAX_CONCURRENT_CALLS",
+	}
+	opts, err := OptionsFromEnv(vars, DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOptions(), opts)
+
+	t.Setenv(vars.Timeout, "2s")
+	t.Setenv(vars.MaxAttempts, "5")
+	t.Setenv(vars.RetryBackoff, "3s")
+	t.Setenv(vars.MaxConcurrentCalls, "7")
+	opts, err = OptionsFromEnv(vars, DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, opts.Timeout)
+	assert.Equal(t, 5, opts.MaxAttempts)
+	assert.Equal(t, 3*time.Second, opts.RetryBackoff)
+	assert.Equal(t, 3*time.Second, opts.MaxBackoff, "the backoff is never capped below the first one")
+	assert.Equal(t, 7, opts.MaxConcurrentCalls)
+
+	t.Setenv(vars.BreakerThreshold, "0")
+	_, err = OptionsFromEnv(vars, DefaultOptions())
+	assert.Error(t, err)
+
+	t.Setenv(vars.BreakerThreshold, "")
+	t.Setenv(vars.MaxConcurrentCalls, "-1")
+	_, err = OptionsFromEnv(vars, DefaultOptions())
+	assert.Error(t, err)
+}
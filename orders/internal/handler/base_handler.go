@@ -1,13 +1,25 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/wathuta/technical_test/orders/internal/common"
+	"github.com/wathuta/technical_test/orders/internal/common/fieldmask"
 	grpcclients "github.com/wathuta/technical_test/orders/internal/grpc_clients"
+	"github.com/wathuta/technical_test/orders/internal/pricing"
+	"github.com/wathuta/technical_test/orders/internal/promotions"
 	"github.com/wathuta/technical_test/orders/internal/repository"
+	"github.com/wathuta/technical_test/orders/internal/scheduling"
+	"github.com/wathuta/technical_test/orders/internal/tax"
 	"github.com/wathuta/technical_test/protos_gen/customers"
 	"github.com/wathuta/technical_test/protos_gen/orders"
 	"github.com/wathuta/technical_test/protos_gen/products"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
 )
 
 const (
@@ -18,18 +30,31 @@ const (
 
 var (
 	errInternal                   = status.Error(codes.Internal, "internal error")
-	errNotFound                   = status.Error(codes.InvalidArgument, "resource not found")
+	errNotFound                   = status.Error(codes.NotFound, "resource not found")
+	errAlreadyExists              = status.Error(codes.AlreadyExists, "resource already exists")
 	errResourceRequired           = status.Error(codes.InvalidArgument, "resource required")
 	errResourceUpdateMaskRequired = status.Error(codes.InvalidArgument, "resource update mask required")
 	errBadRequest                 = status.Error(codes.InvalidArgument, "invalid request payload")
+	errPermissionDenied           = status.Error(codes.PermissionDenied, "permission denied")
+	errCanceled                   = status.Error(codes.Canceled, "request canceled")
+	errDeadlineExceeded           = status.Error(codes.DeadlineExceeded, "request timed out")
+	errUnavailable                = status.Error(codes.Unavailable, "service unavailable, try again later")
+	errReferenceNotFound          = status.Error(codes.FailedPrecondition, "referenced resource not found")
+	errConflict                   = status.Error(codes.Aborted, "request conflicted with a concurrent one, try again")
+	errVersionMismatch            = status.Error(codes.Aborted, "resource was modified since it was read, get it again")
 )
 
 type Handler struct {
 	customers.UnimplementedCustomerServiceServer
 	orders.UnimplementedOrderServiceServer
+	orders.UnimplementedPromotionServiceServer
+	orders.UnimplementedShipmentServiceServer
 	products.UnimplementedProductServiceServer
 
-	repo repository.Repository
+	repo       repository.Repository
+	pricer     *pricing.Calculator
+	promotions *promotions.Rules
+	scheduler  *scheduling.Scheduler
 
 	paymentclients grpcclients.PaymentServiceClient
 }
@@ -39,7 +64,118 @@ func New(
 	clients grpcclients.PaymentServiceClient,
 ) *Handler {
 	return &Handler{
-		repo:    repo,
+		repo:           repo,
+		pricer:         pricing.NewCalculator(repo, tax.NewRules(repo)),
+		promotions:     promotions.NewRules(repo),
+		scheduler:      scheduling.NewScheduler(repo),
 		paymentclients: clients,
 	}
 }
+
+// callError is returned when a call to another service fails. Calls given up because the request
+// was cancelled or ran out of time keep that code so callers can tell them apart from failures.
+func callError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled:
+		return errCanceled
+	case errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded:
+		return errDeadlineExceeded
+	case status.Code(err) == codes.Unavailable:
+		return errUnavailable
+	}
+	return errInternal
+}
+
+// validationError returns an InvalidArgument error with a google.rpc.BadRequest field violation
+// for every field of err, the error of a validator.
+func validationError(err error) error {
+	fields := common.ValidatorErrors(err)
+	if len(fields) == 0 {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return badRequest(fields)
+}
+
+// badRequest returns an InvalidArgument error with a google.rpc.BadRequest field violation for
+// every field, fields maps the path of the fields to the violations.
+func badRequest(fields map[string]string) error {
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	details := &errdetails.BadRequest{}
+	for _, path := range paths {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       path,
+			Description: fields[path],
+		})
+	}
+	return withDetails(status.New(codes.InvalidArgument, "invalid request payload"), details)
+}
+
+// requireVersion returns an InvalidArgument error for field when no version is sent. Updates
+// must send the version the caller read so that they never overwrite changes it has not seen.
+func requireVersion(field string, version int64) error {
+	if version == 0 {
+		return badRequest(map[string]string{field: "is required"})
+	}
+	return nil
+}
+
+// preconditionError returns a FailedPrecondition error with a google.rpc.PreconditionFailure
+// describing the failed check.
+func preconditionError(violationType, subject string, err error) error {
+	return withDetails(status.New(codes.FailedPrecondition, err.Error()), &errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{{
+			Type:        violationType,
+			Subject:     subject,
+			Description: err.Error(),
+		}},
+	})
+}
+
+// withDetails returns the error of s with details attached, or of s alone when they cannot be.
+func withDetails(s *status.Status, details protoiface.MessageV1) error {
+	detailed, err := s.WithDetails(details)
+	if err != nil {
+		return s.Err()
+	}
+	return detailed.Err()
+}
+
+// maskError is returned when the update mask of a request is missing or names fields that cannot
+// be updated.
+func maskError(err error) error {
+	if errors.Is(err, fieldmask.ErrRequired) {
+		return errResourceUpdateMaskRequired
+	}
+	return badRequest(map[string]string{"update_mask": err.Error()})
+}
+
+// dbError is returned when a database call fails, it maps the kinds of repository errors to grpc
+// statuses so every handler reports them alike.
+func dbError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return errDeadlineExceeded
+	case errors.Is(err, repository.ErrNotFound):
+		return errNotFound
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return errAlreadyExists
+	case errors.Is(err, repository.ErrReferenceNotFound):
+		return errReferenceNotFound
+	case errors.Is(err, repository.ErrConstraintViolated):
+		return errBadRequest
+	case errors.Is(err, repository.ErrConflict):
+		return errConflict
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return errVersionMismatch
+	case errors.Is(err, repository.ErrUnavailable):
+		return errUnavailable
+	}
+	return errInternal
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fieldViolations returns the fields of the google.rpc.BadRequest details of err.
+func fieldViolations(err error) []string {
+	var fields []string
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+		}
+	}
+	return fields
+}
+
+// errRowNotFound is returned by the repository for rows that do not exist.
+var errRowNotFound = &repository.Error{Kind: repository.ErrNotFound}
+
+// serviceContext is the context of a call made by another service of the platform.
+func serviceContext() context.Context {
+	return auth.ContextWithPrincipal(context.Background(), &auth.Principal{Subject: "payment", Roles: []auth.Role{auth.RoleService}})
+}
+
+func TestDBError(t *testing.T) {
+	for err, code := range map[error]codes.Code{
+		errors.New("some error"):    codes.Internal,
+		errRowNotFound:              codes.NotFound,
+		repository.ErrAlreadyExists: codes.AlreadyExists,
+		fmt.Errorf("insert: %w", repository.ErrAlreadyExists): codes.AlreadyExists,
+		repository.ErrReferenceNotFound:                       codes.FailedPrecondition,
+		repository.ErrConstraintViolated:                      codes.InvalidArgument,
+		repository.ErrConflict:                                codes.Aborted,
+		repository.ErrUnavailable:                             codes.Unavailable,
+		context.Canceled:                                      codes.Canceled,
+		fmt.Errorf("query: %w", context.DeadlineExceeded):     codes.DeadlineExceeded,
+	} {
+		assert.Equal(t, code, status.Code(dbError(err)), err.Error())
+	}
+}
+
+func TestPreconditionError(t *testing.T) {
+	err := preconditionError("PROMOTION", "SAVE10", errors.New("promotion is not active"))
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	details := status.Convert(err).Details()
+	if assert.Len(t, details, 1) {
+		failure := details[0].(*errdetails.PreconditionFailure)
+		assert.Equal(t, "PROMOTION", failure.Violations[0].Type)
+		assert.Equal(t, "SAVE10", failure.Violations[0].Subject)
+	}
+}
+
+func TestRequireVersion(t *testing.T) {
+	assert.NoError(t, requireVersion("order.version", 3))
+
+	err := requireVersion("order.version", 0)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, []string{"order.version"}, fieldViolations(err))
+}
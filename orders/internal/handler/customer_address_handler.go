@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	"github.com/wathuta/technical_test/orders/internal/common"
+	"github.com/wathuta/technical_test/orders/internal/common/fieldmask"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
+)
+
+// CreateCustomerAddress saves an address in the address book of a customer.
+func (h *Handler) CreateCustomerAddress(ctx context.Context, req *customersPb.CreateCustomerAddressRequest) (*customersPb.CreateCustomerAddressResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || req.Address == nil || len(req.CustomerId) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("create customer address", "customer_id", req.CustomerId)
+
+	customerUUID, err := uuid.Parse(req.CustomerId)
+	if err != nil {
+		logger.Error("invalid customer uuid value", "customer_id", req.CustomerId, "error", err)
+		return nil, errBadRequest
+	}
+	if !auth.CanAccessCustomer(ctx, customerUUID.String()) {
+		logger.Error("customer is not allowed to save addresses for another customer", "customer_id", customerUUID)
+		return nil, errNotFound
+	}
+
+	address := model.CustomerAddressFromProto(req.Address)
+	address.AddressID = uuid.NewString()
+	address.CustomerID = customerUUID.String()
+	address.CreatedAt = time.Now()
+	address.UpdatedAt = address.CreatedAt
+
+	validator := common.NewValidator()
+	if err := validator.Struct(address); err != nil {
+		logger.Error("failed to validate customer address", "error", err)
+		return nil, validationError(err)
+	}
+
+	created, err := h.repo.CreateCustomerAddress(ctx, address)
+	if err != nil {
+		logger.Error("failed to create customer address in db", "error", err)
+		return nil, dbError(err)
+	}
+	logger.Debug("create customer address successful", "address_id", created.AddressID)
+	return &customersPb.CreateCustomerAddressResponse{Address: created.Proto()}, nil
+}
+
+func (h *Handler) GetCustomerAddress(ctx context.Context, req *customersPb.GetCustomerAddressRequest) (*customersPb.GetCustomerAddressResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.CustomerId) == 0 || len(req.AddressId) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("get customer address", "customer_id", req.CustomerId, "address_id", req.AddressId)
+
+	customerId, addressId, err := h.customerAddressIds(ctx, req.CustomerId, req.AddressId)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := h.repo.GetCustomerAddress(ctx, customerId, addressId)
+	if err != nil {
+		logger.Error("failed to get customer address from db", "error", err)
+		return nil, dbError(err)
+	}
+	logger.Debug("get customer address successful")
+	return &customersPb.GetCustomerAddressResponse{Address: address.Proto()}, nil
+}
+
+// ListCustomerAddresses returns a page of the addresses of a customer in the order they were saved.
+func (h *Handler) ListCustomerAddresses(ctx context.Context, req *customersPb.ListCustomerAddressesRequest) (*customersPb.ListCustomerAddressesResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.CustomerId) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	pagesize := common.SetPageSize(int(req.PageSize), defaultPageSize, maxPageSize)
+	token := common.SetPageToken(int(req.PageToken))
+	logger.Debug("list customer addresses", "customer_id", req.CustomerId, "page_size", pagesize, "page_token", token)
+
+	customerUUID, err := uuid.Parse(req.CustomerId)
+	if err != nil {
+		logger.Error("invalid customer uuid value", "customer_id", req.CustomerId, "error", err)
+		return nil, errBadRequest
+	}
+	if !auth.CanAccessCustomer(ctx, customerUUID.String()) {
+		logger.Error("customer is not allowed to list the addresses of another customer", "customer_id", customerUUID)
+		return nil, errNotFound
+	}
+
+	addresses, err := h.repo.ListCustomerAddresses(ctx, customerUUID.String(), pagesize, token)
+	if err != nil {
+		logger.Error("failed to list customer addresses from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	returnAddresses := make([]*customersPb.CustomerAddress, 0, len(addresses))
+	for i := range addresses {
+		returnAddresses = append(returnAddresses, addresses[i].Proto())
+	}
+	logger.Debug("list customer addresses successful")
+	return &customersPb.ListCustomerAddressesResponse{
+		Addresses:     returnAddresses,
+		NextPageToken: int32(common.NextPageToken(token, pagesize, len(addresses))),
+	}, nil
+}
+
+func (h *Handler) UpdateCustomerAddress(ctx context.Context, req *customersPb.UpdateCustomerAddressRequest) (*customersPb.UpdateCustomerAddressResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || req.Address == nil {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("update customer address", "customer_id", req.Address.CustomerId, "address_id", req.Address.AddressId)
+
+	mask, err := fieldmask.New(req.UpdateMask, req.Address)
+	if err != nil {
+		logger.Error("invalid update mask", "error", err)
+		return nil, maskError(err)
+	}
+	mask.RemoveOutputOnly()
+
+	customerId, addressId, err := h.customerAddressIds(ctx, req.Address.CustomerId, req.Address.AddressId)
+	if err != nil {
+		return nil, err
+	}
+
+	address := model.CustomerAddressFromProto(req.Address)
+	updateFields := model.UpdateCustomerAddressMapping(mask.Fields, *address)
+	// a replaced address must be complete, the ids in the request were checked above
+	if _, ok := updateFields["address"].(model.Address); ok {
+		if err := common.NewValidator().Struct(address); err != nil {
+			logger.Error("failed to validate customer address", "error", err)
+			return nil, validationError(err)
+		}
+	}
+
+	// if fieldmask is empty perfom get
+	var updated *model.CustomerAddress
+	if len(updateFields) == 0 {
+		logger.Debug("no fields to update")
+		updated, err = h.repo.GetCustomerAddress(ctx, customerId, addressId)
+	} else {
+		updateFields["updated_at"] = time.Now()
+		updated, err = h.repo.UpdateCustomerAddress(ctx, customerId, addressId, updateFields)
+	}
+	if err != nil {
+		logger.Error("failed to update customer address in db", "error", err)
+		return nil, dbError(err)
+	}
+
+	logger.Debug("update customer address successful")
+	return &customersPb.UpdateCustomerAddressResponse{Address: updated.Proto()}, nil
+}
+
+func (h *Handler) DeleteCustomerAddress(ctx context.Context, req *customersPb.DeleteCustomerAddressRequest) (*customersPb.DeleteCustomerAddressResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.CustomerId) == 0 || len(req.AddressId) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return &customersPb.DeleteCustomerAddressResponse{Success: false}, errResourceRequired
+	}
+	logger.Debug("delete customer address", "customer_id", req.CustomerId, "address_id", req.AddressId)
+
+	customerId, addressId, err := h.customerAddressIds(ctx, req.CustomerId, req.AddressId)
+	if err != nil {
+		return &customersPb.DeleteCustomerAddressResponse{Success: false}, err
+	}
+
+	if _, err := h.repo.DeleteCustomerAddress(ctx, customerId, addressId); err != nil {
+		logger.Error("failed to delete customer address from db", "error", err)
+		return &customersPb.DeleteCustomerAddressResponse{Success: false}, dbError(err)
+	}
+
+	logger.Debug("delete customer address successful")
+	return &customersPb.DeleteCustomerAddressResponse{Success: true}, nil
+}
+
+// customerAddressIds parses the ids of a customer address and checks that the caller may access
+// the addresses of the customer.
+func (h *Handler) customerAddressIds(ctx context.Context, customerId, addressId string) (string, string, error) {
+	logger := logging.FromContext(ctx)
+	customerUUID, err := uuid.Parse(customerId)
+	if err != nil {
+		logger.Error("invalid customer uuid value", "customer_id", customerId, "error", err)
+		return "", "", errBadRequest
+	}
+	addressUUID, err := uuid.Parse(addressId)
+	if err != nil {
+		logger.Error("invalid address uuid value", "address_id", addressId, "error", err)
+		return "", "", errBadRequest
+	}
+	if !auth.CanAccessCustomer(ctx, customerUUID.String()) {
+		logger.Error("customer is not allowed to access the addresses of another customer", "customer_id", customerUUID)
+		return "", "", errNotFound
+	}
+	return customerUUID.String(), addressUUID.String(), nil
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
+	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (st *CustomerHandlerTestSuite) savedAddress() *model.CustomerAddress {
+	return &model.CustomerAddress{
+		AddressID:  st.testUUID1.String(),
+		CustomerID: st.testUUID.String(),
+		Label:      "Home",
+		Address:    model.Address{Street: "1 Moi Ave", City: "Nairobi", State: "Nairobi", PostalCode: "00100", Country: "KE"},
+		CreatedAt:  time.Now(),
+	}
+}
+
+func (st *CustomerHandlerTestSuite) TestCreateCustomerAddress_Success() {
+	request := &customersPb.CreateCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		Address: &customersPb.CustomerAddress{
+			Label:           "Home",
+			Address:         &customersPb.Address{Street: "1 Moi Ave", City: "Nairobi", State: "Nairobi", PostalCode: "00100", Country: "KE"},
+			DefaultShipping: true,
+		},
+	}
+	st.repo.On("CreateCustomerAddress", mock.Anything, mock.MatchedBy(func(address *model.CustomerAddress) bool {
+		return address.CustomerID == st.testUUID.String() && address.AddressID != "" && address.DefaultShipping && address.Address.City == "Nairobi"
+	})).Return(st.savedAddress(), nil)
+
+	response, err := st.handler.CreateCustomerAddress(context.Background(), request)
+
+	st.Require().NoError(err)
+	st.Require().Equal(st.testUUID1.String(), response.Address.AddressId)
+	st.Require().Equal("Nairobi", response.Address.Address.City)
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *CustomerHandlerTestSuite) TestCreateCustomerAddress_ValidationError() {
+	request := &customersPb.CreateCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		Address: &customersPb.CustomerAddress{
+			Address: &customersPb.Address{Street: "1 Moi Ave", State: "Nairobi", PostalCode: "00100", Country: "KE"},
+		},
+	}
+
+	response, err := st.handler.CreateCustomerAddress(context.Background(), request)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"address.city"}, fieldViolations(err))
+}
+
+func (st *CustomerHandlerTestSuite) TestCreateCustomerAddress_OtherCustomer() {
+	ctx := auth.ContextWithPrincipal(context.Background(), &auth.Principal{
+		Subject: st.testUUID1.String(),
+		Roles:   []auth.Role{auth.RoleCustomer},
+	})
+	request := &customersPb.CreateCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		Address:    &customersPb.CustomerAddress{Address: &customersPb.Address{City: "Nairobi"}},
+	}
+
+	response, err := st.handler.CreateCustomerAddress(ctx, request)
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *CustomerHandlerTestSuite) TestCreateCustomerAddress_CustomerNotFound() {
+	request := &customersPb.CreateCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		Address: &customersPb.CustomerAddress{
+			Address: &customersPb.Address{Street: "1 Moi Ave", City: "Nairobi", State: "Nairobi", PostalCode: "00100", Country: "KE"},
+		},
+	}
+	st.repo.On("CreateCustomerAddress", mock.Anything, mock.Anything).Return(nil, &repository.Error{Kind: repository.ErrReferenceNotFound})
+
+	response, err := st.handler.CreateCustomerAddress(context.Background(), request)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (st *CustomerHandlerTestSuite) TestGetCustomerAddress_NotFound() {
+	st.repo.On("GetCustomerAddress", mock.Anything, st.testUUID.String(), st.testUUID1.String()).Return(nil, errRowNotFound)
+
+	response, err := st.handler.GetCustomerAddress(context.Background(), &customersPb.GetCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		AddressId:  st.testUUID1.String(),
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *CustomerHandlerTestSuite) TestGetCustomerAddress_InvalidAddressId() {
+	response, err := st.handler.GetCustomerAddress(context.Background(), &customersPb.GetCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		AddressId:  "not-a-uuid",
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errBadRequest, err)
+}
+
+func (st *CustomerHandlerTestSuite) TestListCustomerAddresses_Success() {
+	st.repo.On("ListCustomerAddresses", mock.Anything, st.testUUID.String(), 2, 0).Return(
+		[]model.CustomerAddress{*st.savedAddress(), *st.savedAddress()}, nil)
+
+	response, err := st.handler.ListCustomerAddresses(context.Background(), &customersPb.ListCustomerAddressesRequest{
+		CustomerId: st.testUUID.String(),
+		PageSize:   2,
+	})
+
+	st.Require().NoError(err)
+	st.Require().Len(response.Addresses, 2)
+	st.Require().Equal(int32(2), response.NextPageToken)
+}
+
+func (st *CustomerHandlerTestSuite) TestUpdateCustomerAddress_AddressField() {
+	request := &customersPb.UpdateCustomerAddressRequest{
+		Address: &customersPb.CustomerAddress{
+			AddressId:      st.testUUID1.String(),
+			CustomerId:     st.testUUID.String(),
+			Address:        &customersPb.Address{City: "Nakuru"},
+			DefaultBilling: true,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"address.city", "defaultBilling"}},
+	}
+	st.repo.On("UpdateCustomerAddress", mock.Anything, st.testUUID.String(), st.testUUID1.String(), mock.MatchedBy(func(fields map[string]interface{}) bool {
+		merge, ok := fields["address"].(model.JSONMerge)
+		return ok && merge["city"] == "Nakuru" && fields["default_billing"] == true
+	})).Return(st.savedAddress(), nil)
+
+	response, err := st.handler.UpdateCustomerAddress(context.Background(), request)
+
+	st.Require().NoError(err)
+	st.Require().NotNil(response.Address)
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *CustomerHandlerTestSuite) TestUpdateCustomerAddress_IncompleteAddress() {
+	request := &customersPb.UpdateCustomerAddressRequest{
+		Address: &customersPb.CustomerAddress{
+			AddressId:  st.testUUID1.String(),
+			CustomerId: st.testUUID.String(),
+			Address:    &customersPb.Address{City: "Nakuru"},
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"address"}},
+	}
+
+	response, err := st.handler.UpdateCustomerAddress(context.Background(), request)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"address.country", "address.postal_code", "address.state", "address.street"}, fieldViolations(err))
+}
+
+func (st *CustomerHandlerTestSuite) TestUpdateCustomerAddress_MissingUpdateMask() {
+	request := &customersPb.UpdateCustomerAddressRequest{
+		Address: &customersPb.CustomerAddress{AddressId: st.testUUID1.String(), CustomerId: st.testUUID.String()},
+	}
+
+	response, err := st.handler.UpdateCustomerAddress(context.Background(), request)
+
+	st.Require().Nil(response)
+	st.Require().Equal(errResourceUpdateMaskRequired, err)
+}
+
+func (st *CustomerHandlerTestSuite) TestDeleteCustomerAddress_NotFound() {
+	st.repo.On("DeleteCustomerAddress", mock.Anything, st.testUUID.String(), st.testUUID1.String()).Return(nil, errRowNotFound)
+
+	response, err := st.handler.DeleteCustomerAddress(context.Background(), &customersPb.DeleteCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		AddressId:  st.testUUID1.String(),
+	})
+
+	st.Require().False(response.Success)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *CustomerHandlerTestSuite) TestDeleteCustomerAddress_Success() {
+	st.repo.On("DeleteCustomerAddress", mock.Anything, st.testUUID.String(), st.testUUID1.String()).Return(st.savedAddress(), nil)
+
+	response, err := st.handler.DeleteCustomerAddress(context.Background(), &customersPb.DeleteCustomerAddressRequest{
+		CustomerId: st.testUUID.String(),
+		AddressId:  st.testUUID1.String(),
+	})
+
+	st.Require().NoError(err)
+	st.Require().True(response.Success)
+}
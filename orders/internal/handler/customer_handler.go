@@ -2,25 +2,24 @@ package handler
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/wathuta/technical_test/orders/internal/auth"
 	"github.com/wathuta/technical_test/orders/internal/common"
 	"github.com/wathuta/technical_test/orders/internal/common/fieldmask"
+	"github.com/wathuta/technical_test/orders/internal/logging"
 	"github.com/wathuta/technical_test/orders/internal/model"
 	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
-	"golang.org/x/exp/slog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 func (h *Handler) CreateCustomer(ctx context.Context, req *customersPb.CreateCustomerRequest) (*customersPb.CreateCustomerResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || req.Customer == nil {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("creating customer", "customer_email", req.Customer.Email)
+	logger.Debug("creating customer", "customer_email", req.Customer.Email)
 
 	customer := model.CustomerFromProto(req.Customer)
 	// generate id for new resource
@@ -30,125 +29,154 @@ func (h *Handler) CreateCustomer(ctx context.Context, req *customersPb.CreateCus
 	validator := common.NewValidator()
 
 	if err := validator.Struct(customer); err != nil {
-		slog.Error("failed to validate payment", "error", err)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		logger.Error("failed to validate payment", "error", err)
+		return nil, validationError(err)
 	}
 
 	//persist to db
 	resource, err := h.repo.CreateCustomer(ctx, customer)
 	if err != nil {
-		slog.Error("failed to create customer in db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to create customer in db", "error", err)
+		return nil, dbError(err)
 	}
-	slog.Debug("create customer successful")
+	logger.Debug("create customer successful")
 	return &customersPb.CreateCustomerResponse{Customer: resource.Proto()}, nil
 }
 func (h *Handler) GetCustomerById(ctx context.Context, req *customersPb.GetCustomerByIdRequest) (*customersPb.GetCustomerByIdResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.CustomerId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get customer by id", "customer_id", req.CustomerId)
+	logger.Debug("get customer by id", "customer_id", req.CustomerId)
 	customerUUID, err := uuid.Parse(req.CustomerId)
 	if err != nil {
-		slog.Error("invalid customer uuid value", "error", err)
+		logger.Error("invalid customer uuid value", "error", err)
 		return nil, errBadRequest
 	}
+	if !auth.CanAccessCustomer(ctx, customerUUID.String()) {
+		logger.Error("customer is not allowed to read another customer", "customer_id", customerUUID)
+		return nil, errNotFound
+	}
 
 	//retrieve from db
 	resource, err := h.repo.GetCustomerById(ctx, customerUUID.String())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("customer with the given id not found", "customer_id", customerUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get customer from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get customer from db", "error", err)
+		return nil, dbError(err)
 	}
-	slog.Debug("get customer successful")
+	logger.Debug("get customer successful")
 	return &customersPb.GetCustomerByIdResponse{Customer: resource.Proto()}, nil
 }
+
+// ListCustomers returns a page of customers in the order they were created.
+func (h *Handler) ListCustomers(ctx context.Context, req *customersPb.ListCustomersRequest) (*customersPb.ListCustomersResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	pagesize := common.SetPageSize(int(req.PageSize), defaultPageSize, maxPageSize)
+	token := common.SetPageToken(int(req.PageToken))
+	logger.Debug("list customers", "page_size", pagesize, "page_token", token)
+
+	customers, err := h.repo.ListCustomers(ctx, pagesize, token)
+	if err != nil {
+		logger.Error("failed to list customers from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	returnCustomers := make([]*customersPb.Customer, 0, len(customers))
+	for i := range customers {
+		returnCustomers = append(returnCustomers, customers[i].Proto())
+	}
+	logger.Debug("list customers successful")
+	return &customersPb.ListCustomersResponse{
+		Customers:     returnCustomers,
+		NextPageToken: int32(common.NextPageToken(token, pagesize, len(customers))),
+	}, nil
+}
+
 func (h *Handler) UpdateCustomer(ctx context.Context, req *customersPb.UpdateCustomerRequest) (*customersPb.UpdateCustomerResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || req.Customer == nil {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("update customer", "customer_id", req.Customer.CustomerId)
+	logger.Debug("update customer", "customer_id", req.Customer.CustomerId)
 
 	// check the mask
-	mask, err := fieldmask.New(req.UpdateMask)
+	mask, err := fieldmask.New(req.UpdateMask, req.Customer)
 	if err != nil {
-		slog.Error("invalid request inputs", "error", err)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		logger.Error("invalid update mask", "error", err)
+		return nil, maskError(err)
 	}
 	mask.RemoveOutputOnly()
 
 	// validate customer UUID
 	customerUUID, err := uuid.Parse(req.Customer.CustomerId)
 	if err != nil {
-		slog.Error("invalid customer uuid value", "customerUUID", customerUUID, "error", err, req)
+		logger.Error("invalid customer uuid value", "customer_id", req.Customer.CustomerId, "error", err)
 		return nil, errBadRequest
 	}
+	if !auth.CanAccessCustomer(ctx, customerUUID.String()) {
+		logger.Error("customer is not allowed to update another customer", "customer_id", customerUUID)
+		return nil, errNotFound
+	}
 
 	customer := model.CustomerFromProto(req.Customer)
 	updatedCustomerDetail := model.UpdateCustomerMapping(mask.Fields, *customer)
 	updatedCustomerDetail["updated_at"] = time.Now()
 	// if fieldmask is empty perfom get
 	if len(mask.Fields) == 0 || len(updatedCustomerDetail) == 0 {
-		slog.Debug("no fields to update")
+		logger.Debug("no fields to update")
 
 		customer, err = h.repo.GetCustomerById(ctx, req.Customer.CustomerId)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				slog.Error("customer with the given id not found", "customer_id", customerUUID, "error", err)
-				return nil, errNotFound
-			}
-			slog.Error("failed to get customer from db", "error", err)
-			return nil, errInternal
+			logger.Error("failed to get customer from db", "error", err)
+			return nil, dbError(err)
 		}
-		slog.Debug("update customer successful")
+		logger.Debug("update customer successful")
 		return &customersPb.UpdateCustomerResponse{Customer: customer.Proto()}, nil
 
 	}
 
+	if err := requireVersion("customer.version", req.Customer.Version); err != nil {
+		logger.Error("customer version required", "customer_id", customerUUID)
+		return nil, err
+	}
+
 	// persist in db
-	customer, err = h.repo.UpdateCustomerFields(ctx, customerUUID.String(), updatedCustomerDetail)
+	customer, err = h.repo.UpdateCustomerFields(ctx, customerUUID.String(), req.Customer.Version, updatedCustomerDetail)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("customer with the given id not found", "customer_id", customerUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to update customer from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to update customer from db", "error", err)
+		return nil, dbError(err)
 	}
 
-	slog.Debug("update customer successful")
+	logger.Debug("update customer successful")
 	return &customersPb.UpdateCustomerResponse{Customer: customer.Proto()}, nil
 }
 func (h *Handler) DeleteCustomer(ctx context.Context, req *customersPb.DeleteCustomerRequest) (*customersPb.DeleteCustomerResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.CustomerId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return &customersPb.DeleteCustomerResponse{Success: false}, errResourceRequired
 	}
-	slog.Debug("delete customer", "customer_id", req.CustomerId)
+	logger.Debug("delete customer", "customer_id", req.CustomerId)
 
 	// verify supplied uuid
 	customerUUID, err := uuid.Parse(req.CustomerId)
 	if err != nil {
-		slog.Error("invalid customer uuid value", "error", err)
+		logger.Error("invalid customer uuid value", "error", err)
 		return &customersPb.DeleteCustomerResponse{Success: false}, errBadRequest
 	}
 
-	resource, err := h.repo.DeleteCustomer(ctx, customerUUID.String())
+	_, err = h.repo.DeleteCustomer(ctx, customerUUID.String())
 	if err != nil {
-		slog.Error("failed to delete customer from db", "error", err)
-		return &customersPb.DeleteCustomerResponse{Success: false}, errInternal
-	}
-	if resource == nil {
-		slog.Error("customer with the given id not found", "customer_id", customerUUID, "error", err)
-		return &customersPb.DeleteCustomerResponse{Success: false}, errNotFound
+		logger.Error("failed to delete customer from db", "customer_id", customerUUID, "error", err)
+		return &customersPb.DeleteCustomerResponse{Success: false}, dbError(err)
 	}
 
-	slog.Debug("delete customer successful")
+	logger.Debug("delete customer successful")
 	return &customersPb.DeleteCustomerResponse{Success: true}, nil
 }
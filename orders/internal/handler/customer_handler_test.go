@@ -2,7 +2,6 @@ package handler
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"os"
 	"testing"
@@ -14,9 +13,12 @@ import (
 	"github.com/wathuta/technical_test/orders/internal/config"
 	"github.com/wathuta/technical_test/orders/internal/mocks"
 	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
 	customersPb "github.com/wathuta/technical_test/protos_gen/customers"
 	"golang.org/x/exp/slog"
 	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type CustomerHandlerTestSuite struct {
@@ -125,6 +127,8 @@ func (st *CustomerHandlerTestSuite) TestCreateCustomer_ValidationError() {
 	// Assertions
 	st.Require().Error(err)
 	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"email"}, fieldViolations(err))
 
 	// Assert expectations for the mock repository (no calls expected)
 	st.repo.AssertExpectations(st.T())
@@ -183,8 +187,8 @@ func (st *CustomerHandlerTestSuite) TestGetCustomerById_DBError() {
 }
 
 func (st *CustomerHandlerTestSuite) TestGetCustomerById_NotFoundError() {
-	// Set up expectations for the mock repository to return sql.ErrNoRows (not found)
-	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	// Set up expectations for the mock repository to return errRowNotFound (not found)
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Call the GetCustomerById function
 	resp, err := st.handler.GetCustomerById(context.Background(), &customersPb.GetCustomerByIdRequest{
@@ -195,6 +199,7 @@ func (st *CustomerHandlerTestSuite) TestGetCustomerById_NotFoundError() {
 	st.Require().Nil(resp)
 	st.Require().NotNil(err)
 	st.Require().Equal(errNotFound, err)
+	st.Require().Equal(codes.NotFound, status.Code(err))
 }
 
 func (st *CustomerHandlerTestSuite) TestGetCustomerById_NilRequest() {
@@ -257,7 +262,7 @@ func (st *CustomerHandlerTestSuite) TestDeleteCustomer_Success() {
 
 func (st *CustomerHandlerTestSuite) TestDeleteCustomer_CustomerNotFound() {
 	// Set up expectations for the mock repository to delete a customer that is not found
-	st.repo.On("DeleteCustomer", mock.Anything, mock.Anything).Return(nil, nil)
+	st.repo.On("DeleteCustomer", mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Call the DeleteCustomer function
 	resp, err := st.handler.DeleteCustomer(context.Background(), &customersPb.DeleteCustomerRequest{
@@ -265,7 +270,7 @@ func (st *CustomerHandlerTestSuite) TestDeleteCustomer_CustomerNotFound() {
 	})
 
 	// Assertions
-	st.Require().NotNil(err)
+	st.Require().Equal(errNotFound, err)
 	st.Require().NotNil(resp)
 	st.Require().False(resp.Success)
 
@@ -319,6 +324,7 @@ func (st *CustomerHandlerTestSuite) TestUpdateCustomer_Success() {
 			Email:       "updated@example.com",
 			PhoneNumber: "+9876543210",
 			Address:     "456 New St",
+			Version:     1,
 		},
 		UpdateMask: &field_mask.FieldMask{
 			Paths: []string{"name", "email", "phone_number", "address"},
@@ -326,7 +332,7 @@ func (st *CustomerHandlerTestSuite) TestUpdateCustomer_Success() {
 	}
 
 	// Set up expectations for the mock repository to update the customer
-	st.repo.On("UpdateCustomerFields", mock.Anything, mock.Anything, mock.Anything).Return(
+	st.repo.On("UpdateCustomerFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
 		&model.Customer{
 			CustomerID:  st.testUUID.String(),
 			Name:        customerRequest.Customer.Name,
@@ -402,6 +408,7 @@ func (st *CustomerHandlerTestSuite) TestUpdateCustomer_UpdateError() {
 			Email:       "updated@example.com",
 			PhoneNumber: "+9876543210",
 			Address:     "456 New St",
+			Version:     1,
 		},
 		UpdateMask: &field_mask.FieldMask{
 			Paths: []string{"name", "email", "phone_number", "address"},
@@ -409,7 +416,7 @@ func (st *CustomerHandlerTestSuite) TestUpdateCustomer_UpdateError() {
 	}
 
 	// Set up expectations for the mock repository to return an update error
-	st.repo.On("UpdateCustomerFields", mock.Anything, mock.Anything, mock.Anything).Return(
+	st.repo.On("UpdateCustomerFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
 		nil, errors.New("update error"),
 	)
 
@@ -423,4 +430,87 @@ func (st *CustomerHandlerTestSuite) TestUpdateCustomer_UpdateError() {
 	st.repo.AssertExpectations(st.T())
 }
 
+func (st *CustomerHandlerTestSuite) TestUpdateCustomer_VersionRequired() {
+	customerRequest := &customersPb.UpdateCustomerRequest{
+		Customer:   &customersPb.Customer{CustomerId: st.testUUID.String(), Name: "Updated Name"},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"name"}},
+	}
+
+	response, err := st.handler.UpdateCustomer(context.Background(), customerRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"customer.version"}, fieldViolations(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateCustomerFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *CustomerHandlerTestSuite) TestListCustomers_Success() {
+	st.repo.On("ListCustomers", mock.Anything, 2, 4).Return([]model.Customer{
+		{CustomerID: st.testUUID.String(), Name: "John Doe"},
+		{CustomerID: st.testUUID1.String(), Name: "Jane Doe"},
+	}, nil)
+
+	resp, err := st.handler.ListCustomers(context.Background(), &customersPb.ListCustomersRequest{PageSize: 2, PageToken: 4})
+
+	st.Require().NoError(err)
+	st.Require().Len(resp.Customers, 2)
+	st.Require().Equal("Jane Doe", resp.Customers[1].Name)
+	// a full page may be followed by another one
+	st.Require().Equal(int32(6), resp.NextPageToken)
+}
+
+func (st *CustomerHandlerTestSuite) TestListCustomers_LastPage() {
+	st.repo.On("ListCustomers", mock.Anything, defaultPageSize, 0).Return([]model.Customer{
+		{CustomerID: st.testUUID.String(), Name: "John Doe"},
+	}, nil)
+
+	resp, err := st.handler.ListCustomers(context.Background(), &customersPb.ListCustomersRequest{})
+
+	st.Require().NoError(err)
+	st.Require().Len(resp.Customers, 1)
+	st.Require().Zero(resp.NextPageToken)
+}
+
+func (st *CustomerHandlerTestSuite) TestListCustomers_DBError() {
+	st.repo.On("ListCustomers", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+	resp, err := st.handler.ListCustomers(context.Background(), &customersPb.ListCustomersRequest{})
+
+	st.Require().Nil(resp)
+	st.Require().Equal(errInternal, err)
+}
+
+func (st *CustomerHandlerTestSuite) TestListCustomers_NilRequest() {
+	resp, err := st.handler.ListCustomers(context.Background(), nil)
+
+	st.Require().Nil(resp)
+	st.Require().Equal(errResourceRequired, err)
+}
+
+func (st *CustomerHandlerTestSuite) TestCreateCustomer_DuplicateEmail() {
+	customerRequest := &customersPb.CreateCustomerRequest{
+		Customer: &customersPb.Customer{
+			Name:        "John Doe",
+			Email:       "john@example.com",
+			PhoneNumber: "+1234567890",
+			Address:     "123 Main St",
+		},
+	}
+	st.repo.On("CreateCustomer", mock.Anything, mock.Anything).Return(nil, &repository.Error{Kind: repository.ErrAlreadyExists, Constraint: "customers_email_key"})
+
+	response, err := st.handler.CreateCustomer(context.Background(), customerRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.AlreadyExists, status.Code(err))
+}
+
+func (st *CustomerHandlerTestSuite) TestGetCustomerById_DBUnavailable() {
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(nil, repository.ErrUnavailable)
 
+	resp, err := st.handler.GetCustomerById(context.Background(), &customersPb.GetCustomerByIdRequest{
+		CustomerId: st.testUUID.String(),
+	})
+
+	st.Require().Nil(resp)
+	st.Require().Equal(codes.Unavailable, status.Code(err))
+}
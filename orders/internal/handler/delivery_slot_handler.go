@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/scheduling"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+)
+
+// List the delivery slots orders to an address can still be delivered in
+func (h *Handler) ListAvailableDeliverySlots(ctx context.Context, req *orderspb.ListAvailableDeliverySlotsRequest) (*orderspb.ListAvailableDeliverySlotsResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+
+	fields := map[string]string{}
+	if req.DeliveryAddress == nil || len(strings.TrimSpace(req.DeliveryAddress.City)) == 0 {
+		fields["delivery_address.city"] = "is required"
+	}
+	if len(strings.TrimSpace(req.ShippingMethod)) == 0 {
+		fields["shipping_method"] = "is required"
+	}
+	if req.Days < 0 || req.Days > scheduling.MaxDays {
+		fields["days"] = "must be between 0 and 31"
+	}
+	if len(fields) > 0 {
+		logger.Error("invalid delivery slots request", "fields", fields)
+		return nil, badRequest(fields)
+	}
+	logger.Debug("listing available delivery slots", "city", req.DeliveryAddress.City, "shipping_method", req.ShippingMethod)
+
+	from := time.Now()
+	if req.ScheduledPickupDatetime != nil {
+		from = req.ScheduledPickupDatetime.AsTime()
+	}
+	windows, err := h.scheduler.Available(ctx, model.Address{City: req.DeliveryAddress.City}, req.ShippingMethod, from, int(req.Days))
+	if err != nil {
+		logger.Error("failed to list delivery slots", "error", err)
+		return nil, dbError(err)
+	}
+
+	slots := make([]*orderspb.DeliverySlot, 0, len(windows))
+	for _, window := range windows {
+		slots = append(slots, window.Proto())
+	}
+	return &orderspb.ListAvailableDeliverySlotsResponse{Slots: slots}, nil
+}
+
+// requiredSchedule returns a violation for each scheduled time missing from req.
+func requiredSchedule(req *orderspb.CreateOrderRequest) map[string]string {
+	fields := map[string]string{}
+	if req.ScheduledPickupDatetime == nil {
+		fields["scheduled_pickup_datetime"] = "is required"
+	}
+	if req.ScheduledDeliveryDatetime == nil {
+		fields["scheduled_delivery_datetime"] = "is required"
+	}
+	return fields
+}
+
+// maskedSchedule returns a violation for each scheduled time of order updated by fields that is
+// missing from it.
+func maskedSchedule(order *orderspb.Order, fields []string) map[string]string {
+	violations := map[string]string{}
+	for _, field := range fields {
+		if field == "scheduled_pickup_datetime" && order.ScheduledPickupDatetime == nil {
+			violations["order.scheduled_pickup_datetime"] = "is required"
+		}
+		if field == "scheduled_delivery_datetime" && order.ScheduledDeliveryDatetime == nil {
+			violations["order.scheduled_delivery_datetime"] = "is required"
+		}
+	}
+	return violations
+}
+
+// reschedules reports whether updating fields of an order may move it to another delivery window.
+// Only the city of the delivery address decides the delivery slots of an order.
+func reschedules(fields []string) bool {
+	for _, field := range fields {
+		switch field {
+		case "scheduled_pickup_datetime", "scheduled_delivery_datetime", "shipping_method", "delivery_address", "delivery_address.city":
+			return true
+		}
+	}
+	return false
+}
+
+// rescheduleOrder checks that the order current can still be delivered at the time it is scheduled
+// once it is updated to order, and returns the delivery window it is then delivered in.
+func (h *Handler) rescheduleOrder(ctx context.Context, current, order *model.Order) (*model.DeliveryWindow, error) {
+	logger := logging.FromContext(ctx)
+	slot, err := h.scheduler.Reschedule(ctx, *current, *order)
+	if err != nil {
+		if statusErr := scheduleError(slot, err); statusErr != nil {
+			logger.Error("order cannot be delivered at the scheduled time", "order_id", current.OrderID, "error", err)
+			return nil, statusErr
+		}
+		logger.Error("failed to schedule order delivery", "order_id", current.OrderID, "error", err)
+		return nil, dbError(err)
+	}
+	return slot, nil
+}
+
+// scheduleError returns the status of err when an order cannot be delivered at the time it is
+// scheduled, nil when err is another error. slot is the delivery window the order was scheduled in.
+func scheduleError(slot *model.DeliveryWindow, err error) error {
+	switch {
+	case errors.Is(err, scheduling.ErrPickupInPast):
+		return badRequest(map[string]string{"scheduled_pickup_datetime": err.Error()})
+	case errors.Is(err, scheduling.ErrDeliveryBeforePickup), errors.Is(err, scheduling.ErrNoDeliverySlot):
+		return badRequest(map[string]string{"scheduled_delivery_datetime": err.Error()})
+	case errors.Is(err, model.ErrDeliverySlotFull):
+		subject := "scheduled_delivery_datetime"
+		if slot != nil {
+			subject = slot.Slot.SlotID
+		}
+		return preconditionError("DELIVERY_SLOT", subject, err)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mockDeliverySlots lets orders to every city be delivered at any time
+func (st *OrderHandlerTestSuite) mockDeliverySlots() {
+	st.repo.On("ListDeliverySlots", mock.Anything, mock.Anything, mock.Anything).Return([]model.DeliverySlot{}, nil)
+}
+
+func (st *OrderHandlerTestSuite) deliverySlot() model.DeliverySlot {
+	return model.DeliverySlot{
+		SlotID:         "mombasa-express-morning",
+		City:           "mombasa",
+		ShippingMethod: "EXPRESS",
+		StartTime:      "08:00:00",
+		EndTime:        "13:00:00",
+		TimeZone:       "Africa/Nairobi",
+		Capacity:       1,
+	}
+}
+
+// scheduledOrderRequest returns an order delivered to Mombasa in three days at the time of day.
+func (st *OrderHandlerTestSuite) scheduledOrderRequest(hour int) *orderspb.CreateOrderRequest {
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	st.Require().NoError(err)
+	year, month, day := time.Now().In(nairobi).AddDate(0, 0, 3).Date()
+
+	req := st.promoOrderRequest("")
+	req.ScheduledPickupDatetime = timestamppb.New(time.Date(year, month, day, 6, 0, 0, 0, nairobi))
+	req.ScheduledDeliveryDatetime = timestamppb.New(time.Date(year, month, day, hour, 0, 0, 0, nairobi))
+	return req
+}
+
+// scheduledOrder returns a pending order of version picked up tomorrow and delivered the day after.
+func (st *OrderHandlerTestSuite) scheduledOrder(version int64) *model.Order {
+	pickup := time.Now().Add(24 * time.Hour)
+	return &model.Order{
+		OrderID:                   st.testUUID.String(),
+		OrderStatus:               model.OrderStatusPending,
+		ShippingMethod:            "standard",
+		ScheduledPickupDatetime:   pickup,
+		ScheduledDeliveryDatetime: pickup.Add(24 * time.Hour),
+		Version:                   version,
+	}
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_DeliverySlotBookedConcurrently() {
+	st.mockPromoOrder()
+	st.mockPromoPricing()
+	req := st.scheduledOrderRequest(10)
+	st.repo.On("ListDeliverySlots", mock.Anything, "mombasa", "EXPRESS").Return([]model.DeliverySlot{st.deliverySlot()}, nil)
+	st.repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return([]model.DeliveryBooking{}, nil)
+	st.repo.On("CreateOrder", mock.Anything,
+		mock.MatchedBy(func(order *model.Order) bool { return order.DeliverySlotID == "mombasa-express-morning" }),
+		mock.Anything, (*model.PromotionRedemption)(nil),
+		mock.MatchedBy(func(slot *model.DeliveryWindow) bool {
+			return slot.Slot.SlotID == "mombasa-express-morning" && slot.Contains(req.ScheduledDeliveryDatetime.AsTime())
+		}),
+	).Return(nil, nil, model.ErrDeliverySlotFull)
+
+	// another order booked the last place in the slot after it was checked
+	response, err := st.handler.CreateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.paymentclient.AssertNotCalled(st.T(), "CreatePaymentRequest", mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_FullDeliverySlot() {
+	st.mockPromoOrder()
+	req := st.scheduledOrderRequest(10)
+	st.repo.On("ListDeliverySlots", mock.Anything, "mombasa", "EXPRESS").Return([]model.DeliverySlot{st.deliverySlot()}, nil)
+	st.repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return(
+		[]model.DeliveryBooking{{SlotID: "mombasa-express-morning", DeliveryAt: req.ScheduledDeliveryDatetime.AsTime().Add(time.Hour)}}, nil)
+
+	response, err := st.handler.CreateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "CreateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_OutsideDeliverySlots() {
+	st.mockPromoOrder()
+	st.repo.On("ListDeliverySlots", mock.Anything, "mombasa", "EXPRESS").Return([]model.DeliverySlot{st.deliverySlot()}, nil)
+
+	response, err := st.handler.CreateOrder(context.Background(), st.scheduledOrderRequest(14))
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"scheduled_delivery_datetime"}, fieldViolations(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_PickupInPast() {
+	st.mockPromoOrder()
+	req := st.promoOrderRequest("")
+	req.ScheduledPickupDatetime = timestamppb.New(time.Now().Add(-time.Hour))
+
+	response, err := st.handler.CreateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"scheduled_pickup_datetime"}, fieldViolations(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_DeliveryBeforePickup() {
+	st.mockPromoOrder()
+	req := st.promoOrderRequest("")
+	req.ScheduledDeliveryDatetime = timestamppb.New(req.ScheduledPickupDatetime.AsTime().Add(-time.Hour))
+
+	response, err := st.handler.CreateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"scheduled_delivery_datetime"}, fieldViolations(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_MissingSchedule() {
+	req := st.promoOrderRequest("")
+	req.ScheduledPickupDatetime = nil
+	req.ScheduledDeliveryDatetime = nil
+
+	response, err := st.handler.CreateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"scheduled_delivery_datetime", "scheduled_pickup_datetime"}, fieldViolations(err))
+}
+
+// rescheduleRequest moves the order delivered to Mombasa by express to the time of day of
+// scheduledOrderRequest(hour), the current order is booked in the morning slot the day before.
+func (st *OrderHandlerTestSuite) rescheduleRequest(hour int) *orderspb.UpdateOrderRequest {
+	scheduled := st.scheduledOrderRequest(hour)
+	current := st.scheduledOrder(2)
+	current.DeliveryAddress = model.Address{City: "Mombasa"}
+	current.ShippingMethod = "express"
+	current.ScheduledPickupDatetime = scheduled.ScheduledPickupDatetime.AsTime().AddDate(0, 0, -1)
+	current.ScheduledDeliveryDatetime = scheduled.ScheduledDeliveryDatetime.AsTime().AddDate(0, 0, -1)
+	current.DeliverySlotID = "mombasa-express-morning"
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(current, nil)
+	st.repo.On("ListDeliverySlots", mock.Anything, "mombasa", "EXPRESS").Return([]model.DeliverySlot{st.deliverySlot()}, nil)
+
+	return &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:                   st.testUUID.String(),
+			ScheduledDeliveryDatetime: scheduled.ScheduledDeliveryDatetime,
+			Version:                   2,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"scheduled_delivery_datetime"}},
+	}
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_Rescheduled() {
+	req := st.rescheduleRequest(10)
+	st.repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return([]model.DeliveryBooking{}, nil)
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(2),
+		mock.MatchedBy(func(fields map[string]interface{}) bool {
+			return fields["delivery_slot_id"] == "mombasa-express-morning" &&
+				req.Order.ScheduledDeliveryDatetime.AsTime().Equal(fields["scheduled_delivery_datetime"].(time.Time))
+		}),
+		mock.MatchedBy(func(slot *model.DeliveryWindow) bool {
+			return slot.Slot.SlotID == "mombasa-express-morning" && slot.Contains(req.Order.ScheduledDeliveryDatetime.AsTime())
+		}),
+	).Return(&model.Order{OrderID: st.testUUID.String(), DeliverySlotID: "mombasa-express-morning", Version: 3}, nil)
+
+	response, err := st.handler.UpdateOrder(context.Background(), req)
+
+	st.Require().NoError(err)
+	st.Require().Equal(int64(3), response.Order.Version)
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_RescheduledInFullDeliverySlot() {
+	req := st.rescheduleRequest(10)
+	st.repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return(
+		[]model.DeliveryBooking{{SlotID: "mombasa-express-morning", DeliveryAt: req.Order.ScheduledDeliveryDatetime.AsTime()}}, nil)
+
+	response, err := st.handler.UpdateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_RescheduledSlotBookedConcurrently() {
+	req := st.rescheduleRequest(10)
+	st.repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return([]model.DeliveryBooking{}, nil)
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(2), mock.Anything, mock.Anything).Return(nil, model.ErrDeliverySlotFull)
+
+	response, err := st.handler.UpdateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_RescheduledOutsideDeliverySlots() {
+	req := st.rescheduleRequest(15)
+
+	response, err := st.handler.UpdateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"scheduled_delivery_datetime"}, fieldViolations(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_MissingScheduledTime() {
+	req := &orderspb.UpdateOrderRequest{
+		Order:      &orderspb.Order{OrderId: st.testUUID.String(), Version: 2},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"scheduled_pickup_datetime", "scheduled_delivery_datetime"}},
+	}
+
+	// the times are not stored as the epoch
+	response, err := st.handler.UpdateOrder(context.Background(), req)
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"order.scheduled_delivery_datetime", "order.scheduled_pickup_datetime"}, fieldViolations(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestListAvailableDeliverySlots_Success() {
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	st.Require().NoError(err)
+	year, month, day := time.Now().In(nairobi).AddDate(0, 0, 10).Date()
+	from := time.Date(year, month, day, 0, 0, 0, 0, nairobi)
+
+	st.repo.On("ListDeliverySlots", mock.Anything, "mombasa", "EXPRESS").Return([]model.DeliverySlot{st.deliverySlot()}, nil)
+	st.repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", from.Add(8*time.Hour), from.AddDate(0, 0, 1).Add(13*time.Hour)).Return(
+		[]model.DeliveryBooking{{SlotID: "mombasa-express-morning", DeliveryAt: from.Add(9 * time.Hour)}}, nil)
+
+	response, err := st.handler.ListAvailableDeliverySlots(context.Background(), &orderspb.ListAvailableDeliverySlotsRequest{
+		DeliveryAddress:         &orderspb.Address{City: " Mombasa"},
+		ShippingMethod:          "express",
+		ScheduledPickupDatetime: timestamppb.New(from),
+		Days:                    2,
+	})
+
+	// the slot on the first day is fully booked
+	st.Require().NoError(err)
+	st.Require().Len(response.Slots, 1)
+	st.Require().Equal("mombasa-express-morning", response.Slots[0].SlotId)
+	st.Require().True(from.AddDate(0, 0, 1).Add(8 * time.Hour).Equal(response.Slots[0].StartTime.AsTime()))
+	st.Require().Equal(int32(1), response.Slots[0].RemainingCapacity)
+}
+
+func (st *OrderHandlerTestSuite) TestListAvailableDeliverySlots_InvalidRequest() {
+	response, err := st.handler.ListAvailableDeliverySlots(context.Background(), &orderspb.ListAvailableDeliverySlotsRequest{Days: 40})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"days", "delivery_address.city", "shipping_method"}, fieldViolations(err))
+}
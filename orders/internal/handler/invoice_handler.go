@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wathuta/technical_test/orders/internal/invoice"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Get the invoice of an order rendered as HTML and PDF
+func (h *Handler) GetInvoice(ctx context.Context, req *orderspb.GetInvoiceRequest) (*orderspb.GetInvoiceResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.OrderId) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("get invoice", "order_id", req.OrderId)
+
+	orderUUID, err := uuid.Parse(req.OrderId)
+	if err != nil {
+		logger.Error("invalid order uuid value", "error", err)
+		return nil, errBadRequest
+	}
+	if err := h.authorizeOrder(ctx, orderUUID.String()); err != nil {
+		return nil, err
+	}
+
+	// invoices of paid orders are rendered once and served from the db afterwards
+	cached, err := h.repo.GetInvoiceByOrderId(ctx, orderUUID.String())
+	if err == nil {
+		logger.Debug("get invoice successful", "order_id", orderUUID, "cached", true)
+		return cached.Proto(), nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		logger.Error("failed to get invoice from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	order, err := h.repo.GetOrderById(ctx, orderUUID.String())
+	if err != nil {
+		logger.Error("failed to get order from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	customer, err := h.repo.GetCustomerById(ctx, order.CustomerID)
+	if err != nil {
+		logger.Error("failed to get customer of order from db", "customer_id", order.CustomerID, "error", err)
+		return nil, dbError(err)
+	}
+
+	details, err := h.repo.GetOrderDetailsByOrderId(ctx, order.OrderID, maxPageSize, 0)
+	if err != nil {
+		logger.Error("failed to get order details from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	products := make(map[string]*model.Product, len(details))
+	for _, detail := range details {
+		product, err := h.repo.GetProductById(ctx, detail.ProductID)
+		if err != nil {
+			// the line keeps the price it was charged so a deleted product is shown by its id
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			logger.Error("failed to get product from db", "product_id", detail.ProductID, "error", err)
+			return nil, dbError(err)
+		}
+		products[detail.ProductID] = product
+	}
+
+	var payment *paymentpb.Payment
+	result := <-h.paymentclients.GetPaymentByOrderId(ctx, order.OrderID)
+	if result.Error != nil {
+		if status.Code(result.Error) != codes.NotFound {
+			logger.Error("failed to get payment of order", "order_id", order.OrderID, "error", result.Error)
+			return nil, status.Error(codes.Unavailable, "unable to get the payment of the order")
+		}
+	} else {
+		payment, _ = result.Result.(*paymentpb.Payment)
+	}
+
+	doc := invoice.NewDocument(order, customer, details, products, payment)
+	html, err := invoice.RenderHTML(doc)
+	if err != nil {
+		logger.Error("failed to render invoice html", "error", err)
+		return nil, errInternal
+	}
+	pdf, err := invoice.RenderPDF(doc)
+	if err != nil {
+		logger.Error("failed to render invoice pdf", "error", err)
+		return nil, errInternal
+	}
+
+	rendered := &model.Invoice{
+		OrderID:       order.OrderID,
+		InvoiceNumber: order.InvoiceNumber,
+		HTML:          html,
+		PDF:           pdf,
+		CreatedAt:     time.Now(),
+		Paid:          doc.Paid,
+	}
+	if rendered.Paid {
+		if _, err := h.repo.CreateInvoice(ctx, rendered); err != nil {
+			// the invoice is still correct, it will be rendered again on the next request
+			logger.Error("failed to cache invoice in db", "order_id", order.OrderID, "error", err)
+		}
+	}
+
+	logger.Debug("get invoice successful", "order_id", orderUUID, "cached", false)
+	return rendered.Proto(), nil
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	grpcclients "github.com/wathuta/technical_test/orders/internal/grpc_clients"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (st *OrderHandlerTestSuite) mockInvoiceOrder() {
+	st.repo.On("GetInvoiceByOrderId", mock.Anything, st.testUUID.String()).Return(nil, errRowNotFound)
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{
+			OrderID:       st.testUUID.String(),
+			CustomerID:    st.testUUID1.String(),
+			InvoiceNumber: "INV-00000001",
+			PaymentMethod: model.PaymentMethod(orderspb.PaymentMethod_PAYMENT_METHOD_MPESA.String()),
+			ShippingCost:  1000,
+			Subtotal:      20000,
+			Tax:           3200,
+			GrandTotal:    24200,
+			Currency:      model.KES,
+			CreatedAt:     time.Now(),
+		},
+		nil,
+	)
+	st.repo.On("GetCustomerById", mock.Anything, st.testUUID1.String()).Return(
+		&model.Customer{
+			CustomerID:  st.testUUID1.String(),
+			Name:        "John Doe",
+			Email:       "johndoe@example.com",
+			PhoneNumber: "+254700000000",
+			Address:     "123 Main St",
+		},
+		nil,
+	)
+	st.repo.On("GetOrderDetailsByOrderId", mock.Anything, st.testUUID.String(), maxPageSize, 0).Return(
+		[]model.OrderDetails{
+			{
+				OrderDetailsID:     st.testUUID2.String(),
+				OrderID:            st.testUUID.String(),
+				ProductID:          st.testUUID2.String(),
+				Quantity:           2,
+				UnitPrice:          10000,
+				LineTotal:          20000,
+				TaxRateBasisPoints: 1600,
+				Tax:                3200,
+				Currency:           model.KES,
+			},
+		},
+		nil,
+	)
+	st.repo.On("GetProductById", mock.Anything, st.testUUID2.String()).Return(
+		&model.Product{ProductID: st.testUUID2.String(), Name: "Sample Product", Sku: "SKU123"},
+		nil,
+	)
+}
+
+func paymentResult(payment *paymentpb.Payment, err error) chan grpcclients.ServiceResult {
+	output := make(chan grpcclients.ServiceResult)
+	go func() {
+		output <- grpcclients.ServiceResult{Result: payment, Error: err}
+	}()
+	return output
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_PaidIsCached() {
+	st.mockInvoiceOrder()
+	st.paymentclient.On("GetPaymentByOrderId", mock.Anything, st.testUUID.String()).Return(paymentResult(&paymentpb.Payment{
+		OrderId:            st.testUUID.String(),
+		PaymentMethod:      paymentpb.PaymentMethod_MPESA,
+		Status:             paymentpb.PaymentStatus_COMPLETED,
+		MpesaReceiptNumber: "NLJ7RT61SV",
+	}, nil))
+	st.repo.On("CreateInvoice", mock.Anything, mock.MatchedBy(func(invoice *model.Invoice) bool {
+		return invoice.OrderID == st.testUUID.String() && invoice.Paid && len(invoice.PDF) > 0
+	})).Return(nil, nil)
+
+	response, err := st.handler.GetInvoice(context.Background(), &orderspb.GetInvoiceRequest{OrderId: st.testUUID.String()})
+
+	st.Require().NoError(err)
+	st.Require().True(response.Paid)
+	st.Require().Equal("INV-00000001", response.InvoiceNumber)
+	st.Require().Contains(response.Html, "NLJ7RT61SV")
+	st.Require().Contains(response.Html, "Sample Product")
+	st.Require().Contains(response.Html, "KES 242.00")
+	st.Require().NotEmpty(response.Pdf)
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_UnpaidIsNotCached() {
+	st.mockInvoiceOrder()
+	st.paymentclient.On("GetPaymentByOrderId", mock.Anything, st.testUUID.String()).Return(paymentResult(&paymentpb.Payment{
+		OrderId:       st.testUUID.String(),
+		PaymentMethod: paymentpb.PaymentMethod_MPESA,
+		Status:        paymentpb.PaymentStatus_PENDING,
+	}, nil))
+
+	response, err := st.handler.GetInvoice(context.Background(), &orderspb.GetInvoiceRequest{OrderId: st.testUUID.String()})
+
+	st.Require().NoError(err)
+	st.Require().False(response.Paid)
+	st.Require().Contains(response.Html, "UNPAID")
+	st.repo.AssertNotCalled(st.T(), "CreateInvoice", mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_NoPayment() {
+	st.mockInvoiceOrder()
+	st.paymentclient.On("GetPaymentByOrderId", mock.Anything, st.testUUID.String()).Return(
+		paymentResult(nil, status.Error(codes.NotFound, "resource not found")),
+	)
+
+	response, err := st.handler.GetInvoice(context.Background(), &orderspb.GetInvoiceRequest{OrderId: st.testUUID.String()})
+
+	st.Require().NoError(err)
+	st.Require().False(response.Paid)
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_PaymentServiceError() {
+	st.mockInvoiceOrder()
+	st.paymentclient.On("GetPaymentByOrderId", mock.Anything, st.testUUID.String()).Return(
+		paymentResult(nil, errors.New("connection refused")),
+	)
+
+	response, err := st.handler.GetInvoice(context.Background(), &orderspb.GetInvoiceRequest{OrderId: st.testUUID.String()})
+
+	st.Require().Error(err)
+	st.Require().Equal(codes.Unavailable, status.Code(err))
+	st.Require().Nil(response)
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_Cached() {
+	st.repo.On("GetInvoiceByOrderId", mock.Anything, st.testUUID.String()).Return(
+		&model.Invoice{
+			OrderID:       st.testUUID.String(),
+			InvoiceNumber: "INV-00000001",
+			HTML:          "<html></html>",
+			PDF:           []byte("%PDF-1.3"),
+			Paid:          true,
+			CreatedAt:     time.Now(),
+		},
+		nil,
+	)
+
+	response, err := st.handler.GetInvoice(context.Background(), &orderspb.GetInvoiceRequest{OrderId: st.testUUID.String()})
+
+	st.Require().NoError(err)
+	st.Require().True(response.Paid)
+	st.Require().Equal("<html></html>", response.Html)
+	st.repo.AssertNotCalled(st.T(), "GetOrderById", mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_OrderNotFound() {
+	st.repo.On("GetInvoiceByOrderId", mock.Anything, st.testUUID.String()).Return(nil, errRowNotFound)
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(nil, errRowNotFound)
+
+	response, err := st.handler.GetInvoice(context.Background(), &orderspb.GetInvoiceRequest{OrderId: st.testUUID.String()})
+
+	st.Require().Equal(errNotFound, err)
+	st.Require().Nil(response)
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_InvalidRequest() {
+	response, err := st.handler.GetInvoice(context.Background(), nil)
+	st.Require().Equal(errResourceRequired, err)
+	st.Require().Nil(response)
+
+	response, err = st.handler.GetInvoice(context.Background(), &orderspb.GetInvoiceRequest{OrderId: "not-a-uuid"})
+	st.Require().Equal(errBadRequest, err)
+	st.Require().Nil(response)
+}
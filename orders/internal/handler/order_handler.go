@@ -2,28 +2,44 @@ package handler
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/wathuta/technical_test/orders/internal/auth"
 	"github.com/wathuta/technical_test/orders/internal/common"
 	"github.com/wathuta/technical_test/orders/internal/common/fieldmask"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/metrics"
 	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/pricing"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	"github.com/wathuta/technical_test/orders/internal/tax"
 	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
-	"golang.org/x/exp/slog"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 // Create a new order
 func (h *Handler) CreateOrder(ctx context.Context, req *orderspb.CreateOrderRequest) (*orderspb.CreateOrderResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.ProductId) == 0 || req.ProductQuantity <= 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("create order and order details")
+	logger.Debug("create order and order details")
+
+	if !auth.CanAccessCustomer(ctx, req.CustomerId) {
+		logger.Error("customer is not allowed to place orders for another customer", "customer_id", req.CustomerId)
+		return nil, errPermissionDenied
+	}
+	if fields := requiredSchedule(req); len(fields) > 0 {
+		logger.Error("order is not scheduled", "fields", fields)
+		return nil, badRequest(fields)
+	}
 
 	order := &model.Order{
 		OrderID:        uuid.New().String(),
@@ -33,69 +49,53 @@ func (h *Handler) CreateOrder(ctx context.Context, req *orderspb.CreateOrderRequ
 		// a random unique identifier for orders
 		TrackingNumber:            uuid.NewString(),
 		PaymentMethod:             model.PaymentMethod(req.PaymentMethod.String()),
-		InvoiceNumber:             req.InvoiceNumber,
-		ShippingCost:              req.ShippingCost,
 		SpecialInstructions:       req.SpecialInstructions,
 		ScheduledPickupDatetime:   req.ScheduledPickupDatetime.AsTime(),
 		ScheduledDeliveryDatetime: req.ScheduledDeliveryDatetime.AsTime(),
 	}
-	if req.PickupAddress != nil {
-		order.PickupAddress = model.Address{
-			Street:     req.PickupAddress.Street,
-			City:       req.PickupAddress.City,
-			State:      req.PickupAddress.State,
-			PostalCode: req.PickupAddress.PostalCode,
-			Country:    req.PickupAddress.Country,
-		}
-	}
-
-	if req.DeliveryAddress != nil {
-		order.DeliveryAddress = model.Address{
-			Street:     req.DeliveryAddress.Street,
-			City:       req.DeliveryAddress.City,
-			State:      req.DeliveryAddress.State,
-			Country:    req.DeliveryAddress.Country,
-			PostalCode: req.PickupAddress.PostalCode,
-		}
-	}
 	order.CreatedAt = time.Now()
 	order.UpdatedAt = time.Time{}
 	order.DeletedAt = time.Time{}
 
-	validator := common.NewValidator()
-	if err := validator.Struct(order); err != nil {
-		slog.Error("failed to validate payment", "error", err)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
-	}
-
 	product, err := h.repo.GetProductById(ctx, req.ProductId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("product with the given product_id not found", "product_id", req.ProductId, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get product from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get product from db", "error", err)
+		return nil, dbError(err)
 	}
 	if product == nil {
-		slog.Error("product with the given id not found", "product_id", req.ProductId, "error", err)
+		logger.Error("product with the given id not found", "product_id", req.ProductId, "error", err)
 		return nil, errNotFound
 	}
 
 	customer, err := h.repo.GetCustomerById(ctx, req.CustomerId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("customer with the given id not found", "customer_id", req.CustomerId, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get customer from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get customer from db", "error", err)
+		return nil, dbError(err)
 	}
 
 	if customer == nil {
-		slog.Error("customer with the given id not found", "customer_id", req.CustomerId, "error", err)
+		logger.Error("customer with the given id not found", "customer_id", req.CustomerId, "error", err)
 		return nil, errNotFound
 	}
+
+	order.PickupAddress, order.DeliveryAddress, err = h.orderAddresses(ctx, customer.CustomerID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	slot, err := h.scheduler.Schedule(ctx, order.DeliveryAddress, order.ShippingMethod,
+		order.ScheduledPickupDatetime, order.ScheduledDeliveryDatetime)
+	if err != nil {
+		if statusErr := scheduleError(slot, err); statusErr != nil {
+			logger.Error("order cannot be delivered at the scheduled time", "error", err)
+			return nil, statusErr
+		}
+		logger.Error("failed to schedule order delivery", "error", err)
+		return nil, dbError(err)
+	}
+	if slot != nil {
+		order.DeliverySlotID = slot.Slot.SlotID
+	}
 	orderdetails := &model.OrderDetails{
 		OrderDetailsID: uuid.NewString(),
 		OrderID:        order.OrderID,
@@ -105,60 +105,147 @@ func (h *Handler) CreateOrder(ctx context.Context, req *orderspb.CreateOrderRequ
 		UpdatedAt:      time.Time{},
 		DeletedAt:      time.Time{},
 	}
-	order, order_details, err := h.repo.CreateOrder(ctx, order, orderdetails)
+
+	var promotion *model.Promotion
+	if len(strings.TrimSpace(req.PromoCode)) > 0 {
+		promotion, err = h.promotions.Redeemable(ctx, req.PromoCode, customer.CustomerID, []*model.Product{product})
+		if err != nil {
+			if statusErr := promotionError(req.PromoCode, err); statusErr != nil {
+				logger.Error("promo code cannot be redeemed", "promo_code", req.PromoCode, "error", err)
+				return nil, statusErr
+			}
+			logger.Error("failed to check promo code", "error", err)
+			return nil, dbError(err)
+		}
+	}
+
+	orderPricing, err := h.pricer.PriceOrder(ctx, order, []pricing.Item{{Product: product, Quantity: int64(req.ProductQuantity)}}, promotion)
 	if err != nil {
-		slog.Error("failed to create order in db", "error", err)
-		return nil, errInternal
+		if errors.Is(err, pricing.ErrUnsupportedShippingMethod) || errors.Is(err, model.ErrCurrencyMismatch) {
+			logger.Error("unable to price order", "error", err)
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		// the product is sold in a category no tax rate has been configured for yet
+		if errors.Is(err, tax.ErrNoTaxRate) {
+			logger.Error("unable to tax order", "category", product.Category, "error", err)
+			return nil, preconditionError("TAX_RATE", string(product.Category), err)
+		}
+		logger.Error("failed to price order", "error", err)
+		return nil, dbError(err)
+	}
+	order.ApplyPricing(orderPricing)
+	orderdetails.ApplyLine(orderPricing.Lines[0])
+
+	// the amounts sent by the client are only used to check that the client showed the customer the right price
+	if err := verifyClientAmounts(req, orderPricing); err != nil {
+		logger.Error("client amounts do not match the order price", "error", err)
+		return nil, preconditionError("PRICE", "order", err)
+	}
+
+	validator := common.NewValidator()
+	if err := validator.Struct(order); err != nil {
+		logger.Error("failed to validate payment", "error", err)
+		return nil, validationError(err)
+	}
+
+	var redemption *model.PromotionRedemption
+	if promotion != nil {
+		order.PromoCode = promotion.Code
+		redemption = &model.PromotionRedemption{
+			RedemptionID: uuid.NewString(),
+			PromotionID:  promotion.PromotionID,
+			OrderID:      order.OrderID,
+			CustomerID:   order.CustomerID,
+			Discount:     orderPricing.Discount.Amount,
+			Currency:     orderPricing.Discount.Currency,
+			CreatedAt:    order.CreatedAt,
+		}
+	}
+
+	order, order_details, err := h.repo.CreateOrder(ctx, order, orderdetails, redemption, slot)
+	if err != nil {
+		// another order may have used up the promotion or booked the delivery slot after it was checked
+		if statusErr := promotionError(req.PromoCode, err); statusErr != nil {
+			logger.Error("promo code cannot be redeemed", "promo_code", req.PromoCode, "error", err)
+			return nil, statusErr
+		}
+		if statusErr := scheduleError(slot, err); statusErr != nil {
+			logger.Error("delivery slot is fully booked", "error", err)
+			return nil, statusErr
+		}
+		logger.Error("failed to create order in db", "error", err)
+		return nil, dbError(err)
+	}
+	metrics.OrdersCreated.WithLabelValues(string(order.Currency)).Inc()
+	if redemption != nil {
+		metrics.PromotionsRedeemed.Inc()
 	}
 
 	response := <-h.paymentclients.CreatePaymentRequest(ctx, &paymentpb.CreatePaymentRequest{
 		OrderId:       order.OrderID,
 		CustomerId:    customer.CustomerID,
 		PaymentMethod: 2,
-		Amount:        order.ShippingCost + product.Price,
+		Amount:        orderPricing.GrandTotal.Proto(),
 		CustomerPhone: strings.ReplaceAll(customer.PhoneNumber, "+", ""),
-		ProductCost:   int64(product.Price),
-		ShippingFee:   int64(order.ShippingCost),
+		ProductCost:   orderPricing.TaxedSubtotal().Proto(),
+		ShippingFee:   orderPricing.Shipping.Proto(),
 	})
 
 	if response.Error != nil {
-		slog.Error("failed to make payment for order", "error", response.Error)
-		return nil, errInternal
+		logger.Error("failed to make payment for order", "error", response.Error)
+		return nil, callError(response.Error)
 	}
 
-	slog.Debug("create order and order details successful")
+	logger.Debug("create order and order details successful")
 	return &orderspb.CreateOrderResponse{
 		Order:        order.Proto(),
 		OrderDetails: order_details.Proto(),
 	}, nil
 }
 
+func verifyClientAmounts(req *orderspb.CreateOrderRequest, orderPricing *model.OrderPricing) error {
+	if req.ShippingCost != nil {
+		shippingCost := model.MoneyFromProto(req.ShippingCost)
+		if err := pricing.Verify("shipping_cost", orderPricing.Shipping, &shippingCost); err != nil {
+			return err
+		}
+	}
+	if req.GrandTotal != nil {
+		grandTotal := model.MoneyFromProto(req.GrandTotal)
+		if err := pricing.Verify("grand_total", orderPricing.GrandTotal, &grandTotal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Get details of an order
 func (h *Handler) GetOrderById(ctx context.Context, req *orderspb.GetOrderRequest) (*orderspb.GetOrderResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.OrderId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get order by id", "order_id", req.OrderId)
+	logger.Debug("get order by id", "order_id", req.OrderId)
 
 	// verify uuid
 	orderUUID, err := uuid.Parse(req.OrderId)
 	if err != nil {
-		slog.Error("invalid order uuid value", "error", err)
+		logger.Error("invalid order uuid value", "error", err)
 		return nil, errBadRequest
 	}
 
 	order, err := h.repo.GetOrderById(ctx, orderUUID.String())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("order with the given id not found", "order_id", orderUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get order from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get order from db", "error", err)
+		return nil, dbError(err)
+	}
+	if !auth.CanAccessCustomer(ctx, order.CustomerID) {
+		logger.Error("customer is not allowed to read order", "order_id", orderUUID)
+		return nil, errNotFound
 	}
 
-	slog.Debug("get order successful")
+	logger.Debug("get order successful")
 	return &orderspb.GetOrderResponse{
 		Order: order.Proto(),
 	}, nil
@@ -166,27 +253,33 @@ func (h *Handler) GetOrderById(ctx context.Context, req *orderspb.GetOrderReques
 
 // Update an order
 func (h *Handler) UpdateOrder(ctx context.Context, req *orderspb.UpdateOrderRequest) (*orderspb.UpdateOrderResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || req.Order == nil {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("update order", "order_id", req.Order.OrderId)
+	logger.Debug("update order", "order_id", req.Order.OrderId)
 
 	// getting the names of the fields that should be updated
-	mask, err := fieldmask.New(req.UpdateMask)
+	mask, err := fieldmask.New(req.UpdateMask, req.Order)
 	if err != nil {
-		slog.Error("invalid request inputs", "error", err)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		logger.Error("invalid update mask", "error", err)
+		return nil, maskError(err)
 	}
 	// remove fields that should never be updated by an external service through this endpoint
 	mask.RemoveOutputOnly()
 
 	orderUUID, err := uuid.Parse(req.Order.OrderId)
 	if err != nil {
-		slog.Error("invalid order uuid value", "orderUUID", orderUUID, "error", err, req)
+		logger.Error("invalid order uuid value", "order_id", req.Order.OrderId, "error", err)
 		return nil, errBadRequest
 	}
 
+	if fields := maskedSchedule(req.Order, mask.Fields); len(fields) > 0 {
+		logger.Error("order is not scheduled", "fields", fields)
+		return nil, badRequest(fields)
+	}
+
 	order := model.OrderFromProto(req.Order)
 
 	// filtering the field to remain with the fields should be updated as stated in the field mask
@@ -195,58 +288,270 @@ func (h *Handler) UpdateOrder(ctx context.Context, req *orderspb.UpdateOrderRequ
 
 	// if fieldmask is empty perfom get
 	if len(mask.Fields) == 0 || len(updatedOrderDetails) == 0 {
-		slog.Debug("no fields to update")
+		logger.Debug("no fields to update")
+		if err := h.authorizeOrder(ctx, orderUUID.String()); err != nil {
+			return nil, err
+		}
 		order, err = h.repo.GetOrderById(ctx, orderUUID.String())
 		if err != nil {
-			if err == sql.ErrNoRows {
-				slog.Error("order with the given id not found", "order_id", orderUUID, "error", err)
-				return nil, errNotFound
-			}
-			slog.Error("failed to get order from db", "error", err)
-			return nil, errInternal
+			logger.Error("failed to get order from db", "error", err)
+			return nil, dbError(err)
 		}
 		return &orderspb.UpdateOrderResponse{Order: order.Proto()}, nil
 	}
 
-	order, err = h.repo.UpdateOrder(ctx, orderUUID.String(), updatedOrderDetails)
+	// other services of the platform may change the status alone without a version, as the payment
+	// service does once an order is paid: updateOrderStatus then applies it to the current version
+	statusOnly := len(mask.Fields) == 1 && mask.Fields[0] == "order_status"
+	if !(statusOnly && auth.IsService(ctx)) {
+		if err := requireVersion("order.version", req.Order.Version); err != nil {
+			logger.Error("order version required", "order_id", orderUUID)
+			return nil, err
+		}
+	}
+
+	var slot *model.DeliveryWindow
+	if reschedules(mask.Fields) || reprices(mask.Fields) {
+		current, err := h.repo.GetOrderById(ctx, orderUUID.String())
+		if err != nil {
+			logger.Error("failed to get order from db", "error", err)
+			return nil, dbError(err)
+		}
+		if current.Version != req.Order.Version {
+			logger.Error("order changed since it was read", "order_id", orderUUID, "version", req.Order.Version)
+			return nil, errVersionMismatch
+		}
+		merged := model.MergeOrder(*current, mask.Fields, *order)
+
+		// orders moved to another time, city or shipping method are booked in their new delivery slot
+		if reschedules(mask.Fields) {
+			slot, err = h.rescheduleOrder(ctx, current, &merged)
+			if err != nil {
+				return nil, err
+			}
+			updatedOrderDetails["delivery_slot_id"] = ""
+			if slot != nil {
+				updatedOrderDetails["delivery_slot_id"] = slot.Slot.SlotID
+			}
+		}
+		// orders shipped another way or between other addresses are charged their new shipping
+		if reprices(mask.Fields) {
+			if err := h.repriceOrder(ctx, &merged); err != nil {
+				return nil, err
+			}
+			updatedOrderDetails["shipping_cost"] = merged.ShippingCost
+			updatedOrderDetails["grand_total"] = merged.GrandTotal
+		}
+	}
+
+	if _, ok := updatedOrderDetails["order_status"]; ok {
+		order, err = h.updateOrderStatus(ctx, orderUUID.String(), req.Order.Version, updatedOrderDetails, slot)
+	} else {
+		order, err = h.repo.UpdateOrder(ctx, orderUUID.String(), req.Order.Version, updatedOrderDetails, slot)
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("order with the given id not found", "order_id", orderUUID, "error", err)
-			return nil, errNotFound
+		if _, ok := status.FromError(err); ok {
+			logger.Error("order status cannot be changed", "order_id", orderUUID, "error", err)
+			return nil, err
+		}
+		// another order may have booked the delivery slot after it was checked
+		if statusErr := scheduleError(slot, err); statusErr != nil {
+			logger.Error("delivery slot is fully booked", "order_id", orderUUID, "error", err)
+			return nil, statusErr
 		}
-		slog.Error("failed to update order from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to update order from db", "error", err)
+		return nil, dbError(err)
 	}
-	slog.Debug("update order successful")
+	if _, ok := updatedOrderDetails["order_status"]; ok {
+		metrics.OrderStatusUpdates.WithLabelValues(string(order.OrderStatus)).Inc()
+	}
+	logger.Debug("update order successful")
 	return &orderspb.UpdateOrderResponse{Order: order.Proto()}, nil
 }
 
+// reprices reports whether updating fields of an order changes what its shipping costs.
+// Only the city and country of the addresses decide the shipping zone of an order.
+func reprices(fields []string) bool {
+	for _, field := range fields {
+		switch field {
+		case "shipping_method", "pickup_address", "pickup_address.city", "pickup_address.country",
+			"delivery_address", "delivery_address.city", "delivery_address.country":
+			return true
+		}
+	}
+	return false
+}
+
+// repriceOrder charges order the shipping of its shipping method between its addresses. The
+// products keep the price, discount and tax they were ordered at.
+func (h *Handler) repriceOrder(ctx context.Context, order *model.Order) error {
+	logger := logging.FromContext(ctx)
+	details, err := h.repo.GetOrderDetailsByOrderId(ctx, order.OrderID, maxPageSize, 0)
+	if err != nil {
+		logger.Error("failed to get order details from db", "order_id", order.OrderID, "error", err)
+		return dbError(err)
+	}
+	var quantity int64
+	for _, detail := range details {
+		quantity += int64(detail.Quantity)
+	}
+
+	orderPricing, err := h.pricer.PriceShipping(ctx, order, quantity)
+	if err != nil {
+		if errors.Is(err, pricing.ErrUnsupportedShippingMethod) || errors.Is(err, model.ErrCurrencyMismatch) {
+			logger.Error("unable to price order", "error", err)
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		logger.Error("failed to price order", "error", err)
+		return dbError(err)
+	}
+	order.ApplyPricing(orderPricing)
+	return nil
+}
+
+// orderAddresses returns the pickup and delivery addresses of an order, each either sent in req or
+// saved by the customer. Orders naming no delivery address are delivered to the default shipping
+// address of the customer when there is one.
+func (h *Handler) orderAddresses(ctx context.Context, customerId string, req *orderspb.CreateOrderRequest) (model.Address, model.Address, error) {
+	logger := logging.FromContext(ctx)
+	pickup, err := h.orderAddress(ctx, customerId, "pickup_address", req.PickupAddress, req.PickupAddressId)
+	if err != nil {
+		return model.Address{}, model.Address{}, err
+	}
+	delivery, err := h.orderAddress(ctx, customerId, "delivery_address", req.DeliveryAddress, req.DeliveryAddressId)
+	if err != nil {
+		return model.Address{}, model.Address{}, err
+	}
+
+	if req.DeliveryAddress == nil && len(req.DeliveryAddressId) == 0 {
+		saved, err := h.repo.GetDefaultShippingAddress(ctx, customerId)
+		switch {
+		case err == nil:
+			delivery = saved.Address
+		case !errors.Is(err, repository.ErrNotFound):
+			logger.Error("failed to get default shipping address from db", "error", err)
+			return model.Address{}, model.Address{}, dbError(err)
+		}
+	}
+	return pickup, delivery, nil
+}
+
+// orderAddress returns the address of an order sent in the field of the request, or the saved
+// address with the id sent in the field with an _id suffix.
+func (h *Handler) orderAddress(ctx context.Context, customerId, field string, address *orderspb.Address, addressId string) (model.Address, error) {
+	logger := logging.FromContext(ctx)
+	if len(addressId) == 0 {
+		if address == nil {
+			return model.Address{}, nil
+		}
+		return model.Address{
+			Street:     address.Street,
+			City:       address.City,
+			State:      address.State,
+			PostalCode: address.PostalCode,
+			Country:    address.Country,
+		}, nil
+	}
+
+	if address != nil {
+		logger.Error("order has both an address and a saved address", "field", field)
+		return model.Address{}, badRequest(map[string]string{field + "_id": "cannot be combined with " + field})
+	}
+	addressUUID, err := uuid.Parse(addressId)
+	if err != nil {
+		logger.Error("invalid address uuid value", "field", field, "address_id", addressId, "error", err)
+		return model.Address{}, badRequest(map[string]string{field + "_id": "must be a valid UUID"})
+	}
+	saved, err := h.repo.GetCustomerAddress(ctx, customerId, addressUUID.String())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			logger.Error("saved address not found", "field", field, "address_id", addressId)
+			return model.Address{}, badRequest(map[string]string{field + "_id": "address not found"})
+		}
+		logger.Error("failed to get customer address from db", "error", err)
+		return model.Address{}, dbError(err)
+	}
+	return saved.Address, nil
+}
+
+// updateOrderStatus applies fields, which change the status of the order, to the version of the
+// order it was checked against: orders only move forward through orderStatusTransitions. slot is
+// the delivery window the order is moved to and may be nil. Without an expected version an update
+// that lost a race with another one is checked and applied again on the new version, up to
+// maxUpdateRetry times.
+func (h *Handler) updateOrderStatus(ctx context.Context, orderId string, version int64, fields map[string]interface{}, slot *model.DeliveryWindow) (*model.Order, error) {
+	logger := logging.FromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		current, err := h.repo.GetOrderById(ctx, orderId)
+		if err != nil {
+			return nil, err
+		}
+		if version != 0 && current.Version != version {
+			return nil, repository.ErrVersionMismatch
+		}
+		if next, _ := fields["order_status"].(model.OrderStatus); !canMoveOrder(current.OrderStatus, next) {
+			return nil, preconditionError("STATUS", "order", fmt.Errorf("order is %s, it cannot move to %s", current.OrderStatus, next))
+		}
+
+		order, err := h.repo.UpdateOrder(ctx, orderId, current.Version, fields, slot)
+		if version != 0 || attempt == maxUpdateRetry || !errors.Is(err, repository.ErrVersionMismatch) {
+			return order, err
+		}
+		logger.Warn("order changed while updating its status, retrying", "order_id", orderId, "attempt", attempt)
+	}
+}
+
+// orderStatusTransitions are the statuses an order in each status can move to. Orders only move
+// forward, so a late or repeated payment callback cannot move a shipped order back to processing,
+// and delivered or cancelled orders keep their status.
+var orderStatusTransitions = map[model.OrderStatus][]model.OrderStatus{
+	model.OrderStatusPending:    {model.OrderStatusProcessing, model.OrderStatusShipped, model.OrderStatusDelivered, model.OrderStatusCanceled},
+	model.OrderStatusProcessing: {model.OrderStatusShipped, model.OrderStatusDelivered, model.OrderStatusCanceled},
+	model.OrderStatusShipped:    {model.OrderStatusDelivered, model.OrderStatusCanceled},
+}
+
+// canMoveOrder reports whether an order in status from can move to status to, it can always keep
+// its status.
+func canMoveOrder(from, to model.OrderStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isFinalOrderStatus reports whether an order in status can no longer move to another status.
+func isFinalOrderStatus(status model.OrderStatus) bool {
+	return len(orderStatusTransitions[status]) == 0
+}
+
 // Delete an order
 func (h *Handler) DeleteOrder(ctx context.Context, req *orderspb.DeleteOrderRequest) (*orderspb.DeleteOrderResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.OrderId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return &orderspb.DeleteOrderResponse{Success: false}, errResourceRequired
 	}
-	slog.Debug("delete order", "order_id", req.OrderId)
+	logger.Debug("delete order", "order_id", req.OrderId)
 
 	// verify supplied uuid
 	orderUUID, err := uuid.Parse(req.OrderId)
 	if err != nil {
-		slog.Error("invalid order uuid value", "error", err)
+		logger.Error("invalid order uuid value", "error", err)
 		return &orderspb.DeleteOrderResponse{Success: false}, errBadRequest
 	}
 
-	resource, err := h.repo.DeleteOrder(ctx, orderUUID.String())
+	_, err = h.repo.DeleteOrder(ctx, orderUUID.String())
 	if err != nil {
-		slog.Error("failed to order from db", "error", err)
-		return &orderspb.DeleteOrderResponse{Success: false}, errInternal
-	}
-	if resource == nil {
-		slog.Error("order with the given id not found", "order_id", orderUUID, "error", err)
-		return &orderspb.DeleteOrderResponse{Success: false}, errNotFound
+		logger.Error("failed to delete order from db", "order_id", orderUUID, "error", err)
+		return &orderspb.DeleteOrderResponse{Success: false}, dbError(err)
 	}
 
-	slog.Debug("delete order successful")
+	logger.Debug("delete order successful")
 	return &orderspb.DeleteOrderResponse{
 		Success: true,
 	}, nil
@@ -254,29 +559,30 @@ func (h *Handler) DeleteOrder(ctx context.Context, req *orderspb.DeleteOrderRequ
 
 // Get orders by customer ID
 func (h *Handler) ListOrdersByCustomerId(ctx context.Context, req *orderspb.ListOrdersByCustomerIdRequest) (*orderspb.ListOrdersByCustomerIdResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.CustomerId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get order by customer id", "customer_id", req.CustomerId)
+	logger.Debug("get order by customer id", "customer_id", req.CustomerId)
 
 	customerUUID, err := uuid.Parse(req.CustomerId)
 	if err != nil {
-		slog.Error("invalid order uuid value", "error", err)
+		logger.Error("invalid order uuid value", "error", err)
 		return nil, errBadRequest
 	}
+	if !auth.CanAccessCustomer(ctx, customerUUID.String()) {
+		logger.Error("customer is not allowed to list orders of another customer", "customer_id", customerUUID)
+		return nil, errPermissionDenied
+	}
 	// Basic pagination, To do: improve this
 	pagesize := common.SetPageSize(int(req.PageSize), defaultPageSize, maxPageSize)
 	token := common.SetPageToken(int(req.PageToken))
 
 	orders, err := h.repo.GetOrdersByCustomerId(ctx, customerUUID.String(), pagesize, token)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("order with the given customer id not found", "customer_id", customerUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get order from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get order from db", "error", err)
+		return nil, dbError(err)
 	}
 
 	returnOrders := []*orderspb.Order{}
@@ -285,21 +591,22 @@ func (h *Handler) ListOrdersByCustomerId(ctx context.Context, req *orderspb.List
 		returnOrders = append(returnOrders, newOrder.Proto())
 	}
 
-	slog.Debug("get orders by customer id successful")
+	logger.Debug("get orders by customer id successful")
 	return &orderspb.ListOrdersByCustomerIdResponse{Orders: returnOrders}, nil
 }
 
 // Get orders by product ID
 func (h *Handler) ListOrdersByProductId(ctx context.Context, req *orderspb.ListOrdersByProductIdRequest) (*orderspb.ListOrdersByProductIdResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.ProductId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get order by product_id", "order_id", req.ProductId)
+	logger.Debug("get order by product_id", "order_id", req.ProductId)
 
 	productUUID, err := uuid.Parse(req.ProductId)
 	if err != nil {
-		slog.Error("invalid product uuid value", "error", err)
+		logger.Error("invalid product uuid value", "error", err)
 		return nil, errBadRequest
 	}
 	pagesize := common.SetPageSize(int(req.PageSize), defaultPageSize, maxPageSize)
@@ -307,12 +614,8 @@ func (h *Handler) ListOrdersByProductId(ctx context.Context, req *orderspb.ListO
 
 	orderDetails, err := h.repo.GetOrderDetailsByProductId(ctx, productUUID.String(), pagesize, token)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("order details with the given id not found", "order_id", productUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get order details from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get order details from db", "error", err)
+		return nil, dbError(err)
 	}
 
 	var returnOrders []*orderspb.Order
@@ -321,18 +624,14 @@ func (h *Handler) ListOrdersByProductId(ctx context.Context, req *orderspb.ListO
 	for _, orderDetail := range orderDetails {
 		order, err := h.repo.GetOrderById(ctx, orderDetail.OrderID)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				slog.Error("order details with the given id not found", "order_id", productUUID, "error", err)
-				return nil, errNotFound
-			}
-			slog.Error("failed to get order details from db", "error", err)
-			return nil, errInternal
+			logger.Error("failed to get order details from db", "error", err)
+			return nil, dbError(err)
 		}
 		returnOrders = append(returnOrders, order.Proto())
 		returnOrderDetails = append(returnOrderDetails, orderDetail.Proto())
 	}
 
-	slog.Debug("get order successful")
+	logger.Debug("get order successful")
 	return &orderspb.ListOrdersByProductIdResponse{
 		Orders:       returnOrders,
 		OrderDetails: returnOrderDetails,
@@ -341,58 +640,58 @@ func (h *Handler) ListOrdersByProductId(ctx context.Context, req *orderspb.ListO
 
 // Get order details by ID
 func (h *Handler) GetOrderDetailsById(ctx context.Context, req *orderspb.GetOrderDetailByIdRequest) (*orderspb.GetOrderDetailByIdResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.OrderDetailsId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get order details by id", "order_details_id", req.OrderDetailsId)
+	logger.Debug("get order details by id", "order_details_id", req.OrderDetailsId)
 
 	orderUUID, err := uuid.Parse(req.OrderDetailsId)
 	if err != nil {
-		slog.Error("invalid order details uuid value", "error", err)
+		logger.Error("invalid order details uuid value", "error", err)
 		return nil, errBadRequest
 	}
 
 	orderDetails, err := h.repo.GetOrderDetailsById(ctx, orderUUID.String())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("order details with the given id not found", "order_id", orderUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get order details from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get order details from db", "error", err)
+		return nil, dbError(err)
+	}
+	if err := h.authorizeOrder(ctx, orderDetails.OrderID); err != nil {
+		return nil, err
 	}
 
-	slog.Debug("get order details successful")
+	logger.Debug("get order details successful")
 	return &orderspb.GetOrderDetailByIdResponse{
 		OrderDetails: orderDetails.Proto(),
 	}, nil
 }
 
 func (h *Handler) ListOrderDetailsByOrderId(ctx context.Context, req *orderspb.ListOrderDetailsByOrderIdRequest) (*orderspb.ListOrderDetailsByOrderIdResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.OrderId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get order details by order id", "order_id", req.OrderId)
+	logger.Debug("get order details by order id", "order_id", req.OrderId)
 
 	// validate UUID
 	orderUUID, err := uuid.Parse(req.OrderId)
 	if err != nil {
-		slog.Error("invalid order uuid value", "error", err)
+		logger.Error("invalid order uuid value", "error", err)
 		return nil, errBadRequest
 	}
+	if err := h.authorizeOrder(ctx, orderUUID.String()); err != nil {
+		return nil, err
+	}
 	pagesize := common.SetPageSize(int(req.PageSize), defaultPageSize, maxPageSize)
 	token := common.SetPageToken(int(req.PageToken))
 
 	orderDetails, err := h.repo.GetOrderDetailsByOrderId(ctx, orderUUID.String(), pagesize, token)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("order details with the given order id not found", "order_id", orderUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get order details from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get order details from db", "error", err)
+		return nil, dbError(err)
 	}
 
 	var returnOrderDetails []*orderspb.OrderDetails
@@ -401,7 +700,7 @@ func (h *Handler) ListOrderDetailsByOrderId(ctx context.Context, req *orderspb.L
 		returnOrderDetails = append(returnOrderDetails, newOrderDetail.Proto())
 	}
 
-	slog.Debug("get order details by order id successful")
+	logger.Debug("get order details by order id successful")
 	return &orderspb.ListOrderDetailsByOrderIdResponse{
 		OrderDetails: returnOrderDetails,
 	}, nil
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"os"
 	"testing"
@@ -11,14 +10,19 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/wathuta/technical_test/orders/internal/auth"
 	"github.com/wathuta/technical_test/orders/internal/config"
 	grpcclients "github.com/wathuta/technical_test/orders/internal/grpc_clients"
 	"github.com/wathuta/technical_test/orders/internal/mocks"
 	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
 	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
 	"golang.org/x/exp/slog"
 	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -62,17 +66,17 @@ func (st *OrderHandlerTestSuite) SetupTest() {
 }
 
 func (st *OrderHandlerTestSuite) TestCreateOrder_Success() {
+	st.mockDeliverySlots()
 
 	output := make(chan grpcclients.ServiceResult)
 	go func() {
 		output <- grpcclients.ServiceResult{
 			Result: &paymentpb.CreatePaymentResponse{
 				Payment: &paymentpb.Payment{
-					PaymenId:   st.testUUID2.String(),
+					Id:         st.testUUID2.String(),
 					OrderId:    st.testUUID.String(),
 					CustomerId: st.testUUID.String(),
-					Amount:     120.0,
-					Currency:   "USD",
+					Amount:     &moneypb.Money{CurrencyCode: "KES", Amount: 11000},
 					Status:     paymentpb.PaymentStatus_COMPLETED,
 					CreatedAt:  timestamppb.New(time.Now()),
 				}}, Error: nil,
@@ -86,8 +90,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_Success() {
 		ProductQuantity:           2,
 		ShippingMethod:            "Express",
 		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD,
-		InvoiceNumber:             "INV12345",
-		ShippingCost:              10.0,
+		ShippingCost:              &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		SpecialInstructions:       "Handle with care",
 		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
 		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
@@ -116,7 +119,8 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_Success() {
 			Category:      "Electronics",
 			StockQuantity: 100,
 			ProductAttributes: model.ProductAttributes{
-				Price: 100.0,
+				Price:    10000,
+				Currency: model.KES,
 			},
 			IsAvailable: true,
 		},
@@ -136,7 +140,21 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_Success() {
 	)
 
 	// Set up expectations for the mock repository to create an order
-	st.repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(
+	st.repo.On("GetTaxRate", mock.Anything, mock.Anything).Return(
+		&model.TaxRate{RateBasisPoints: 1600},
+		nil,
+	)
+	st.repo.On("GetShippingRate", mock.Anything, "EXPRESS", model.ShippingZoneInternational).Return(
+		&model.ShippingRate{
+			ShippingMethod: "EXPRESS",
+			Zone:           model.ShippingZoneInternational,
+			BaseFee:        600,
+			PerItemFee:     200,
+			Currency:       model.KES,
+		},
+		nil,
+	)
+	st.repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything, (*model.PromotionRedemption)(nil), (*model.DeliveryWindow)(nil)).Return(
 		&model.Order{
 			OrderID:                   st.testUUID.String(),
 			CustomerID:                orderRequest.CustomerId,
@@ -144,8 +162,12 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_Success() {
 			OrderStatus:               model.OrderStatus(orderspb.OrderStatus_ORDER_STATUS_PENDING.String()),
 			TrackingNumber:            "1234567890",
 			PaymentMethod:             model.PaymentMethod(orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD.String()),
-			InvoiceNumber:             orderRequest.InvoiceNumber,
-			ShippingCost:              orderRequest.ShippingCost,
+			InvoiceNumber:             "INV-00000001",
+			ShippingCost:              orderRequest.ShippingCost.Amount,
+			Currency:                  model.KES,
+			Subtotal:                  20000,
+			Tax:                       3200,
+			GrandTotal:                24200,
 			SpecialInstructions:       orderRequest.SpecialInstructions,
 			ScheduledPickupDatetime:   orderRequest.ScheduledPickupDatetime.AsTime(),
 			ScheduledDeliveryDatetime: orderRequest.ScheduledDeliveryDatetime.AsTime(),
@@ -179,7 +201,10 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_Success() {
 	)
 
 	// Set up expectations for the mock payment service to create payment
-	st.paymentclient.On("CreatePaymentRequest", mock.Anything, mock.Anything).Return(output)
+	// 2 items at KES 100 with 16% VAT and KES 10 shipping
+	st.paymentclient.On("CreatePaymentRequest", mock.Anything, mock.MatchedBy(func(req *paymentpb.CreatePaymentRequest) bool {
+		return req.Amount.Amount == 24200 && req.ProductCost.Amount == 23200 && req.ShippingFee.Amount == 1000
+	})).Return(output)
 
 	// Call the CreateOrder function
 	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
@@ -192,8 +217,12 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_Success() {
 	st.Require().NotEmpty(response.Order.OrderId)
 	st.Require().Equal(orderRequest.CustomerId, response.Order.CustomerId)
 	st.Require().Equal(orderRequest.ShippingMethod, response.Order.ShippingMethod)
-	st.Require().Equal(orderRequest.InvoiceNumber, response.Order.InvoiceNumber)
-	st.Require().Equal(orderRequest.ShippingCost, response.Order.ShippingCost)
+	st.Require().Equal("INV-00000001", response.Order.InvoiceNumber)
+	st.Require().Equal(orderRequest.ShippingCost.Amount, response.Order.ShippingCost.Amount)
+	st.Require().Equal(orderRequest.ShippingCost.CurrencyCode, response.Order.ShippingCost.CurrencyCode)
+	st.Require().Equal(int64(20000), response.Order.Subtotal.Amount)
+	st.Require().Equal(int64(3200), response.Order.Tax.Amount)
+	st.Require().Equal(int64(24200), response.Order.GrandTotal.Amount)
 	st.Require().Equal(orderRequest.SpecialInstructions, response.Order.SpecialInstructions)
 	st.Require().Equal(orderRequest.ScheduledPickupDatetime.AsTime(), response.Order.ScheduledPickupDatetime.AsTime())
 	st.Require().Equal(orderRequest.ScheduledDeliveryDatetime.AsTime(), response.Order.ScheduledDeliveryDatetime.AsTime())
@@ -246,8 +275,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_ProductNotFound() {
 		ProductQuantity:           2,
 		ShippingMethod:            "Express",
 		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD,
-		InvoiceNumber:             "INV12345",
-		ShippingCost:              10.0,
+		ShippingCost:              &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		SpecialInstructions:       "Handle with care",
 		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
 		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
@@ -267,7 +295,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_ProductNotFound() {
 		},
 	}
 
-	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
 
@@ -283,8 +311,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CustomerNotFound() {
 		ProductQuantity:           2,
 		ShippingMethod:            "Express",
 		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD,
-		InvoiceNumber:             "INV12345",
-		ShippingCost:              10.0,
+		ShippingCost:              &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		SpecialInstructions:       "Handle with care",
 		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
 		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
@@ -311,7 +338,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CustomerNotFound() {
 		},
 		nil,
 	)
-	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
 
@@ -320,6 +347,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CustomerNotFound() {
 }
 
 func (st *OrderHandlerTestSuite) TestCreateOrder_CreateOrderError() {
+	st.mockDeliverySlots()
 	// Test case where an error occurs while creating an order
 	orderRequest := &orderspb.CreateOrderRequest{
 		CustomerId:                st.testUUID.String(),
@@ -327,8 +355,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreateOrderError() {
 		ProductQuantity:           2,
 		ShippingMethod:            "Express",
 		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD,
-		InvoiceNumber:             "INV12345",
-		ShippingCost:              10.0,
+		ShippingCost:              &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		SpecialInstructions:       "Handle with care",
 		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
 		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
@@ -362,7 +389,21 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreateOrderError() {
 		},
 		nil,
 	)
-	st.repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, errors.New("order creation failed"))
+	st.repo.On("GetTaxRate", mock.Anything, mock.Anything).Return(
+		&model.TaxRate{RateBasisPoints: 1600},
+		nil,
+	)
+	st.repo.On("GetShippingRate", mock.Anything, "EXPRESS", model.ShippingZoneInternational).Return(
+		&model.ShippingRate{
+			ShippingMethod: "EXPRESS",
+			Zone:           model.ShippingZoneInternational,
+			BaseFee:        600,
+			PerItemFee:     200,
+			Currency:       model.KES,
+		},
+		nil,
+	)
+	st.repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything, (*model.PromotionRedemption)(nil), (*model.DeliveryWindow)(nil)).Return(nil, nil, errors.New("order creation failed"))
 
 	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
 
@@ -371,6 +412,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreateOrderError() {
 }
 
 func (st *OrderHandlerTestSuite) TestCreateOrder_CreatePaymentError() {
+	st.mockDeliverySlots()
 	// Test case where an error occurs while creating a payment
 	orderRequest := &orderspb.CreateOrderRequest{
 		CustomerId:                st.testUUID.String(),
@@ -378,8 +420,7 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreatePaymentError() {
 		ProductQuantity:           2,
 		ShippingMethod:            "Express",
 		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD,
-		InvoiceNumber:             "INV12345",
-		ShippingCost:              10.0,
+		ShippingCost:              &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		SpecialInstructions:       "Handle with care",
 		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
 		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
@@ -399,19 +440,16 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreatePaymentError() {
 		},
 	}
 
-	output := make(chan grpcclients.ServiceResult)
-	go func() {
-		output <- grpcclients.ServiceResult{
-			Result: nil, Error: errors.New("some error"),
-		}
-	}()
+	// the payment service fails, then runs out of time
+	paymentErrors := []error{errors.New("some error"), status.Error(codes.DeadlineExceeded, "context deadline exceeded")}
 
 	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(
 		&model.Product{
 			ProductID: st.testUUID1.String(),
 			Name:      "Sample Product",
 			ProductAttributes: model.ProductAttributes{
-				Price: 100.0,
+				Price:    10000,
+				Currency: model.KES,
 			},
 		},
 		nil,
@@ -423,7 +461,21 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreatePaymentError() {
 		},
 		nil,
 	)
-	st.repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(
+	st.repo.On("GetTaxRate", mock.Anything, mock.Anything).Return(
+		&model.TaxRate{RateBasisPoints: 1600},
+		nil,
+	)
+	st.repo.On("GetShippingRate", mock.Anything, "EXPRESS", model.ShippingZoneInternational).Return(
+		&model.ShippingRate{
+			ShippingMethod: "EXPRESS",
+			Zone:           model.ShippingZoneInternational,
+			BaseFee:        600,
+			PerItemFee:     200,
+			Currency:       model.KES,
+		},
+		nil,
+	)
+	st.repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything, (*model.PromotionRedemption)(nil), (*model.DeliveryWindow)(nil)).Return(
 		&model.Order{
 			OrderID:                   st.testUUID.String(),
 			CustomerID:                orderRequest.CustomerId,
@@ -431,8 +483,9 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreatePaymentError() {
 			OrderStatus:               model.OrderStatus(orderspb.OrderStatus_ORDER_STATUS_PENDING.String()),
 			TrackingNumber:            "1234567890",
 			PaymentMethod:             model.PaymentMethod(orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD.String()),
-			InvoiceNumber:             orderRequest.InvoiceNumber,
-			ShippingCost:              orderRequest.ShippingCost,
+			InvoiceNumber:             "INV-00000001",
+			ShippingCost:              orderRequest.ShippingCost.Amount,
+			Currency:                  model.KES,
 			SpecialInstructions:       orderRequest.SpecialInstructions,
 			ScheduledPickupDatetime:   orderRequest.ScheduledPickupDatetime.AsTime(),
 			ScheduledDeliveryDatetime: orderRequest.ScheduledDeliveryDatetime.AsTime(),
@@ -464,12 +517,213 @@ func (st *OrderHandlerTestSuite) TestCreateOrder_CreatePaymentError() {
 		},
 		nil,
 	)
-	st.paymentclient.On("CreatePaymentRequest", mock.Anything, mock.Anything).Return(output)
+	st.paymentclient.On("CreatePaymentRequest", mock.Anything, mock.Anything).Return(func(context.Context, *paymentpb.CreatePaymentRequest) chan grpcclients.ServiceResult {
+		output := make(chan grpcclients.ServiceResult, 1)
+		output <- grpcclients.ServiceResult{Error: paymentErrors[0]}
+		paymentErrors = paymentErrors[1:]
+		return output
+	})
+
+	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
+
+	st.Require().Equal(codes.Internal, status.Code(err))
+	st.Require().Nil(response)
+
+	// a timeout is reported as such rather than as an internal error
+	response, err = st.handler.CreateOrder(context.Background(), orderRequest)
+
+	st.Require().Equal(codes.DeadlineExceeded, status.Code(err))
+	st.Require().Nil(response)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_ClientAmountMismatch() {
+	st.mockDeliverySlots()
+	// Test case where the client was shown a different shipping cost from the one calculated
+	orderRequest := &orderspb.CreateOrderRequest{
+		CustomerId:                st.testUUID.String(),
+		ProductId:                 st.testUUID1.String(),
+		ProductQuantity:           2,
+		ShippingMethod:            "Express",
+		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_MPESA,
+		ShippingCost:              &moneypb.Money{CurrencyCode: "KES", Amount: 1},
+		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
+		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
+		PickupAddress: &orderspb.Address{
+			Street:     "123 Pickup St",
+			City:       "Nairobi",
+			State:      "Nairobi",
+			PostalCode: "00100",
+			Country:    "Kenya",
+		},
+		DeliveryAddress: &orderspb.Address{
+			Street:     "123 Delivery St",
+			City:       "Nairobi",
+			State:      "Nairobi",
+			PostalCode: "00100",
+			Country:    "Kenya",
+		},
+	}
+
+	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(
+		&model.Product{
+			ProductID:         st.testUUID1.String(),
+			ProductAttributes: model.ProductAttributes{Price: 10000, Currency: model.KES},
+		},
+		nil,
+	)
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(&model.Customer{CustomerID: st.testUUID.String()}, nil)
+	st.repo.On("GetTaxRate", mock.Anything, mock.Anything).Return(
+		&model.TaxRate{RateBasisPoints: 1600},
+		nil,
+	)
+	st.repo.On("GetShippingRate", mock.Anything, "EXPRESS", model.ShippingZoneLocal).Return(
+		&model.ShippingRate{ShippingMethod: "EXPRESS", Zone: model.ShippingZoneLocal, BaseFee: 40000, PerItemFee: 5000, Currency: model.KES},
+		nil,
+	)
+
+	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
+
+	st.Require().Error(err)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.Require().Nil(response)
+	st.repo.AssertNotCalled(st.T(), "CreateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_UnsupportedShippingMethod() {
+	st.mockDeliverySlots()
+	// Test case where there is no shipping rate for the shipping method
+	orderRequest := &orderspb.CreateOrderRequest{
+		CustomerId:                st.testUUID.String(),
+		ProductId:                 st.testUUID1.String(),
+		ProductQuantity:           1,
+		ShippingMethod:            "Teleport",
+		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_MPESA,
+		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
+		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
+		PickupAddress: &orderspb.Address{
+			Street:     "123 Pickup St",
+			City:       "Nairobi",
+			State:      "Nairobi",
+			PostalCode: "00100",
+			Country:    "Kenya",
+		},
+		DeliveryAddress: &orderspb.Address{
+			Street:     "123 Delivery St",
+			City:       "Mombasa",
+			State:      "Mombasa",
+			PostalCode: "80100",
+			Country:    "Kenya",
+		},
+	}
+
+	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(
+		&model.Product{
+			ProductID:         st.testUUID1.String(),
+			ProductAttributes: model.ProductAttributes{Price: 10000, Currency: model.KES},
+		},
+		nil,
+	)
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(&model.Customer{CustomerID: st.testUUID.String()}, nil)
+	st.repo.On("GetTaxRate", mock.Anything, mock.Anything).Return(
+		&model.TaxRate{RateBasisPoints: 1600},
+		nil,
+	)
+	st.repo.On("GetShippingRate", mock.Anything, "TELEPORT", model.ShippingZoneDomestic).Return(nil, errRowNotFound)
 
 	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
 
 	st.Require().Error(err)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Nil(response)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_NoTaxRate() {
+	st.mockPromoOrder()
+	st.mockDeliverySlots()
+	st.repo.On("GetTaxRate", mock.Anything, model.Electronics).Return(nil, errRowNotFound)
+
+	response, err := st.handler.CreateOrder(context.Background(), st.promoOrderRequest(""))
+
 	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "CreateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_SavedAddresses() {
+	st.mockDeliverySlots()
+	// the pickup address is saved and the delivery address is the default shipping address
+	orderRequest := &orderspb.CreateOrderRequest{
+		CustomerId:                st.testUUID.String(),
+		ProductId:                 st.testUUID1.String(),
+		ProductQuantity:           1,
+		ShippingMethod:            "Teleport",
+		PickupAddressId:           st.testUUID2.String(),
+		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
+		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
+	}
+
+	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(
+		&model.Product{
+			ProductID:         st.testUUID1.String(),
+			ProductAttributes: model.ProductAttributes{Price: 10000, Currency: model.KES},
+		},
+		nil,
+	)
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(&model.Customer{CustomerID: st.testUUID.String()}, nil)
+	st.repo.On("GetCustomerAddress", mock.Anything, st.testUUID.String(), st.testUUID2.String()).Return(
+		&model.CustomerAddress{Address: model.Address{Street: "1 Moi Ave", City: "Nairobi", Country: "Kenya"}}, nil)
+	st.repo.On("GetDefaultShippingAddress", mock.Anything, st.testUUID.String()).Return(
+		&model.CustomerAddress{Address: model.Address{Street: "2 Nkrumah Rd", City: "Mombasa", Country: "Kenya"}}, nil)
+	st.repo.On("GetTaxRate", mock.Anything, mock.Anything).Return(&model.TaxRate{RateBasisPoints: 1600}, nil)
+	st.repo.On("GetShippingRate", mock.Anything, "TELEPORT", model.ShippingZoneDomestic).Return(nil, errRowNotFound)
+
+	_, err := st.handler.CreateOrder(context.Background(), orderRequest)
+
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_SavedAddressNotFound() {
+	orderRequest := &orderspb.CreateOrderRequest{
+		CustomerId:                st.testUUID.String(),
+		ProductId:                 st.testUUID1.String(),
+		ProductQuantity:           1,
+		PickupAddress:             &orderspb.Address{Street: "1 Moi Ave", City: "Nairobi", Country: "Kenya"},
+		DeliveryAddressId:         st.testUUID2.String(),
+		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
+		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
+	}
+
+	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(&model.Product{ProductID: st.testUUID1.String()}, nil)
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(&model.Customer{CustomerID: st.testUUID.String()}, nil)
+	st.repo.On("GetCustomerAddress", mock.Anything, st.testUUID.String(), st.testUUID2.String()).Return(nil, errRowNotFound)
+
+	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"delivery_address_id"}, fieldViolations(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_SavedAndSentAddress() {
+	orderRequest := &orderspb.CreateOrderRequest{
+		CustomerId:                st.testUUID.String(),
+		ProductId:                 st.testUUID1.String(),
+		ProductQuantity:           1,
+		PickupAddress:             &orderspb.Address{Street: "1 Moi Ave", City: "Nairobi", Country: "Kenya"},
+		PickupAddressId:           st.testUUID2.String(),
+		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
+		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
+	}
+
+	st.repo.On("GetProductById", mock.Anything, mock.Anything).Return(&model.Product{ProductID: st.testUUID1.String()}, nil)
+	st.repo.On("GetCustomerById", mock.Anything, mock.Anything).Return(&model.Customer{CustomerID: st.testUUID.String()}, nil)
+
+	response, err := st.handler.CreateOrder(context.Background(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"pickup_address_id"}, fieldViolations(err))
+	st.repo.AssertNotCalled(st.T(), "GetCustomerAddress", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (st *OrderHandlerTestSuite) TestGetOrderById_Success() {
@@ -485,7 +739,8 @@ func (st *OrderHandlerTestSuite) TestGetOrderById_Success() {
 		TrackingNumber:            "1234567890",
 		PaymentMethod:             model.PaymentMethod(orderspb.PaymentMethod_PAYMENT_METHOD_CREDIT_CARD.String()),
 		InvoiceNumber:             "INV12345",
-		ShippingCost:              10.0,
+		ShippingCost:              1000,
+		Currency:                  model.KES,
 		SpecialInstructions:       "Handle with care",
 		ScheduledPickupDatetime:   time.Now(),
 		ScheduledDeliveryDatetime: time.Now().Add(24 * time.Hour),
@@ -525,7 +780,7 @@ func (st *OrderHandlerTestSuite) TestGetOrderById_Success() {
 	st.Require().Equal("Express", response.Order.ShippingMethod)
 	st.Require().Equal("1234567890", response.Order.TrackingNumber)
 	st.Require().Equal("INV12345", response.Order.InvoiceNumber)
-	st.Require().Equal(10.0, response.Order.ShippingCost)
+	st.Require().Equal(int64(1000), response.Order.ShippingCost.Amount)
 	st.Require().Equal("Handle with care", response.Order.SpecialInstructions)
 	// Add more assertions as needed for other fields
 
@@ -537,7 +792,7 @@ func (st *OrderHandlerTestSuite) TestGetOrderById_OrderNotFound() {
 	orderID := st.testUUID.String()
 
 	// Set up expectations for the mock repository to return a "not found" error
-	st.repo.On("GetOrderById", mock.Anything, orderID).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetOrderById", mock.Anything, orderID).Return(nil, errRowNotFound)
 
 	// Create a GetOrderRequest
 	request := &orderspb.GetOrderRequest{
@@ -635,6 +890,15 @@ func (st *OrderHandlerTestSuite) TestDeleteOrder_InvalidOrderID() {
 	st.Require().Equal(errBadRequest, err)
 }
 
+func (st *OrderHandlerTestSuite) TestDeleteOrder_OrderNotFound() {
+	st.repo.On("DeleteOrder", mock.Anything, st.testUUID.String()).Return(nil, errRowNotFound)
+
+	response, err := st.handler.DeleteOrder(context.Background(), &orderspb.DeleteOrderRequest{OrderId: st.testUUID.String()})
+
+	st.Require().Equal(errNotFound, err)
+	st.Require().False(response.Success)
+}
+
 func (st *OrderHandlerTestSuite) TestDeleteOrder_DeleteError() {
 	// Create a mock order ID
 	orderID := st.testUUID.String()
@@ -667,8 +931,9 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_Success() {
 			TrackingNumber:      "9876543210",
 			PaymentMethod:       orderspb.PaymentMethod_PAYMENT_METHOD_MPESA,
 			InvoiceNumber:       "UPDATED-INV56789",
-			ShippingCost:        15.0,
+			ShippingCost:        &moneypb.Money{CurrencyCode: "KES", Amount: 1500},
 			SpecialInstructions: "Fragile",
+			Version:             1,
 			// Add other fields as needed
 		},
 		UpdateMask: &field_mask.FieldMask{
@@ -677,7 +942,10 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_Success() {
 	}
 
 	// Set up expectations for the mock repository to update the order
-	st.repo.On("UpdateOrder", mock.Anything, mock.Anything, mock.Anything).Return(
+	st.mockDeliverySlots()
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(st.scheduledOrder(1), nil)
+	st.mockShipping()
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(1), mock.Anything, (*model.DeliveryWindow)(nil)).Return(
 		&model.Order{
 			OrderID:             st.testUUID.String(),
 			ShippingMethod:      orderRequest.Order.ShippingMethod,
@@ -685,7 +953,8 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_Success() {
 			TrackingNumber:      orderRequest.Order.TrackingNumber,
 			PaymentMethod:       model.PaymentMethod(orderRequest.Order.PaymentMethod.String()),
 			InvoiceNumber:       orderRequest.Order.InvoiceNumber,
-			ShippingCost:        orderRequest.Order.ShippingCost,
+			ShippingCost:        orderRequest.Order.ShippingCost.Amount,
+			Currency:            model.KES,
 			SpecialInstructions: orderRequest.Order.SpecialInstructions,
 			CreatedAt:           time.Now(),
 			UpdatedAt:           time.Now(),
@@ -705,12 +974,70 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_Success() {
 	st.Require().Equal(orderspb.OrderStatus_ORDER_STATUS_SHIPPED, response.Order.OrderStatus)
 	st.Require().Equal(orderRequest.Order.TrackingNumber, response.Order.TrackingNumber)
 	st.Require().Equal(orderRequest.Order.InvoiceNumber, response.Order.InvoiceNumber)
-	st.Require().Equal(15.0, response.Order.ShippingCost)
+	st.Require().Equal(int64(1500), response.Order.ShippingCost.Amount)
 	st.Require().Equal(orderRequest.Order.SpecialInstructions, response.Order.SpecialInstructions)
 
 	st.repo.AssertExpectations(st.T())
 }
 
+// mockShipping charges the shipping of the 2 items of the order being updated at 6.00 and 2.00 an item.
+func (st *OrderHandlerTestSuite) mockShipping() {
+	st.repo.On("GetOrderDetailsByOrderId", mock.Anything, st.testUUID.String(), maxPageSize, 0).Return([]model.OrderDetails{{Quantity: 2}}, nil)
+	st.repo.On("GetShippingRate", mock.Anything, mock.Anything, mock.Anything).Return(
+		&model.ShippingRate{BaseFee: 600, PerItemFee: 200, Currency: model.KES},
+		nil,
+	)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_Repriced() {
+	current := st.scheduledOrder(2)
+	current.PickupAddress = model.Address{City: "Nairobi", Country: "Kenya"}
+	current.DeliveryAddress = model.Address{City: "Nairobi", Country: "Kenya"}
+	current.Currency = model.KES
+	current.Subtotal = 2000
+	current.Tax = 320
+	current.ShippingCost = 400
+	current.GrandTotal = 2720
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(current, nil)
+	st.repo.On("GetOrderDetailsByOrderId", mock.Anything, st.testUUID.String(), maxPageSize, 0).Return([]model.OrderDetails{{Quantity: 2}}, nil)
+	st.repo.On("GetShippingRate", mock.Anything, "STANDARD", model.ShippingZoneInternational).Return(
+		&model.ShippingRate{BaseFee: 1500, PerItemFee: 500, Currency: model.KES},
+		nil,
+	)
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(2), mock.MatchedBy(func(fields map[string]interface{}) bool {
+		// the products keep their price, only the shipping to Uganda is charged again
+		return fields["shipping_cost"] == int64(2500) && fields["grand_total"] == int64(4820)
+	}), (*model.DeliveryWindow)(nil)).Return(&model.Order{OrderID: st.testUUID.String()}, nil)
+
+	_, err := st.handler.UpdateOrder(context.Background(), &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:         st.testUUID.String(),
+			DeliveryAddress: &orderspb.Address{City: "Kampala", Country: "Uganda"},
+			Version:         2,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"delivery_address.country"}},
+	})
+
+	st.Require().NoError(err)
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_UnsupportedShippingMethod() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(st.scheduledOrder(2), nil)
+	st.mockDeliverySlots()
+	st.repo.On("GetOrderDetailsByOrderId", mock.Anything, st.testUUID.String(), maxPageSize, 0).Return([]model.OrderDetails{{Quantity: 2}}, nil)
+	st.repo.On("GetShippingRate", mock.Anything, "TELEPORT", mock.Anything).Return(nil, errRowNotFound)
+
+	response, err := st.handler.UpdateOrder(context.Background(), &orderspb.UpdateOrderRequest{
+		Order:      &orderspb.Order{OrderId: st.testUUID.String(), ShippingMethod: "teleport", Version: 2},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"shipping_method"}},
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (st *OrderHandlerTestSuite) TestUpdateOrder_InvalidRequest() {
 	// Create an invalid request with nil Order
 	orderRequest := &orderspb.UpdateOrderRequest{
@@ -739,8 +1066,9 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_UpdateError() {
 			TrackingNumber:      "9876543210",
 			PaymentMethod:       orderspb.PaymentMethod_PAYMENT_METHOD_MPESA,
 			InvoiceNumber:       "UPDATED-INV56789",
-			ShippingCost:        15.0,
+			ShippingCost:        &moneypb.Money{CurrencyCode: "KES", Amount: 1500},
 			SpecialInstructions: "Fragile",
+			Version:             1,
 			// Add other fields as needed
 		},
 		UpdateMask: &field_mask.FieldMask{
@@ -749,7 +1077,10 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_UpdateError() {
 	}
 
 	// Set up expectations for the mock repository to return an error during update
-	st.repo.On("UpdateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("update error"))
+	st.mockDeliverySlots()
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(st.scheduledOrder(1), nil)
+	st.mockShipping()
+	st.repo.On("UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, (*model.DeliveryWindow)(nil)).Return(nil, errors.New("update error"))
 
 	// Call the UpdateOrder function
 	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
@@ -797,8 +1128,9 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_OrderNotFound() {
 			TrackingNumber:      "9876543210",
 			PaymentMethod:       orderspb.PaymentMethod_PAYMENT_METHOD_MPESA,
 			InvoiceNumber:       "UPDATED-INV56789",
-			ShippingCost:        15.0,
+			ShippingCost:        &moneypb.Money{CurrencyCode: "KES", Amount: 1500},
 			SpecialInstructions: "Fragile",
+			Version:             1,
 			// Add other fields as needed
 		},
 		UpdateMask: &field_mask.FieldMask{
@@ -807,7 +1139,7 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_OrderNotFound() {
 	}
 
 	// Set up expectations for the mock repository to return an error indicating order not found
-	st.repo.On("UpdateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(nil, errRowNotFound)
 
 	// Call the UpdateOrder function
 	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
@@ -820,6 +1152,200 @@ func (st *OrderHandlerTestSuite) TestUpdateOrder_OrderNotFound() {
 	st.repo.AssertExpectations(st.T())
 }
 
+func (st *OrderHandlerTestSuite) TestUpdateOrder_VersionMismatch() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:        st.testUUID.String(),
+			ShippingMethod: "express",
+			Version:        3,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"shipping_method"}},
+	}
+	st.mockDeliverySlots()
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(st.scheduledOrder(3), nil)
+	st.mockShipping()
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(3), mock.Anything, (*model.DeliveryWindow)(nil)).Return(nil, repository.ErrVersionMismatch)
+
+	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(errVersionMismatch, err)
+	st.Require().Equal(codes.Aborted, status.Code(err))
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_StatusOfStaleVersion() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:     st.testUUID.String(),
+			OrderStatus: orderspb.OrderStatus_ORDER_STATUS_CANCELLED,
+			Version:     1,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"order_status"}},
+	}
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusProcessing, Version: 2}, nil)
+
+	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(errVersionMismatch, err)
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_StatusRetried() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:     st.testUUID.String(),
+			OrderStatus: orderspb.OrderStatus_ORDER_STATUS_CANCELLED,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"order_status"}},
+	}
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusPending, Version: 1}, nil).Once()
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusProcessing, Version: 2}, nil).Once()
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(1), mock.Anything, (*model.DeliveryWindow)(nil)).Return(nil, repository.ErrVersionMismatch).Once()
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(2), mock.Anything, (*model.DeliveryWindow)(nil)).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusCanceled, Version: 3}, nil).Once()
+
+	response, err := st.handler.UpdateOrder(serviceContext(), orderRequest)
+
+	st.Require().NoError(err)
+	st.Require().Equal(orderspb.OrderStatus_ORDER_STATUS_CANCELLED, response.Order.OrderStatus)
+	st.Require().Equal(int64(3), response.Order.Version)
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_StatusRetriesExhausted() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:     st.testUUID.String(),
+			OrderStatus: orderspb.OrderStatus_ORDER_STATUS_CANCELLED,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"order_status"}},
+	}
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusPending, Version: 1}, nil)
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(1), mock.Anything, (*model.DeliveryWindow)(nil)).Return(nil, repository.ErrVersionMismatch)
+
+	response, err := st.handler.UpdateOrder(serviceContext(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(errVersionMismatch, err)
+	st.repo.AssertNumberOfCalls(st.T(), "UpdateOrder", maxUpdateRetry)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_VersionRequired() {
+	staff := auth.ContextWithPrincipal(context.Background(), &auth.Principal{Subject: "staff", Roles: []auth.Role{auth.RoleStaff}})
+	for ctx, paths := range map[context.Context][]string{
+		staff:            {"order_status"},
+		serviceContext(): {"order_status", "shipping_method"},
+	} {
+		orderRequest := &orderspb.UpdateOrderRequest{
+			Order: &orderspb.Order{
+				OrderId:        st.testUUID.String(),
+				OrderStatus:    orderspb.OrderStatus_ORDER_STATUS_CANCELLED,
+				ShippingMethod: "express",
+			},
+			UpdateMask: &field_mask.FieldMask{Paths: paths},
+		}
+
+		response, err := st.handler.UpdateOrder(ctx, orderRequest)
+
+		st.Require().Nil(response)
+		st.Require().Equal(codes.InvalidArgument, status.Code(err))
+		st.Require().Equal([]string{"order.version"}, fieldViolations(err))
+	}
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_FinalStatus() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:     st.testUUID.String(),
+			OrderStatus: orderspb.OrderStatus_ORDER_STATUS_CANCELLED,
+			Version:     4,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"order_status"}},
+	}
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusDelivered, Version: 4}, nil)
+
+	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_StatusMovesBack() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:     st.testUUID.String(),
+			OrderStatus: orderspb.OrderStatus_ORDER_STATUS_PROCESSING,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"order_status"}},
+	}
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusShipped, Version: 5}, nil)
+
+	// a late payment callback does not move a shipped order back to processing
+	response, err := st.handler.UpdateOrder(serviceContext(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_MissingUpdateMask() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{OrderId: st.testUUID.String()},
+	}
+
+	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(errResourceUpdateMaskRequired, err)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_UnknownUpdateMaskPath() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order:      &orderspb.Order{OrderId: st.testUUID.String()},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"shipping_method", "pickup_address.town"}},
+	}
+
+	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"update_mask"}, fieldViolations(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_AddressField() {
+	orderRequest := &orderspb.UpdateOrderRequest{
+		Order: &orderspb.Order{
+			OrderId:       st.testUUID.String(),
+			PickupAddress: &orderspb.Address{City: "Nakuru"},
+			Version:       2,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"pickupAddress.city"}},
+	}
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(st.scheduledOrder(2), nil)
+	st.mockShipping()
+	st.repo.On("UpdateOrder", mock.Anything, st.testUUID.String(), int64(2), mock.MatchedBy(func(fields map[string]interface{}) bool {
+		merge, ok := fields["pickup_address"].(model.JSONMerge)
+		return ok && len(merge) == 1 && merge["city"] == "Nakuru"
+	}), (*model.DeliveryWindow)(nil)).Return(&model.Order{OrderID: st.testUUID.String(), PickupAddress: model.Address{Street: "Kenyatta Ave", City: "Nakuru"}}, nil)
+
+	response, err := st.handler.UpdateOrder(context.Background(), orderRequest)
+
+	st.Require().NoError(err)
+	st.Require().Equal("Kenyatta Ave", response.Order.PickupAddress.Street)
+	st.repo.AssertExpectations(st.T())
+}
+
 func (st *OrderHandlerTestSuite) TestUpdateOrder_EmptyUpdateFields() {
 	// Create an order request with an empty update mask
 	orderRequest := &orderspb.UpdateOrderRequest{
@@ -915,7 +1441,7 @@ func (st *OrderHandlerTestSuite) TestListOrdersByCustomerId_NotFound() {
 		PageToken:  0,
 	}
 
-	st.repo.On("GetOrdersByCustomerId", mock.Anything, request.CustomerId, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetOrdersByCustomerId", mock.Anything, request.CustomerId, mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Call the ListOrdersByCustomerId function
 	response, err := st.handler.ListOrdersByCustomerId(context.Background(), request)
@@ -1009,7 +1535,7 @@ func (st *OrderHandlerTestSuite) TestListOrderDetailsByOrderId_OrderDetailsNotFo
 	orderID := st.testUUID.String()
 
 	// Set up expectations for the mock repository to return a "order details not found" error
-	st.repo.On("GetOrderDetailsByOrderId", mock.Anything, orderID, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetOrderDetailsByOrderId", mock.Anything, orderID, mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Create a ListOrderDetailsByOrderIdRequest
 	request := &orderspb.ListOrderDetailsByOrderIdRequest{
@@ -1127,7 +1653,7 @@ func (st *OrderHandlerTestSuite) TestListOrdersByProductId_OrderDetailsNotFound(
 	productID := st.testUUID.String()
 
 	// Set up expectations for the mock repository to return "order details not found" error
-	st.repo.On("GetOrderDetailsByProductId", mock.Anything, productID, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetOrderDetailsByProductId", mock.Anything, productID, mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Create a ListOrdersByProductIdRequest
 	request := &orderspb.ListOrdersByProductIdRequest{
@@ -1166,7 +1692,7 @@ func (st *OrderHandlerTestSuite) TestListOrdersByProductId_OrderNotFound() {
 
 	st.repo.On("GetOrderDetailsByProductId", mock.Anything, productID, mock.Anything, mock.Anything).Return(mockOrderDetails, nil)
 
-	st.repo.On("GetOrderById", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetOrderById", mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Create a ListOrdersByProductIdRequest
 	request := &orderspb.ListOrdersByProductIdRequest{
@@ -1266,7 +1792,7 @@ func (st *OrderHandlerTestSuite) TestGetOrderDetailsById_OrderDetailsNotFound()
 	orderDetailsID := st.testUUID.String()
 
 	// Set up expectations for the mock repository to return "order details not found" error
-	st.repo.On("GetOrderDetailsById", mock.Anything, orderDetailsID).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetOrderDetailsById", mock.Anything, orderDetailsID).Return(nil, errRowNotFound)
 
 	// Create a GetOrderDetailByIdRequest
 	request := &orderspb.GetOrderDetailByIdRequest{
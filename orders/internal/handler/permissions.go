@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+)
+
+var (
+	allowEveryone   = auth.Allow(auth.RoleCustomer, auth.RoleStaff, auth.RoleAdmin, auth.RoleService)
+	allowCustomers  = auth.Allow(auth.RoleCustomer, auth.RoleStaff, auth.RoleAdmin)
+	allowStaff      = auth.Allow(auth.RoleStaff, auth.RoleAdmin)
+	allowAdmins     = auth.Allow(auth.RoleAdmin)
+	allowOperations = auth.Allow(auth.RoleStaff, auth.RoleAdmin, auth.RoleService)
+)
+
+// Permissions declares who may call each RPC served by the handler. Customers are also limited
+// to their own orders and customer record by the handlers.
+func Permissions() auth.Policy {
+	return auth.Policy{
+		"/orders.OrderService/CreateOrder":                allowCustomers,
+		"/orders.OrderService/GetOrderById":               allowEveryone,
+		"/orders.OrderService/UpdateOrder":                allowOperations,
+		"/orders.OrderService/DeleteOrder":                allowAdmins,
+		"/orders.OrderService/ListOrdersByCustomerId":     allowCustomers,
+		"/orders.OrderService/ListOrdersByProductId":      allowStaff,
+		"/orders.OrderService/GetOrderDetailsById":        allowCustomers,
+		"/orders.OrderService/ListOrderDetailsByOrderId":  allowCustomers,
+		"/orders.OrderService/GetInvoice":                 allowCustomers,
+		"/orders.OrderService/ListAvailableDeliverySlots": allowEveryone,
+
+		"/orders.PromotionService/CreatePromotion":    allowAdmins,
+		"/orders.PromotionService/GetPromotionByCode": allowCustomers,
+
+		"/orders.ShipmentService/CreateShipment":      allowOperations,
+		"/orders.ShipmentService/RecordShipmentEvent": allowOperations,
+		"/orders.ShipmentService/TrackShipment":       auth.Public(),
+
+		"/customers.CustomerService/CreateCustomer":  allowStaff,
+		"/customers.CustomerService/GetCustomerById": allowCustomers,
+		"/customers.CustomerService/ListCustomers":   allowStaff,
+		"/customers.CustomerService/UpdateCustomer":  allowCustomers,
+		"/customers.CustomerService/DeleteCustomer":  allowAdmins,
+
+		"/customers.CustomerService/CreateCustomerAddress": allowCustomers,
+		"/customers.CustomerService/GetCustomerAddress":    allowCustomers,
+		"/customers.CustomerService/ListCustomerAddresses": allowCustomers,
+		"/customers.CustomerService/UpdateCustomerAddress": allowCustomers,
+		"/customers.CustomerService/DeleteCustomerAddress": allowCustomers,
+
+		"/products.ProductService/CreateProduct":  allowStaff,
+		"/products.ProductService/GetProductById": allowEveryone,
+		"/products.ProductService/ListProducts":   allowEveryone,
+		"/products.ProductService/UpdateProduct":  allowStaff,
+		"/products.ProductService/DeleteProduct":  allowAdmins,
+
+		// probes and load balancers check health without a token
+		"/grpc.health.v1.Health/Check": auth.Public(),
+		"/grpc.health.v1.Health/Watch": auth.Public(),
+
+		// the schema is only described to operators, when reflection is enabled
+		"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      allowStaff,
+		"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": allowStaff,
+	}
+}
+
+// authorizeOrder checks that a customer is reading one of their own orders. Orders of other
+// customers are reported as not found so customers cannot find out which order ids exist.
+func (h *Handler) authorizeOrder(ctx context.Context, orderId string) error {
+	logger := logging.FromContext(ctx)
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || !principal.CustomerOnly() {
+		return nil
+	}
+
+	order, err := h.repo.GetOrderById(ctx, orderId)
+	if err != nil {
+		logger.Error("failed to get order from db", "error", err)
+		return dbError(err)
+	}
+	if order.CustomerID != principal.Subject {
+		logger.Error("customer is not allowed to read order", "order_id", orderId, "customer_id", principal.Subject)
+		return errNotFound
+	}
+	return nil
+}
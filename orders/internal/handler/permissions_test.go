@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wathuta/technical_test/orders/internal/auth"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	customerspb "github.com/wathuta/technical_test/protos_gen/customers"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	productspb "github.com/wathuta/technical_test/protos_gen/products"
+	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionalphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+func (st *OrderHandlerTestSuite) TestPermissions_EveryMethodDeclared() {
+	policy := Permissions()
+	for _, service := range []grpc.ServiceDesc{
+		orderspb.OrderService_ServiceDesc,
+		orderspb.PromotionService_ServiceDesc,
+		orderspb.ShipmentService_ServiceDesc,
+		customerspb.CustomerService_ServiceDesc,
+		productspb.ProductService_ServiceDesc,
+		reflectionpb.ServerReflection_ServiceDesc,
+		reflectionalphapb.ServerReflection_ServiceDesc,
+	} {
+		for _, method := range service.Methods {
+			fullMethod := "/" + service.ServiceName + "/" + method.MethodName
+			_, ok := policy[fullMethod]
+			st.Require().True(ok, "no permission declared for %s", fullMethod)
+		}
+		for _, stream := range service.Streams {
+			fullMethod := "/" + service.ServiceName + "/" + stream.StreamName
+			_, ok := policy[fullMethod]
+			st.Require().True(ok, "no permission declared for %s", fullMethod)
+		}
+	}
+}
+
+func (st *OrderHandlerTestSuite) customerContext() context.Context {
+	return auth.ContextWithPrincipal(context.Background(), &auth.Principal{
+		Subject: st.testUUID1.String(),
+		Roles:   []auth.Role{auth.RoleCustomer},
+	})
+}
+
+func (st *OrderHandlerTestSuite) TestGetOrderById_OtherCustomer() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), CustomerID: st.testUUID2.String()},
+		nil,
+	)
+
+	response, err := st.handler.GetOrderById(st.customerContext(), &orderspb.GetOrderRequest{OrderId: st.testUUID.String()})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *OrderHandlerTestSuite) TestUpdateOrder_EmptyMaskOtherCustomer() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), CustomerID: st.testUUID2.String()},
+		nil,
+	)
+
+	response, err := st.handler.UpdateOrder(st.customerContext(), &orderspb.UpdateOrderRequest{
+		Order:      &orderspb.Order{OrderId: st.testUUID.String()},
+		UpdateMask: &field_mask.FieldMask{},
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *OrderHandlerTestSuite) TestGetOrderById_OwnOrder() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), CustomerID: st.testUUID1.String()},
+		nil,
+	)
+
+	response, err := st.handler.GetOrderById(st.customerContext(), &orderspb.GetOrderRequest{OrderId: st.testUUID.String()})
+
+	st.Require().NoError(err)
+	st.Require().Equal(st.testUUID1.String(), response.Order.CustomerId)
+}
+
+func (st *OrderHandlerTestSuite) TestListOrdersByCustomerId_OtherCustomer() {
+	response, err := st.handler.ListOrdersByCustomerId(st.customerContext(), &orderspb.ListOrdersByCustomerIdRequest{CustomerId: st.testUUID2.String()})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.PermissionDenied, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "GetOrdersByCustomerId", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_ForOtherCustomer() {
+	response, err := st.handler.CreateOrder(st.customerContext(), &orderspb.CreateOrderRequest{
+		CustomerId:      st.testUUID2.String(),
+		ProductId:       st.testUUID.String(),
+		ProductQuantity: 1,
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (st *OrderHandlerTestSuite) TestListOrderDetailsByOrderId_OtherCustomer() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), CustomerID: st.testUUID2.String()},
+		nil,
+	)
+
+	response, err := st.handler.ListOrderDetailsByOrderId(st.customerContext(), &orderspb.ListOrderDetailsByOrderIdRequest{OrderId: st.testUUID.String()})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+	st.repo.AssertNotCalled(st.T(), "GetOrderDetailsByOrderId", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestGetInvoice_OtherCustomer() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(nil, errRowNotFound)
+
+	response, err := st.handler.GetInvoice(st.customerContext(), &orderspb.GetInvoiceRequest{OrderId: st.testUUID.String()})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+	st.repo.AssertNotCalled(st.T(), "GetInvoiceByOrderId", mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestGetCustomerById_OtherCustomer() {
+	response, err := st.handler.GetCustomerById(st.customerContext(), &customerspb.GetCustomerByIdRequest{CustomerId: st.testUUID2.String()})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
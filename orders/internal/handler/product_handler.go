@@ -2,26 +2,24 @@ package handler
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/wathuta/technical_test/orders/internal/common"
 	"github.com/wathuta/technical_test/orders/internal/common/fieldmask"
+	"github.com/wathuta/technical_test/orders/internal/logging"
 	"github.com/wathuta/technical_test/orders/internal/model"
 	productspb "github.com/wathuta/technical_test/protos_gen/products"
-	"golang.org/x/exp/slog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 func (h *Handler) CreateProduct(ctx context.Context, req *productspb.CreateProductRequest) (*productspb.CreateProductResponse, error) {
+	logger := logging.FromContext(ctx)
 	var err error
 	if req == nil || req.Product == nil {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("creating product", "product_name", req.Product.Name)
+	logger.Debug("creating product", "product_name", req.Product.Name)
 
 	product := model.ProductFromProto(req.Product)
 
@@ -32,67 +30,94 @@ func (h *Handler) CreateProduct(ctx context.Context, req *productspb.CreateProdu
 	// validate Model
 	validator := common.NewValidator()
 	if err := validator.Struct(product); err != nil {
-		slog.Error("failed to validate payment", "error", err)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		logger.Error("failed to validate payment", "error", err)
+		return nil, validationError(err)
 	}
 
 	// persist in db
 	product, err = h.repo.CreateProduct(ctx, product)
 	if err != nil {
-		slog.Error("failed to create product in db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to create product in db", "error", err)
+		return nil, dbError(err)
 	}
-	slog.Debug("create product successful")
+	logger.Debug("create product successful")
 	return &productspb.CreateProductResponse{
 		Product: product.Proto(),
 	}, nil
 }
 
 func (h *Handler) GetProductById(ctx context.Context, req *productspb.GetProductByIdRequest) (*productspb.GetProductByIdResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.ProductId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get product by id", "product_id", req.ProductId)
+	logger.Debug("get product by id", "product_id", req.ProductId)
 
 	productUUID, err := uuid.Parse(req.ProductId)
 	if err != nil {
-		slog.Error("invalid product uuid value", "error", err)
+		logger.Error("invalid product uuid value", "error", err)
 		return nil, errBadRequest
 	}
 
-	product, err := h.repo.GetProductById(ctx, req.ProductId)
+	product, err := h.repo.GetProductById(ctx, productUUID.String())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Error("product with the given product_id not found", "product_id", productUUID, "error", err)
-			return nil, errNotFound
-		}
-		slog.Error("failed to get product from db", "error", err)
-		return nil, errInternal
+		logger.Error("failed to get product from db", "product_id", productUUID, "error", err)
+		return nil, dbError(err)
 	}
 
-	slog.Debug("get product successful")
+	logger.Debug("get product successful")
 	return &productspb.GetProductByIdResponse{Product: product.Proto()}, nil
 }
+
+// ListProducts returns a page of the catalogue in the order the products were created.
+func (h *Handler) ListProducts(ctx context.Context, req *productspb.ListProductsRequest) (*productspb.ListProductsResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	pagesize := common.SetPageSize(int(req.PageSize), defaultPageSize, maxPageSize)
+	token := common.SetPageToken(int(req.PageToken))
+	logger.Debug("list products", "page_size", pagesize, "page_token", token)
+
+	products, err := h.repo.ListProducts(ctx, pagesize, token)
+	if err != nil {
+		logger.Error("failed to list products from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	returnProducts := make([]*productspb.Product, 0, len(products))
+	for i := range products {
+		returnProducts = append(returnProducts, products[i].Proto())
+	}
+	logger.Debug("list products successful")
+	return &productspb.ListProductsResponse{
+		Products:      returnProducts,
+		NextPageToken: int32(common.NextPageToken(token, pagesize, len(products))),
+	}, nil
+}
+
 func (h *Handler) UpdateProduct(ctx context.Context, req *productspb.UpdateProductRequest) (*productspb.UpdateProductResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || req.Product == nil {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
 
-	slog.Debug("update product", "product_id", req.Product.ProductId)
+	logger.Debug("update product", "product_id", req.Product.ProductId)
 
 	// Allows update of specific fields
-	mask, err := fieldmask.New(req.UpdateMask)
+	mask, err := fieldmask.New(req.UpdateMask, req.Product)
 	if err != nil {
-		slog.Error("invalid request inputs", "error", err)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		logger.Error("invalid update mask", "error", err)
+		return nil, maskError(err)
 	}
 	mask.RemoveOutputOnly()
 
 	productUUID, err := uuid.Parse(req.Product.ProductId)
 	if err != nil {
-		slog.Error("invalid product uuid value", "productUUID", productUUID, "error", err, req)
+		logger.Error("invalid product uuid value", "product_id", req.Product.ProductId, "error", err)
 		return nil, errBadRequest
 	}
 
@@ -104,58 +129,53 @@ func (h *Handler) UpdateProduct(ctx context.Context, req *productspb.UpdateProdu
 
 	if len(mask.Fields) == 0 || len(updateProductDetails) == 0 {
 
-		slog.Debug("no fields to update")
+		logger.Debug("no fields to update")
 		product, err = h.repo.GetProductById(ctx, productUUID.String())
 		if err != nil {
-			if err == sql.ErrNoRows {
-				slog.Error("product with the given id not found", "product_id", productUUID, "error", err)
-				return nil, errNotFound
-			}
-			slog.Error("failed to get product from db", "error", err)
-			return nil, errInternal
+			logger.Error("failed to get product from db", "error", err)
+			return nil, dbError(err)
 		}
-	} else {
+		logger.Debug("update product successful")
+		return &productspb.UpdateProductResponse{Product: product.Proto()}, nil
+	}
 
-		//persist in dbcall
-		product, err = h.repo.UpdateProductFields(ctx, productUUID.String(), updateProductDetails)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				slog.Error("product with the given id not found", "product_id", productUUID, "error", err)
-				return nil, errNotFound
-			}
-			slog.Error("failed to update product from db", "error", err)
-			return nil, errInternal
-		}
+	if err := requireVersion("product.version", req.Product.Version); err != nil {
+		logger.Error("product version required", "product_id", productUUID)
+		return nil, err
 	}
 
-	slog.Debug("update product successful")
+	//persist in dbcall
+	product, err = h.repo.UpdateProductFields(ctx, productUUID.String(), req.Product.Version, updateProductDetails)
+	if err != nil {
+		logger.Error("failed to update product from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	logger.Debug("update product successful")
 	return &productspb.UpdateProductResponse{Product: product.Proto()}, nil
 }
 
 func (h *Handler) DeleteProduct(ctx context.Context, req *productspb.DeleteProductRequest) (*productspb.DeleteProductResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.ProductId) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return &productspb.DeleteProductResponse{Success: false}, errResourceRequired
 	}
-	slog.Debug("delete product", "product_id", req.ProductId)
+	logger.Debug("delete product", "product_id", req.ProductId)
 
 	// check if supplied id is a valid uuid
 	productUUID, err := uuid.Parse(req.ProductId)
 	if err != nil {
-		slog.Error("invalid product uuid value", "error", err)
+		logger.Error("invalid product uuid value", "error", err)
 		return &productspb.DeleteProductResponse{Success: false}, errBadRequest
 	}
 
-	resource, err := h.repo.DeleteProduct(ctx, productUUID.String())
+	_, err = h.repo.DeleteProduct(ctx, productUUID.String())
 	if err != nil {
-		slog.Error("failed to delete product from db", "error", err)
-		return &productspb.DeleteProductResponse{Success: false}, errInternal
-	}
-	if resource == nil {
-		slog.Error("product with the given id not found", "product_id", productUUID, "error", err)
-		return &productspb.DeleteProductResponse{Success: false}, errNotFound
+		logger.Error("failed to delete product from db", "product_id", productUUID, "error", err)
+		return &productspb.DeleteProductResponse{Success: false}, dbError(err)
 	}
-	slog.Debug("delete product successful")
+	logger.Debug("delete product successful")
 
 	return &productspb.DeleteProductResponse{Success: true}, nil
 }
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"os"
 	"testing"
@@ -14,9 +13,13 @@ import (
 	"github.com/wathuta/technical_test/orders/internal/config"
 	"github.com/wathuta/technical_test/orders/internal/mocks"
 	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
 	productspb "github.com/wathuta/technical_test/protos_gen/products"
 	"golang.org/x/exp/slog"
 	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type ProductHandlerTestSuite struct {
@@ -63,7 +66,7 @@ func (st *ProductHandlerTestSuite) TestCreateProduct_Success() {
 			Attributes: &productspb.ProductAttributes{
 				Brand: "Sample Brand",
 				Model: "Sample Model",
-				Price: 100.00,
+				Price: &moneypb.Money{CurrencyCode: "KES", Amount: 10000},
 			},
 			StockQuantity: 10,
 			IsAvailable:   true,
@@ -77,9 +80,10 @@ func (st *ProductHandlerTestSuite) TestCreateProduct_Success() {
 		Sku:       productRequest.Product.Sku,
 		Category:  model.ProductCategory(productRequest.Product.Category.String()),
 		ProductAttributes: model.ProductAttributes{
-			Brand: productRequest.Product.Attributes.Brand,
-			Model: productRequest.Product.Attributes.Model,
-			Price: productRequest.Product.Attributes.Price,
+			Brand:    productRequest.Product.Attributes.Brand,
+			Model:    productRequest.Product.Attributes.Model,
+			Price:    productRequest.Product.Attributes.Price.Amount,
+			Currency: model.Currency(productRequest.Product.Attributes.Price.CurrencyCode),
 		},
 		StockQuantity: productRequest.Product.StockQuantity,
 		IsAvailable:   productRequest.Product.IsAvailable,
@@ -100,7 +104,8 @@ func (st *ProductHandlerTestSuite) TestCreateProduct_Success() {
 	st.Require().Equal(productRequest.Product.Category, response.Product.Category)
 	st.Require().Equal(productRequest.Product.Attributes.Brand, response.Product.Attributes.Brand)
 	st.Require().Equal(productRequest.Product.Attributes.Model, response.Product.Attributes.Model)
-	st.Require().Equal(productRequest.Product.Attributes.Price, response.Product.Attributes.Price)
+	st.Require().Equal(productRequest.Product.Attributes.Price.Amount, response.Product.Attributes.Price.Amount)
+	st.Require().Equal(productRequest.Product.Attributes.Price.CurrencyCode, response.Product.Attributes.Price.CurrencyCode)
 	st.Require().Equal(productRequest.Product.StockQuantity, response.Product.StockQuantity)
 	st.Require().Equal(productRequest.Product.IsAvailable, response.Product.IsAvailable)
 
@@ -134,7 +139,7 @@ func (st *ProductHandlerTestSuite) TestCreateProduct_ValidationError() {
 			Attributes: &productspb.ProductAttributes{
 				Brand: "Sample Brand",
 				Model: "Sample Model",
-				Price: -100.00, // Invalid price (negative value)
+				Price: &moneypb.Money{CurrencyCode: "KES", Amount: -10000}, // Invalid price (negative value)
 			},
 			StockQuantity: 10,
 			IsAvailable:   true,
@@ -147,6 +152,8 @@ func (st *ProductHandlerTestSuite) TestCreateProduct_ValidationError() {
 	// Assertions
 	st.Require().Error(err)
 	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"attributes.price"}, fieldViolations(err))
 
 	// Assert expectations for the mock repository (no calls expected)
 	st.repo.AssertExpectations(st.T())
@@ -162,7 +169,7 @@ func (st *ProductHandlerTestSuite) TestCreateProduct_CreateProductError() {
 			Attributes: &productspb.ProductAttributes{
 				Brand: "Sample Brand",
 				Model: "Sample Model",
-				Price: 100.00,
+				Price: &moneypb.Money{CurrencyCode: "KES", Amount: 10000},
 			},
 			StockQuantity: 10,
 			IsAvailable:   true,
@@ -228,7 +235,7 @@ func (st *ProductHandlerTestSuite) TestGetProductById_ProductNotFound() {
 	productID := st.testUUID.String()
 
 	// Set up expectations for the mock repository to return a "not found" error
-	st.repo.On("GetProductById", mock.Anything, productID).Return(nil, sql.ErrNoRows)
+	st.repo.On("GetProductById", mock.Anything, productID).Return(nil, errRowNotFound)
 
 	// Create a GetProductById request
 	request := &productspb.GetProductByIdRequest{
@@ -307,7 +314,7 @@ func (st *ProductHandlerTestSuite) TestDeleteProduct_Success() {
 
 func (st *ProductHandlerTestSuite) TestDeleteProduct_ProductNotFound() {
 	// Set up expectations for the mock repository to delete a product that is not found
-	st.repo.On("DeleteProduct", mock.Anything, mock.Anything).Return(nil, nil)
+	st.repo.On("DeleteProduct", mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Call the DeleteProduct function
 	resp, err := st.handler.DeleteProduct(context.Background(), &productspb.DeleteProductRequest{
@@ -315,7 +322,7 @@ func (st *ProductHandlerTestSuite) TestDeleteProduct_ProductNotFound() {
 	})
 
 	// Assertions
-	st.Require().NotNil(err)
+	st.Require().Equal(errNotFound, err)
 	st.Require().NotNil(resp)
 	st.Require().False(resp.Success)
 
@@ -370,6 +377,7 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_Success() {
 			Category:      productspb.ProductCategory_CLOTHING,
 			StockQuantity: 50,
 			IsAvailable:   true,
+			Version:       1,
 		},
 		UpdateMask: &field_mask.FieldMask{
 			Paths: []string{"name", "sku", "category", "stock_quantity", "is_available"},
@@ -377,7 +385,7 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_Success() {
 	}
 
 	// Set up expectations for the mock repository to update the product
-	st.repo.On("UpdateProductFields", mock.Anything, mock.Anything, mock.Anything).Return(
+	st.repo.On("UpdateProductFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
 		&model.Product{
 			ProductID:     st.testUUID.String(),
 			Name:          productRequest.Product.Name,
@@ -408,6 +416,26 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_Success() {
 	st.repo.AssertExpectations(st.T())
 }
 
+func (st *ProductHandlerTestSuite) TestUpdateProduct_AttributePrice() {
+	productRequest := &productspb.UpdateProductRequest{
+		Product: &productspb.Product{
+			ProductId:  st.testUUID.String(),
+			Attributes: &productspb.ProductAttributes{Brand: "ignored", Price: &moneypb.Money{CurrencyCode: "KES", Amount: 2500}},
+			Version:    2,
+		},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"attributes.price"}},
+	}
+	st.repo.On("UpdateProductFields", mock.Anything, st.testUUID.String(), int64(2), mock.MatchedBy(func(fields map[string]interface{}) bool {
+		_, brand := fields["brand"]
+		return !brand && fields["price"] == int64(2500) && fields["currency"] == model.KES
+	})).Return(&model.Product{ProductID: st.testUUID.String()}, nil)
+
+	_, err := st.handler.UpdateProduct(context.Background(), productRequest)
+
+	st.Require().NoError(err)
+	st.repo.AssertExpectations(st.T())
+}
+
 func (st *ProductHandlerTestSuite) TestUpdateProduct_ProductNotFound() {
 	// Create a mock product request for updating
 	productRequest := &productspb.UpdateProductRequest{
@@ -418,6 +446,7 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_ProductNotFound() {
 			Category:      productspb.ProductCategory_CLOTHING,
 			StockQuantity: 50,
 			IsAvailable:   true,
+			Version:       1,
 		},
 		UpdateMask: &field_mask.FieldMask{
 			Paths: []string{"name", "sku", "category", "stock_quantity", "is_available"},
@@ -425,7 +454,7 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_ProductNotFound() {
 	}
 
 	// Set up expectations for the mock repository to return a product not found error
-	st.repo.On("UpdateProductFields", mock.Anything, mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+	st.repo.On("UpdateProductFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errRowNotFound)
 
 	// Call the UpdateProduct function
 	response, err := st.handler.UpdateProduct(context.Background(), productRequest)
@@ -448,6 +477,7 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_InvalidUUIDError() {
 			Category:      productspb.ProductCategory_CLOTHING,
 			StockQuantity: 50,
 			IsAvailable:   true,
+			Version:       1,
 		},
 		UpdateMask: &field_mask.FieldMask{
 			Paths: []string{"name", "sku", "category", "stock_quantity", "is_available"},
@@ -472,6 +502,7 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_DBError() {
 			Category:      productspb.ProductCategory_CLOTHING,
 			StockQuantity: 50,
 			IsAvailable:   true,
+			Version:       1,
 		},
 		UpdateMask: &field_mask.FieldMask{
 			Paths: []string{"name", "sku", "category", "stock_quantity", "is_available"},
@@ -479,7 +510,7 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_DBError() {
 	}
 
 	// Set up expectations for the mock repository to return an error
-	st.repo.On("UpdateProductFields", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("some error"))
+	st.repo.On("UpdateProductFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("some error"))
 
 	// Call the UpdateProduct function
 	response, err := st.handler.UpdateProduct(context.Background(), productRequest)
@@ -492,6 +523,20 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_DBError() {
 	st.repo.AssertExpectations(st.T())
 }
 
+func (st *ProductHandlerTestSuite) TestUpdateProduct_VersionRequired() {
+	productRequest := &productspb.UpdateProductRequest{
+		Product:    &productspb.Product{ProductId: st.testUUID.String(), StockQuantity: 50},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"stock_quantity"}},
+	}
+
+	response, err := st.handler.UpdateProduct(context.Background(), productRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"product.version"}, fieldViolations(err))
+	st.repo.AssertNotCalled(st.T(), "UpdateProductFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (st *ProductHandlerTestSuite) TestUpdateProduct_NilRequest() {
 	// Call the UpdateProduct function with a nil request
 	response, err := st.handler.UpdateProduct(context.Background(), nil)
@@ -500,3 +545,66 @@ func (st *ProductHandlerTestSuite) TestUpdateProduct_NilRequest() {
 	st.Require().NotNil(err)
 	st.Require().Nil(response)
 }
+
+func (st *ProductHandlerTestSuite) TestListProducts_Success() {
+	st.repo.On("ListProducts", mock.Anything, 2, 0).Return([]model.Product{
+		{ProductID: st.testUUID.String(), Name: "Sample Product", Category: model.ProductCategory("ELECTRONICS")},
+		{ProductID: st.testUUID1.String(), Name: "Another Product", Category: model.ProductCategory("BOOKS")},
+	}, nil)
+
+	resp, err := st.handler.ListProducts(context.Background(), &productspb.ListProductsRequest{PageSize: 2})
+
+	st.Require().NoError(err)
+	st.Require().Len(resp.Products, 2)
+	st.Require().Equal(productspb.ProductCategory_BOOKS, resp.Products[1].Category)
+	st.Require().Equal(int32(2), resp.NextPageToken)
+}
+
+func (st *ProductHandlerTestSuite) TestListProducts_CappedPageSize() {
+	st.repo.On("ListProducts", mock.Anything, maxPageSize, 10).Return([]model.Product{}, nil)
+
+	resp, err := st.handler.ListProducts(context.Background(), &productspb.ListProductsRequest{PageSize: maxPageSize + 1, PageToken: 10})
+
+	st.Require().NoError(err)
+	st.Require().Empty(resp.Products)
+	st.Require().Zero(resp.NextPageToken)
+}
+
+func (st *ProductHandlerTestSuite) TestListProducts_DBError() {
+	st.repo.On("ListProducts", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+	resp, err := st.handler.ListProducts(context.Background(), &productspb.ListProductsRequest{})
+
+	st.Require().Nil(resp)
+	st.Require().Equal(errInternal, err)
+}
+
+func (st *ProductHandlerTestSuite) TestListProducts_NilRequest() {
+	resp, err := st.handler.ListProducts(context.Background(), nil)
+
+	st.Require().Nil(resp)
+	st.Require().Equal(errResourceRequired, err)
+}
+
+func (st *ProductHandlerTestSuite) TestCreateProduct_DuplicateSku() {
+	productRequest := &productspb.CreateProductRequest{
+		Product: &productspb.Product{
+			Name:     "Sample Product",
+			Sku:      "SKU123",
+			Category: productspb.ProductCategory_BOOKS,
+			Attributes: &productspb.ProductAttributes{
+				Brand: "Sample Brand",
+				Model: "Sample Model",
+				Price: &moneypb.Money{CurrencyCode: "KES", Amount: 10000},
+			},
+			StockQuantity: 10,
+			IsAvailable:   true,
+		},
+	}
+	st.repo.On("CreateProduct", mock.Anything, mock.Anything).Return(nil, &repository.Error{Kind: repository.ErrAlreadyExists, Constraint: "products_sku_key"})
+
+	response, err := st.handler.CreateProduct(context.Background(), productRequest)
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.AlreadyExists, status.Code(err))
+}
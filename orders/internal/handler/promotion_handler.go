@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wathuta/technical_test/orders/internal/common"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Create a new promotion
+func (h *Handler) CreatePromotion(ctx context.Context, req *orderspb.CreatePromotionRequest) (*orderspb.CreatePromotionResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || req.Promotion == nil {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("creating promotion", "code", req.Promotion.Code)
+
+	promotion := model.PromotionFromProto(req.Promotion)
+	promotion.PromotionID = uuid.NewString()
+	promotion.RedemptionCount = 0
+	promotion.CreatedAt = time.Now()
+	promotion.UpdatedAt = promotion.CreatedAt
+
+	validator := common.NewValidator()
+	if err := validator.Struct(promotion); err != nil {
+		logger.Error("failed to validate promotion", "error", err)
+		return nil, validationError(err)
+	}
+	if err := promotion.CheckDiscount(); err != nil {
+		logger.Error("failed to validate promotion", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	_, err := h.repo.GetPromotionByCode(ctx, promotion.Code)
+	if err == nil {
+		logger.Error("promotion with the given code already exists", "code", promotion.Code)
+		return nil, errAlreadyExists
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		logger.Error("failed to get promotion from db", "error", err)
+		return nil, dbError(err)
+	}
+
+	promotion, err = h.repo.CreatePromotion(ctx, promotion)
+	if err != nil {
+		logger.Error("failed to create promotion in db", "error", err)
+		return nil, dbError(err)
+	}
+	logger.Debug("create promotion successful")
+	return &orderspb.CreatePromotionResponse{Promotion: promotion.Proto()}, nil
+}
+
+// Get a promotion by its promo code
+func (h *Handler) GetPromotionByCode(ctx context.Context, req *orderspb.GetPromotionByCodeRequest) (*orderspb.GetPromotionByCodeResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.Code) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("get promotion by code", "code", req.Code)
+
+	promotion, err := h.repo.GetPromotionByCode(ctx, model.NormalizePromoCode(req.Code))
+	if err != nil {
+		logger.Error("failed to get promotion from db", "error", err)
+		return nil, dbError(err)
+	}
+	logger.Debug("get promotion by code successful")
+	return &orderspb.GetPromotionByCodeResponse{Promotion: promotion.Proto()}, nil
+}
+
+// promotionError maps the reasons a promo code cannot be redeemed to grpc statuses.
+// It returns nil for errors that are not about the promotion.
+func promotionError(code string, err error) error {
+	switch {
+	case errors.Is(err, model.ErrPromotionNotFound):
+		return badRequest(map[string]string{"promo_code": err.Error()})
+	case errors.Is(err, model.ErrPromotionNotActive),
+		errors.Is(err, model.ErrPromotionExhausted),
+		errors.Is(err, model.ErrPromotionCustomerLimitReached),
+		errors.Is(err, model.ErrPromotionNotApplicable):
+		return preconditionError("PROMOTION", code, err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	grpcclients "github.com/wathuta/technical_test/orders/internal/grpc_clients"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (st *OrderHandlerTestSuite) promoOrderRequest(promoCode string) *orderspb.CreateOrderRequest {
+	return &orderspb.CreateOrderRequest{
+		CustomerId:                st.testUUID.String(),
+		ProductId:                 st.testUUID1.String(),
+		ProductQuantity:           2,
+		ShippingMethod:            "Express",
+		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_MPESA,
+		ScheduledPickupDatetime:   timestamppb.New(time.Now().Add(24 * time.Hour)),
+		ScheduledDeliveryDatetime: timestamppb.New(time.Now().Add(48 * time.Hour)),
+		PickupAddress:             &orderspb.Address{Street: "Moi Avenue", City: "Nairobi", State: "Nairobi", PostalCode: "00100", Country: "Kenya"},
+		DeliveryAddress:           &orderspb.Address{Street: "Nyali Road", City: "Mombasa", State: "Mombasa", PostalCode: "80100", Country: "Kenya"},
+		PromoCode:                 promoCode,
+	}
+}
+
+// mockPromoOrder prices 2 electronics at KES 100 with 16% VAT and KES 10 shipping
+func (st *OrderHandlerTestSuite) mockPromoOrder() {
+	st.repo.On("GetProductById", mock.Anything, st.testUUID1.String()).Return(
+		&model.Product{
+			ProductID:         st.testUUID1.String(),
+			Name:              "Radio",
+			Sku:               "RAD-1",
+			Category:          model.Electronics,
+			StockQuantity:     100,
+			ProductAttributes: model.ProductAttributes{Price: 10000, Currency: model.KES},
+			IsAvailable:       true,
+		},
+		nil,
+	)
+	st.repo.On("GetCustomerById", mock.Anything, st.testUUID.String()).Return(
+		&model.Customer{CustomerID: st.testUUID.String(), Name: "Jane Wanjiru", PhoneNumber: "+254700000000"},
+		nil,
+	)
+}
+
+func (st *OrderHandlerTestSuite) mockPromoPricing() {
+	st.repo.On("GetTaxRate", mock.Anything, model.Electronics).Return(&model.TaxRate{RateBasisPoints: 1600}, nil)
+	st.repo.On("GetShippingRate", mock.Anything, "EXPRESS", model.ShippingZoneDomestic).Return(
+		&model.ShippingRate{ShippingMethod: "EXPRESS", Zone: model.ShippingZoneDomestic, BaseFee: 600, PerItemFee: 200, Currency: model.KES},
+		nil,
+	)
+}
+
+func (st *OrderHandlerTestSuite) testPromotion() *model.Promotion {
+	return &model.Promotion{
+		PromotionID:           st.testUUID2.String(),
+		Code:                  "XMAS10",
+		DiscountType:          model.DiscountTypePercentage,
+		PercentOffBasisPoints: 1000,
+		Currency:              model.KES,
+		StartsAt:              time.Now().Add(-time.Hour),
+		EndsAt:                time.Now().Add(time.Hour),
+		MaxRedemptions:        100,
+		RedemptionCount:       3,
+		Restrictions:          model.PromotionRestrictions{Categories: []model.ProductCategory{model.Electronics}},
+	}
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_PromoCode() {
+	st.mockPromoOrder()
+	st.mockDeliverySlots()
+	st.mockPromoPricing()
+	st.repo.On("GetPromotionByCode", mock.Anything, "XMAS10").Return(st.testPromotion(), nil)
+
+	st.repo.On("CreateOrder", mock.Anything,
+		mock.MatchedBy(func(order *model.Order) bool {
+			// 10% off KES 200 with 16% VAT on KES 180 and KES 10 shipping
+			return order.PromoCode == "XMAS10" && order.Discount == 2000 && order.Tax == 2880 && order.GrandTotal == 21880
+		}),
+		mock.MatchedBy(func(details *model.OrderDetails) bool { return details.Discount == 2000 }),
+		mock.MatchedBy(func(redemption *model.PromotionRedemption) bool {
+			return redemption.PromotionID == st.testUUID2.String() && redemption.CustomerID == st.testUUID.String() &&
+				redemption.Discount == 2000 && redemption.Currency == model.KES
+		}),
+		(*model.DeliveryWindow)(nil),
+	).Return(
+		func(_ context.Context, order *model.Order, details *model.OrderDetails, _ *model.PromotionRedemption, _ *model.DeliveryWindow) *model.Order {
+			return order
+		},
+		func(_ context.Context, order *model.Order, details *model.OrderDetails, _ *model.PromotionRedemption, _ *model.DeliveryWindow) *model.OrderDetails {
+			return details
+		},
+		nil,
+	)
+
+	output := make(chan grpcclients.ServiceResult)
+	go func() {
+		output <- grpcclients.ServiceResult{Result: &paymentpb.CreatePaymentResponse{Payment: &paymentpb.Payment{
+			Amount: &moneypb.Money{CurrencyCode: "KES", Amount: 21880},
+			Status: paymentpb.PaymentStatus_PENDING,
+		}}}
+	}()
+	st.paymentclient.On("CreatePaymentRequest", mock.Anything, mock.MatchedBy(func(req *paymentpb.CreatePaymentRequest) bool {
+		return req.Amount.Amount == 21880 && req.ProductCost.Amount == 20880 && req.ShippingFee.Amount == 1000
+	})).Return(output)
+
+	response, err := st.handler.CreateOrder(context.Background(), st.promoOrderRequest("xmas10"))
+
+	st.Require().NoError(err)
+	st.Require().Equal("XMAS10", response.Order.PromoCode)
+	st.Require().Equal(int64(2000), response.Order.Discount.Amount)
+	st.Require().Equal(int64(21880), response.Order.GrandTotal.Amount)
+	st.Require().Equal(int64(2000), response.OrderDetails.Discount.Amount)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_UnknownPromoCode() {
+	st.mockPromoOrder()
+	st.mockDeliverySlots()
+	st.repo.On("GetPromotionByCode", mock.Anything, "NOPE").Return(nil, errRowNotFound)
+
+	response, err := st.handler.CreateOrder(context.Background(), st.promoOrderRequest("nope"))
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "CreateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_ExpiredPromoCode() {
+	st.mockPromoOrder()
+	st.mockDeliverySlots()
+	promotion := st.testPromotion()
+	promotion.EndsAt = time.Now().Add(-time.Minute)
+	st.repo.On("GetPromotionByCode", mock.Anything, "XMAS10").Return(promotion, nil)
+
+	response, err := st.handler.CreateOrder(context.Background(), st.promoOrderRequest("XMAS10"))
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreateOrder_PromoCodeUsedUpConcurrently() {
+	st.mockPromoOrder()
+	st.mockDeliverySlots()
+	st.mockPromoPricing()
+	st.repo.On("GetPromotionByCode", mock.Anything, "XMAS10").Return(st.testPromotion(), nil)
+	st.repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, model.ErrPromotionExhausted)
+
+	response, err := st.handler.CreateOrder(context.Background(), st.promoOrderRequest("XMAS10"))
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	st.paymentclient.AssertNotCalled(st.T(), "CreatePaymentRequest", mock.Anything, mock.Anything)
+}
+
+func (st *OrderHandlerTestSuite) TestCreatePromotion_Success() {
+	st.repo.On("GetPromotionByCode", mock.Anything, "XMAS10").Return(nil, errRowNotFound)
+	st.repo.On("CreatePromotion", mock.Anything, mock.MatchedBy(func(promotion *model.Promotion) bool {
+		return promotion.Code == "XMAS10" && promotion.PromotionID != "" && promotion.RedemptionCount == 0
+	})).Return(func(_ context.Context, promotion *model.Promotion) *model.Promotion { return promotion }, nil)
+
+	response, err := st.handler.CreatePromotion(context.Background(), &orderspb.CreatePromotionRequest{
+		Promotion: &orderspb.Promotion{
+			Code:                  "xmas10",
+			DiscountType:          orderspb.DiscountType_DISCOUNT_TYPE_PERCENTAGE,
+			PercentOffBasisPoints: 1000,
+			StartsAt:              timestamppb.Now(),
+			EndsAt:                timestamppb.New(time.Now().AddDate(0, 1, 0)),
+			ProductCategories:     []string{"electronics"},
+			RedemptionCount:       50,
+		},
+	})
+
+	st.Require().NoError(err)
+	st.Require().Equal("XMAS10", response.Promotion.Code)
+	st.Require().Equal([]string{"ELECTRONICS"}, response.Promotion.ProductCategories)
+	st.Require().Equal(int32(0), response.Promotion.RedemptionCount)
+}
+
+func (st *OrderHandlerTestSuite) TestCreatePromotion_AlreadyExists() {
+	st.repo.On("GetPromotionByCode", mock.Anything, "XMAS10").Return(st.testPromotion(), nil)
+
+	response, err := st.handler.CreatePromotion(context.Background(), &orderspb.CreatePromotionRequest{
+		Promotion: &orderspb.Promotion{
+			Code:                  "XMAS10",
+			DiscountType:          orderspb.DiscountType_DISCOUNT_TYPE_PERCENTAGE,
+			PercentOffBasisPoints: 1000,
+			StartsAt:              timestamppb.Now(),
+			EndsAt:                timestamppb.New(time.Now().AddDate(0, 1, 0)),
+		},
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.AlreadyExists, status.Code(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreatePromotion_InvalidDiscount() {
+	response, err := st.handler.CreatePromotion(context.Background(), &orderspb.CreatePromotionRequest{
+		Promotion: &orderspb.Promotion{
+			Code:         "FREE",
+			DiscountType: orderspb.DiscountType_DISCOUNT_TYPE_FIXED_AMOUNT,
+			StartsAt:     timestamppb.Now(),
+			EndsAt:       timestamppb.New(time.Now().AddDate(0, 1, 0)),
+		},
+	})
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+
+	response, err = st.handler.CreatePromotion(context.Background(), nil)
+	st.Require().Nil(response)
+	st.Require().Equal(errResourceRequired, err)
+}
+
+func (st *OrderHandlerTestSuite) TestGetPromotionByCode() {
+	st.repo.On("GetPromotionByCode", mock.Anything, "XMAS10").Return(st.testPromotion(), nil)
+	st.repo.On("GetPromotionByCode", mock.Anything, "NOPE").Return(nil, errRowNotFound)
+
+	response, err := st.handler.GetPromotionByCode(context.Background(), &orderspb.GetPromotionByCodeRequest{Code: "xmas10"})
+	st.Require().NoError(err)
+	st.Require().Equal(orderspb.DiscountType_DISCOUNT_TYPE_PERCENTAGE, response.Promotion.DiscountType)
+
+	response, err = st.handler.GetPromotionByCode(context.Background(), &orderspb.GetPromotionByCodeRequest{Code: "nope"})
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
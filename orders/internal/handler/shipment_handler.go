@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wathuta/technical_test/orders/internal/common"
+	"github.com/wathuta/technical_test/orders/internal/logging"
+	"github.com/wathuta/technical_test/orders/internal/metrics"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+)
+
+// maxEventClockSkew is how far in the future the time of a tracking event may be, the clocks of
+// the carriers are not in sync with ours.
+const maxEventClockSkew = 5 * time.Minute
+
+// CreateShipment hands an order to a carrier. The tracking number defaults to the one of the order.
+func (h *Handler) CreateShipment(ctx context.Context, req *orderspb.CreateShipmentRequest) (*orderspb.CreateShipmentResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.OrderId) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("create shipment", "order_id", req.OrderId, "carrier", req.Carrier)
+
+	orderUUID, err := uuid.Parse(req.OrderId)
+	if err != nil {
+		logger.Error("invalid order uuid value", "order_id", req.OrderId, "error", err)
+		return nil, errBadRequest
+	}
+
+	order, err := h.repo.GetOrderById(ctx, orderUUID.String())
+	if err != nil {
+		logger.Error("failed to get order from db", "error", err)
+		return nil, dbError(err)
+	}
+	if isFinalOrderStatus(order.OrderStatus) {
+		logger.Error("order can no longer be shipped", "order_id", order.OrderID, "order_status", order.OrderStatus)
+		return nil, preconditionError("STATUS", "order", fmt.Errorf("order is %s, it can no longer be shipped", order.OrderStatus))
+	}
+
+	now := time.Now()
+	shipment := &model.Shipment{
+		ShipmentID:     uuid.NewString(),
+		OrderID:        order.OrderID,
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		Status:         model.ShipmentStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if len(shipment.TrackingNumber) == 0 {
+		shipment.TrackingNumber = order.TrackingNumber
+	}
+
+	validator := common.NewValidator()
+	if err := validator.Struct(shipment); err != nil {
+		logger.Error("failed to validate shipment", "error", err)
+		return nil, validationError(err)
+	}
+
+	created, err := h.repo.CreateShipment(ctx, shipment)
+	if err != nil {
+		logger.Error("failed to create shipment in db", "error", err)
+		return nil, dbError(err)
+	}
+	logger.Debug("create shipment successful", "shipment_id", created.ShipmentID)
+	return &orderspb.CreateShipmentResponse{Shipment: created.Proto()}, nil
+}
+
+// RecordShipmentEvent records a tracking event reported by the carrier of a shipment and moves its
+// order to shipped or delivered. Events reported more than once are only recorded once.
+func (h *Handler) RecordShipmentEvent(ctx context.Context, req *orderspb.RecordShipmentEventRequest) (*orderspb.RecordShipmentEventResponse, error) {
+	shipment, err := h.recordShipmentEvent(ctx, "", req)
+	if err != nil {
+		return nil, err
+	}
+	return &orderspb.RecordShipmentEventResponse{Shipment: shipment.Proto()}, nil
+}
+
+// RecordCarrierEvent records a tracking event posted by carrier to the webhook. The shipments of
+// other carriers are not found.
+func (h *Handler) RecordCarrierEvent(ctx context.Context, carrier string, req *orderspb.RecordShipmentEventRequest) error {
+	_, err := h.recordShipmentEvent(ctx, carrier, req)
+	return err
+}
+
+// recordShipmentEvent records the event of req for the shipment with its tracking number, which
+// must be shipped by carrier unless it is empty.
+func (h *Handler) recordShipmentEvent(ctx context.Context, carrier string, req *orderspb.RecordShipmentEventRequest) (*model.Shipment, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || req.Event == nil || len(req.TrackingNumber) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("record shipment event", "tracking_number", req.TrackingNumber, "type", req.Event.Type, "carrier", carrier)
+
+	event := model.ShipmentEventFromProto(req.Event)
+	violations := map[string]string{}
+	if !event.Type.Valid() {
+		violations["event.type"] = "must be specified"
+	}
+	if req.Event.OccurredAt == nil {
+		violations["event.occurred_at"] = "is required"
+	} else if event.OccurredAt.After(time.Now().Add(maxEventClockSkew)) {
+		violations["event.occurred_at"] = "cannot be in the future"
+	}
+	if len(violations) > 0 {
+		logger.Error("invalid shipment event", "violations", violations)
+		return nil, badRequest(violations)
+	}
+
+	shipment, err := h.repo.GetShipmentByTrackingNumber(ctx, req.TrackingNumber)
+	if err != nil {
+		logger.Error("failed to get shipment from db", "error", err)
+		return nil, dbError(err)
+	}
+	if len(carrier) > 0 && !strings.EqualFold(shipment.Carrier, carrier) {
+		logger.Error("shipment is shipped by another carrier", "tracking_number", req.TrackingNumber, "carrier", carrier)
+		return nil, errNotFound
+	}
+
+	event.EventID = uuid.NewString()
+	event.ShipmentID = shipment.ShipmentID
+	event.RecordedAt = time.Now()
+	shipment, orderUpdated, err := h.repo.RecordShipmentEvent(ctx, shipment, event)
+	if err != nil {
+		logger.Error("failed to record shipment event in db", "error", err)
+		return nil, dbError(err)
+	}
+	if orderUpdated {
+		orderStatus := event.Type.OrderStatus()
+		logger.Info("order status updated by shipment event", "order_id", shipment.OrderID, "order_status", orderStatus)
+		metrics.OrderStatusUpdates.WithLabelValues(string(orderStatus)).Inc()
+	}
+
+	logger.Debug("record shipment event successful", "shipment_status", shipment.Status)
+	return shipment, nil
+}
+
+// TrackShipment returns the carrier, status and events of a shipment to anyone with its tracking
+// number.
+func (h *Handler) TrackShipment(ctx context.Context, req *orderspb.TrackShipmentRequest) (*orderspb.TrackShipmentResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.TrackingNumber) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("track shipment", "tracking_number", req.TrackingNumber)
+
+	shipment, err := h.repo.GetShipmentByTrackingNumber(ctx, req.TrackingNumber)
+	if err != nil {
+		logger.Error("failed to get shipment from db", "error", err)
+		return nil, dbError(err)
+	}
+	logger.Debug("track shipment successful")
+	return shipment.TrackingProto(), nil
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (st *OrderHandlerTestSuite) shipment() *model.Shipment {
+	return &model.Shipment{
+		ShipmentID:     st.testUUID2.String(),
+		OrderID:        st.testUUID.String(),
+		Carrier:        "G4S",
+		TrackingNumber: "TRK-1",
+		Status:         model.ShipmentStatusPending,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		Events:         []model.ShipmentEvent{},
+	}
+}
+
+func (st *OrderHandlerTestSuite) TestCreateShipment_DefaultTrackingNumber() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusProcessing, TrackingNumber: "TRK-1"}, nil)
+	st.repo.On("CreateShipment", mock.Anything, mock.MatchedBy(func(shipment *model.Shipment) bool {
+		return shipment.OrderID == st.testUUID.String() && shipment.TrackingNumber == "TRK-1" &&
+			shipment.Carrier == "G4S" && shipment.Status == model.ShipmentStatusPending
+	})).Return(st.shipment(), nil)
+
+	response, err := st.handler.CreateShipment(context.Background(), &orderspb.CreateShipmentRequest{
+		OrderId: st.testUUID.String(),
+		Carrier: "G4S",
+	})
+
+	st.Require().NoError(err)
+	st.Require().Equal("TRK-1", response.Shipment.TrackingNumber)
+	st.Require().Equal(orderspb.ShipmentStatus_SHIPMENT_STATUS_PENDING, response.Shipment.Status)
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *OrderHandlerTestSuite) TestCreateShipment_MissingCarrier() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusProcessing, TrackingNumber: "TRK-1"}, nil)
+
+	response, err := st.handler.CreateShipment(context.Background(), &orderspb.CreateShipmentRequest{OrderId: st.testUUID.String()})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"carrier"}, fieldViolations(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreateShipment_CancelledOrder() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusCanceled}, nil)
+
+	response, err := st.handler.CreateShipment(context.Background(), &orderspb.CreateShipmentRequest{
+		OrderId: st.testUUID.String(),
+		Carrier: "G4S",
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (st *OrderHandlerTestSuite) TestCreateShipment_AlreadyShipped() {
+	st.repo.On("GetOrderById", mock.Anything, st.testUUID.String()).Return(
+		&model.Order{OrderID: st.testUUID.String(), OrderStatus: model.OrderStatusShipped, TrackingNumber: "TRK-1"}, nil)
+	st.repo.On("CreateShipment", mock.Anything, mock.Anything).Return(nil, &repository.Error{Kind: repository.ErrAlreadyExists})
+
+	response, err := st.handler.CreateShipment(context.Background(), &orderspb.CreateShipmentRequest{
+		OrderId: st.testUUID.String(),
+		Carrier: "G4S",
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errAlreadyExists, err)
+}
+
+func (st *OrderHandlerTestSuite) TestRecordShipmentEvent_Delivered() {
+	occurredAt := time.Now().Add(-time.Hour)
+	st.repo.On("GetShipmentByTrackingNumber", mock.Anything, "TRK-1").Return(st.shipment(), nil)
+	st.repo.On("RecordShipmentEvent", mock.Anything, mock.Anything, mock.MatchedBy(func(event *model.ShipmentEvent) bool {
+		return event.ShipmentID == st.testUUID2.String() && event.EventID != "" &&
+			event.Type == model.ShipmentEventDelivered && event.OccurredAt.Equal(occurredAt.UTC())
+	})).Return(func() *model.Shipment {
+		shipment := st.shipment()
+		shipment.Status = model.ShipmentStatusDelivered
+		shipment.Events = []model.ShipmentEvent{{Type: model.ShipmentEventDelivered, OccurredAt: occurredAt}}
+		return shipment
+	}(), true, nil)
+
+	response, err := st.handler.RecordShipmentEvent(context.Background(), &orderspb.RecordShipmentEventRequest{
+		TrackingNumber: "TRK-1",
+		Event: &orderspb.ShipmentEvent{
+			Type:       orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_DELIVERED,
+			Location:   "Mombasa",
+			OccurredAt: timestamppb.New(occurredAt),
+		},
+	})
+
+	st.Require().NoError(err)
+	st.Require().Equal(orderspb.ShipmentStatus_SHIPMENT_STATUS_DELIVERED, response.Shipment.Status)
+	st.Require().Len(response.Shipment.Events, 1)
+	st.repo.AssertExpectations(st.T())
+}
+
+func (st *OrderHandlerTestSuite) TestRecordShipmentEvent_InvalidEvent() {
+	response, err := st.handler.RecordShipmentEvent(context.Background(), &orderspb.RecordShipmentEventRequest{
+		TrackingNumber: "TRK-1",
+		Event:          &orderspb.ShipmentEvent{},
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+	st.Require().Equal([]string{"event.occurred_at", "event.type"}, fieldViolations(err))
+}
+
+func (st *OrderHandlerTestSuite) TestRecordShipmentEvent_FutureEvent() {
+	response, err := st.handler.RecordShipmentEvent(context.Background(), &orderspb.RecordShipmentEventRequest{
+		TrackingNumber: "TRK-1",
+		Event: &orderspb.ShipmentEvent{
+			Type:       orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_PICKED_UP,
+			OccurredAt: timestamppb.New(time.Now().Add(time.Hour)),
+		},
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal([]string{"event.occurred_at"}, fieldViolations(err))
+}
+
+func (st *OrderHandlerTestSuite) TestRecordShipmentEvent_UnknownTrackingNumber() {
+	st.repo.On("GetShipmentByTrackingNumber", mock.Anything, "TRK-2").Return(nil, errRowNotFound)
+
+	response, err := st.handler.RecordShipmentEvent(context.Background(), &orderspb.RecordShipmentEventRequest{
+		TrackingNumber: "TRK-2",
+		Event: &orderspb.ShipmentEvent{
+			Type:       orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_PICKED_UP,
+			OccurredAt: timestamppb.Now(),
+		},
+	})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *OrderHandlerTestSuite) TestTrackShipment_Success() {
+	shipment := st.shipment()
+	shipment.Status = model.ShipmentStatusInTransit
+	shipment.Events = []model.ShipmentEvent{
+		{Type: model.ShipmentEventPickedUp, Location: "Nairobi", OccurredAt: time.Now().Add(-2 * time.Hour)},
+		{Type: model.ShipmentEventInTransit, Location: "Voi", OccurredAt: time.Now().Add(-time.Hour)},
+	}
+	st.repo.On("GetShipmentByTrackingNumber", mock.Anything, "TRK-1").Return(shipment, nil)
+
+	response, err := st.handler.TrackShipment(context.Background(), &orderspb.TrackShipmentRequest{TrackingNumber: "TRK-1"})
+
+	st.Require().NoError(err)
+	st.Require().Equal("G4S", response.Carrier)
+	st.Require().Equal(orderspb.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT, response.Status)
+	st.Require().Len(response.Events, 2)
+	st.Require().Equal("Voi", response.Events[1].Location)
+}
+
+func (st *OrderHandlerTestSuite) TestTrackShipment_NotFound() {
+	st.repo.On("GetShipmentByTrackingNumber", mock.Anything, "TRK-2").Return(nil, errRowNotFound)
+
+	response, err := st.handler.TrackShipment(context.Background(), &orderspb.TrackShipmentRequest{TrackingNumber: "TRK-2"})
+
+	st.Require().Nil(response)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *OrderHandlerTestSuite) TestRecordCarrierEvent_OtherCarrier() {
+	st.repo.On("GetShipmentByTrackingNumber", mock.Anything, "TRK-1").Return(st.shipment(), nil)
+
+	err := st.handler.RecordCarrierEvent(context.Background(), "sendy", &orderspb.RecordShipmentEventRequest{
+		TrackingNumber: "TRK-1",
+		Event: &orderspb.ShipmentEvent{
+			Type:       orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_PICKED_UP,
+			OccurredAt: timestamppb.Now(),
+		},
+	})
+
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *OrderHandlerTestSuite) TestRecordCarrierEvent_Success() {
+	st.repo.On("GetShipmentByTrackingNumber", mock.Anything, "TRK-1").Return(st.shipment(), nil)
+	st.repo.On("RecordShipmentEvent", mock.Anything, mock.Anything, mock.Anything).Return(st.shipment(), false, nil)
+
+	// carriers are matched ignoring case
+	err := st.handler.RecordCarrierEvent(context.Background(), "g4s", &orderspb.RecordShipmentEventRequest{
+		TrackingNumber: "TRK-1",
+		Event: &orderspb.ShipmentEvent{
+			Type:       orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_PICKED_UP,
+			OccurredAt: timestamppb.Now(),
+		},
+	})
+
+	st.Require().NoError(err)
+}
@@ -0,0 +1,115 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// toggle is a check whose result is switched by the test.
+type toggle struct {
+	failing atomic.Bool
+}
+
+func (c *toggle) check(ctx context.Context) error {
+	if c.failing.Load() {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func status(t *testing.T, server *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.Status
+}
+
+func newTestMonitor() (*Monitor, *health.Server, *toggle, *toggle) {
+	server := health.NewServer()
+	monitor := NewMonitor(server, time.Hour, time.Second)
+	db, peer := &toggle{}, &toggle{}
+	monitor.AddCheck("postgres", db.check)
+	monitor.AddCheck("payment", peer.check)
+	monitor.AddService("", "postgres")
+	monitor.AddService("orders.OrderService", "postgres", "payment")
+	monitor.AddService("products.ProductService", "postgres")
+	return monitor, server, db, peer
+}
+
+func TestMonitor_NotServingBeforeFirstCheck(t *testing.T) {
+	_, server, _, _ := newTestMonitor()
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, "orders.OrderService"))
+}
+
+func TestMonitor_StatusFollowsDependencies(t *testing.T) {
+	monitor, server, db, peer := newTestMonitor()
+
+	monitor.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, server, "orders.OrderService"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, server, "products.ProductService"))
+
+	peer.failing.Store(true)
+	monitor.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, "orders.OrderService"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, server, "products.ProductService"))
+
+	db.failing.Store(true)
+	peer.failing.Store(false)
+	monitor.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, "orders.OrderService"))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, "products.ProductService"))
+
+	db.failing.Store(false)
+	monitor.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, server, "orders.OrderService"))
+}
+
+func TestMonitor_CheckTimeout(t *testing.T) {
+	server := health.NewServer()
+	monitor := NewMonitor(server, time.Hour, 10*time.Millisecond)
+	monitor.AddCheck("postgres", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	monitor.AddService("", "postgres")
+
+	monitor.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, ""))
+}
+
+func TestMonitor_Shutdown(t *testing.T) {
+	monitor, server, _, _ := newTestMonitor()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		monitor.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		return status(t, server, "") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, time.Millisecond)
+
+	monitor.Shutdown()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, "orders.OrderService"))
+
+	// later checks do not bring the services back while the server drains
+	monitor.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, server, ""))
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,219 @@
+package invoice
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+)
+
+//go:embed templates/invoice.html
+var templates embed.FS
+
+var htmlTemplate = template.Must(template.ParseFS(templates, "templates/invoice.html"))
+
+// Line is a single product on an invoice.
+type Line struct {
+	Description string
+	SKU         string
+	Quantity    int64
+	UnitPrice   model.Money
+	TaxRate     string
+	Tax         model.Money
+	LineTotal   model.Money
+}
+
+// Total is a labelled amount in the totals section of an invoice.
+type Total struct {
+	Label  string
+	Amount model.Money
+}
+
+// Document holds everything shown on an invoice.
+type Document struct {
+	InvoiceNumber      string
+	IssuedAt           time.Time
+	Order              *model.Order
+	Customer           *model.Customer
+	Lines              []Line
+	Totals             []Total
+	PaymentMethod      string
+	MpesaReceiptNumber string
+	Paid               bool
+}
+
+// NewDocument builds the invoice of an order. products maps product ids to the products of the order lines.
+// payment may be nil when the customer has not started paying.
+func NewDocument(order *model.Order, customer *model.Customer, details []model.OrderDetails, products map[string]*model.Product, payment *paymentpb.Payment) *Document {
+	doc := &Document{
+		InvoiceNumber: order.InvoiceNumber,
+		IssuedAt:      order.CreatedAt,
+		Order:         order,
+		Customer:      customer,
+		PaymentMethod: displayPaymentMethod(string(order.PaymentMethod)),
+	}
+
+	for _, detail := range details {
+		line := Line{
+			Description: detail.ProductID,
+			Quantity:    int64(detail.Quantity),
+			UnitPrice:   model.NewMoney(detail.UnitPrice, detail.Currency),
+			TaxRate:     displayTaxRate(detail.TaxRateBasisPoints, detail.TaxExempt),
+			Tax:         model.NewMoney(detail.Tax, detail.Currency),
+			LineTotal:   model.NewMoney(detail.LineTotal, detail.Currency),
+		}
+		if product, ok := products[detail.ProductID]; ok {
+			line.Description = product.Name
+			line.SKU = product.Sku
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+
+	doc.Totals = []Total{{Label: "Subtotal", Amount: model.NewMoney(order.Subtotal, order.Currency)}}
+	if order.Discount > 0 {
+		doc.Totals = append(doc.Totals, Total{Label: "Discount (" + order.PromoCode + ")", Amount: model.NewMoney(-order.Discount, order.Currency)})
+	}
+	doc.Totals = append(doc.Totals,
+		Total{Label: "Shipping", Amount: order.Shipping()},
+		Total{Label: "VAT", Amount: model.NewMoney(order.Tax, order.Currency)},
+		Total{Label: "Total", Amount: model.NewMoney(order.GrandTotal, order.Currency)},
+	)
+
+	if payment != nil {
+		doc.PaymentMethod = displayPaymentMethod(payment.PaymentMethod.String())
+		doc.MpesaReceiptNumber = payment.MpesaReceiptNumber
+		doc.Paid = payment.Status == paymentpb.PaymentStatus_COMPLETED
+	}
+	return doc
+}
+
+// RenderHTML renders the invoice as an HTML page.
+func RenderHTML(doc *Document) (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, doc); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderPDF renders the invoice as an A4 PDF.
+func RenderPDF(doc *Document) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Invoice "+doc.InvoiceNumber, true)
+	// the documents are cached so the creation date is fixed and the catalog sorted to keep renders
+	// of the same invoice identical
+	pdf.SetCreationDate(doc.IssuedAt)
+	pdf.SetModificationDate(doc.IssuedAt)
+	pdf.SetCatalogSort(true)
+	pdf.AddPage()
+	// the core fonts only support latin-1
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.Cell(0, 10, tr("Invoice "+doc.InvoiceNumber))
+	pdf.Ln(10)
+
+	pdf.SetFont("Helvetica", "", 10)
+	status := "UNPAID"
+	if doc.Paid {
+		status = "PAID"
+	}
+	for _, text := range []string{
+		"Issued " + doc.IssuedAt.Format("02 Jan 2006"),
+		"Order " + doc.Order.OrderID,
+		status,
+	} {
+		pdf.Cell(0, 5, tr(text))
+		pdf.Ln(5)
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.Cell(0, 6, "Billed to")
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 10)
+	for _, text := range []string{doc.Customer.Name, doc.Customer.Email, doc.Customer.PhoneNumber, doc.Customer.Address} {
+		pdf.Cell(0, 5, tr(text))
+		pdf.Ln(5)
+	}
+	pdf.Ln(4)
+
+	widths := []float64{50, 30, 12, 28, 18, 24, 28}
+	headers := []string{"Item", "SKU", "Qty", "Unit price", "VAT", "Tax", "Amount"}
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "B", 0, alignment(i), false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range doc.Lines {
+		values := []string{
+			line.Description,
+			line.SKU,
+			fmt.Sprint(line.Quantity),
+			line.UnitPrice.String(),
+			line.TaxRate,
+			line.Tax.String(),
+			line.LineTotal.String(),
+		}
+		for i, value := range values {
+			pdf.CellFormat(widths[i], 7, tr(value), "B", 0, alignment(i), false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	for _, total := range doc.Totals {
+		pdf.CellFormat(162, 6, total.Label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(28, 6, total.Amount.String(), "", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.Cell(0, 6, "Payment")
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 5, tr("Method: "+doc.PaymentMethod))
+	pdf.Ln(5)
+	if doc.MpesaReceiptNumber != "" {
+		pdf.Cell(0, 5, tr("M-Pesa receipt: "+doc.MpesaReceiptNumber))
+		pdf.Ln(5)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// alignment right aligns the numeric columns of the line items table.
+func alignment(column int) string {
+	if column >= 2 {
+		return "R"
+	}
+	return "L"
+}
+
+func displayTaxRate(basisPoints int64, exempt bool) string {
+	if exempt {
+		return "Exempt"
+	}
+	if basisPoints%100 == 0 {
+		return fmt.Sprintf("%d%%", basisPoints/100)
+	}
+	return fmt.Sprintf("%d.%02d%%", basisPoints/100, basisPoints%100)
+}
+
+// displayPaymentMethod turns enum names such as PAYMENT_METHOD_MPESA into MPESA.
+func displayPaymentMethod(method string) string {
+	return strings.TrimPrefix(method, "PAYMENT_METHOD_")
+}
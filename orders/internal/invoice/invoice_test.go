@@ -0,0 +1,105 @@
+package invoice
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+)
+
+func testDocument(payment *paymentpb.Payment) *Document {
+	order := &model.Order{
+		OrderID:         "0b5c6bf4-6f39-4b6e-9c55-1a0c3ad0e3a1",
+		InvoiceNumber:   "INV-00000042",
+		PaymentMethod:   "PAYMENT_METHOD_MPESA",
+		DeliveryAddress: model.Address{Street: "Moi Avenue", City: "Nairobi", State: "Nairobi", PostalCode: "00100", Country: "Kenya"},
+		ShippingCost:    40000,
+		Currency:        model.KES,
+		Subtotal:        30000,
+		Tax:             3200,
+		GrandTotal:      73200,
+		CreatedAt:       time.Date(2023, 10, 4, 12, 0, 0, 0, time.UTC),
+	}
+	customer := &model.Customer{Name: "Jane Wanjiru", Email: "jane@example.com", PhoneNumber: "+254700000000", Address: "Moi Avenue"}
+	details := []model.OrderDetails{
+		{ProductID: "radio", Quantity: 2, UnitPrice: 10000, LineTotal: 20000, TaxRateBasisPoints: 1600, Tax: 3200, Currency: model.KES},
+		{ProductID: "maize", Quantity: 1, UnitPrice: 10000, LineTotal: 10000, TaxExempt: true, Currency: model.KES},
+	}
+	products := map[string]*model.Product{
+		"radio": {ProductID: "radio", Name: "Radio", Sku: "RAD-1"},
+	}
+	return NewDocument(order, customer, details, products, payment)
+}
+
+func TestNewDocument(t *testing.T) {
+	doc := testDocument(&paymentpb.Payment{
+		PaymentMethod:      paymentpb.PaymentMethod_MPESA,
+		Status:             paymentpb.PaymentStatus_COMPLETED,
+		MpesaReceiptNumber: "NLJ7RT61SV",
+	})
+
+	assert.True(t, doc.Paid)
+	assert.Equal(t, "MPESA", doc.PaymentMethod)
+	assert.Equal(t, "NLJ7RT61SV", doc.MpesaReceiptNumber)
+	require.Len(t, doc.Lines, 2)
+	assert.Equal(t, "Radio", doc.Lines[0].Description)
+	assert.Equal(t, "16%", doc.Lines[0].TaxRate)
+	// a product that no longer exists is shown by its id
+	assert.Equal(t, "maize", doc.Lines[1].Description)
+	assert.Equal(t, "Exempt", doc.Lines[1].TaxRate)
+	assert.Equal(t, Total{Label: "Total", Amount: model.NewMoney(73200, model.KES)}, doc.Totals[len(doc.Totals)-1])
+}
+
+func TestNewDocument_Discount(t *testing.T) {
+	doc := testDocument(nil)
+	doc.Order.Discount = 2000
+	doc.Order.PromoCode = "XMAS10"
+	doc = NewDocument(doc.Order, doc.Customer, nil, nil, nil)
+
+	assert.Contains(t, doc.Totals, Total{Label: "Discount (XMAS10)", Amount: model.NewMoney(-2000, model.KES)})
+}
+
+func TestNewDocument_Unpaid(t *testing.T) {
+	doc := testDocument(nil)
+	assert.False(t, doc.Paid)
+	assert.Equal(t, "MPESA", doc.PaymentMethod)
+	assert.Empty(t, doc.MpesaReceiptNumber)
+
+	doc = testDocument(&paymentpb.Payment{PaymentMethod: paymentpb.PaymentMethod_MPESA, Status: paymentpb.PaymentStatus_PENDING})
+	assert.False(t, doc.Paid)
+}
+
+func TestRenderHTML(t *testing.T) {
+	html, err := RenderHTML(testDocument(&paymentpb.Payment{
+		PaymentMethod:      paymentpb.PaymentMethod_MPESA,
+		Status:             paymentpb.PaymentStatus_COMPLETED,
+		MpesaReceiptNumber: "NLJ7RT61SV",
+	}))
+	require.NoError(t, err)
+
+	for _, want := range []string{"INV-00000042", "Jane Wanjiru", "Radio", "RAD-1", "KES 100.00", "KES 732.00", "PAID", "NLJ7RT61SV"} {
+		assert.Contains(t, html, want)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	doc := testDocument(nil)
+	pdf, err := RenderPDF(doc)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+
+	// the same invoice renders to the same bytes so a cached copy matches a fresh render
+	again, err := RenderPDF(doc)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, again)
+}
+
+func TestDisplayTaxRate(t *testing.T) {
+	assert.Equal(t, "16%", displayTaxRate(1600, false))
+	assert.Equal(t, "12.50%", displayTaxRate(1250, false))
+	assert.Equal(t, "Exempt", displayTaxRate(0, true))
+}
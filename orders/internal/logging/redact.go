@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// Redacted replaces sensitive values in the logs.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the endings of attribute keys, lower case without separators, whose values
+// are never logged, e.g. "customer_phone" and "PhoneNumber".
+var sensitiveKeys = []string{
+	"phone",
+	"phonenumber",
+	"partya",
+	"password",
+	"passkey",
+	"authorization",
+	"token",
+	"secret",
+	"email",
+}
+
+func sensitive(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(key))
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.HasSuffix(key, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact is a slog.HandlerOptions.ReplaceAttr function hiding the values of sensitive attributes
+// such as phone numbers, passwords and tokens.
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.34.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	http "net/http"
+
+	mock "github.com/stretchr/testify/mock"
+	orders "github.com/wathuta/technical_test/protos_gen/orders"
+)
+
+// CarrierAdapter is an autogenerated mock type for the CarrierAdapter type
+type CarrierAdapter struct {
+	mock.Mock
+}
+
+// Name provides a mock function with given fields:
+func (_m *CarrierAdapter) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Parse provides a mock function with given fields: body
+func (_m *CarrierAdapter) Parse(body []byte) ([]*orders.RecordShipmentEventRequest, error) {
+	ret := _m.Called(body)
+
+	var r0 []*orders.RecordShipmentEventRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]byte) ([]*orders.RecordShipmentEventRequest, error)); ok {
+		return rf(body)
+	}
+	if rf, ok := ret.Get(0).(func([]byte) []*orders.RecordShipmentEventRequest); ok {
+		r0 = rf(body)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*orders.RecordShipmentEventRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = rf(body)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Verify provides a mock function with given fields: header, body
+func (_m *CarrierAdapter) Verify(header http.Header, body []byte) error {
+	ret := _m.Called(header, body)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(http.Header, []byte) error); ok {
+		r0 = rf(header, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCarrierAdapter creates a new instance of CarrierAdapter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCarrierAdapter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CarrierAdapter {
+	mock := &CarrierAdapter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
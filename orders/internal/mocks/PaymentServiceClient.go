@@ -16,6 +16,20 @@ type PaymentServiceClient struct {
 	mock.Mock
 }
 
+// CheckHealth provides a mock function with given fields: ctx
+func (_m *PaymentServiceClient) CheckHealth(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreatePaymentRequest provides a mock function with given fields: ctx, status
 func (_m *PaymentServiceClient) CreatePaymentRequest(ctx context.Context, status *payment.CreatePaymentRequest) chan grpcclients.ServiceResult {
 	ret := _m.Called(ctx, status)
@@ -32,6 +46,22 @@ func (_m *PaymentServiceClient) CreatePaymentRequest(ctx context.Context, status
 	return r0
 }
 
+// GetPaymentByOrderId provides a mock function with given fields: ctx, orderId
+func (_m *PaymentServiceClient) GetPaymentByOrderId(ctx context.Context, orderId string) chan grpcclients.ServiceResult {
+	ret := _m.Called(ctx, orderId)
+
+	var r0 chan grpcclients.ServiceResult
+	if rf, ok := ret.Get(0).(func(context.Context, string) chan grpcclients.ServiceResult); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan grpcclients.ServiceResult)
+		}
+	}
+
+	return r0
+}
+
 // NewPaymentServiceClient creates a new instance of PaymentServiceClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPaymentServiceClient(t interface {
@@ -0,0 +1,79 @@
+// Code generated by mockery v2.34.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/wathuta/technical_test/orders/internal/model"
+)
+
+// PromotionRepository is an autogenerated mock type for the PromotionRepository type
+type PromotionRepository struct {
+	mock.Mock
+}
+
+// CountPromotionRedemptions provides a mock function with given fields: ctx, promotionId, customerId
+func (_m *PromotionRepository) CountPromotionRedemptions(ctx context.Context, promotionId string, customerId string) (int64, error) {
+	ret := _m.Called(ctx, promotionId, customerId)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (int64, error)); ok {
+		return rf(ctx, promotionId, customerId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, promotionId, customerId)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, promotionId, customerId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromotionByCode provides a mock function with given fields: ctx, code
+func (_m *PromotionRepository) GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error) {
+	ret := _m.Called(ctx, code)
+
+	var r0 *model.Promotion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Promotion, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Promotion); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Promotion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewPromotionRepository creates a new instance of PromotionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPromotionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PromotionRepository {
+	mock := &PromotionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
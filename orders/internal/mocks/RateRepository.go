@@ -0,0 +1,55 @@
+// Code generated by mockery v2.34.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/wathuta/technical_test/orders/internal/model"
+)
+
+// RateRepository is an autogenerated mock type for the RateRepository type
+type RateRepository struct {
+	mock.Mock
+}
+
+// GetTaxRate provides a mock function with given fields: ctx, category
+func (_m *RateRepository) GetTaxRate(ctx context.Context, category model.ProductCategory) (*model.TaxRate, error) {
+	ret := _m.Called(ctx, category)
+
+	var r0 *model.TaxRate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ProductCategory) (*model.TaxRate, error)); ok {
+		return rf(ctx, category)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.ProductCategory) *model.TaxRate); ok {
+		r0 = rf(ctx, category)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.TaxRate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.ProductCategory) error); ok {
+		r1 = rf(ctx, category)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRateRepository creates a new instance of RateRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateRepository {
+	mock := &RateRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,43 @@
+// Code generated by mockery v2.34.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	orders "github.com/wathuta/technical_test/protos_gen/orders"
+)
+
+// Recorder is an autogenerated mock type for the Recorder type
+type Recorder struct {
+	mock.Mock
+}
+
+// RecordCarrierEvent provides a mock function with given fields: ctx, carrier, req
+func (_m *Recorder) RecordCarrierEvent(ctx context.Context, carrier string, req *orders.RecordShipmentEventRequest) error {
+	ret := _m.Called(ctx, carrier, req)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *orders.RecordShipmentEventRequest) error); ok {
+		r0 = rf(ctx, carrier, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRecorder creates a new instance of Recorder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRecorder(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Recorder {
+	mock := &Recorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 	model "github.com/wathuta/technical_test/orders/internal/model"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -14,6 +16,30 @@ type Repository struct {
 	mock.Mock
 }
 
+// CountPromotionRedemptions provides a mock function with given fields: ctx, promotionId, customerId
+func (_m *Repository) CountPromotionRedemptions(ctx context.Context, promotionId string, customerId string) (int64, error) {
+	ret := _m.Called(ctx, promotionId, customerId)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (int64, error)); ok {
+		return rf(ctx, promotionId, customerId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, promotionId, customerId)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, promotionId, customerId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateCustomer provides a mock function with given fields: ctx, customer
 func (_m *Repository) CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error) {
 	ret := _m.Called(ctx, customer)
@@ -40,34 +66,86 @@ func (_m *Repository) CreateCustomer(ctx context.Context, customer *model.Custom
 	return r0, r1
 }
 
-// CreateOrder provides a mock function with given fields: ctx, order, order_details
-func (_m *Repository) CreateOrder(ctx context.Context, order *model.Order, order_details *model.OrderDetails) (*model.Order, *model.OrderDetails, error) {
-	ret := _m.Called(ctx, order, order_details)
+// CreateCustomerAddress provides a mock function with given fields: ctx, address
+func (_m *Repository) CreateCustomerAddress(ctx context.Context, address *model.CustomerAddress) (*model.CustomerAddress, error) {
+	ret := _m.Called(ctx, address)
+
+	var r0 *model.CustomerAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.CustomerAddress) (*model.CustomerAddress, error)); ok {
+		return rf(ctx, address)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.CustomerAddress) *model.CustomerAddress); ok {
+		r0 = rf(ctx, address)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CustomerAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.CustomerAddress) error); ok {
+		r1 = rf(ctx, address)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateInvoice provides a mock function with given fields: ctx, invoice
+func (_m *Repository) CreateInvoice(ctx context.Context, invoice *model.Invoice) (*model.Invoice, error) {
+	ret := _m.Called(ctx, invoice)
+
+	var r0 *model.Invoice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Invoice) (*model.Invoice, error)); ok {
+		return rf(ctx, invoice)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Invoice) *model.Invoice); ok {
+		r0 = rf(ctx, invoice)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Invoice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Invoice) error); ok {
+		r1 = rf(ctx, invoice)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateOrder provides a mock function with given fields: ctx, order, order_details, redemption, slot
+func (_m *Repository) CreateOrder(ctx context.Context, order *model.Order, order_details *model.OrderDetails, redemption *model.PromotionRedemption, slot *model.DeliveryWindow) (*model.Order, *model.OrderDetails, error) {
+	ret := _m.Called(ctx, order, order_details, redemption, slot)
 
 	var r0 *model.Order
 	var r1 *model.OrderDetails
 	var r2 error
-	if rf, ok := ret.Get(0).(func(context.Context, *model.Order, *model.OrderDetails) (*model.Order, *model.OrderDetails, error)); ok {
-		return rf(ctx, order, order_details)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Order, *model.OrderDetails, *model.PromotionRedemption, *model.DeliveryWindow) (*model.Order, *model.OrderDetails, error)); ok {
+		return rf(ctx, order, order_details, redemption, slot)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *model.Order, *model.OrderDetails) *model.Order); ok {
-		r0 = rf(ctx, order, order_details)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Order, *model.OrderDetails, *model.PromotionRedemption, *model.DeliveryWindow) *model.Order); ok {
+		r0 = rf(ctx, order, order_details, redemption, slot)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *model.Order, *model.OrderDetails) *model.OrderDetails); ok {
-		r1 = rf(ctx, order, order_details)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Order, *model.OrderDetails, *model.PromotionRedemption, *model.DeliveryWindow) *model.OrderDetails); ok {
+		r1 = rf(ctx, order, order_details, redemption, slot)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*model.OrderDetails)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(context.Context, *model.Order, *model.OrderDetails) error); ok {
-		r2 = rf(ctx, order, order_details)
+	if rf, ok := ret.Get(2).(func(context.Context, *model.Order, *model.OrderDetails, *model.PromotionRedemption, *model.DeliveryWindow) error); ok {
+		r2 = rf(ctx, order, order_details, redemption, slot)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -101,6 +179,58 @@ func (_m *Repository) CreateProduct(ctx context.Context, product *model.Product)
 	return r0, r1
 }
 
+// CreatePromotion provides a mock function with given fields: ctx, promotion
+func (_m *Repository) CreatePromotion(ctx context.Context, promotion *model.Promotion) (*model.Promotion, error) {
+	ret := _m.Called(ctx, promotion)
+
+	var r0 *model.Promotion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Promotion) (*model.Promotion, error)); ok {
+		return rf(ctx, promotion)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Promotion) *model.Promotion); ok {
+		r0 = rf(ctx, promotion)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Promotion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Promotion) error); ok {
+		r1 = rf(ctx, promotion)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateShipment provides a mock function with given fields: ctx, shipment
+func (_m *Repository) CreateShipment(ctx context.Context, shipment *model.Shipment) (*model.Shipment, error) {
+	ret := _m.Called(ctx, shipment)
+
+	var r0 *model.Shipment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Shipment) (*model.Shipment, error)); ok {
+		return rf(ctx, shipment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Shipment) *model.Shipment); ok {
+		r0 = rf(ctx, shipment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Shipment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Shipment) error); ok {
+		r1 = rf(ctx, shipment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteCustomer provides a mock function with given fields: ctx, customerID
 func (_m *Repository) DeleteCustomer(ctx context.Context, customerID string) (*model.Customer, error) {
 	ret := _m.Called(ctx, customerID)
@@ -127,6 +257,32 @@ func (_m *Repository) DeleteCustomer(ctx context.Context, customerID string) (*m
 	return r0, r1
 }
 
+// DeleteCustomerAddress provides a mock function with given fields: ctx, customerId, addressId
+func (_m *Repository) DeleteCustomerAddress(ctx context.Context, customerId string, addressId string) (*model.CustomerAddress, error) {
+	ret := _m.Called(ctx, customerId, addressId)
+
+	var r0 *model.CustomerAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.CustomerAddress, error)); ok {
+		return rf(ctx, customerId, addressId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.CustomerAddress); ok {
+		r0 = rf(ctx, customerId, addressId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CustomerAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, customerId, addressId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteOrder provides a mock function with given fields: ctx, orderId
 func (_m *Repository) DeleteOrder(ctx context.Context, orderId string) (*model.Order, error) {
 	ret := _m.Called(ctx, orderId)
@@ -179,6 +335,32 @@ func (_m *Repository) DeleteProduct(ctx context.Context, productId string) (*mod
 	return r0, r1
 }
 
+// GetCustomerAddress provides a mock function with given fields: ctx, customerId, addressId
+func (_m *Repository) GetCustomerAddress(ctx context.Context, customerId string, addressId string) (*model.CustomerAddress, error) {
+	ret := _m.Called(ctx, customerId, addressId)
+
+	var r0 *model.CustomerAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.CustomerAddress, error)); ok {
+		return rf(ctx, customerId, addressId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.CustomerAddress); ok {
+		r0 = rf(ctx, customerId, addressId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CustomerAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, customerId, addressId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCustomerById provides a mock function with given fields: ctx, customerID
 func (_m *Repository) GetCustomerById(ctx context.Context, customerID string) (*model.Customer, error) {
 	ret := _m.Called(ctx, customerID)
@@ -205,6 +387,58 @@ func (_m *Repository) GetCustomerById(ctx context.Context, customerID string) (*
 	return r0, r1
 }
 
+// GetDefaultShippingAddress provides a mock function with given fields: ctx, customerId
+func (_m *Repository) GetDefaultShippingAddress(ctx context.Context, customerId string) (*model.CustomerAddress, error) {
+	ret := _m.Called(ctx, customerId)
+
+	var r0 *model.CustomerAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.CustomerAddress, error)); ok {
+		return rf(ctx, customerId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.CustomerAddress); ok {
+		r0 = rf(ctx, customerId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CustomerAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, customerId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoiceByOrderId provides a mock function with given fields: ctx, orderId
+func (_m *Repository) GetInvoiceByOrderId(ctx context.Context, orderId string) (*model.Invoice, error) {
+	ret := _m.Called(ctx, orderId)
+
+	var r0 *model.Invoice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Invoice, error)); ok {
+		return rf(ctx, orderId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Invoice); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Invoice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrderById provides a mock function with given fields: ctx, orderId
 func (_m *Repository) GetOrderById(ctx context.Context, orderId string) (*model.Order, error) {
 	ret := _m.Called(ctx, orderId)
@@ -361,25 +595,318 @@ func (_m *Repository) GetProductById(ctx context.Context, productId string) (*mo
 	return r0, r1
 }
 
-// UpdateCustomerFields provides a mock function with given fields: ctx, customerID, updateFields
-func (_m *Repository) UpdateCustomerFields(ctx context.Context, customerID string, updateFields map[string]interface{}) (*model.Customer, error) {
-	ret := _m.Called(ctx, customerID, updateFields)
+// GetPromotionByCode provides a mock function with given fields: ctx, code
+func (_m *Repository) GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error) {
+	ret := _m.Called(ctx, code)
+
+	var r0 *model.Promotion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Promotion, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Promotion); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Promotion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetShipmentByTrackingNumber provides a mock function with given fields: ctx, trackingNumber
+func (_m *Repository) GetShipmentByTrackingNumber(ctx context.Context, trackingNumber string) (*model.Shipment, error) {
+	ret := _m.Called(ctx, trackingNumber)
+
+	var r0 *model.Shipment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Shipment, error)); ok {
+		return rf(ctx, trackingNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Shipment); ok {
+		r0 = rf(ctx, trackingNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Shipment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, trackingNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetShippingRate provides a mock function with given fields: ctx, shippingMethod, zone
+func (_m *Repository) GetShippingRate(ctx context.Context, shippingMethod string, zone model.ShippingZone) (*model.ShippingRate, error) {
+	ret := _m.Called(ctx, shippingMethod, zone)
+
+	var r0 *model.ShippingRate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ShippingZone) (*model.ShippingRate, error)); ok {
+		return rf(ctx, shippingMethod, zone)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ShippingZone) *model.ShippingRate); ok {
+		r0 = rf(ctx, shippingMethod, zone)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ShippingRate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.ShippingZone) error); ok {
+		r1 = rf(ctx, shippingMethod, zone)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTaxRate provides a mock function with given fields: ctx, category
+func (_m *Repository) GetTaxRate(ctx context.Context, category model.ProductCategory) (*model.TaxRate, error) {
+	ret := _m.Called(ctx, category)
+
+	var r0 *model.TaxRate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ProductCategory) (*model.TaxRate, error)); ok {
+		return rf(ctx, category)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.ProductCategory) *model.TaxRate); ok {
+		r0 = rf(ctx, category)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.TaxRate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.ProductCategory) error); ok {
+		r1 = rf(ctx, category)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCustomerAddresses provides a mock function with given fields: ctx, customerId, limit, offset
+func (_m *Repository) ListCustomerAddresses(ctx context.Context, customerId string, limit int, offset int) ([]model.CustomerAddress, error) {
+	ret := _m.Called(ctx, customerId, limit, offset)
+
+	var r0 []model.CustomerAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]model.CustomerAddress, error)); ok {
+		return rf(ctx, customerId, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []model.CustomerAddress); ok {
+		r0 = rf(ctx, customerId, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomerAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, customerId, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCustomers provides a mock function with given fields: ctx, limit, offset
+func (_m *Repository) ListCustomers(ctx context.Context, limit int, offset int) ([]model.Customer, error) {
+	ret := _m.Called(ctx, limit, offset)
+
+	var r0 []model.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]model.Customer, error)); ok {
+		return rf(ctx, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []model.Customer); ok {
+		r0 = rf(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeliveryBookings provides a mock function with given fields: ctx, city, shippingMethod, from, to
+func (_m *Repository) ListDeliveryBookings(ctx context.Context, city string, shippingMethod string, from time.Time, to time.Time) ([]model.DeliveryBooking, error) {
+	ret := _m.Called(ctx, city, shippingMethod, from, to)
+
+	var r0 []model.DeliveryBooking
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time) ([]model.DeliveryBooking, error)); ok {
+		return rf(ctx, city, shippingMethod, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time) []model.DeliveryBooking); ok {
+		r0 = rf(ctx, city, shippingMethod, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeliveryBooking)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, city, shippingMethod, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeliverySlots provides a mock function with given fields: ctx, city, shippingMethod
+func (_m *Repository) ListDeliverySlots(ctx context.Context, city string, shippingMethod string) ([]model.DeliverySlot, error) {
+	ret := _m.Called(ctx, city, shippingMethod)
+
+	var r0 []model.DeliverySlot
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]model.DeliverySlot, error)); ok {
+		return rf(ctx, city, shippingMethod)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.DeliverySlot); ok {
+		r0 = rf(ctx, city, shippingMethod)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeliverySlot)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, city, shippingMethod)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListProducts provides a mock function with given fields: ctx, limit, offset
+func (_m *Repository) ListProducts(ctx context.Context, limit int, offset int) ([]model.Product, error) {
+	ret := _m.Called(ctx, limit, offset)
+
+	var r0 []model.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]model.Product, error)); ok {
+		return rf(ctx, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []model.Product); ok {
+		r0 = rf(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Product)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordShipmentEvent provides a mock function with given fields: ctx, shipment, event
+func (_m *Repository) RecordShipmentEvent(ctx context.Context, shipment *model.Shipment, event *model.ShipmentEvent) (*model.Shipment, bool, error) {
+	ret := _m.Called(ctx, shipment, event)
+
+	var r0 *model.Shipment
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Shipment, *model.ShipmentEvent) (*model.Shipment, bool, error)); ok {
+		return rf(ctx, shipment, event)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Shipment, *model.ShipmentEvent) *model.Shipment); ok {
+		r0 = rf(ctx, shipment, event)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Shipment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Shipment, *model.ShipmentEvent) bool); ok {
+		r1 = rf(ctx, shipment, event)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *model.Shipment, *model.ShipmentEvent) error); ok {
+		r2 = rf(ctx, shipment, event)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpdateCustomerAddress provides a mock function with given fields: ctx, customerId, addressId, updateFields
+func (_m *Repository) UpdateCustomerAddress(ctx context.Context, customerId string, addressId string, updateFields map[string]interface{}) (*model.CustomerAddress, error) {
+	ret := _m.Called(ctx, customerId, addressId, updateFields)
+
+	var r0 *model.CustomerAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]interface{}) (*model.CustomerAddress, error)); ok {
+		return rf(ctx, customerId, addressId, updateFields)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]interface{}) *model.CustomerAddress); ok {
+		r0 = rf(ctx, customerId, addressId, updateFields)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CustomerAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, map[string]interface{}) error); ok {
+		r1 = rf(ctx, customerId, addressId, updateFields)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCustomerFields provides a mock function with given fields: ctx, customerID, version, updateFields
+func (_m *Repository) UpdateCustomerFields(ctx context.Context, customerID string, version int64, updateFields map[string]interface{}) (*model.Customer, error) {
+	ret := _m.Called(ctx, customerID, version, updateFields)
 
 	var r0 *model.Customer
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) (*model.Customer, error)); ok {
-		return rf(ctx, customerID, updateFields)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, map[string]interface{}) (*model.Customer, error)); ok {
+		return rf(ctx, customerID, version, updateFields)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) *model.Customer); ok {
-		r0 = rf(ctx, customerID, updateFields)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, map[string]interface{}) *model.Customer); ok {
+		r0 = rf(ctx, customerID, version, updateFields)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Customer)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]interface{}) error); ok {
-		r1 = rf(ctx, customerID, updateFields)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, map[string]interface{}) error); ok {
+		r1 = rf(ctx, customerID, version, updateFields)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -387,25 +914,25 @@ func (_m *Repository) UpdateCustomerFields(ctx context.Context, customerID strin
 	return r0, r1
 }
 
-// UpdateOrder provides a mock function with given fields: ctx, orderId, updateFields
-func (_m *Repository) UpdateOrder(ctx context.Context, orderId string, updateFields map[string]interface{}) (*model.Order, error) {
-	ret := _m.Called(ctx, orderId, updateFields)
+// UpdateOrder provides a mock function with given fields: ctx, orderId, version, updateFields, slot
+func (_m *Repository) UpdateOrder(ctx context.Context, orderId string, version int64, updateFields map[string]interface{}, slot *model.DeliveryWindow) (*model.Order, error) {
+	ret := _m.Called(ctx, orderId, version, updateFields, slot)
 
 	var r0 *model.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) (*model.Order, error)); ok {
-		return rf(ctx, orderId, updateFields)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, map[string]interface{}, *model.DeliveryWindow) (*model.Order, error)); ok {
+		return rf(ctx, orderId, version, updateFields, slot)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) *model.Order); ok {
-		r0 = rf(ctx, orderId, updateFields)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, map[string]interface{}, *model.DeliveryWindow) *model.Order); ok {
+		r0 = rf(ctx, orderId, version, updateFields, slot)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]interface{}) error); ok {
-		r1 = rf(ctx, orderId, updateFields)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, map[string]interface{}, *model.DeliveryWindow) error); ok {
+		r1 = rf(ctx, orderId, version, updateFields, slot)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -413,25 +940,25 @@ func (_m *Repository) UpdateOrder(ctx context.Context, orderId string, updateFie
 	return r0, r1
 }
 
-// UpdateProductFields provides a mock function with given fields: ctx, productId, updateFields
-func (_m *Repository) UpdateProductFields(ctx context.Context, productId string, updateFields map[string]interface{}) (*model.Product, error) {
-	ret := _m.Called(ctx, productId, updateFields)
+// UpdateProductFields provides a mock function with given fields: ctx, productId, version, updateFields
+func (_m *Repository) UpdateProductFields(ctx context.Context, productId string, version int64, updateFields map[string]interface{}) (*model.Product, error) {
+	ret := _m.Called(ctx, productId, version, updateFields)
 
 	var r0 *model.Product
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) (*model.Product, error)); ok {
-		return rf(ctx, productId, updateFields)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, map[string]interface{}) (*model.Product, error)); ok {
+		return rf(ctx, productId, version, updateFields)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) *model.Product); ok {
-		r0 = rf(ctx, productId, updateFields)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, map[string]interface{}) *model.Product); ok {
+		r0 = rf(ctx, productId, version, updateFields)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Product)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]interface{}) error); ok {
-		r1 = rf(ctx, productId, updateFields)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, map[string]interface{}) error); ok {
+		r1 = rf(ctx, productId, version, updateFields)
 	} else {
 		r1 = ret.Error(1)
 	}
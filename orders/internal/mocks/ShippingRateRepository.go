@@ -0,0 +1,55 @@
+// Code generated by mockery v2.34.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/wathuta/technical_test/orders/internal/model"
+)
+
+// ShippingRateRepository is an autogenerated mock type for the ShippingRateRepository type
+type ShippingRateRepository struct {
+	mock.Mock
+}
+
+// GetShippingRate provides a mock function with given fields: ctx, shippingMethod, zone
+func (_m *ShippingRateRepository) GetShippingRate(ctx context.Context, shippingMethod string, zone model.ShippingZone) (*model.ShippingRate, error) {
+	ret := _m.Called(ctx, shippingMethod, zone)
+
+	var r0 *model.ShippingRate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ShippingZone) (*model.ShippingRate, error)); ok {
+		return rf(ctx, shippingMethod, zone)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ShippingZone) *model.ShippingRate); ok {
+		r0 = rf(ctx, shippingMethod, zone)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ShippingRate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.ShippingZone) error); ok {
+		r1 = rf(ctx, shippingMethod, zone)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewShippingRateRepository creates a new instance of ShippingRateRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewShippingRateRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ShippingRateRepository {
+	mock := &ShippingRateRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.34.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/wathuta/technical_test/orders/internal/model"
+
+	time "time"
+)
+
+// SlotRepository is an autogenerated mock type for the SlotRepository type
+type SlotRepository struct {
+	mock.Mock
+}
+
+// ListDeliveryBookings provides a mock function with given fields: ctx, city, shippingMethod, from, to
+func (_m *SlotRepository) ListDeliveryBookings(ctx context.Context, city string, shippingMethod string, from time.Time, to time.Time) ([]model.DeliveryBooking, error) {
+	ret := _m.Called(ctx, city, shippingMethod, from, to)
+
+	var r0 []model.DeliveryBooking
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time) ([]model.DeliveryBooking, error)); ok {
+		return rf(ctx, city, shippingMethod, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time) []model.DeliveryBooking); ok {
+		r0 = rf(ctx, city, shippingMethod, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeliveryBooking)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, city, shippingMethod, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeliverySlots provides a mock function with given fields: ctx, city, shippingMethod
+func (_m *SlotRepository) ListDeliverySlots(ctx context.Context, city string, shippingMethod string) ([]model.DeliverySlot, error) {
+	ret := _m.Called(ctx, city, shippingMethod)
+
+	var r0 []model.DeliverySlot
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]model.DeliverySlot, error)); ok {
+		return rf(ctx, city, shippingMethod)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.DeliverySlot); ok {
+		r0 = rf(ctx, city, shippingMethod)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeliverySlot)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, city, shippingMethod)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewSlotRepository creates a new instance of SlotRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSlotRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SlotRepository {
+	mock := &SlotRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	customerspb "github.com/wathuta/technical_test/protos_gen/customers"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CustomerAddress is an address saved in the address book of a customer. The address is stored as
+// the same JSON object as the addresses of orders.
+type CustomerAddress struct {
+	AddressID       string    `validate:"required,uuid" db:"address_id"`
+	CustomerID      string    `validate:"required,uuid" db:"customer_id"`
+	Label           string    `validate:"max=64" db:"label"`
+	Address         Address   `validate:"required" db:"address"`
+	DefaultShipping bool      `db:"default_shipping"`
+	DefaultBilling  bool      `db:"default_billing"`
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// AddressFromProto returns the address of a customer as stored on orders.
+func AddressFromProto(e *customerspb.Address) Address {
+	if e == nil {
+		return Address{}
+	}
+	return Address{
+		Street:     e.Street,
+		City:       e.City,
+		State:      e.State,
+		PostalCode: e.PostalCode,
+		Country:    e.Country,
+	}
+}
+
+func CustomerAddressFromProto(e *customerspb.CustomerAddress) *CustomerAddress {
+	return &CustomerAddress{
+		AddressID:       e.AddressId,
+		CustomerID:      e.CustomerId,
+		Label:           e.Label,
+		Address:         AddressFromProto(e.Address),
+		DefaultShipping: e.DefaultShipping,
+		DefaultBilling:  e.DefaultBilling,
+	}
+}
+
+func (a *CustomerAddress) Proto() *customerspb.CustomerAddress {
+	return &customerspb.CustomerAddress{
+		AddressId:  a.AddressID,
+		CustomerId: a.CustomerID,
+		Label:      a.Label,
+		Address: &customerspb.Address{
+			Street:     a.Address.Street,
+			City:       a.Address.City,
+			State:      a.Address.State,
+			PostalCode: a.Address.PostalCode,
+			Country:    a.Address.Country,
+		},
+		DefaultShipping: a.DefaultShipping,
+		DefaultBilling:  a.DefaultBilling,
+		CreatedAt:       timestamppb.New(a.CreatedAt),
+		UpdatedAt:       timestamppb.New(a.UpdatedAt),
+	}
+}
+
+// UpdateCustomerAddressMapping returns the columns of address updated by updateFields. A sub-field
+// of the address is merged into the stored address with a JSONMerge.
+func UpdateCustomerAddressMapping(updateFields []string, address CustomerAddress) map[string]interface{} {
+	updateValues := make(map[string]interface{})
+	for _, updateField := range updateFields {
+		if updateField == "label" {
+			updateValues[updateField] = address.Label
+		}
+		if updateField == "address" {
+			updateValues[updateField] = address.Address
+		}
+		if field, ok := strings.CutPrefix(updateField, "address."); ok {
+			mergeAddressField(updateValues, "address", field, address.Address)
+		}
+		if updateField == "default_shipping" {
+			updateValues[updateField] = address.DefaultShipping
+		}
+		if updateField == "default_billing" {
+			updateValues[updateField] = address.DefaultBilling
+		}
+	}
+	return updateValues
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	customerspb "github.com/wathuta/technical_test/protos_gen/customers"
+)
+
+func TestCustomerAddressFromProto(t *testing.T) {
+	address := CustomerAddressFromProto(&customerspb.CustomerAddress{
+		AddressId:       "a1",
+		CustomerId:      "c1",
+		Label:           "Home",
+		Address:         &customerspb.Address{Street: "1 Moi Ave", City: "Nairobi", State: "Nairobi", PostalCode: "00100", Country: "KE"},
+		DefaultShipping: true,
+	})
+
+	assert.Equal(t, "a1", address.AddressID)
+	assert.Equal(t, "c1", address.CustomerID)
+	assert.Equal(t, "Home", address.Label)
+	assert.Equal(t, Address{Street: "1 Moi Ave", City: "Nairobi", State: "Nairobi", PostalCode: "00100", Country: "KE"}, address.Address)
+	assert.True(t, address.DefaultShipping)
+	assert.False(t, address.DefaultBilling)
+
+	// a missing address is left empty for the validator to reject
+	assert.Equal(t, Address{}, CustomerAddressFromProto(&customerspb.CustomerAddress{}).Address)
+}
+
+func TestCustomerAddressProto(t *testing.T) {
+	address := &CustomerAddress{
+		AddressID:      "a1",
+		CustomerID:     "c1",
+		Label:          "Office",
+		Address:        Address{Street: "2 Kenyatta Ave", City: "Nakuru", Country: "KE"},
+		DefaultBilling: true,
+		CreatedAt:      time.Now(),
+	}
+
+	protoAddress := address.Proto()
+
+	assert.Equal(t, "a1", protoAddress.AddressId)
+	assert.Equal(t, "c1", protoAddress.CustomerId)
+	assert.Equal(t, "Office", protoAddress.Label)
+	assert.Equal(t, "Nakuru", protoAddress.Address.City)
+	assert.True(t, protoAddress.DefaultBilling)
+	assert.Equal(t, address.CreatedAt.Unix(), protoAddress.CreatedAt.AsTime().Unix())
+}
+
+func TestUpdateCustomerAddressMapping(t *testing.T) {
+	address := CustomerAddress{
+		Label:           "Home",
+		Address:         Address{Street: "1 Moi Ave", City: "Nairobi"},
+		DefaultShipping: true,
+	}
+
+	updateValues := UpdateCustomerAddressMapping([]string{"label", "address.city", "default_shipping"}, address)
+
+	assert.Equal(t, map[string]interface{}{
+		"label":            "Home",
+		"address":          JSONMerge{"city": "Nairobi"},
+		"default_shipping": true,
+	}, updateValues)
+
+	updateValues = UpdateCustomerAddressMapping([]string{"address"}, address)
+	assert.Equal(t, map[string]interface{}{"address": address.Address}, updateValues)
+}
@@ -16,6 +16,7 @@ type Customer struct {
 	CreatedAt   time.Time `db:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
 	DeletedAt   time.Time `db:"deleted_at"`
+	Version     int64     `db:"version"` // incremented by every update
 	// Add more fields as needed for customers.
 }
 
@@ -25,6 +26,7 @@ func CustomerFromProto(e *customerspb.Customer) *Customer {
 		Email:       e.Email,
 		PhoneNumber: e.PhoneNumber,
 		Address:     e.Address,
+		Version:     e.Version,
 	}
 }
 func (c *Customer) Proto() *customerspb.Customer {
@@ -37,6 +39,7 @@ func (c *Customer) Proto() *customerspb.Customer {
 		Address:     c.Address,
 		CreatedAt:   timestamppb.New(c.CreatedAt),
 		UpdatedAt:   timestamppb.New(c.UpdatedAt),
+		Version:     c.Version,
 	}
 }
 
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	// the time zones of the delivery slots are loaded from the embedded database so they do not
+	// depend on the zoneinfo of the host
+	_ "time/tzdata"
+)
+
+var ErrDeliverySlotFull = errors.New("delivery slot is fully booked")
+
+// slotTimeLayout is the layout of the times of day of delivery slots, as postgres prints a TIME.
+const slotTimeLayout = "15:04:05"
+
+// DeliverySlot is a window orders to a city are delivered in with a shipping method, it is offered
+// every day.
+type DeliverySlot struct {
+	SlotID         string `db:"slot_id"`
+	City           string `db:"city"`
+	ShippingMethod string `db:"shipping_method"`
+	StartTime      string `db:"start_time"` // time of day in TimeZone e.g 09:00:00
+	EndTime        string `db:"end_time"`
+	TimeZone       string `db:"time_zone"`
+	Capacity       int64  `db:"capacity"` // orders delivered in the slot on a day
+}
+
+// DeliveryCity returns the city of address as the delivery slots store it.
+func DeliveryCity(address Address) string {
+	return strings.ToLower(strings.TrimSpace(address.City))
+}
+
+// On returns the window of the slot on the day t falls on in the time zone of the slot.
+func (s DeliverySlot) On(t time.Time) (DeliveryWindow, error) {
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return DeliveryWindow{}, fmt.Errorf("delivery slot %s: %w", s.SlotID, err)
+	}
+	start, err := time.Parse(slotTimeLayout, s.StartTime)
+	if err != nil {
+		return DeliveryWindow{}, fmt.Errorf("delivery slot %s: invalid start time: %w", s.SlotID, err)
+	}
+	end, err := time.Parse(slotTimeLayout, s.EndTime)
+	if err != nil {
+		return DeliveryWindow{}, fmt.Errorf("delivery slot %s: invalid end time: %w", s.SlotID, err)
+	}
+
+	year, month, day := t.In(location).Date()
+	return DeliveryWindow{
+		Slot:  s,
+		Start: time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), 0, location),
+		End:   time.Date(year, month, day, end.Hour(), end.Minute(), end.Second(), 0, location),
+	}, nil
+}
+
+// DeliveryWindow is a delivery slot on a day and the orders booked in it.
+type DeliveryWindow struct {
+	Slot   DeliverySlot
+	Start  time.Time
+	End    time.Time
+	Booked int64
+}
+
+// Contains reports whether an order delivered at t is delivered in the window.
+func (w DeliveryWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Remaining returns the number of orders that can still be delivered in the window.
+func (w DeliveryWindow) Remaining() int64 {
+	if w.Booked >= w.Slot.Capacity {
+		return 0
+	}
+	return w.Slot.Capacity - w.Booked
+}
+
+func (w DeliveryWindow) Proto() *orderspb.DeliverySlot {
+	return &orderspb.DeliverySlot{
+		SlotId:            w.Slot.SlotID,
+		StartTime:         timestamppb.New(w.Start),
+		EndTime:           timestamppb.New(w.End),
+		RemainingCapacity: int32(w.Remaining()),
+	}
+}
+
+// DeliveryBooking is an order delivered in a delivery slot.
+type DeliveryBooking struct {
+	SlotID     string    `db:"delivery_slot_id"`
+	DeliveryAt time.Time `db:"scheduled_delivery_datetime"`
+}
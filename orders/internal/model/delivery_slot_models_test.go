@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var morningSlot = DeliverySlot{
+	SlotID:    "nairobi-standard-morning",
+	StartTime: "09:00:00",
+	EndTime:   "13:00:00",
+	TimeZone:  "Africa/Nairobi",
+	Capacity:  2,
+}
+
+func TestDeliverySlotOn(t *testing.T) {
+	// 22:30 UTC is already the next day in Nairobi
+	window, err := morningSlot.On(time.Date(2023, 12, 10, 22, 30, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.True(t, time.Date(2023, 12, 11, 6, 0, 0, 0, time.UTC).Equal(window.Start))
+	assert.True(t, time.Date(2023, 12, 11, 10, 0, 0, 0, time.UTC).Equal(window.End))
+	assert.True(t, window.Contains(window.Start))
+	assert.False(t, window.Contains(window.End))
+}
+
+func TestDeliverySlotOn_Invalid(t *testing.T) {
+	slot := morningSlot
+	slot.TimeZone = "Africa/Atlantis"
+	_, err := slot.On(time.Now())
+	assert.Error(t, err)
+
+	slot = morningSlot
+	slot.EndTime = "1pm"
+	_, err = slot.On(time.Now())
+	assert.Error(t, err)
+}
+
+func TestDeliveryWindowRemaining(t *testing.T) {
+	window := DeliveryWindow{Slot: morningSlot, Booked: 1}
+	assert.Equal(t, int64(1), window.Remaining())
+	assert.Equal(t, int32(1), window.Proto().RemainingCapacity)
+
+	// slots can be booked beyond a capacity that was lowered
+	window.Booked = 3
+	assert.Equal(t, int64(0), window.Remaining())
+}
+
+func TestDeliveryCity(t *testing.T) {
+	assert.Equal(t, "nairobi", DeliveryCity(Address{City: " Nairobi "}))
+}
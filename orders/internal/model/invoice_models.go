@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Invoice is the rendered invoice of an order.
+type Invoice struct {
+	OrderID       string    `db:"order_id"`
+	InvoiceNumber string    `db:"invoice_number"`
+	HTML          string    `db:"html"`
+	PDF           []byte    `db:"pdf"`
+	CreatedAt     time.Time `db:"created_at"`
+	// Paid is true once the order has been paid for, only paid invoices are stored.
+	Paid bool `db:"-"`
+}
+
+func (i *Invoice) Proto() *orderspb.GetInvoiceResponse {
+	return &orderspb.GetInvoiceResponse{
+		OrderId:       i.OrderID,
+		InvoiceNumber: i.InvoiceNumber,
+		Paid:          i.Paid,
+		Html:          i.HTML,
+		Pdf:           i.PDF,
+		CreatedAt:     timestamppb.New(i.CreatedAt),
+	}
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	KES Currency = "KES"
+	USD Currency = "USD"
+)
+
+// DefaultCurrency is the currency used when a price is created without one.
+const DefaultCurrency = KES
+
+var ErrCurrencyMismatch = errors.New("money values have different currencies")
+
+// Money is an amount in the minor unit of its currency (e.g cents) so that prices
+// can be added and multiplied without floating point rounding errors.
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func NewMoney(amount int64, currency Currency) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+func MoneyFromProto(m *moneypb.Money) Money {
+	if m == nil {
+		return Money{Currency: DefaultCurrency}
+	}
+	return NewMoney(m.Amount, Currency(m.CurrencyCode))
+}
+
+func (m Money) Proto() *moneypb.Money {
+	return &moneypb.Money{
+		CurrencyCode: string(m.Currency),
+		Amount:       m.Amount,
+	}
+}
+
+// Add returns the sum of m and other. Both values must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Multiply returns m multiplied by a quantity e.g the line total of a product.
+func (m Money) Multiply(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// String formats the amount in major units e.g "KES 10.50".
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s %s%d.%02d", m.Currency, sign, amount/100, amount%100)
+}
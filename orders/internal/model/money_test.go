@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
+)
+
+func TestMoneyFromProto(t *testing.T) {
+	m := MoneyFromProto(&moneypb.Money{CurrencyCode: "USD", Amount: 1050})
+	assert.Equal(t, Money{Amount: 1050, Currency: USD}, m)
+
+	// a missing value is zero in the default currency
+	m = MoneyFromProto(nil)
+	assert.Equal(t, Money{Amount: 0, Currency: DefaultCurrency}, m)
+}
+
+func TestMoneyProto(t *testing.T) {
+	m := NewMoney(1050, KES).Proto()
+	assert.Equal(t, "KES", m.CurrencyCode)
+	assert.Equal(t, int64(1050), m.Amount)
+}
+
+func TestMoneyAdd(t *testing.T) {
+	// 0.1 + 0.2 is exact when using minor units
+	sum, err := NewMoney(10, KES).Add(NewMoney(20, KES))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(30, KES), sum)
+
+	_, err = NewMoney(10, KES).Add(NewMoney(20, USD))
+	assert.True(t, errors.Is(err, ErrCurrencyMismatch))
+}
+
+func TestMoneyMultiply(t *testing.T) {
+	assert.Equal(t, NewMoney(2997, KES), NewMoney(999, KES).Multiply(3))
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "KES 10.50", NewMoney(1050, KES).String())
+	assert.Equal(t, "KES 0.05", NewMoney(5, KES).String())
+	assert.Equal(t, "USD -1.25", NewMoney(-125, USD).String())
+}
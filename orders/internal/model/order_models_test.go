@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
 	orderspb "github.com/wathuta/technical_test/protos_gen/orders" // Import your ordersPb package
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -19,7 +20,7 @@ func TestOrderFromProto(t *testing.T) {
 		TrackingNumber:            "789",
 		PaymentMethod:             orderspb.PaymentMethod_PAYMENT_METHOD_MPESA,
 		InvoiceNumber:             "INV123",
-		ShippingCost:              15.0,
+		ShippingCost:              &moneypb.Money{CurrencyCode: "KES", Amount: 1500},
 		SpecialInstructions:       "Fragile",
 		ScheduledPickupDatetime:   timestamppb.Now(),
 		ScheduledDeliveryDatetime: timestamppb.Now(),
@@ -37,8 +38,13 @@ func TestOrderFromProto(t *testing.T) {
 	assert.Equal(t, "789", order.TrackingNumber)
 	assert.Equal(t, PaymentMethod(orderspb.PaymentMethod_PAYMENT_METHOD_MPESA.String()), order.PaymentMethod)
 	assert.Equal(t, "INV123", order.InvoiceNumber)
-	assert.Equal(t, 15.0, order.ShippingCost)
+	assert.Equal(t, int64(1500), order.ShippingCost)
+	assert.Equal(t, KES, order.Currency)
 	assert.Equal(t, "Fragile", order.SpecialInstructions)
+
+	// an update may only send the delivery address
+	order = OrderFromProto(&orderspb.Order{DeliveryAddress: &orderspb.Address{City: "Mombasa", PostalCode: "80100"}})
+	assert.Equal(t, Address{City: "Mombasa", PostalCode: "80100"}, order.DeliveryAddress)
 }
 
 func TestOrderProto(t *testing.T) {
@@ -51,7 +57,8 @@ func TestOrderProto(t *testing.T) {
 		TrackingNumber:            "456",
 		PaymentMethod:             PaymentMethodCreditCard,
 		InvoiceNumber:             "INV456",
-		ShippingCost:              10.0,
+		ShippingCost:              1000,
+		Currency:                  USD,
 		SpecialInstructions:       "Handle with care",
 		ScheduledPickupDatetime:   time.Now(),
 		ScheduledDeliveryDatetime: time.Now(),
@@ -67,7 +74,8 @@ func TestOrderProto(t *testing.T) {
 	assert.Equal(t, "Standard", protoOrder.ShippingMethod)
 	assert.Equal(t, orderspb.OrderStatus_ORDER_STATUS_DELIVERED, protoOrder.OrderStatus)
 	assert.Equal(t, "456", protoOrder.TrackingNumber)
-	assert.Equal(t, 10.0, protoOrder.ShippingCost)
+	assert.Equal(t, int64(1000), protoOrder.ShippingCost.Amount)
+	assert.Equal(t, "USD", protoOrder.ShippingCost.CurrencyCode)
 	assert.Equal(t, "INV456", protoOrder.InvoiceNumber)
 	assert.Equal(t, "Handle with care", protoOrder.SpecialInstructions)
 }
@@ -85,7 +93,8 @@ func TestUpdateOrderMaping(t *testing.T) {
 		PaymentMethod:             PaymentMethodMpesa,
 		InvoiceNumber:             "INV789",
 		SpecialInstructions:       "Fragile",
-		ShippingCost:              20.0,
+		ShippingCost:              2000,
+		Currency:                  KES,
 	}
 
 	// Define the fields to update
@@ -101,3 +110,93 @@ func TestUpdateOrderMaping(t *testing.T) {
 	assert.Equal(t, "Express", updateValues["shipping_method"])
 	assert.Equal(t, "Fragile", updateValues["special_instructions"])
 }
+
+func TestUpdateOrderMaping_AddressFields(t *testing.T) {
+	order := Order{
+		PickupAddress:   Address{Street: "123 Main St", City: "Cityville"},
+		DeliveryAddress: Address{Street: "456 Elm St", City: "Townsville", PostalCode: "67890"},
+	}
+
+	updateValues := UpdateOrderMaping([]string{"pickup_address.city", "delivery_address.city", "delivery_address.postal_code"}, order)
+
+	assert.Equal(t, map[string]interface{}{
+		"pickup_address":   JSONMerge{"city": "Cityville"},
+		"delivery_address": JSONMerge{"city": "Townsville", "postal_code": "67890"},
+	}, updateValues)
+
+	// the whole address replaces its fields whatever their order
+	updateValues = UpdateOrderMaping([]string{"pickup_address.city", "pickup_address", "pickup_address.street"}, order)
+	assert.Equal(t, map[string]interface{}{"pickup_address": order.PickupAddress}, updateValues)
+}
+
+func TestMergeOrder(t *testing.T) {
+	current := Order{
+		OrderID:         "123",
+		ShippingMethod:  "standard",
+		PickupAddress:   Address{Street: "1 Moi Ave", City: "Nairobi", Country: "Kenya"},
+		DeliveryAddress: Address{Street: "456 Elm St", City: "Nairobi", Country: "Kenya"},
+		ShippingCost:    45000,
+	}
+	update := Order{
+		ShippingMethod:  "express",
+		PickupAddress:   Address{City: "Mombasa"},
+		DeliveryAddress: Address{Street: "7 Kampala Rd", City: "Kampala", Country: "Uganda"},
+		ShippingCost:    1,
+	}
+
+	merged := MergeOrder(current, []string{"shipping_method", "pickup_address.city", "delivery_address"}, update)
+
+	assert.Equal(t, "express", merged.ShippingMethod)
+	assert.Equal(t, Address{Street: "1 Moi Ave", City: "Mombasa", Country: "Kenya"}, merged.PickupAddress)
+	assert.Equal(t, update.DeliveryAddress, merged.DeliveryAddress)
+	// fields that are not updated are kept
+	assert.Equal(t, "123", merged.OrderID)
+	assert.Equal(t, int64(45000), merged.ShippingCost)
+	assert.Equal(t, "Nairobi", current.PickupAddress.City)
+}
+
+func TestAddress_Value(t *testing.T) {
+	value, err := Address{Street: "1 Moi Ave", City: "Nairobi", Country: "KE"}.Value()
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"street":"1 Moi Ave","city":"Nairobi","state":"","postal_code":"","country":"KE"}`, string(value.([]byte)))
+}
+
+func TestOrder_ApplyPricing(t *testing.T) {
+	order := &Order{}
+	order.ApplyPricing(&OrderPricing{
+		Subtotal:   NewMoney(2000, KES),
+		Shipping:   NewMoney(500, KES),
+		Tax:        NewMoney(320, KES),
+		GrandTotal: NewMoney(2820, KES),
+	})
+
+	assert.Equal(t, int64(2000), order.Subtotal)
+	assert.Equal(t, int64(500), order.ShippingCost)
+	assert.Equal(t, int64(320), order.Tax)
+	assert.Equal(t, int64(2820), order.GrandTotal)
+	assert.Equal(t, KES, order.Currency)
+
+	protoOrder := order.Proto()
+	assert.Equal(t, int64(2820), protoOrder.GrandTotal.Amount)
+	assert.Equal(t, "KES", protoOrder.GrandTotal.CurrencyCode)
+}
+
+func TestOrderDetails_ApplyLine(t *testing.T) {
+	details := &OrderDetails{}
+	details.ApplyLine(LineItem{
+		Quantity:  2,
+		UnitPrice: NewMoney(1000, KES),
+		Total:     NewMoney(2000, KES),
+		TaxRate:   TaxRate{RateBasisPoints: 1600},
+		Tax:       NewMoney(320, KES),
+	})
+
+	protoDetails := details.Proto()
+	assert.Equal(t, int64(1000), protoDetails.UnitPrice.Amount)
+	assert.Equal(t, int64(2000), protoDetails.LineTotal.Amount)
+	assert.Equal(t, int32(1600), protoDetails.TaxRateBasisPoints)
+	assert.False(t, protoDetails.TaxExempt)
+	assert.Equal(t, int64(320), protoDetails.Tax.Amount)
+	assert.Equal(t, "KES", protoDetails.Tax.CurrencyCode)
+}
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
@@ -58,12 +60,21 @@ type Order struct {
 	ScheduledDeliveryDatetime time.Time     `validate:"required" db:"scheduled_delivery_datetime"`
 	TrackingNumber            string        `validate:"required" db:"tracking_number"`
 	PaymentMethod             PaymentMethod `validate:"required" db:"payment_method"`
-	InvoiceNumber             string        `validate:"required" db:"invoice_number"`
+	InvoiceNumber             string        `db:"invoice_number"` // assigned when the order is stored
 	SpecialInstructions       string        `db:"special_instructions"`
-	ShippingCost              float64       `validate:"required" db:"shipping_cost"`
+	ShippingCost              int64         `validate:"required" db:"shipping_cost"` // in the minor unit of Currency e.g cents
 	CreatedAt                 time.Time     `db:"created_at"`
 	UpdatedAt                 time.Time     `db:"updated_at"`
 	DeletedAt                 time.Time     `db:"deleted_at"`
+	Currency                  Currency      `validate:"required,len=3" db:"currency"`
+	Subtotal                  int64         `db:"subtotal"`    // in the minor unit of Currency e.g cents
+	Tax                       int64         `db:"tax"`         // in the minor unit of Currency e.g cents
+	GrandTotal                int64         `db:"grand_total"` // in the minor unit of Currency e.g cents
+	TaxBreakdown              TaxBreakdown  `db:"tax_breakdown"`
+	Discount                  int64         `db:"discount"`         // in the minor unit of Currency e.g cents
+	PromoCode                 string        `db:"promo_code"`       // the promotion redeemed on the order if any
+	Version                   int64         `db:"version"`          // incremented by every update
+	DeliverySlotID            string        `db:"delivery_slot_id"` // empty when the delivery city has no delivery slots
 	// Add more fields as needed for orders.
 }
 
@@ -76,6 +87,14 @@ type OrderDetails struct {
 	CreatedAt      time.Time `db:"created_at"`
 	UpdatedAt      time.Time `db:"updated_at"`
 	DeletedAt      time.Time `db:"deleted_at"`
+	// the price and tax charged when the order was placed in the minor unit of Currency e.g cents
+	UnitPrice          int64    `db:"unit_price"`
+	LineTotal          int64    `db:"line_total"`
+	TaxRateBasisPoints int64    `db:"tax_rate_basis_points"`
+	TaxExempt          bool     `db:"tax_exempt"`
+	Tax                int64    `db:"tax"`
+	Currency           Currency `db:"currency"`
+	Discount           int64    `db:"discount"`
 	// Add more fields as needed for order details.
 }
 
@@ -89,11 +108,15 @@ func OrderFromProto(req *orderspb.Order) *Order {
 		TrackingNumber:            req.TrackingNumber,
 		PaymentMethod:             PaymentMethod(req.PaymentMethod.String()),
 		InvoiceNumber:             req.InvoiceNumber,
-		ShippingCost:              req.ShippingCost,
 		SpecialInstructions:       req.SpecialInstructions,
 		ScheduledPickupDatetime:   req.ScheduledPickupDatetime.AsTime(),
 		ScheduledDeliveryDatetime: req.ScheduledDeliveryDatetime.AsTime(),
+		Version:                   req.Version,
 	}
+	shippingCost := MoneyFromProto(req.ShippingCost)
+	order.ShippingCost = shippingCost.Amount
+	order.Currency = shippingCost.Currency
+
 	if req.PickupAddress != nil {
 		order.PickupAddress = Address{
 			Street:     req.PickupAddress.Street,
@@ -110,7 +133,7 @@ func OrderFromProto(req *orderspb.Order) *Order {
 			City:       req.DeliveryAddress.City,
 			State:      req.DeliveryAddress.State,
 			Country:    req.DeliveryAddress.Country,
-			PostalCode: req.PickupAddress.PostalCode,
+			PostalCode: req.DeliveryAddress.PostalCode,
 		}
 	}
 	return order
@@ -137,7 +160,7 @@ func (o *Order) Proto() *orderspb.Order {
 		ShippingMethod:            o.ShippingMethod,
 		OrderStatus:               orderspb.OrderStatus(orderspb.OrderStatus_value[string(o.OrderStatus)]),
 		TrackingNumber:            o.TrackingNumber,
-		ShippingCost:              o.ShippingCost,
+		ShippingCost:              o.Shipping().Proto(),
 		InvoiceNumber:             o.InvoiceNumber,
 		PaymentMethod:             orderspb.PaymentMethod(orderspb.PaymentMethod_value[string(o.PaymentMethod)]),
 		SpecialInstructions:       o.SpecialInstructions,
@@ -146,18 +169,59 @@ func (o *Order) Proto() *orderspb.Order {
 		CreatedAt:                 timestamppb.New(o.CreatedAt),
 		UpdatedAt:                 timestamppb.New(o.UpdatedAt),
 		DeletedAt:                 timestamppb.New(o.DeletedAt),
+		Subtotal:                  NewMoney(o.Subtotal, o.Currency).Proto(),
+		Tax:                       NewMoney(o.Tax, o.Currency).Proto(),
+		GrandTotal:                NewMoney(o.GrandTotal, o.Currency).Proto(),
+		TaxBreakdown:              o.TaxBreakdown.Proto(o.Currency),
+		Discount:                  NewMoney(o.Discount, o.Currency).Proto(),
+		PromoCode:                 o.PromoCode,
+		Version:                   o.Version,
+		DeliverySlotId:            o.DeliverySlotID,
 	}
 }
 
+// Shipping returns the shipping cost of the order.
+func (o *Order) Shipping() Money {
+	return NewMoney(o.ShippingCost, o.Currency)
+}
+
+// ApplyPricing sets the amounts charged for the order.
+func (o *Order) ApplyPricing(p *OrderPricing) {
+	o.Currency = p.GrandTotal.Currency
+	o.Subtotal = p.Subtotal.Amount
+	o.ShippingCost = p.Shipping.Amount
+	o.Tax = p.Tax.Amount
+	o.GrandTotal = p.GrandTotal.Amount
+	o.TaxBreakdown = p.TaxBreakdown
+	o.Discount = p.Discount.Amount
+}
+
+// ApplyLine sets the price and tax charged for the order line.
+func (od *OrderDetails) ApplyLine(line LineItem) {
+	od.Currency = line.UnitPrice.Currency
+	od.UnitPrice = line.UnitPrice.Amount
+	od.LineTotal = line.Total.Amount
+	od.TaxRateBasisPoints = line.TaxRate.RateBasisPoints
+	od.TaxExempt = line.TaxRate.Exempt
+	od.Tax = line.Tax.Amount
+	od.Discount = line.Discount.Amount
+}
+
 func (od *OrderDetails) Proto() *orderspb.OrderDetails {
 	return &orderspb.OrderDetails{
-		OrderDetailsId:  od.OrderDetailsID,
-		OrderId:         od.OrderID,
-		ProductId:       od.ProductID,
-		ProductQuantity: od.Quantity,
-		CreatedAt:       timestamppb.New(od.CreatedAt),
-		UpdatedAt:       timestamppb.New(od.UpdatedAt),
-		DeletedAt:       timestamppb.New(od.DeletedAt),
+		OrderDetailsId:     od.OrderDetailsID,
+		OrderId:            od.OrderID,
+		ProductId:          od.ProductID,
+		ProductQuantity:    od.Quantity,
+		CreatedAt:          timestamppb.New(od.CreatedAt),
+		UpdatedAt:          timestamppb.New(od.UpdatedAt),
+		DeletedAt:          timestamppb.New(od.DeletedAt),
+		UnitPrice:          NewMoney(od.UnitPrice, od.Currency).Proto(),
+		LineTotal:          NewMoney(od.LineTotal, od.Currency).Proto(),
+		TaxRateBasisPoints: int32(od.TaxRateBasisPoints),
+		TaxExempt:          od.TaxExempt,
+		Tax:                NewMoney(od.Tax, od.Currency).Proto(),
+		Discount:           NewMoney(od.Discount, od.Currency).Proto(),
 	}
 }
 
@@ -170,6 +234,95 @@ func (a *Address) Scan(value interface{}) error {
 	return json.Unmarshal(b, &a)
 }
 
+// Value stores the address as a JSON object.
+func (a Address) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// member returns the value of the address field named by its JSON name.
+func (a Address) member(name string) string {
+	switch name {
+	case "street":
+		return a.Street
+	case "city":
+		return a.City
+	case "state":
+		return a.State
+	case "postal_code":
+		return a.PostalCode
+	case "country":
+		return a.Country
+	}
+	return ""
+}
+
+// setMember sets the value of the address field named by its JSON name.
+func (a *Address) setMember(name, value string) {
+	switch name {
+	case "street":
+		a.Street = value
+	case "city":
+		a.City = value
+	case "state":
+		a.State = value
+	case "postal_code":
+		a.PostalCode = value
+	case "country":
+		a.Country = value
+	}
+}
+
+// JSONMerge holds the members an update merges into a JSONB object column, the members it does
+// not hold are kept.
+type JSONMerge map[string]interface{}
+
+// Value stores the members as a JSON object.
+func (m JSONMerge) Value() (driver.Value, error) {
+	return json.Marshal(map[string]interface{}(m))
+}
+
+// mergeAddressField sets the update of column to merge the field of address into it, unless the
+// update already replaces the whole address.
+func mergeAddressField(updateValues map[string]interface{}, column, field string, address Address) {
+	merge, ok := updateValues[column].(JSONMerge)
+	if !ok {
+		if _, replaced := updateValues[column]; replaced {
+			return
+		}
+		merge = JSONMerge{}
+		updateValues[column] = merge
+	}
+	merge[field] = address.member(field)
+}
+
+// MergeOrder returns current with the fields named by updateFields taken from update, which is the
+// order once the update is applied. Only the fields that decide how an order is shipped are merged.
+func MergeOrder(current Order, updateFields []string, update Order) Order {
+	for _, updateField := range updateFields {
+		switch updateField {
+		case "pickup_address":
+			current.PickupAddress = update.PickupAddress
+		case "delivery_address":
+			current.DeliveryAddress = update.DeliveryAddress
+		case "shipping_method":
+			current.ShippingMethod = update.ShippingMethod
+		case "scheduled_pickup_datetime":
+			current.ScheduledPickupDatetime = update.ScheduledPickupDatetime
+		case "scheduled_delivery_datetime":
+			current.ScheduledDeliveryDatetime = update.ScheduledDeliveryDatetime
+		}
+		if field, ok := strings.CutPrefix(updateField, "pickup_address."); ok {
+			current.PickupAddress.setMember(field, update.PickupAddress.member(field))
+		}
+		if field, ok := strings.CutPrefix(updateField, "delivery_address."); ok {
+			current.DeliveryAddress.setMember(field, update.DeliveryAddress.member(field))
+		}
+	}
+	return current
+}
+
+// UpdateOrderMaping returns the columns of order updated by updateFields. A sub-field of an
+// address is merged into the stored address with a JSONMerge.
 func UpdateOrderMaping(updateFields []string, order Order) map[string]interface{} {
 	updateValues := make(map[string]interface{})
 	for _, updateField := range updateFields {
@@ -179,6 +332,12 @@ func UpdateOrderMaping(updateFields []string, order Order) map[string]interface{
 		if updateField == "delivery_address" {
 			updateValues[updateField] = order.DeliveryAddress
 		}
+		if field, ok := strings.CutPrefix(updateField, "pickup_address."); ok {
+			mergeAddressField(updateValues, "pickup_address", field, order.PickupAddress)
+		}
+		if field, ok := strings.CutPrefix(updateField, "delivery_address."); ok {
+			mergeAddressField(updateValues, "delivery_address", field, order.DeliveryAddress)
+		}
 		if updateField == "shipping_method" {
 			updateValues[updateField] = order.ShippingMethod
 		}
@@ -195,15 +354,9 @@ func UpdateOrderMaping(updateFields []string, order Order) map[string]interface{
 		if updateField == "payment_method" {
 			updateValues[updateField] = order.PaymentMethod
 		}
-		if updateField == "invoice_number" {
-			updateValues[updateField] = order.InvoiceNumber
-		}
 		if updateField == "special_instructions" {
 			updateValues[updateField] = order.SpecialInstructions
 		}
-		if updateField == "shipping_cost" {
-			updateValues[updateField] = order.ShippingCost
-		}
 	}
 	return updateValues
 }
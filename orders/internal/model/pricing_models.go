@@ -0,0 +1,74 @@
+package model
+
+import "strings"
+
+// ShippingZone groups deliveries by the distance between the pickup and delivery address.
+type ShippingZone string
+
+const (
+	// ShippingZoneLocal is a delivery within the same city.
+	ShippingZoneLocal ShippingZone = "LOCAL"
+	// ShippingZoneDomestic is a delivery to another city in the same country.
+	ShippingZoneDomestic ShippingZone = "DOMESTIC"
+	// ShippingZoneInternational is a delivery to another country.
+	ShippingZoneInternational ShippingZone = "INTERNATIONAL"
+)
+
+// ShippingZoneFor returns the zone of a delivery from pickup to delivery.
+func ShippingZoneFor(pickup, delivery Address) ShippingZone {
+	if !strings.EqualFold(strings.TrimSpace(pickup.Country), strings.TrimSpace(delivery.Country)) {
+		return ShippingZoneInternational
+	}
+	if !strings.EqualFold(strings.TrimSpace(pickup.City), strings.TrimSpace(delivery.City)) {
+		return ShippingZoneDomestic
+	}
+	return ShippingZoneLocal
+}
+
+// ShippingRate is a row of the shipping rate table.
+type ShippingRate struct {
+	ShippingMethod string       `db:"shipping_method"`
+	Zone           ShippingZone `db:"zone"`
+	BaseFee        int64        `db:"base_fee"`     // in the minor unit of Currency e.g cents
+	PerItemFee     int64        `db:"per_item_fee"` // in the minor unit of Currency e.g cents
+	Currency       Currency     `db:"currency"`
+}
+
+// Fee returns the cost of shipping quantity items at this rate.
+func (r ShippingRate) Fee(quantity int64) Money {
+	return NewMoney(r.BaseFee+r.PerItemFee*quantity, r.Currency)
+}
+
+// LineItem is a priced order line.
+type LineItem struct {
+	ProductID string
+	Quantity  int64
+	UnitPrice Money
+	// Total is the line total excluding tax and before any discount.
+	Total Money
+	// Discount is taken off Total before tax is charged.
+	Discount Money
+	TaxRate  TaxRate
+	Tax      Money
+}
+
+// Taxable returns the line total after the discount, which is the amount tax is charged on.
+func (l LineItem) Taxable() Money {
+	return NewMoney(l.Total.Amount-l.Discount.Amount, l.Total.Currency)
+}
+
+// OrderPricing holds the amounts charged for an order.
+type OrderPricing struct {
+	Lines        []LineItem
+	Subtotal     Money
+	Discount     Money
+	Shipping     Money
+	Tax          Money
+	TaxBreakdown TaxBreakdown
+	GrandTotal   Money
+}
+
+// TaxedSubtotal returns the line totals after discounts including tax, which is what the customer pays for the products.
+func (p *OrderPricing) TaxedSubtotal() Money {
+	return NewMoney(p.Subtotal.Amount-p.Discount.Amount+p.Tax.Amount, p.Subtotal.Currency)
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShippingZoneFor(t *testing.T) {
+	nairobi := Address{City: "Nairobi", Country: "Kenya"}
+
+	assert.Equal(t, ShippingZoneLocal, ShippingZoneFor(nairobi, Address{City: " nairobi", Country: "KENYA"}))
+	assert.Equal(t, ShippingZoneDomestic, ShippingZoneFor(nairobi, Address{City: "Kisumu", Country: "Kenya"}))
+	assert.Equal(t, ShippingZoneInternational, ShippingZoneFor(nairobi, Address{City: "Kampala", Country: "Uganda"}))
+}
+
+func TestShippingRateFee(t *testing.T) {
+	rate := ShippingRate{BaseFee: 20000, PerItemFee: 5000, Currency: KES}
+	assert.Equal(t, NewMoney(35000, KES), rate.Fee(3))
+}
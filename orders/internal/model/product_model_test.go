@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
 	productspb "github.com/wathuta/technical_test/protos_gen/products"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -41,7 +42,7 @@ func TestProductFromProto(t *testing.T) {
 		Attributes: &productspb.ProductAttributes{
 			Brand: "Sample Brand",
 			Model: "Model XYZ",
-			Price: 99.99,
+			Price: &moneypb.Money{CurrencyCode: "KES", Amount: 9999},
 		},
 		StockQuantity: 100,
 		IsAvailable:   true,
@@ -60,7 +61,8 @@ func TestProductFromProto(t *testing.T) {
 	assert.Equal(t, ProductCategory(Books), product.Category)
 	assert.Equal(t, productProto.Attributes.Brand, product.Brand)
 	assert.Equal(t, productProto.Attributes.Model, product.Model)
-	assert.Equal(t, productProto.Attributes.Price, product.Price)
+	assert.Equal(t, productProto.Attributes.Price.Amount, product.Price)
+	assert.Equal(t, KES, product.Currency)
 	assert.Equal(t, productProto.StockQuantity, product.StockQuantity)
 	assert.Equal(t, productProto.IsAvailable, product.IsAvailable)
 }
@@ -72,9 +74,10 @@ func TestProductProto(t *testing.T) {
 		Sku:       "SKU123",
 		Category:  Clothing,
 		ProductAttributes: ProductAttributes{
-			Brand: "Sample Brand",
-			Model: "Model XYZ",
-			Price: 99.99,
+			Brand:    "Sample Brand",
+			Model:    "Model XYZ",
+			Price:    9999,
+			Currency: KES,
 		},
 		StockQuantity: 100,
 		IsAvailable:   true,
@@ -93,7 +96,8 @@ func TestProductProto(t *testing.T) {
 	assert.Equal(t, productspb.ProductCategory_value[string(product.Category)], productspb.ProductCategory_value[string(product.Category)])
 	assert.Equal(t, product.Brand, productProto.Attributes.Brand)
 	assert.Equal(t, product.Model, productProto.Attributes.Model)
-	assert.Equal(t, product.Price, productProto.Attributes.Price)
+	assert.Equal(t, product.Price, productProto.Attributes.Price.Amount)
+	assert.Equal(t, string(product.Currency), productProto.Attributes.Price.CurrencyCode)
 	assert.Equal(t, product.StockQuantity, productProto.StockQuantity)
 	assert.Equal(t, product.IsAvailable, productProto.IsAvailable)
 }
@@ -104,9 +108,10 @@ func TestUpdateProductMapping(t *testing.T) {
 		Sku:      "Updated SKU",
 		Category: Clothing,
 		ProductAttributes: ProductAttributes{
-			Brand: "Updated Brand",
-			Model: "Updated Model",
-			Price: 49.99,
+			Brand:    "Updated Brand",
+			Model:    "Updated Model",
+			Price:    4999,
+			Currency: KES,
 		},
 		StockQuantity: 50,
 		IsAvailable:   false,
@@ -116,9 +121,9 @@ func TestUpdateProductMapping(t *testing.T) {
 		"name",
 		"sku",
 		"category",
-		"brand",
-		"model",
-		"price",
+		"attributes.brand",
+		"attributes.model",
+		"attributes.price",
 		"stock_quantity",
 		"is_available",
 	}
@@ -132,9 +137,25 @@ func TestUpdateProductMapping(t *testing.T) {
 		"brand":          product.Brand,
 		"model":          product.Model,
 		"price":          product.Price,
+		"currency":       product.Currency,
 		"stock_quantity": product.StockQuantity,
 		"is_available":   product.IsAvailable,
 	}
 
 	assert.Equal(t, expectedValues, updateValues)
 }
+
+func TestUpdateProductMapping_Attributes(t *testing.T) {
+	product := Product{
+		ProductAttributes: ProductAttributes{Brand: "Brand", Model: "Model", Price: 4999, Currency: KES},
+	}
+
+	updateValues := UpdateProductMapping([]string{"attributes"}, product)
+
+	assert.Equal(t, map[string]interface{}{
+		"brand":    product.Brand,
+		"model":    product.Model,
+		"price":    product.Price,
+		"currency": product.Currency,
+	}, updateValues)
+}
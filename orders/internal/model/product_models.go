@@ -21,9 +21,15 @@ const (
 
 // ProductAttributes represents attributes of a product.
 type ProductAttributes struct {
-	Brand string  `validate:"required" db:"brand"`
-	Model string  `validate:"required" db:"model"`
-	Price float64 `validate:"required,numeric,gt=0" db:"price"`
+	Brand    string   `validate:"required" db:"brand"`
+	Model    string   `validate:"required" db:"model"`
+	Price    int64    `validate:"required,numeric,gt=0" db:"price"` // in the minor unit of Currency e.g cents
+	Currency Currency `validate:"required,len=3" db:"currency"`
+}
+
+// UnitPrice returns the price of a single item of the product.
+func (a ProductAttributes) UnitPrice() Money {
+	return NewMoney(a.Price, a.Currency)
 }
 
 // Product represents a product.
@@ -32,12 +38,13 @@ type Product struct {
 	Name              string          `validate:"required" db:"name"`
 	Sku               string          `validate:"required" db:"sku"`
 	Category          ProductCategory `validate:"required" db:"category"`
-	ProductAttributes `validate:"required"`
+	ProductAttributes `validate:"required" json:"attributes"`
 	StockQuantity     int32     `validate:"required,gt=0" db:"stock_quantity"`
 	IsAvailable       bool      `validate:"required" db:"is_available"`
 	CreatedAt         time.Time `validate:"-" db:"created_at"`
 	UpdatedAt         time.Time `validate:"-" db:"updated_at"`
 	DeletedAt         time.Time `validate:"-" db:"deleted_at"`
+	Version           int64     `validate:"-" db:"version"` // incremented by every update
 }
 
 // ValidateProductCategory validates if a Product's Category is a valid enum value.
@@ -58,12 +65,16 @@ func ProductFromProto(e *productspb.Product) *Product {
 		Category:      ProductCategory(e.Category.String()),
 		StockQuantity: e.StockQuantity,
 		IsAvailable:   e.IsAvailable,
+		Version:       e.Version,
 	}
 
 	if e.Attributes != nil {
 		product.Brand = e.Attributes.Brand
 		product.Model = e.Attributes.Model
-		product.Price = e.Attributes.Price
+
+		price := MoneyFromProto(e.Attributes.Price)
+		product.Price = price.Amount
+		product.Currency = price.Currency
 	}
 	return product
 }
@@ -75,7 +86,7 @@ func (c *Product) Proto() *productspb.Product {
 		Attributes: &productspb.ProductAttributes{
 			Brand: c.Brand,
 			Model: c.Model,
-			Price: c.Price,
+			Price: c.UnitPrice().Proto(),
 		},
 		Category:      productspb.ProductCategory(productspb.ProductCategory_value[string(c.Category)]),
 		StockQuantity: c.StockQuantity,
@@ -83,8 +94,12 @@ func (c *Product) Proto() *productspb.Product {
 		CreatedAt:     timestamppb.New(c.CreatedAt),
 		UpdatedAt:     timestamppb.New(c.UpdatedAt),
 		DeletedAt:     timestamppb.New(c.DeletedAt),
+		Version:       c.Version,
 	}
 }
+
+// UpdateProductMapping returns the columns of product updated by updateFields. The attributes are
+// stored in columns of their own so each of them can be updated alone.
 func UpdateProductMapping(updateFields []string, product Product) map[string]interface{} {
 	updatedProductValues := make(map[string]interface{})
 
@@ -98,15 +113,15 @@ func UpdateProductMapping(updateFields []string, product Product) map[string]int
 		if updateField == "category" {
 			updatedProductValues[updateField] = product.Category
 		}
-		if updateField == "brand" {
-			updatedProductValues[updateField] = product.Brand
+		if updateField == "attributes" || updateField == "attributes.brand" {
+			updatedProductValues["brand"] = product.Brand
 		}
-
-		if updateField == "model" {
-			updatedProductValues[updateField] = product.Model
+		if updateField == "attributes" || updateField == "attributes.model" {
+			updatedProductValues["model"] = product.Model
 		}
-		if updateField == "price" {
-			updatedProductValues[updateField] = product.Price
+		if updateField == "attributes" || updateField == "attributes.price" {
+			updatedProductValues["price"] = product.Price
+			updatedProductValues["currency"] = product.Currency
 		}
 		if updateField == "stock_quantity" {
 			updatedProductValues[updateField] = product.StockQuantity
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrPromotionNotFound              = errors.New("promo code not found")
+	ErrPromotionNotActive             = errors.New("promo code is not active")
+	ErrPromotionExhausted             = errors.New("promo code has been fully redeemed")
+	ErrPromotionCustomerLimitReached  = errors.New("promo code has already been redeemed the maximum number of times by the customer")
+	ErrPromotionNotApplicable         = errors.New("promo code does not apply to any product in the order")
+	ErrPromotionInvalidDiscount       = errors.New("promotion must have a percentage between 0 and 100% or a positive fixed amount")
+	ErrPromotionInvalidValidityWindow = errors.New("promotion must end after it starts")
+)
+
+// DiscountType is how the discount of a promotion is calculated.
+type DiscountType string
+
+const (
+	// DiscountTypePercentage takes a percentage off the eligible lines.
+	DiscountTypePercentage DiscountType = "DISCOUNT_TYPE_PERCENTAGE"
+	// DiscountTypeFixedAmount takes a fixed amount off the eligible lines.
+	DiscountTypeFixedAmount DiscountType = "DISCOUNT_TYPE_FIXED_AMOUNT"
+)
+
+// PromotionRestrictions limits a promotion to some products. A promotion without restrictions applies to every product.
+type PromotionRestrictions struct {
+	Categories []ProductCategory `json:"categories,omitempty"`
+	ProductIDs []string          `json:"product_ids,omitempty"`
+}
+
+func (r *PromotionRestrictions) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(b, r)
+}
+
+// Promotion is a discount code customers can use when placing an order.
+type Promotion struct {
+	PromotionID           string       `validate:"required,uuid" db:"promotion_id"`
+	Code                  string       `validate:"required,max=64" db:"code"`
+	Description           string       `db:"description"`
+	DiscountType          DiscountType `validate:"required,oneof=DISCOUNT_TYPE_PERCENTAGE DISCOUNT_TYPE_FIXED_AMOUNT" db:"discount_type"`
+	PercentOffBasisPoints int64        `validate:"gte=0,lte=10000" db:"percent_off_basis_points"` // 1000 is 10%
+	AmountOff             int64        `validate:"gte=0" db:"amount_off"`                         // in the minor unit of Currency e.g cents
+	Currency              Currency     `validate:"required,len=3" db:"currency"`
+	StartsAt              time.Time    `validate:"required" db:"starts_at"`
+	EndsAt                time.Time    `validate:"required" db:"ends_at"`
+	// a limit of 0 means unlimited
+	MaxRedemptions            int64                 `validate:"gte=0" db:"max_redemptions"`
+	MaxRedemptionsPerCustomer int64                 `validate:"gte=0" db:"max_redemptions_per_customer"`
+	RedemptionCount           int64                 `db:"redemption_count"`
+	Restrictions              PromotionRestrictions `db:"restrictions"`
+	CreatedAt                 time.Time             `db:"created_at"`
+	UpdatedAt                 time.Time             `db:"updated_at"`
+}
+
+// PromotionRedemption records the use of a promotion on an order.
+type PromotionRedemption struct {
+	RedemptionID string    `db:"redemption_id"`
+	PromotionID  string    `db:"promotion_id"`
+	OrderID      string    `db:"order_id"`
+	CustomerID   string    `db:"customer_id"`
+	Discount     int64     `db:"discount"` // in the minor unit of Currency e.g cents
+	Currency     Currency  `db:"currency"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// NormalizePromoCode returns the form promo codes are stored in so that codes are matched case insensitively.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CheckDiscount returns an error if the promotion can never give a discount or is never valid.
+func (p *Promotion) CheckDiscount() error {
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		if p.PercentOffBasisPoints <= 0 || p.PercentOffBasisPoints > basisPointsPerUnit {
+			return ErrPromotionInvalidDiscount
+		}
+	case DiscountTypeFixedAmount:
+		if p.AmountOff <= 0 {
+			return ErrPromotionInvalidDiscount
+		}
+	default:
+		return fmt.Errorf("%w: unknown discount type %q", ErrPromotionInvalidDiscount, p.DiscountType)
+	}
+	for _, category := range p.Restrictions.Categories {
+		if !ValidateProductCategory(category) {
+			return fmt.Errorf("invalid product category %q", category)
+		}
+	}
+	if !p.EndsAt.After(p.StartsAt) {
+		return ErrPromotionInvalidValidityWindow
+	}
+	return nil
+}
+
+// Active reports whether the promotion can be used at now.
+func (p *Promotion) Active(now time.Time) bool {
+	return !now.Before(p.StartsAt) && now.Before(p.EndsAt)
+}
+
+// Exhausted reports whether the promotion has been redeemed the maximum number of times.
+func (p *Promotion) Exhausted() bool {
+	return p.MaxRedemptions > 0 && p.RedemptionCount >= p.MaxRedemptions
+}
+
+// AppliesTo reports whether the promotion gives a discount on product.
+func (p *Promotion) AppliesTo(product *Product) bool {
+	if len(p.Restrictions.Categories) == 0 && len(p.Restrictions.ProductIDs) == 0 {
+		return true
+	}
+	for _, category := range p.Restrictions.Categories {
+		if category == product.Category {
+			return true
+		}
+	}
+	for _, productID := range p.Restrictions.ProductIDs {
+		if productID == product.ProductID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discounts splits the discount of the promotion over the eligible line totals. The discount on a line
+// never exceeds the line total. A fixed amount is shared in proportion to the line totals with the
+// rounding remainder on the last eligible line.
+func (p *Promotion) Discounts(totals []Money, eligible []bool) ([]Money, error) {
+	discounts := make([]Money, len(totals))
+	var eligibleTotal int64
+	last := -1
+	for i, total := range totals {
+		discounts[i] = NewMoney(0, total.Currency)
+		if eligible[i] {
+			eligibleTotal += total.Amount
+			last = i
+		}
+	}
+	if last < 0 || eligibleTotal <= 0 {
+		return discounts, nil
+	}
+
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		for i, total := range totals {
+			if eligible[i] {
+				discounts[i].Amount = (total.Amount*p.PercentOffBasisPoints + basisPointsPerUnit/2) / basisPointsPerUnit
+			}
+		}
+	case DiscountTypeFixedAmount:
+		if p.Currency != totals[last].Currency {
+			return nil, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, p.Currency, totals[last].Currency)
+		}
+		amountOff := p.AmountOff
+		if amountOff > eligibleTotal {
+			amountOff = eligibleTotal
+		}
+		remaining := amountOff
+		for i, total := range totals {
+			if !eligible[i] {
+				continue
+			}
+			if i == last {
+				discounts[i].Amount = remaining
+				break
+			}
+			discounts[i].Amount = amountOff * total.Amount / eligibleTotal
+			remaining -= discounts[i].Amount
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown discount type %q", ErrPromotionInvalidDiscount, p.DiscountType)
+	}
+	return discounts, nil
+}
+
+func PromotionFromProto(p *orderspb.Promotion) *Promotion {
+	promotion := &Promotion{
+		PromotionID:               p.PromotionId,
+		Code:                      NormalizePromoCode(p.Code),
+		Description:               p.Description,
+		DiscountType:              DiscountType(p.DiscountType.String()),
+		PercentOffBasisPoints:     int64(p.PercentOffBasisPoints),
+		StartsAt:                  p.StartsAt.AsTime(),
+		EndsAt:                    p.EndsAt.AsTime(),
+		MaxRedemptions:            int64(p.MaxRedemptions),
+		MaxRedemptionsPerCustomer: int64(p.MaxRedemptionsPerCustomer),
+		Restrictions: PromotionRestrictions{
+			ProductIDs: p.ProductIds,
+		},
+	}
+	amountOff := MoneyFromProto(p.AmountOff)
+	promotion.AmountOff = amountOff.Amount
+	promotion.Currency = amountOff.Currency
+
+	for _, category := range p.ProductCategories {
+		promotion.Restrictions.Categories = append(promotion.Restrictions.Categories, ProductCategory(strings.ToUpper(category)))
+	}
+	return promotion
+}
+
+func (p *Promotion) Proto() *orderspb.Promotion {
+	promotion := &orderspb.Promotion{
+		PromotionId:               p.PromotionID,
+		Code:                      p.Code,
+		Description:               p.Description,
+		DiscountType:              orderspb.DiscountType(orderspb.DiscountType_value[string(p.DiscountType)]),
+		PercentOffBasisPoints:     int32(p.PercentOffBasisPoints),
+		AmountOff:                 NewMoney(p.AmountOff, p.Currency).Proto(),
+		StartsAt:                  timestamppb.New(p.StartsAt),
+		EndsAt:                    timestamppb.New(p.EndsAt),
+		MaxRedemptions:            int32(p.MaxRedemptions),
+		MaxRedemptionsPerCustomer: int32(p.MaxRedemptionsPerCustomer),
+		RedemptionCount:           int32(p.RedemptionCount),
+		ProductIds:                p.Restrictions.ProductIDs,
+		CreatedAt:                 timestamppb.New(p.CreatedAt),
+		UpdatedAt:                 timestamppb.New(p.UpdatedAt),
+	}
+	for _, category := range p.Restrictions.Categories {
+		promotion.ProductCategories = append(promotion.ProductCategories, string(category))
+	}
+	return promotion
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromotion_Active(t *testing.T) {
+	start := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	promotion := Promotion{StartsAt: start, EndsAt: start.AddDate(0, 1, 0)}
+
+	assert.False(t, promotion.Active(start.Add(-time.Second)))
+	assert.True(t, promotion.Active(start))
+	assert.True(t, promotion.Active(start.AddDate(0, 0, 15)))
+	assert.False(t, promotion.Active(promotion.EndsAt))
+}
+
+func TestPromotion_Exhausted(t *testing.T) {
+	assert.False(t, (&Promotion{RedemptionCount: 100}).Exhausted())
+	assert.False(t, (&Promotion{MaxRedemptions: 10, RedemptionCount: 9}).Exhausted())
+	assert.True(t, (&Promotion{MaxRedemptions: 10, RedemptionCount: 10}).Exhausted())
+}
+
+func TestPromotion_AppliesTo(t *testing.T) {
+	radio := &Product{ProductID: "radio", Category: Electronics}
+	maize := &Product{ProductID: "maize", Category: Food}
+
+	assert.True(t, (&Promotion{}).AppliesTo(maize))
+
+	electronics := &Promotion{Restrictions: PromotionRestrictions{Categories: []ProductCategory{Electronics}}}
+	assert.True(t, electronics.AppliesTo(radio))
+	assert.False(t, electronics.AppliesTo(maize))
+
+	maizeOnly := &Promotion{Restrictions: PromotionRestrictions{ProductIDs: []string{"maize"}}}
+	assert.False(t, maizeOnly.AppliesTo(radio))
+	assert.True(t, maizeOnly.AppliesTo(maize))
+}
+
+func TestPromotion_Discounts_Percentage(t *testing.T) {
+	promotion := &Promotion{DiscountType: DiscountTypePercentage, PercentOffBasisPoints: 1250}
+
+	discounts, err := promotion.Discounts(
+		[]Money{NewMoney(10000, KES), NewMoney(999, KES), NewMoney(5000, KES)},
+		[]bool{true, true, false},
+	)
+	require.NoError(t, err)
+	// 12.5% of 9.99 is 1.24875 which rounds to 1.25
+	assert.Equal(t, []Money{NewMoney(1250, KES), NewMoney(125, KES), NewMoney(0, KES)}, discounts)
+}
+
+func TestPromotion_Discounts_FixedAmount(t *testing.T) {
+	promotion := &Promotion{DiscountType: DiscountTypeFixedAmount, AmountOff: 1000, Currency: KES}
+
+	// shared in proportion to the line totals with the remainder on the last eligible line
+	discounts, err := promotion.Discounts(
+		[]Money{NewMoney(3000, KES), NewMoney(5000, KES), NewMoney(3000, KES)},
+		[]bool{true, false, true},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []Money{NewMoney(500, KES), NewMoney(0, KES), NewMoney(500, KES)}, discounts)
+
+	// the discount never exceeds the eligible total
+	discounts, err = promotion.Discounts([]Money{NewMoney(300, KES)}, []bool{true})
+	require.NoError(t, err)
+	assert.Equal(t, []Money{NewMoney(300, KES)}, discounts)
+
+	_, err = promotion.Discounts([]Money{NewMoney(300, USD)}, []bool{true})
+	assert.True(t, errors.Is(err, ErrCurrencyMismatch))
+}
+
+func TestPromotion_CheckDiscount(t *testing.T) {
+	start := time.Now()
+	valid := Promotion{DiscountType: DiscountTypePercentage, PercentOffBasisPoints: 1000, StartsAt: start, EndsAt: start.Add(time.Hour)}
+	assert.NoError(t, valid.CheckDiscount())
+
+	tooMuch := valid
+	tooMuch.PercentOffBasisPoints = 10001
+	assert.True(t, errors.Is(tooMuch.CheckDiscount(), ErrPromotionInvalidDiscount))
+
+	noAmount := valid
+	noAmount.DiscountType = DiscountTypeFixedAmount
+	assert.True(t, errors.Is(noAmount.CheckDiscount(), ErrPromotionInvalidDiscount))
+
+	backwards := valid
+	backwards.EndsAt = start.Add(-time.Hour)
+	assert.True(t, errors.Is(backwards.CheckDiscount(), ErrPromotionInvalidValidityWindow))
+
+	badCategory := valid
+	badCategory.Restrictions.Categories = []ProductCategory{"GADGETS"}
+	assert.Error(t, badCategory.CheckDiscount())
+}
+
+func TestPromotion_Proto(t *testing.T) {
+	promotion := &Promotion{
+		Code:                      "XMAS10",
+		DiscountType:              DiscountTypeFixedAmount,
+		AmountOff:                 50000,
+		Currency:                  KES,
+		MaxRedemptions:            100,
+		MaxRedemptionsPerCustomer: 1,
+		Restrictions:              PromotionRestrictions{Categories: []ProductCategory{Toys}, ProductIDs: []string{"radio"}},
+		StartsAt:                  time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+		EndsAt:                    time.Date(2023, 12, 26, 0, 0, 0, 0, time.UTC),
+	}
+
+	roundTrip := PromotionFromProto(promotion.Proto())
+	assert.Equal(t, promotion.Code, roundTrip.Code)
+	assert.Equal(t, promotion.DiscountType, roundTrip.DiscountType)
+	assert.Equal(t, promotion.AmountOff, roundTrip.AmountOff)
+	assert.Equal(t, promotion.Currency, roundTrip.Currency)
+	assert.Equal(t, promotion.MaxRedemptionsPerCustomer, roundTrip.MaxRedemptionsPerCustomer)
+	assert.Equal(t, promotion.Restrictions, roundTrip.Restrictions)
+	assert.Equal(t, promotion.StartsAt, roundTrip.StartsAt)
+}
+
+func TestNormalizePromoCode(t *testing.T) {
+	assert.Equal(t, "XMAS10", NormalizePromoCode(" xmas10 "))
+}
+
+func TestPromotionRestrictions_Scan(t *testing.T) {
+	restrictions := PromotionRestrictions{Categories: []ProductCategory{Books}}
+	b, err := json.Marshal(restrictions)
+	require.NoError(t, err)
+
+	var scanned PromotionRestrictions
+	require.NoError(t, scanned.Scan(b))
+	assert.Equal(t, restrictions, scanned)
+	assert.Error(t, scanned.Scan("{}"))
+}
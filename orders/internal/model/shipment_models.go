@@ -0,0 +1,137 @@
+package model
+
+import (
+	"time"
+
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ShipmentStatus is the status of the latest tracking event of a shipment.
+type ShipmentStatus string
+
+const (
+	ShipmentStatusPending        ShipmentStatus = "SHIPMENT_STATUS_PENDING"
+	ShipmentStatusPickedUp       ShipmentStatus = "SHIPMENT_STATUS_PICKED_UP"
+	ShipmentStatusInTransit      ShipmentStatus = "SHIPMENT_STATUS_IN_TRANSIT"
+	ShipmentStatusOutForDelivery ShipmentStatus = "SHIPMENT_STATUS_OUT_FOR_DELIVERY"
+	ShipmentStatusDelivered      ShipmentStatus = "SHIPMENT_STATUS_DELIVERED"
+	ShipmentStatusFailedAttempt  ShipmentStatus = "SHIPMENT_STATUS_FAILED_ATTEMPT"
+)
+
+// ShipmentEventType is the kind of a tracking event reported by a carrier.
+type ShipmentEventType string
+
+const (
+	ShipmentEventPickedUp       ShipmentEventType = "SHIPMENT_EVENT_TYPE_PICKED_UP"
+	ShipmentEventInTransit      ShipmentEventType = "SHIPMENT_EVENT_TYPE_IN_TRANSIT"
+	ShipmentEventOutForDelivery ShipmentEventType = "SHIPMENT_EVENT_TYPE_OUT_FOR_DELIVERY"
+	ShipmentEventDelivered      ShipmentEventType = "SHIPMENT_EVENT_TYPE_DELIVERED"
+	ShipmentEventFailedAttempt  ShipmentEventType = "SHIPMENT_EVENT_TYPE_FAILED_ATTEMPT"
+)
+
+// shipmentStatuses maps the event types to the status a shipment is in after them.
+var shipmentStatuses = map[ShipmentEventType]ShipmentStatus{
+	ShipmentEventPickedUp:       ShipmentStatusPickedUp,
+	ShipmentEventInTransit:      ShipmentStatusInTransit,
+	ShipmentEventOutForDelivery: ShipmentStatusOutForDelivery,
+	ShipmentEventDelivered:      ShipmentStatusDelivered,
+	ShipmentEventFailedAttempt:  ShipmentStatusFailedAttempt,
+}
+
+// Valid reports whether t is a known event type.
+func (t ShipmentEventType) Valid() bool {
+	_, ok := shipmentStatuses[t]
+	return ok
+}
+
+// ShipmentStatus returns the status of a shipment after an event of type t.
+func (t ShipmentEventType) ShipmentStatus() ShipmentStatus {
+	return shipmentStatuses[t]
+}
+
+// OrderStatus returns the status the order of a shipment moves to after an event of type t: it is
+// shipped once the carrier picked it up and delivered with the delivery.
+func (t ShipmentEventType) OrderStatus() OrderStatus {
+	if t == ShipmentEventDelivered {
+		return OrderStatusDelivered
+	}
+	return OrderStatusShipped
+}
+
+// Shipment is an order handed to a carrier.
+type Shipment struct {
+	ShipmentID     string          `validate:"required,uuid" db:"shipment_id"`
+	OrderID        string          `validate:"required,uuid" db:"order_id"`
+	Carrier        string          `validate:"required,max=64" db:"carrier"`
+	TrackingNumber string          `validate:"required,max=255" db:"tracking_number"`
+	Status         ShipmentStatus  `validate:"required" db:"status"`
+	CreatedAt      time.Time       `db:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at"`
+	Events         []ShipmentEvent `db:"-"` // oldest first
+}
+
+// ShipmentEvent is a tracking event reported by the carrier of a shipment.
+type ShipmentEvent struct {
+	EventID     string            `validate:"required,uuid" db:"event_id"`
+	ShipmentID  string            `validate:"required,uuid" db:"shipment_id"`
+	Type        ShipmentEventType `validate:"required" db:"event_type"`
+	Location    string            `db:"location"`
+	Description string            `db:"description"`
+	OccurredAt  time.Time         `validate:"required" db:"occurred_at"`
+	RecordedAt  time.Time         `db:"recorded_at"`
+}
+
+func ShipmentEventFromProto(e *orderspb.ShipmentEvent) *ShipmentEvent {
+	event := &ShipmentEvent{
+		Type:        ShipmentEventType(e.Type.String()),
+		Location:    e.Location,
+		Description: e.Description,
+	}
+	if e.OccurredAt != nil {
+		event.OccurredAt = e.OccurredAt.AsTime()
+	}
+	return event
+}
+
+func (e *ShipmentEvent) Proto() *orderspb.ShipmentEvent {
+	return &orderspb.ShipmentEvent{
+		EventId:     e.EventID,
+		Type:        orderspb.ShipmentEventType(orderspb.ShipmentEventType_value[string(e.Type)]),
+		Location:    e.Location,
+		Description: e.Description,
+		OccurredAt:  timestamppb.New(e.OccurredAt),
+		RecordedAt:  timestamppb.New(e.RecordedAt),
+	}
+}
+
+func (s *Shipment) Proto() *orderspb.Shipment {
+	return &orderspb.Shipment{
+		ShipmentId:     s.ShipmentID,
+		OrderId:        s.OrderID,
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		Status:         orderspb.ShipmentStatus(orderspb.ShipmentStatus_value[string(s.Status)]),
+		Events:         s.eventsProto(),
+		CreatedAt:      timestamppb.New(s.CreatedAt),
+		UpdatedAt:      timestamppb.New(s.UpdatedAt),
+	}
+}
+
+// TrackingProto returns the public view of the shipment.
+func (s *Shipment) TrackingProto() *orderspb.TrackShipmentResponse {
+	return &orderspb.TrackShipmentResponse{
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		Status:         orderspb.ShipmentStatus(orderspb.ShipmentStatus_value[string(s.Status)]),
+		Events:         s.eventsProto(),
+	}
+}
+
+func (s *Shipment) eventsProto() []*orderspb.ShipmentEvent {
+	events := make([]*orderspb.ShipmentEvent, 0, len(s.Events))
+	for i := range s.Events {
+		events = append(events, s.Events[i].Proto())
+	}
+	return events
+}
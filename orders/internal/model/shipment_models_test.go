@@ -0,0 +1,77 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestShipmentEventTypeStatuses(t *testing.T) {
+	tests := []struct {
+		eventType      ShipmentEventType
+		shipmentStatus ShipmentStatus
+		orderStatus    OrderStatus
+	}{
+		{ShipmentEventPickedUp, ShipmentStatusPickedUp, OrderStatusShipped},
+		{ShipmentEventInTransit, ShipmentStatusInTransit, OrderStatusShipped},
+		{ShipmentEventOutForDelivery, ShipmentStatusOutForDelivery, OrderStatusShipped},
+		{ShipmentEventFailedAttempt, ShipmentStatusFailedAttempt, OrderStatusShipped},
+		{ShipmentEventDelivered, ShipmentStatusDelivered, OrderStatusDelivered},
+	}
+	for _, test := range tests {
+		assert.True(t, test.eventType.Valid(), test.eventType)
+		assert.Equal(t, test.shipmentStatus, test.eventType.ShipmentStatus(), test.eventType)
+		assert.Equal(t, test.orderStatus, test.eventType.OrderStatus(), test.eventType)
+	}
+
+	assert.False(t, ShipmentEventType("SHIPMENT_EVENT_TYPE_UNSPECIFIED").Valid())
+}
+
+func TestShipmentEventFromProto(t *testing.T) {
+	occurredAt := time.Now().UTC()
+	event := ShipmentEventFromProto(&orderspb.ShipmentEvent{
+		Type:        orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_OUT_FOR_DELIVERY,
+		Location:    "Mombasa",
+		Description: "With the courier",
+		OccurredAt:  timestamppb.New(occurredAt),
+	})
+
+	assert.Equal(t, ShipmentEventOutForDelivery, event.Type)
+	assert.Equal(t, "Mombasa", event.Location)
+	assert.Equal(t, "With the courier", event.Description)
+	assert.True(t, occurredAt.Equal(event.OccurredAt))
+
+	// a missing time is left zero for the handler to reject
+	assert.True(t, ShipmentEventFromProto(&orderspb.ShipmentEvent{}).OccurredAt.IsZero())
+}
+
+func TestShipmentProto(t *testing.T) {
+	shipment := &Shipment{
+		ShipmentID:     "s1",
+		OrderID:        "o1",
+		Carrier:        "G4S",
+		TrackingNumber: "TRK-1",
+		Status:         ShipmentStatusInTransit,
+		Events: []ShipmentEvent{
+			{EventID: "e1", Type: ShipmentEventPickedUp, Location: "Nairobi"},
+			{EventID: "e2", Type: ShipmentEventInTransit, Location: "Voi"},
+		},
+	}
+
+	protoShipment := shipment.Proto()
+	assert.Equal(t, "s1", protoShipment.ShipmentId)
+	assert.Equal(t, "o1", protoShipment.OrderId)
+	assert.Equal(t, orderspb.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT, protoShipment.Status)
+	assert.Len(t, protoShipment.Events, 2)
+	assert.Equal(t, orderspb.ShipmentEventType_SHIPMENT_EVENT_TYPE_PICKED_UP, protoShipment.Events[0].Type)
+
+	// the public view leaves out the ids of the shipment and the order
+	tracking := shipment.TrackingProto()
+	assert.Equal(t, "G4S", tracking.Carrier)
+	assert.Equal(t, "TRK-1", tracking.TrackingNumber)
+	assert.Equal(t, orderspb.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT, tracking.Status)
+	assert.Equal(t, "Voi", tracking.Events[1].Location)
+}
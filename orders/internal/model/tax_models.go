@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+)
+
+// basisPointsPerUnit is the number of basis points in 100%.
+const basisPointsPerUnit = 10000
+
+// TaxRate is the VAT charged on a product category.
+type TaxRate struct {
+	Category        ProductCategory `db:"category"`
+	RateBasisPoints int64           `db:"rate_basis_points"` // 1600 is 16%
+	Exempt          bool            `db:"exempt"`
+}
+
+// Tax returns the tax charged on amount rounded half up to the minor unit.
+func (r TaxRate) Tax(amount Money) Money {
+	if r.Exempt || r.RateBasisPoints == 0 {
+		return NewMoney(0, amount.Currency)
+	}
+	return NewMoney((amount.Amount*r.RateBasisPoints+basisPointsPerUnit/2)/basisPointsPerUnit, amount.Currency)
+}
+
+// TaxBreakdownEntry is the total tax charged at a single rate on an order.
+type TaxBreakdownEntry struct {
+	RateBasisPoints int64 `json:"rate_basis_points"`
+	Exempt          bool  `json:"exempt"`
+	TaxableAmount   int64 `json:"taxable_amount"` // in the minor unit of the order currency e.g cents
+	Tax             int64 `json:"tax"`            // in the minor unit of the order currency e.g cents
+}
+
+// TaxBreakdown groups the tax charged on an order by rate.
+type TaxBreakdown []TaxBreakdownEntry
+
+// Add records tax charged on a taxable amount at rate.
+func (b TaxBreakdown) Add(rate TaxRate, taxableAmount, tax int64) TaxBreakdown {
+	for i := range b {
+		if b[i].RateBasisPoints == rate.RateBasisPoints && b[i].Exempt == rate.Exempt {
+			b[i].TaxableAmount += taxableAmount
+			b[i].Tax += tax
+			return b
+		}
+	}
+	return append(b, TaxBreakdownEntry{
+		RateBasisPoints: rate.RateBasisPoints,
+		Exempt:          rate.Exempt,
+		TaxableAmount:   taxableAmount,
+		Tax:             tax,
+	})
+}
+
+func (b TaxBreakdown) Proto(currency Currency) []*orderspb.TaxBreakdown {
+	breakdown := make([]*orderspb.TaxBreakdown, 0, len(b))
+	for _, entry := range b {
+		breakdown = append(breakdown, &orderspb.TaxBreakdown{
+			RateBasisPoints: int32(entry.RateBasisPoints),
+			Exempt:          entry.Exempt,
+			TaxableAmount:   NewMoney(entry.TaxableAmount, currency).Proto(),
+			Tax:             NewMoney(entry.Tax, currency).Proto(),
+		})
+	}
+	return breakdown
+}
+
+func (b *TaxBreakdown) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, b)
+}
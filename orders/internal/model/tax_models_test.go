@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaxRate_Tax(t *testing.T) {
+	vat := TaxRate{Category: Electronics, RateBasisPoints: 1600}
+	assert.Equal(t, NewMoney(1600, KES), vat.Tax(NewMoney(10000, KES)))
+	// 16% of 0.03 is 0.0048 which rounds to 0.00 and 16% of 0.04 is 0.0064 which rounds to 0.01
+	assert.Equal(t, NewMoney(0, KES), vat.Tax(NewMoney(3, KES)))
+	assert.Equal(t, NewMoney(1, KES), vat.Tax(NewMoney(4, KES)))
+
+	exempt := TaxRate{Category: Food, RateBasisPoints: 1600, Exempt: true}
+	assert.Equal(t, NewMoney(0, KES), exempt.Tax(NewMoney(10000, KES)))
+}
+
+func TestTaxBreakdown_Add(t *testing.T) {
+	vat := TaxRate{RateBasisPoints: 1600}
+	exempt := TaxRate{Exempt: true}
+
+	var breakdown TaxBreakdown
+	breakdown = breakdown.Add(vat, 10000, 1600)
+	breakdown = breakdown.Add(exempt, 5000, 0)
+	breakdown = breakdown.Add(vat, 2500, 400)
+
+	assert.Equal(t, TaxBreakdown{
+		{RateBasisPoints: 1600, TaxableAmount: 12500, Tax: 2000},
+		{Exempt: true, TaxableAmount: 5000},
+	}, breakdown)
+
+	protoBreakdown := breakdown.Proto(KES)
+	require.Len(t, protoBreakdown, 2)
+	assert.Equal(t, int32(1600), protoBreakdown[0].RateBasisPoints)
+	assert.Equal(t, int64(2000), protoBreakdown[0].Tax.Amount)
+	assert.True(t, protoBreakdown[1].Exempt)
+}
+
+func TestTaxBreakdown_Scan(t *testing.T) {
+	breakdown := TaxBreakdown{{RateBasisPoints: 1600, TaxableAmount: 10000, Tax: 1600}}
+	b, err := json.Marshal(breakdown)
+	require.NoError(t, err)
+
+	var scanned TaxBreakdown
+	require.NoError(t, scanned.Scan(b))
+	assert.Equal(t, breakdown, scanned)
+
+	assert.Error(t, scanned.Scan("not bytes"))
+}
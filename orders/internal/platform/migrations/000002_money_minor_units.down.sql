@@ -0,0 +1,5 @@
+ALTER TABLE orders ALTER COLUMN shipping_cost TYPE DOUBLE PRECISION USING shipping_cost / 100.0;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE products ALTER COLUMN price TYPE DECIMAL(10, 2) USING price / 100.0;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
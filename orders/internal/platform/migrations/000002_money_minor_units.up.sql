@@ -0,0 +1,7 @@
+-- Money is stored as an integer amount in the minor unit of the currency (e.g cents) alongside an ISO 4217 currency code.
+-- Existing rows were priced in Kenyan shillings.
+ALTER TABLE products ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'KES';
+ALTER TABLE products ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100)::BIGINT;
+
+ALTER TABLE orders ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'KES';
+ALTER TABLE orders ALTER COLUMN shipping_cost TYPE BIGINT USING ROUND(shipping_cost * 100)::BIGINT;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS shipping_rates;
+
+ALTER TABLE orders DROP COLUMN grand_total;
+ALTER TABLE orders DROP COLUMN tax;
+ALTER TABLE orders DROP COLUMN subtotal;
@@ -0,0 +1,29 @@
+-- Order totals are calculated by the orders service and stored in the minor unit of the order currency.
+ALTER TABLE orders ADD COLUMN subtotal BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN tax BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN grand_total BIGINT NOT NULL DEFAULT 0;
+
+UPDATE orders o
+SET subtotal = d.quantity * p.price,
+    grand_total = d.quantity * p.price + COALESCE(o.shipping_cost, 0)
+FROM order_details d
+JOIN products p ON p.product_id = d.product_id
+WHERE d.order_id = o.order_id;
+
+-- shipping_rates is the rate table used to price shipping. The zone is derived from the pickup and delivery addresses.
+CREATE TABLE shipping_rates (
+    shipping_method VARCHAR(255) NOT NULL,
+    zone VARCHAR(32) NOT NULL,
+    base_fee BIGINT NOT NULL,
+    per_item_fee BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'KES',
+    PRIMARY KEY (shipping_method, zone)
+);
+
+INSERT INTO shipping_rates (shipping_method, zone, base_fee, per_item_fee, currency) VALUES
+    ('STANDARD', 'LOCAL', 20000, 5000, 'KES'),
+    ('STANDARD', 'DOMESTIC', 45000, 10000, 'KES'),
+    ('STANDARD', 'INTERNATIONAL', 350000, 50000, 'KES'),
+    ('EXPRESS', 'LOCAL', 40000, 5000, 'KES'),
+    ('EXPRESS', 'DOMESTIC', 90000, 15000, 'KES'),
+    ('EXPRESS', 'INTERNATIONAL', 700000, 75000, 'KES');
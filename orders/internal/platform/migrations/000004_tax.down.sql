@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS invoice_counters;
+
+ALTER TABLE orders DROP COLUMN tax_breakdown;
+
+ALTER TABLE order_details DROP COLUMN currency;
+ALTER TABLE order_details DROP COLUMN tax;
+ALTER TABLE order_details DROP COLUMN tax_exempt;
+ALTER TABLE order_details DROP COLUMN tax_rate_basis_points;
+ALTER TABLE order_details DROP COLUMN line_total;
+ALTER TABLE order_details DROP COLUMN unit_price;
+
+DROP TABLE IF EXISTS tax_rates;
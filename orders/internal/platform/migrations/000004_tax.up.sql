@@ -0,0 +1,34 @@
+-- tax_rates holds the VAT rate for each product category in basis points (1600 is 16%).
+-- Exempt categories are not charged VAT.
+CREATE TABLE tax_rates (
+    category VARCHAR(255) PRIMARY KEY,
+    rate_basis_points INT NOT NULL CHECK (rate_basis_points >= 0),
+    exempt BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+INSERT INTO tax_rates (category, rate_basis_points, exempt) VALUES
+    ('ELECTRONICS', 1600, FALSE),
+    ('CLOTHING', 1600, FALSE),
+    ('BOOKS', 1600, FALSE),
+    ('FOOD', 0, TRUE),
+    ('TOYS', 1600, FALSE),
+    ('OTHER', 1600, FALSE);
+
+-- Every order line records the price and tax it was charged.
+ALTER TABLE order_details ADD COLUMN unit_price BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE order_details ADD COLUMN line_total BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE order_details ADD COLUMN tax_rate_basis_points INT NOT NULL DEFAULT 0;
+ALTER TABLE order_details ADD COLUMN tax_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE order_details ADD COLUMN tax BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE order_details ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'KES';
+
+ALTER TABLE orders ADD COLUMN tax_breakdown JSONB NOT NULL DEFAULT '[]';
+
+-- invoice_counters hands out invoice numbers. The counter row is locked and incremented in the
+-- transaction creating the order so a rolled back order does not leave a gap like a sequence would.
+CREATE TABLE invoice_counters (
+    name VARCHAR(64) PRIMARY KEY,
+    last_value BIGINT NOT NULL
+);
+
+INSERT INTO invoice_counters (name, last_value) VALUES ('orders', 0);
@@ -0,0 +1,9 @@
+-- invoices caches the rendered invoice of a paid order. Paid invoices no longer change.
+CREATE TABLE invoices (
+    order_id UUID PRIMARY KEY,
+    invoice_number VARCHAR(255) NOT NULL,
+    html TEXT NOT NULL,
+    pdf BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+ALTER TABLE order_details DROP COLUMN discount;
+ALTER TABLE orders DROP COLUMN promo_code;
+ALTER TABLE orders DROP COLUMN discount;
+
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,42 @@
+-- promotions are discount codes. A percentage promotion takes percent_off_basis_points (1000 is 10%)
+-- off the eligible lines and a fixed amount promotion takes amount_off in the minor unit of currency.
+-- A limit of 0 means the promotion can be redeemed any number of times.
+-- restrictions holds the product categories and product ids a promotion is limited to, none means every product.
+CREATE TABLE promotions (
+    promotion_id UUID PRIMARY KEY,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(64) NOT NULL,
+    percent_off_basis_points INT NOT NULL DEFAULT 0 CHECK (percent_off_basis_points >= 0 AND percent_off_basis_points <= 10000),
+    amount_off BIGINT NOT NULL DEFAULT 0 CHECK (amount_off >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'KES',
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    max_redemptions INT NOT NULL DEFAULT 0 CHECK (max_redemptions >= 0),
+    max_redemptions_per_customer INT NOT NULL DEFAULT 0 CHECK (max_redemptions_per_customer >= 0),
+    redemption_count INT NOT NULL DEFAULT 0,
+    restrictions JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+-- promotion_redemptions records every order a promotion was used on. Rows are written in the
+-- transaction creating the order so a failed order does not use up a redemption.
+CREATE TABLE promotion_redemptions (
+    redemption_id UUID PRIMARY KEY,
+    promotion_id UUID NOT NULL,
+    order_id UUID NOT NULL UNIQUE,
+    customer_id UUID NOT NULL,
+    discount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(promotion_id),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
+
+CREATE INDEX promotion_redemptions_customer_idx ON promotion_redemptions (promotion_id, customer_id);
+
+ALTER TABLE orders ADD COLUMN discount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN promo_code VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE order_details ADD COLUMN discount BIGINT NOT NULL DEFAULT 0;
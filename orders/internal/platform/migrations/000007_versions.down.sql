@@ -0,0 +1,3 @@
+ALTER TABLE products DROP COLUMN version;
+ALTER TABLE customers DROP COLUMN version;
+ALTER TABLE orders DROP COLUMN version;
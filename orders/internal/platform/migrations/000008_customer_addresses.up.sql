@@ -0,0 +1,18 @@
+-- customer_addresses is the address book of a customer. address holds the same JSON object as the
+-- addresses of orders so a saved address is copied onto an order as it is. A customer has at most
+-- one default shipping and one default billing address.
+CREATE TABLE customer_addresses (
+    address_id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    label VARCHAR(64) NOT NULL DEFAULT '',
+    address JSONB NOT NULL,
+    default_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+    default_billing BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id) ON DELETE CASCADE
+);
+
+CREATE INDEX customer_addresses_customer_idx ON customer_addresses (customer_id, created_at);
+CREATE UNIQUE INDEX customer_addresses_default_shipping_idx ON customer_addresses (customer_id) WHERE default_shipping;
+CREATE UNIQUE INDEX customer_addresses_default_billing_idx ON customer_addresses (customer_id) WHERE default_billing;
@@ -0,0 +1,27 @@
+-- shipments are orders handed to a carrier, an order is shipped once. status is the status of the
+-- latest event by occurred_at, last_event_at, so events reported out of order do not move it back.
+CREATE TABLE shipments (
+    shipment_id UUID PRIMARY KEY,
+    order_id UUID NOT NULL UNIQUE,
+    carrier VARCHAR(64) NOT NULL,
+    tracking_number VARCHAR(255) NOT NULL UNIQUE,
+    status VARCHAR(64) NOT NULL,
+    last_event_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
+
+-- shipment_events are the tracking events reported by carriers. Carriers may report an event more
+-- than once, an event of the same type at the same time is only recorded once.
+CREATE TABLE shipment_events (
+    event_id UUID PRIMARY KEY,
+    shipment_id UUID NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    location TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (shipment_id) REFERENCES shipments(shipment_id) ON DELETE CASCADE,
+    UNIQUE (shipment_id, event_type, occurred_at)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_delivery_slot_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_slot_id;
+DROP TABLE IF EXISTS delivery_slots;
@@ -0,0 +1,31 @@
+-- delivery_slots are the windows orders to a city are delivered in with a shipping method, every
+-- slot is offered every day. start_time and end_time are local times in time_zone, city is lower
+-- case and shipping_method upper case. capacity is the number of orders delivered in a slot on a
+-- day. Orders to cities without slots can be delivered at any time.
+CREATE TABLE delivery_slots (
+    slot_id VARCHAR(64) PRIMARY KEY,
+    city VARCHAR(255) NOT NULL,
+    shipping_method VARCHAR(255) NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'Africa/Nairobi',
+    capacity INT NOT NULL CHECK (capacity >= 0),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX delivery_slots_city_idx ON delivery_slots (city, shipping_method);
+
+INSERT INTO delivery_slots (slot_id, city, shipping_method, start_time, end_time, capacity) VALUES
+    ('nairobi-standard-morning', 'nairobi', 'STANDARD', '09:00', '13:00', 50),
+    ('nairobi-standard-afternoon', 'nairobi', 'STANDARD', '13:00', '18:00', 50),
+    ('nairobi-express-morning', 'nairobi', 'EXPRESS', '08:00', '12:00', 20),
+    ('nairobi-express-afternoon', 'nairobi', 'EXPRESS', '12:00', '16:00', 20),
+    ('nairobi-express-evening', 'nairobi', 'EXPRESS', '16:00', '20:00', 20),
+    ('mombasa-standard-day', 'mombasa', 'STANDARD', '09:00', '17:00', 30),
+    ('mombasa-express-morning', 'mombasa', 'EXPRESS', '08:00', '13:00', 10),
+    ('mombasa-express-afternoon', 'mombasa', 'EXPRESS', '13:00', '18:00', 10);
+
+-- orders store the slot they are delivered in, the orders of a slot that are not cancelled count
+-- towards its capacity on the day of their scheduled_delivery_datetime.
+ALTER TABLE orders ADD COLUMN delivery_slot_id VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX orders_delivery_slot_idx ON orders (delivery_slot_id, scheduled_delivery_datetime) WHERE delivery_slot_id <> '';
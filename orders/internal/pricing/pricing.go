@@ -0,0 +1,172 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	"github.com/wathuta/technical_test/orders/internal/tax"
+)
+
+var (
+	ErrUnsupportedShippingMethod = errors.New("no shipping rate for the shipping method and addresses")
+	ErrPriceMismatch             = errors.New("client amount does not match the calculated amount")
+	ErrNoItems                   = errors.New("an order must have at least one item")
+)
+
+// ShippingRateRepository looks up rows of the shipping rate table.
+type ShippingRateRepository interface {
+	GetShippingRate(ctx context.Context, shippingMethod string, zone model.ShippingZone) (*model.ShippingRate, error)
+}
+
+// Item is a product and the quantity of it being ordered.
+type Item struct {
+	Product  *model.Product
+	Quantity int64
+}
+
+// Calculator prices orders from the catalogue price of the products, the shipping rate table
+// and the tax rules. Amounts sent by clients are never used to price an order.
+type Calculator struct {
+	rates ShippingRateRepository
+	taxes *tax.Rules
+}
+
+func NewCalculator(rates ShippingRateRepository, taxes *tax.Rules) *Calculator {
+	return &Calculator{rates: rates, taxes: taxes}
+}
+
+// PriceOrder calculates the line totals, discount, shipping, tax and grand total of an order.
+// promotion is the validated promotion the customer redeemed on the order and may be nil.
+// The discount is taken off the line totals before tax so tax is only charged on what the customer pays.
+func (c *Calculator) PriceOrder(ctx context.Context, order *model.Order, items []Item, promotion *model.Promotion) (*model.OrderPricing, error) {
+	if len(items) == 0 {
+		return nil, ErrNoItems
+	}
+
+	pricing := &model.OrderPricing{}
+	var quantity int64
+	totals := make([]model.Money, 0, len(items))
+	eligible := make([]bool, 0, len(items))
+	for i, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity %d for product %s", item.Quantity, item.Product.ProductID)
+		}
+		unitPrice := item.Product.UnitPrice()
+		line := model.LineItem{
+			ProductID: item.Product.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+			Total:     unitPrice.Multiply(item.Quantity),
+			Discount:  model.NewMoney(0, unitPrice.Currency),
+		}
+		if i == 0 {
+			pricing.Subtotal = model.NewMoney(0, unitPrice.Currency)
+			pricing.Discount = model.NewMoney(0, unitPrice.Currency)
+			pricing.Tax = model.NewMoney(0, unitPrice.Currency)
+		}
+
+		var err error
+		pricing.Subtotal, err = pricing.Subtotal.Add(line.Total)
+		if err != nil {
+			return nil, err
+		}
+		pricing.Lines = append(pricing.Lines, line)
+		totals = append(totals, line.Total)
+		eligible = append(eligible, promotion != nil && promotion.AppliesTo(item.Product))
+		quantity += item.Quantity
+	}
+
+	if promotion != nil {
+		discounts, err := promotion.Discounts(totals, eligible)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pricing.Lines {
+			pricing.Lines[i].Discount = discounts[i]
+			pricing.Discount, err = pricing.Discount.Add(discounts[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, item := range items {
+		line := &pricing.Lines[i]
+		rate, lineTax, err := c.taxes.TaxLine(ctx, item.Product, line.Taxable())
+		if err != nil {
+			return nil, err
+		}
+		line.TaxRate = *rate
+		line.Tax = lineTax
+
+		pricing.Tax, err = pricing.Tax.Add(line.Tax)
+		if err != nil {
+			return nil, err
+		}
+		pricing.TaxBreakdown = pricing.TaxBreakdown.Add(line.TaxRate, line.Taxable().Amount, line.Tax.Amount)
+	}
+
+	var err error
+	pricing.Shipping, err = c.shipping(ctx, order, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing.GrandTotal = pricing.TaxedSubtotal()
+	pricing.GrandTotal, err = pricing.GrandTotal.Add(pricing.Shipping)
+	if err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// PriceShipping recalculates the shipping and grand total of an order priced before, once its
+// shipping method or addresses changed. The products keep the price, discount and tax they were
+// ordered at, quantity is the number of items shipped.
+func (c *Calculator) PriceShipping(ctx context.Context, order *model.Order, quantity int64) (*model.OrderPricing, error) {
+	pricing := &model.OrderPricing{
+		Subtotal:     model.NewMoney(order.Subtotal, order.Currency),
+		Discount:     model.NewMoney(order.Discount, order.Currency),
+		Tax:          model.NewMoney(order.Tax, order.Currency),
+		TaxBreakdown: order.TaxBreakdown,
+	}
+
+	var err error
+	pricing.Shipping, err = c.shipping(ctx, order, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing.GrandTotal, err = pricing.TaxedSubtotal().Add(pricing.Shipping)
+	if err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// shipping returns the cost of shipping quantity items by the shipping method of the order
+// between its addresses.
+func (c *Calculator) shipping(ctx context.Context, order *model.Order, quantity int64) (model.Money, error) {
+	zone := model.ShippingZoneFor(order.PickupAddress, order.DeliveryAddress)
+	rate, err := c.rates.GetShippingRate(ctx, strings.ToUpper(strings.TrimSpace(order.ShippingMethod)), zone)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Money{}, fmt.Errorf("%w: %q in zone %s", ErrUnsupportedShippingMethod, order.ShippingMethod, zone)
+		}
+		return model.Money{}, err
+	}
+	return rate.Fee(quantity), nil
+}
+
+// Verify checks an amount sent by a client against the calculated amount.
+// A nil client amount is not verified.
+func Verify(field string, calculated model.Money, client *model.Money) error {
+	if client == nil || *client == calculated {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is %s but was sent as %s", ErrPriceMismatch, field, calculated, *client)
+}
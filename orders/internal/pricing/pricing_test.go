@@ -0,0 +1,181 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/mocks"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+	"github.com/wathuta/technical_test/orders/internal/tax"
+)
+
+func testOrder() *model.Order {
+	return &model.Order{
+		ShippingMethod:  "standard",
+		PickupAddress:   model.Address{City: "Nairobi", Country: "Kenya"},
+		DeliveryAddress: model.Address{City: "Mombasa", Country: "Kenya"},
+	}
+}
+
+func testProduct(category model.ProductCategory, price int64, currency model.Currency) *model.Product {
+	return &model.Product{
+		ProductID:         "product",
+		Category:          category,
+		ProductAttributes: model.ProductAttributes{Price: price, Currency: currency},
+	}
+}
+
+// testTaxRules charges 16% VAT on everything except food
+func testTaxRules(t *testing.T) *tax.Rules {
+	taxRates := mocks.NewRateRepository(t)
+	taxRates.On("GetTaxRate", mock.Anything, model.Food).Return(&model.TaxRate{Category: model.Food, Exempt: true}, nil).Maybe()
+	taxRates.On("GetTaxRate", mock.Anything, mock.Anything).Return(&model.TaxRate{RateBasisPoints: 1600}, nil).Maybe()
+	return tax.NewRules(taxRates)
+}
+
+func TestPriceOrder(t *testing.T) {
+	rates := mocks.NewShippingRateRepository(t)
+	rates.On("GetShippingRate", mock.Anything, "STANDARD", model.ShippingZoneDomestic).Return(
+		&model.ShippingRate{ShippingMethod: "STANDARD", Zone: model.ShippingZoneDomestic, BaseFee: 45000, PerItemFee: 10000, Currency: model.KES},
+		nil,
+	)
+
+	pricing, err := NewCalculator(rates, testTaxRules(t)).PriceOrder(context.Background(), testOrder(), []Item{
+		{Product: testProduct(model.Electronics, 999, model.KES), Quantity: 3},
+		{Product: testProduct(model.Food, 5000, model.KES), Quantity: 1},
+	}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, pricing.Lines, 2)
+	assert.Equal(t, model.NewMoney(2997, model.KES), pricing.Lines[0].Total)
+	// 16% of 29.97 is 4.7952
+	assert.Equal(t, model.NewMoney(480, model.KES), pricing.Lines[0].Tax)
+	assert.Equal(t, model.NewMoney(0, model.KES), pricing.Lines[1].Tax)
+	assert.True(t, pricing.Lines[1].TaxRate.Exempt)
+	assert.Equal(t, model.NewMoney(7997, model.KES), pricing.Subtotal)
+	// base fee and 4 items
+	assert.Equal(t, model.NewMoney(85000, model.KES), pricing.Shipping)
+	assert.Equal(t, model.NewMoney(480, model.KES), pricing.Tax)
+	assert.Equal(t, model.TaxBreakdown{
+		{RateBasisPoints: 1600, TaxableAmount: 2997, Tax: 480},
+		{Exempt: true, TaxableAmount: 5000},
+	}, pricing.TaxBreakdown)
+	assert.Equal(t, model.NewMoney(93477, model.KES), pricing.GrandTotal)
+}
+
+func TestPriceOrder_Promotion(t *testing.T) {
+	rates := mocks.NewShippingRateRepository(t)
+	rates.On("GetShippingRate", mock.Anything, "STANDARD", model.ShippingZoneDomestic).Return(
+		&model.ShippingRate{ShippingMethod: "STANDARD", Zone: model.ShippingZoneDomestic, BaseFee: 45000, Currency: model.KES},
+		nil,
+	)
+	items := []Item{
+		{Product: testProduct(model.Electronics, 10000, model.KES), Quantity: 2},
+		{Product: testProduct(model.Food, 5000, model.KES), Quantity: 1},
+	}
+	// 10% off electronics only
+	promotion := &model.Promotion{
+		DiscountType:          model.DiscountTypePercentage,
+		PercentOffBasisPoints: 1000,
+		Restrictions:          model.PromotionRestrictions{Categories: []model.ProductCategory{model.Electronics}},
+	}
+
+	pricing, err := NewCalculator(rates, testTaxRules(t)).PriceOrder(context.Background(), testOrder(), items, promotion)
+	require.NoError(t, err)
+
+	assert.Equal(t, model.NewMoney(2000, model.KES), pricing.Lines[0].Discount)
+	assert.Equal(t, model.NewMoney(0, model.KES), pricing.Lines[1].Discount)
+	assert.Equal(t, model.NewMoney(25000, model.KES), pricing.Subtotal)
+	assert.Equal(t, model.NewMoney(2000, model.KES), pricing.Discount)
+	// tax is charged on the discounted line total, 16% of 180.00
+	assert.Equal(t, model.NewMoney(2880, model.KES), pricing.Tax)
+	assert.Equal(t, model.TaxBreakdown{
+		{RateBasisPoints: 1600, TaxableAmount: 18000, Tax: 2880},
+		{Exempt: true, TaxableAmount: 5000},
+	}, pricing.TaxBreakdown)
+	assert.Equal(t, model.NewMoney(25880, model.KES), pricing.TaxedSubtotal())
+	assert.Equal(t, model.NewMoney(70880, model.KES), pricing.GrandTotal)
+}
+
+func TestPriceOrder_UnsupportedShippingMethod(t *testing.T) {
+	rates := mocks.NewShippingRateRepository(t)
+	rates.On("GetShippingRate", mock.Anything, mock.Anything, mock.Anything).Return(nil, &repository.Error{Kind: repository.ErrNotFound})
+
+	_, err := NewCalculator(rates, testTaxRules(t)).PriceOrder(context.Background(), testOrder(), []Item{{Product: testProduct(model.Books, 999, model.KES), Quantity: 1}}, nil)
+	assert.True(t, errors.Is(err, ErrUnsupportedShippingMethod))
+}
+
+func TestPriceOrder_CurrencyMismatch(t *testing.T) {
+	rates := mocks.NewShippingRateRepository(t)
+	rates.On("GetShippingRate", mock.Anything, mock.Anything, mock.Anything).Return(
+		&model.ShippingRate{BaseFee: 100, Currency: model.USD},
+		nil,
+	)
+
+	_, err := NewCalculator(rates, testTaxRules(t)).PriceOrder(context.Background(), testOrder(), []Item{{Product: testProduct(model.Books, 999, model.KES), Quantity: 1}}, nil)
+	assert.True(t, errors.Is(err, model.ErrCurrencyMismatch))
+}
+
+func TestPriceOrder_InvalidItems(t *testing.T) {
+	calculator := NewCalculator(mocks.NewShippingRateRepository(t), testTaxRules(t))
+
+	_, err := calculator.PriceOrder(context.Background(), testOrder(), nil, nil)
+	assert.True(t, errors.Is(err, ErrNoItems))
+
+	_, err = calculator.PriceOrder(context.Background(), testOrder(), []Item{{Product: testProduct(model.Books, 999, model.KES), Quantity: 0}}, nil)
+	assert.Error(t, err)
+}
+
+func TestPriceShipping(t *testing.T) {
+	rates := mocks.NewShippingRateRepository(t)
+	rates.On("GetShippingRate", mock.Anything, "EXPRESS", model.ShippingZoneInternational).Return(
+		&model.ShippingRate{ShippingMethod: "EXPRESS", Zone: model.ShippingZoneInternational, BaseFee: 150000, PerItemFee: 20000, Currency: model.KES},
+		nil,
+	)
+
+	order := testOrder()
+	order.ShippingMethod = "express"
+	order.DeliveryAddress = model.Address{City: "Kampala", Country: "Uganda"}
+	order.Currency = model.KES
+	order.Subtotal = 2997
+	order.Discount = 300
+	order.Tax = 432
+	order.ShippingCost = 75000
+	order.GrandTotal = 78129
+
+	pricing, err := NewCalculator(rates, testTaxRules(t)).PriceShipping(context.Background(), order, 3)
+	require.NoError(t, err)
+
+	// the products keep the price they were ordered at
+	assert.Equal(t, model.NewMoney(2997, model.KES), pricing.Subtotal)
+	assert.Equal(t, model.NewMoney(300, model.KES), pricing.Discount)
+	assert.Equal(t, model.NewMoney(432, model.KES), pricing.Tax)
+	// base fee and 3 items
+	assert.Equal(t, model.NewMoney(210000, model.KES), pricing.Shipping)
+	assert.Equal(t, model.NewMoney(213129, model.KES), pricing.GrandTotal)
+}
+
+func TestPriceShipping_UnsupportedShippingMethod(t *testing.T) {
+	rates := mocks.NewShippingRateRepository(t)
+	rates.On("GetShippingRate", mock.Anything, mock.Anything, mock.Anything).Return(nil, &repository.Error{Kind: repository.ErrNotFound})
+
+	_, err := NewCalculator(rates, testTaxRules(t)).PriceShipping(context.Background(), testOrder(), 1)
+	assert.True(t, errors.Is(err, ErrUnsupportedShippingMethod))
+}
+
+func TestVerify(t *testing.T) {
+	calculated := model.NewMoney(1000, model.KES)
+
+	assert.NoError(t, Verify("grand_total", calculated, nil))
+
+	client := model.NewMoney(1000, model.KES)
+	assert.NoError(t, Verify("grand_total", calculated, &client))
+
+	client = model.NewMoney(900, model.KES)
+	assert.True(t, errors.Is(Verify("grand_total", calculated, &client), ErrPriceMismatch))
+}
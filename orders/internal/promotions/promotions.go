@@ -0,0 +1,71 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+)
+
+// PromotionRepository looks up promotions and how often they have been redeemed.
+type PromotionRepository interface {
+	GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error)
+	CountPromotionRedemptions(ctx context.Context, promotionId, customerId string) (int64, error)
+}
+
+// Rules checks that a customer can redeem a promo code on an order.
+// The usage limits are checked again when the redemption is stored as another order may
+// have used up the promotion in the meantime.
+type Rules struct {
+	promotions PromotionRepository
+	now        func() time.Time
+}
+
+func NewRules(promotions PromotionRepository) *Rules {
+	return &Rules{promotions: promotions, now: time.Now}
+}
+
+// Redeemable returns the promotion of code if customerId can redeem it on an order of products.
+func (r *Rules) Redeemable(ctx context.Context, code, customerId string, products []*model.Product) (*model.Promotion, error) {
+	code = model.NormalizePromoCode(code)
+	promotion, err := r.promotions.GetPromotionByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %q", model.ErrPromotionNotFound, code)
+		}
+		return nil, err
+	}
+
+	if !promotion.Active(r.now()) {
+		return nil, fmt.Errorf("%w: %q is valid from %s to %s", model.ErrPromotionNotActive, code,
+			promotion.StartsAt.Format(time.RFC3339), promotion.EndsAt.Format(time.RFC3339))
+	}
+	if promotion.Exhausted() {
+		return nil, fmt.Errorf("%w: %q", model.ErrPromotionExhausted, code)
+	}
+
+	applies := false
+	for _, product := range products {
+		if promotion.AppliesTo(product) {
+			applies = true
+			break
+		}
+	}
+	if !applies {
+		return nil, fmt.Errorf("%w: %q", model.ErrPromotionNotApplicable, code)
+	}
+
+	if promotion.MaxRedemptionsPerCustomer > 0 {
+		redemptions, err := r.promotions.CountPromotionRedemptions(ctx, promotion.PromotionID, customerId)
+		if err != nil {
+			return nil, err
+		}
+		if redemptions >= promotion.MaxRedemptionsPerCustomer {
+			return nil, fmt.Errorf("%w: %q", model.ErrPromotionCustomerLimitReached, code)
+		}
+	}
+	return promotion, nil
+}
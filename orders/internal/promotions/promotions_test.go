@@ -0,0 +1,97 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/mocks"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+)
+
+var now = time.Date(2023, 12, 10, 12, 0, 0, 0, time.UTC)
+
+func testRules(t *testing.T, promotion *model.Promotion) (*Rules, *mocks.PromotionRepository) {
+	repo := mocks.NewPromotionRepository(t)
+	if promotion != nil {
+		repo.On("GetPromotionByCode", mock.Anything, promotion.Code).Return(promotion, nil)
+	}
+	rules := NewRules(repo)
+	rules.now = func() time.Time { return now }
+	return rules, repo
+}
+
+func testPromotion() *model.Promotion {
+	return &model.Promotion{
+		PromotionID:           "promotion",
+		Code:                  "XMAS10",
+		DiscountType:          model.DiscountTypePercentage,
+		PercentOffBasisPoints: 1000,
+		StartsAt:              time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+		EndsAt:                time.Date(2023, 12, 26, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+var radio = &model.Product{ProductID: "radio", Category: model.Electronics}
+
+func TestRules_Redeemable(t *testing.T) {
+	promotion := testPromotion()
+	promotion.MaxRedemptionsPerCustomer = 2
+	rules, repo := testRules(t, promotion)
+	repo.On("CountPromotionRedemptions", mock.Anything, "promotion", "customer").Return(int64(1), nil)
+
+	redeemable, err := rules.Redeemable(context.Background(), " xmas10", "customer", []*model.Product{radio})
+	require.NoError(t, err)
+	assert.Equal(t, promotion, redeemable)
+}
+
+func TestRules_NotFound(t *testing.T) {
+	rules, repo := testRules(t, nil)
+	repo.On("GetPromotionByCode", mock.Anything, "NOPE").Return(nil, &repository.Error{Kind: repository.ErrNotFound})
+
+	_, err := rules.Redeemable(context.Background(), "nope", "customer", []*model.Product{radio})
+	assert.True(t, errors.Is(err, model.ErrPromotionNotFound))
+}
+
+func TestRules_NotActive(t *testing.T) {
+	promotion := testPromotion()
+	promotion.EndsAt = now.Add(-time.Hour)
+	rules, _ := testRules(t, promotion)
+
+	_, err := rules.Redeemable(context.Background(), promotion.Code, "customer", []*model.Product{radio})
+	assert.True(t, errors.Is(err, model.ErrPromotionNotActive))
+}
+
+func TestRules_Exhausted(t *testing.T) {
+	promotion := testPromotion()
+	promotion.MaxRedemptions = 10
+	promotion.RedemptionCount = 10
+	rules, _ := testRules(t, promotion)
+
+	_, err := rules.Redeemable(context.Background(), promotion.Code, "customer", []*model.Product{radio})
+	assert.True(t, errors.Is(err, model.ErrPromotionExhausted))
+}
+
+func TestRules_NotApplicable(t *testing.T) {
+	promotion := testPromotion()
+	promotion.Restrictions.Categories = []model.ProductCategory{model.Books}
+	rules, _ := testRules(t, promotion)
+
+	_, err := rules.Redeemable(context.Background(), promotion.Code, "customer", []*model.Product{radio})
+	assert.True(t, errors.Is(err, model.ErrPromotionNotApplicable))
+}
+
+func TestRules_CustomerLimitReached(t *testing.T) {
+	promotion := testPromotion()
+	promotion.MaxRedemptionsPerCustomer = 1
+	rules, repo := testRules(t, promotion)
+	repo.On("CountPromotionRedemptions", mock.Anything, "promotion", "customer").Return(int64(1), nil)
+
+	_, err := rules.Redeemable(context.Background(), promotion.Code, "customer", []*model.Product{radio})
+	assert.True(t, errors.Is(err, model.ErrPromotionCustomerLimitReached))
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+)
+
+const customerAddressColumns = `address_id, customer_id, label, address, default_shipping, default_billing, created_at, updated_at`
+
+func (r *repository) CreateCustomerAddress(ctx context.Context, address *model.CustomerAddress) (*model.CustomerAddress, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreateCustomerAddress")
+	defer span.End()
+
+	tx, err := r.connection.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, translate(err)
+	}
+	defer rollback(ctx, tx)
+
+	err = clearDefaultAddresses(ctx, tx, address.CustomerID, address.AddressID, address.DefaultShipping, address.DefaultBilling)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        INSERT INTO customer_addresses (` + customerAddressColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING ` + customerAddressColumns
+
+	created := model.CustomerAddress{}
+	err = tx.QueryRowxContext(
+		ctx, query,
+		address.AddressID,
+		address.CustomerID,
+		address.Label,
+		address.Address,
+		address.DefaultShipping,
+		address.DefaultBilling,
+		address.CreatedAt,
+		address.UpdatedAt,
+	).StructScan(&created)
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, translate(err)
+	}
+	return &created, nil
+}
+
+// GetCustomerAddress returns an address of the customer, the addresses of other customers are not
+// found.
+func (r *repository) GetCustomerAddress(ctx context.Context, customerId, addressId string) (*model.CustomerAddress, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetCustomerAddress")
+	defer span.End()
+
+	address := model.CustomerAddress{}
+	query := `SELECT ` + customerAddressColumns + ` FROM customer_addresses WHERE customer_id = $1 AND address_id = $2`
+
+	err := r.connection.GetContext(ctx, &address, query, customerId, addressId)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &address, nil
+}
+
+// GetDefaultShippingAddress returns the default shipping address of the customer, or
+// ErrNotFound when the customer has none.
+func (r *repository) GetDefaultShippingAddress(ctx context.Context, customerId string) (*model.CustomerAddress, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetDefaultShippingAddress")
+	defer span.End()
+
+	address := model.CustomerAddress{}
+	query := `SELECT ` + customerAddressColumns + ` FROM customer_addresses WHERE customer_id = $1 AND default_shipping`
+
+	err := r.connection.GetContext(ctx, &address, query, customerId)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &address, nil
+}
+
+func (r *repository) ListCustomerAddresses(ctx context.Context, customerId string, limit, offset int) ([]model.CustomerAddress, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "ListCustomerAddresses")
+	defer span.End()
+
+	addresses := []model.CustomerAddress{}
+	query := `SELECT ` + customerAddressColumns + ` FROM customer_addresses WHERE customer_id = $1
+        ORDER BY created_at, address_id LIMIT $2 OFFSET $3`
+
+	err := r.connection.SelectContext(ctx, &addresses, query, customerId, limit, offset)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return addresses, nil
+}
+
+func (r *repository) UpdateCustomerAddress(ctx context.Context, customerId, addressId string, updateFields map[string]interface{}) (*model.CustomerAddress, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "UpdateCustomerAddress")
+	defer span.End()
+
+	tx, err := r.connection.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, translate(err)
+	}
+	defer rollback(ctx, tx)
+
+	shipping, _ := updateFields["default_shipping"].(bool)
+	billing, _ := updateFields["default_billing"].(bool)
+	if err := clearDefaultAddresses(ctx, tx, customerId, addressId, shipping, billing); err != nil {
+		return nil, err
+	}
+
+	namedArgs := make(map[string]interface{})
+	setClauses := []string{}
+	for field, value := range updateFields {
+		setClauses = append(setClauses, setClause(field, value))
+		namedArgs[field] = value
+	}
+	query := "UPDATE customer_addresses SET " + strings.Join(setClauses, ",") +
+		" WHERE customer_id = :customer_id AND address_id = :address_id RETURNING " + customerAddressColumns
+	namedArgs["customer_id"] = customerId
+	namedArgs["address_id"] = addressId
+
+	query, args, err := tx.BindNamed(query, namedArgs)
+	if err != nil {
+		return nil, translate(err)
+	}
+	updated := model.CustomerAddress{}
+	if err := tx.QueryRowxContext(ctx, query, args...).StructScan(&updated); err != nil {
+		return nil, translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, translate(err)
+	}
+	return &updated, nil
+}
+
+func (r *repository) DeleteCustomerAddress(ctx context.Context, customerId, addressId string) (*model.CustomerAddress, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "DeleteCustomerAddress")
+	defer span.End()
+
+	query := `DELETE FROM customer_addresses WHERE customer_id = $1 AND address_id = $2 RETURNING ` + customerAddressColumns
+
+	deleted := model.CustomerAddress{}
+	err := r.connection.QueryRowxContext(ctx, query, customerId, addressId).StructScan(&deleted)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &deleted, nil
+}
+
+// clearDefaultAddresses unsets the defaults of the other addresses of a customer when the address
+// becomes the default shipping or billing address, a customer has at most one of each.
+func clearDefaultAddresses(ctx context.Context, tx *sqlx.Tx, customerId, addressId string, shipping, billing bool) error {
+	if !shipping && !billing {
+		return nil
+	}
+
+	query := `
+        UPDATE customer_addresses
+        SET default_shipping = default_shipping AND NOT $3, default_billing = default_billing AND NOT $4
+        WHERE customer_id = $1 AND address_id <> $2 AND (($3 AND default_shipping) OR ($4 AND default_billing))`
+
+	_, err := tx.ExecContext(ctx, query, customerId, addressId, shipping, billing)
+	return translate(err)
+}
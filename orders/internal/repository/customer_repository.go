@@ -5,15 +5,19 @@ import (
 	"strings"
 
 	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
 )
 
 func (r *repository) CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreateCustomer")
+	defer span.End()
+
 	// Define the SQL query with the RETURNING clause
 	query := `
 		INSERT INTO customers
 		(customer_id, name, email, phone_number, address, created_at, updated_at, deleted_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING customer_id, name, email, phone_number, address, created_at, updated_at, deleted_at
+		RETURNING customer_id, name, email, phone_number, address, created_at, updated_at, deleted_at, version
 	`
 
 	// Execute the SQL query and scan the result into the createdCustomer struct
@@ -23,35 +27,57 @@ func (r *repository) CreateCustomer(ctx context.Context, customer *model.Custome
 		customer.Address, customer.CreatedAt, customer.UpdatedAt, customer.DeletedAt,
 	).Scan(
 		&customer.CustomerID, &customer.Name, &customer.Email, &customer.PhoneNumber,
-		&customer.Address, &customer.CreatedAt, &customer.UpdatedAt, &customer.DeletedAt,
+		&customer.Address, &customer.CreatedAt, &customer.UpdatedAt, &customer.DeletedAt, &customer.Version,
 	)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 	// Return the created customer
 	return customer, nil
 }
 
 func (r *repository) GetCustomerById(ctx context.Context, customerID string) (*model.Customer, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetCustomerById")
+	defer span.End()
+
 	customer := model.Customer{}
 
 	query := `SELECT * FROM customers WHERE customer_id = $1`
 
-	err := r.connection.Get(&customer, query, customerID)
+	err := r.connection.GetContext(ctx, &customer, query, customerID)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	// Return query result.
 	return &customer, nil
 
 }
-func (r *repository) UpdateCustomerFields(ctx context.Context, customerID string, updateFields map[string]interface{}) (*model.Customer, error) {
+
+func (r *repository) ListCustomers(ctx context.Context, limit, offset int) ([]model.Customer, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "ListCustomers")
+	defer span.End()
+
+	customers := []model.Customer{}
+
+	query := `SELECT * FROM customers ORDER BY created_at, customer_id LIMIT $1 OFFSET $2`
+
+	err := r.connection.SelectContext(ctx, &customers, query, limit, offset)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return customers, nil
+}
+
+func (r *repository) UpdateCustomerFields(ctx context.Context, customerID string, version int64, updateFields map[string]interface{}) (*model.Customer, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "UpdateCustomerFields")
+	defer span.End()
+
 	tx, err := r.connection.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
-	defer tx.Rollback()
+	defer rollback(ctx, tx)
 
 	// Prepare the UPDATE statement
 	query := "UPDATE customers SET "
@@ -63,38 +89,48 @@ func (r *repository) UpdateCustomerFields(ctx context.Context, customerID string
 		setClauses = append(setClauses, field+"=:"+field) // Use named placeholders
 		namedArgs[field] = value
 	}
-	query += strings.Join(setClauses, ",") + " WHERE customer_id = :customer_id"
+	query += strings.Join(setClauses, ",") + ", version = version + 1 WHERE customer_id = :customer_id"
 	namedArgs["customer_id"] = customerID
+	if version != 0 {
+		query += " AND version = :version"
+		namedArgs["version"] = version
+	}
 
 	// Execute the UPDATE statement
-	_, err = tx.NamedExec(query, namedArgs)
+	result, err := tx.NamedExecContext(ctx, query, namedArgs)
 	if err != nil {
+		return nil, translate(err)
+	}
+	if err := checkUpdated(ctx, tx, result, "customers", "customer_id", customerID); err != nil {
 		return nil, err
 	}
 
 	// Commit the transaction
 	err = tx.Commit()
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	// Return the updated customer (you may need to fetch it from the database again)
 	updatedCustomer, err := r.GetCustomerById(ctx, customerID)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	return updatedCustomer, nil
 }
 
 func (r *repository) DeleteCustomer(ctx context.Context, customerID string) (*model.Customer, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "DeleteCustomer")
+	defer span.End()
+
 	// Start a transaction because this delete operation is not atomic. This ensures that all parts of the queries are a success before making changes
 	// if one part fails then all the changes are not made
 	tx, err := r.connection.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
-	defer tx.Rollback() // Rollback if there's an error
+	defer rollback(ctx, tx) // Rollback if there's an error
 
 	query := `
         DELETE FROM customers
@@ -106,17 +142,17 @@ func (r *repository) DeleteCustomer(ctx context.Context, customerID string) (*mo
 
 	// Use the transaction to execute the query and scan the result
 	err = tx.QueryRowContext(ctx, query, customerID).
-		Scan(&customer.CustomerID, &customer.Name, &customer.Email, &customer.PhoneNumber, &customer.Address, &customer.CreatedAt, &customer.UpdatedAt, &customer.DeletedAt)
+		Scan(&customer.CustomerID, &customer.Name, &customer.Email, &customer.PhoneNumber, &customer.Address, &customer.CreatedAt, &customer.UpdatedAt, &customer.DeletedAt, &customer.Version)
 
 	if err != nil {
 		// Rollback the transaction in case of an error
-		tx.Rollback()
-		return nil, err
+		rollback(ctx, tx)
+		return nil, translate(err)
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	return &customer, nil
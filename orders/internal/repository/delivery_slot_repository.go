@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+)
+
+// ListDeliverySlots returns the delivery slots of a city and shipping method sorted by start time.
+// The city is lower case as DeliveryCity returns it and the shipping method upper case.
+func (r *repository) ListDeliverySlots(ctx context.Context, city, shippingMethod string) ([]model.DeliverySlot, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "ListDeliverySlots")
+	defer span.End()
+
+	slots := []model.DeliverySlot{}
+	query := `
+        SELECT slot_id, city, shipping_method, start_time::text AS start_time, end_time::text AS end_time, time_zone, capacity
+        FROM delivery_slots WHERE city = $1 AND shipping_method = $2 ORDER BY start_time, slot_id`
+
+	err := r.connection.SelectContext(ctx, &slots, query, city, shippingMethod)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return slots, nil
+}
+
+// ListDeliveryBookings returns the orders, other than cancelled ones, delivered in the delivery slots
+// of a city and shipping method from from until to.
+func (r *repository) ListDeliveryBookings(ctx context.Context, city, shippingMethod string, from, to time.Time) ([]model.DeliveryBooking, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "ListDeliveryBookings")
+	defer span.End()
+
+	bookings := []model.DeliveryBooking{}
+	query := `
+        SELECT o.delivery_slot_id, o.scheduled_delivery_datetime
+        FROM orders o JOIN delivery_slots s ON s.slot_id = o.delivery_slot_id
+        WHERE s.city = $1 AND s.shipping_method = $2
+        AND o.scheduled_delivery_datetime >= $3 AND o.scheduled_delivery_datetime < $4 AND o.order_status <> $5`
+
+	err := r.connection.SelectContext(ctx, &bookings, query, city, shippingMethod, from.UTC(), to.UTC(), model.OrderStatusCanceled)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return bookings, nil
+}
+
+// bookDeliverySlot checks that window has capacity left for orderId besides the other orders booked
+// in it. The slot stays locked until the transaction ends so concurrent orders cannot book its last
+// place twice.
+func bookDeliverySlot(ctx context.Context, tx *sqlx.Tx, window *model.DeliveryWindow, orderId string) error {
+	var capacity int64
+	err := tx.QueryRowContext(ctx, `SELECT capacity FROM delivery_slots WHERE slot_id = $1 FOR UPDATE`, window.Slot.SlotID).
+		Scan(&capacity)
+	if err != nil {
+		return err
+	}
+
+	var booked int64
+	err = tx.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM orders
+        WHERE delivery_slot_id = $1 AND scheduled_delivery_datetime >= $2 AND scheduled_delivery_datetime < $3
+        AND order_status <> $4 AND order_id <> $5`,
+		window.Slot.SlotID, window.Start.UTC(), window.End.UTC(), model.OrderStatusCanceled, orderId,
+	).Scan(&booked)
+	if err != nil {
+		return err
+	}
+	if booked >= capacity {
+		return model.ErrDeliverySlotFull
+	}
+	return nil
+}
@@ -1,7 +1,98 @@
 package repository
 
-import "errors"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
 
+	"github.com/jackc/pgconn"
+)
+
+// Kinds of errors returned by the repository, test for them with errors.Is.
 var (
-	ErrIdDoesntExists = errors.New("Entity with Id not found")
+	// ErrNotFound is returned when the row looked up, updated or deleted does not exist.
+	ErrNotFound = errors.New("resource not found")
+	// ErrAlreadyExists is returned when a unique column such as a customer email or a product SKU
+	// is already used by another row.
+	ErrAlreadyExists = errors.New("resource already exists")
+	// ErrReferenceNotFound is returned when a row refers to a row that does not exist.
+	ErrReferenceNotFound = errors.New("referenced resource not found")
+	// ErrConstraintViolated is returned when a value is rejected by a check or not null constraint.
+	ErrConstraintViolated = errors.New("constraint violated")
+	// ErrConflict is returned when a transaction conflicts with a concurrent one, it can be retried.
+	ErrConflict = errors.New("conflicting concurrent transaction")
+	// ErrUnavailable is returned when the database cannot be reached.
+	ErrUnavailable = errors.New("database unavailable")
+	// ErrVersionMismatch is returned when an update expected a version of the row that has
+	// since been changed by another update.
+	ErrVersionMismatch = errors.New("version mismatch")
 )
+
+// errorCodes maps the postgres error codes to the kinds of errors.
+var errorCodes = map[string]error{
+	"23505": ErrAlreadyExists,      // unique_violation
+	"23503": ErrReferenceNotFound,  // foreign_key_violation
+	"23514": ErrConstraintViolated, // check_violation
+	"23502": ErrConstraintViolated, // not_null_violation
+	"40001": ErrConflict,           // serialization_failure
+	"40P01": ErrConflict,           // deadlock_detected
+	"53300": ErrUnavailable,        // too_many_connections
+	"57P01": ErrUnavailable,        // admin_shutdown
+	"57P02": ErrUnavailable,        // crash_shutdown
+	"57P03": ErrUnavailable,        // cannot_connect_now
+}
+
+// Error is a failed database call of a known kind.
+type Error struct {
+	// Kind is one of the Err values.
+	Kind error
+	// Constraint is the name of the violated constraint, if any.
+	Constraint string
+
+	cause error
+}
+
+func (e *Error) Error() string {
+	if e.Constraint != "" {
+		return fmt.Sprintf("%v: %s: %v", e.Kind, e.Constraint, e.cause)
+	}
+	return fmt.Sprintf("%v: %v", e.Kind, e.cause)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.cause}
+}
+
+// translate returns err as an *Error when it is of a known kind, other errors are returned as
+// they are.
+func translate(err error) error {
+	var repoErr *Error
+	if err == nil || errors.As(err, &repoErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, cause: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		kind, ok := errorCodes[pgErr.Code]
+		if !ok && strings.HasPrefix(pgErr.Code, "08") { // connection_exception
+			kind, ok = ErrUnavailable, true
+		}
+		if !ok {
+			return err
+		}
+		return &Error{Kind: kind, Constraint: pgErr.ConstraintName, cause: err}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) || pgconn.SafeToRetry(err) {
+		return &Error{Kind: ErrUnavailable, cause: err}
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/wathuta/technical_test/orders/internal/model"
+)
+
+func TestTranslate(t *testing.T) {
+	for err, kind := range map[error]error{
+		&pgconn.PgError{Code: "23505", ConstraintName: "customers_email_key"}: ErrAlreadyExists,
+		fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"}):              ErrAlreadyExists,
+		&pgconn.PgError{Code: "23503"}:                                        ErrReferenceNotFound,
+		&pgconn.PgError{Code: "23514"}:                                        ErrConstraintViolated,
+		&pgconn.PgError{Code: "40001"}:                                        ErrConflict,
+		&pgconn.PgError{Code: "40P01"}:                                        ErrConflict,
+		&pgconn.PgError{Code: "08006"}:                                        ErrUnavailable,
+		&pgconn.PgError{Code: "57P01"}:                                        ErrUnavailable,
+		driver.ErrBadConn:                                                     ErrUnavailable,
+		&net.OpError{Op: "dial", Err: errors.New("connection refused")}:       ErrUnavailable,
+		sql.ErrNoRows:                        ErrNotFound,
+		fmt.Errorf("get: %w", sql.ErrNoRows): ErrNotFound,
+	} {
+		translated := translate(err)
+		assert.ErrorIs(t, translated, kind, err.Error())
+		assert.ErrorIs(t, translated, err, "the cause is kept")
+	}
+
+	var repoErr *Error
+	assert.ErrorAs(t, translate(&pgconn.PgError{Code: "23505", ConstraintName: "products_sku_key"}), &repoErr)
+	assert.Equal(t, "products_sku_key", repoErr.Constraint)
+	assert.Same(t, repoErr, translate(repoErr), "errors are translated once")
+
+	// other errors are returned as they are
+	for _, err := range []error{
+		model.ErrPromotionExhausted,
+		context.DeadlineExceeded,
+		&pgconn.PgError{Code: "42P01"},
+	} {
+		assert.Equal(t, err, translate(err))
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+)
+
+func (r *repository) CreateInvoice(ctx context.Context, invoice *model.Invoice) (*model.Invoice, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreateInvoice")
+	defer span.End()
+
+	// a concurrent request may have stored the invoice first, both render the same paid invoice
+	query := `
+		INSERT INTO invoices (order_id, invoice_number, html, pdf, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (order_id) DO NOTHING
+	`
+
+	_, err := r.connection.ExecContext(ctx, query, invoice.OrderID, invoice.InvoiceNumber, invoice.HTML, invoice.PDF, invoice.CreatedAt)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return invoice, nil
+}
+
+func (r *repository) GetInvoiceByOrderId(ctx context.Context, orderId string) (*model.Invoice, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetInvoiceByOrderId")
+	defer span.End()
+
+	invoice := model.Invoice{}
+	query := `SELECT order_id, invoice_number, html, pdf, created_at FROM invoices WHERE order_id = $1`
+
+	err := r.connection.GetContext(ctx, &invoice, query, orderId)
+	if err != nil {
+		return nil, translate(err)
+	}
+	invoice.Paid = true
+	return &invoice, nil
+}
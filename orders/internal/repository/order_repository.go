@@ -2,45 +2,90 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/wathuta/technical_test/orders/internal/common"
 	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
 )
 
-func (r *repository) CreateOrder(ctx context.Context, order *model.Order, orderDetails *model.OrderDetails) (*model.Order, *model.OrderDetails, error) {
+// orderInvoiceCounter is the invoice_counters row used to number order invoices.
+const orderInvoiceCounter = "orders"
+
+// orderColumns are the columns of the orders table read into a model.Order.
+const orderColumns = `order_id, customer_id, pickup_address, delivery_address, shipping_method, order_status,
+        scheduled_pickup_datetime, scheduled_delivery_datetime, tracking_number, payment_method,
+        invoice_number, special_instructions, shipping_cost, currency, subtotal, tax, grand_total,
+        tax_breakdown, discount, promo_code, created_at, updated_at, deleted_at, delivery_slot_id, version`
+
+func formatInvoiceNumber(sequence int64) string {
+	return fmt.Sprintf("INV-%08d", sequence)
+}
+
+// CreateOrder stores an order and its details. redemption is the promotion redeemed on the order and may be nil,
+// it is recorded in the same transaction so the order fails if the promotion has been used up. slot is the
+// delivery window the order is delivered in and may be nil, the order fails if it has been fully booked.
+func (r *repository) CreateOrder(ctx context.Context, order *model.Order, orderDetails *model.OrderDetails, redemption *model.PromotionRedemption, slot *model.DeliveryWindow) (*model.Order, *model.OrderDetails, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreateOrder")
+	defer span.End()
+
 	// Start a transaction
 	tx, err := r.connection.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, translate(err)
 	}
 
 	// Defer rollback in case of error or return
 	defer func() {
 		if err != nil {
-			tx.Rollback()
+			rollback(ctx, tx)
 		}
 	}()
 
 	deliveryAddressToDB, err := common.MarshalToBytes(order.DeliveryAddress)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, translate(err)
 	}
 
 	pickupAddressToDB, err := common.MarshalToBytes(order.PickupAddress)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, translate(err)
+	}
+
+	if order.TaxBreakdown == nil {
+		order.TaxBreakdown = model.TaxBreakdown{}
+	}
+	taxBreakdownToDB, err := common.MarshalToBytes(order.TaxBreakdown)
+	if err != nil {
+		return nil, nil, translate(err)
+	}
+
+	if slot != nil {
+		err = bookDeliverySlot(ctx, tx, slot, order.OrderID)
+		if err != nil {
+			return nil, nil, translate(err)
+		}
 	}
 
+	// the counter row stays locked until the transaction ends so invoice numbers are handed out in
+	// order and a rolled back order gives its number back
+	var invoiceSequence int64
+	err = tx.QueryRowContext(ctx, `UPDATE invoice_counters SET last_value = last_value + 1 WHERE name = $1 RETURNING last_value`, orderInvoiceCounter).
+		Scan(&invoiceSequence)
+	if err != nil {
+		return nil, nil, translate(err)
+	}
+	order.InvoiceNumber = formatInvoiceNumber(invoiceSequence)
+
 	query := `
         INSERT INTO orders
         (order_id, customer_id, pickup_address, delivery_address, shipping_method, order_status,
         scheduled_pickup_datetime, scheduled_delivery_datetime, tracking_number, payment_method,
-        invoice_number, special_instructions, shipping_cost, created_at, updated_at, deleted_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-        RETURNING order_id, customer_id, pickup_address, delivery_address, shipping_method, order_status,
-        scheduled_pickup_datetime, scheduled_delivery_datetime, tracking_number, payment_method,
-        invoice_number, special_instructions, shipping_cost, created_at, updated_at, deleted_at`
+        invoice_number, special_instructions, shipping_cost, currency, subtotal, tax, grand_total,
+        tax_breakdown, discount, promo_code, created_at, updated_at, deleted_at, delivery_slot_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+        RETURNING ` + orderColumns
 
 	// Execute the SQL query and scan the result into the createdCustomer struct
 	err = tx.QueryRowContext(
@@ -58,9 +103,17 @@ func (r *repository) CreateOrder(ctx context.Context, order *model.Order, orderD
 		order.InvoiceNumber,
 		order.SpecialInstructions,
 		order.ShippingCost,
+		order.Currency,
+		order.Subtotal,
+		order.Tax,
+		order.GrandTotal,
+		taxBreakdownToDB,
+		order.Discount,
+		order.PromoCode,
 		order.CreatedAt,
 		order.UpdatedAt,
 		order.DeletedAt,
+		order.DeliverySlotID,
 	).Scan(
 		&order.OrderID,
 		&order.CustomerID,
@@ -75,19 +128,30 @@ func (r *repository) CreateOrder(ctx context.Context, order *model.Order, orderD
 		&order.InvoiceNumber,
 		&order.SpecialInstructions,
 		&order.ShippingCost,
+		&order.Currency,
+		&order.Subtotal,
+		&order.Tax,
+		&order.GrandTotal,
+		&order.TaxBreakdown,
+		&order.Discount,
+		&order.PromoCode,
 		&order.CreatedAt,
 		&order.UpdatedAt,
 		&order.DeletedAt,
+		&order.DeliverySlotID,
+		&order.Version,
 	)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, translate(err)
 	}
 
 	query = `
         INSERT INTO order_details
-        (order_details_id, order_id, product_id, quantity, created_at, updated_at, deleted_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
-        RETURNING order_details_id, order_id, product_id, quantity, created_at, updated_at, deleted_at
+        (order_details_id, order_id, product_id, quantity, created_at, updated_at, deleted_at,
+        unit_price, line_total, tax_rate_basis_points, tax_exempt, tax, currency, discount)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        RETURNING order_details_id, order_id, product_id, quantity, created_at, updated_at, deleted_at,
+        unit_price, line_total, tax_rate_basis_points, tax_exempt, tax, currency, discount
     `
 
 	// Execute the SQL query and scan the result into the createdCustomer struct
@@ -100,6 +164,13 @@ func (r *repository) CreateOrder(ctx context.Context, order *model.Order, orderD
 		orderDetails.CreatedAt,
 		orderDetails.UpdatedAt,
 		orderDetails.DeletedAt,
+		orderDetails.UnitPrice,
+		orderDetails.LineTotal,
+		orderDetails.TaxRateBasisPoints,
+		orderDetails.TaxExempt,
+		orderDetails.Tax,
+		orderDetails.Currency,
+		orderDetails.Discount,
 	).Scan(
 		&orderDetails.OrderDetailsID,
 		&orderDetails.OrderID,
@@ -108,27 +179,52 @@ func (r *repository) CreateOrder(ctx context.Context, order *model.Order, orderD
 		&orderDetails.CreatedAt,
 		&orderDetails.UpdatedAt,
 		&orderDetails.DeletedAt,
+		&orderDetails.UnitPrice,
+		&orderDetails.LineTotal,
+		&orderDetails.TaxRateBasisPoints,
+		&orderDetails.TaxExempt,
+		&orderDetails.Tax,
+		&orderDetails.Currency,
+		&orderDetails.Discount,
 	)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, translate(err)
+	}
+
+	if redemption != nil {
+		err = redeemPromotion(ctx, tx, redemption)
+		if err != nil {
+			return nil, nil, translate(err)
+		}
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
-		return nil, nil, err
+		return nil, nil, translate(err)
 	}
 
 	// Return the created customer
 	return order, orderDetails, nil
 }
 
-func (r *repository) UpdateOrder(ctx context.Context, orderId string, updateFields map[string]interface{}) (*model.Order, error) {
+// UpdateOrder updates the fields of an order. slot is the delivery window the order is moved to and
+// may be nil, the update fails if it has been fully booked by other orders.
+func (r *repository) UpdateOrder(ctx context.Context, orderId string, version int64, updateFields map[string]interface{}, slot *model.DeliveryWindow) (*model.Order, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "UpdateOrder")
+	defer span.End()
+
 	// Start a SQL transaction
 	tx, err := r.connection.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
+	}
+	defer rollback(ctx, tx)
+
+	if slot != nil {
+		if err := bookDeliverySlot(ctx, tx, slot, orderId); err != nil {
+			return nil, translate(err)
+		}
 	}
-	defer tx.Rollback()
 
 	// Prepare the UPDATE statement
 	query := "UPDATE orders SET "
@@ -137,178 +233,123 @@ func (r *repository) UpdateOrder(ctx context.Context, orderId string, updateFiel
 	// Build the SET clause for each field in the updateFields map
 	setClauses := []string{}
 	for field, value := range updateFields {
-		setClauses = append(setClauses, field+"=:"+field) // Use named placeholders
+		setClauses = append(setClauses, setClause(field, value))
 		namedArgs[field] = value
 	}
-	query += strings.Join(setClauses, ",") + " WHERE order_id = :order_id"
+	query += strings.Join(setClauses, ",") + ", version = version + 1 WHERE order_id = :order_id"
 	namedArgs["order_id"] = orderId
+	if version != 0 {
+		query += " AND version = :version"
+		namedArgs["version"] = version
+	}
 
 	// Execute the UPDATE statement
-	_, err = tx.NamedExec(query, namedArgs)
+	result, err := tx.NamedExecContext(ctx, query, namedArgs)
 	if err != nil {
+		return nil, translate(err)
+	}
+	if err := checkUpdated(ctx, tx, result, "orders", "order_id", orderId); err != nil {
 		return nil, err
 	}
 
 	// Commit the transaction
 	err = tx.Commit()
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	// Return the updated order (you may need to fetch it from the database again)
 	updatedOrder, err := r.GetOrderById(ctx, orderId)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	return updatedOrder, nil
 }
 
 func (r *repository) GetOrderById(ctx context.Context, orderId string) (*model.Order, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetOrderById")
+	defer span.End()
+
 	order := model.Order{}
 	// var addressFromDB interface{}
-	query := `SELECT * FROM orders WHERE order_id = $1`
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE order_id = $1`
 
-	err := r.connection.Get(&order, query, orderId)
+	err := r.connection.GetContext(ctx, &order, query, orderId)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 	return &order, nil
 }
 func (r *repository) GetOrdersByCustomerId(ctx context.Context, customerId string, limit, offset int) ([]model.Order, error) {
-	orders := []model.Order{}
-	order := model.Order{}
-	pickupAddr := &model.Address{}
-	deliveryAddr := &model.Address{}
+	ctx, span := tracing.StartDBSpan(ctx, "GetOrdersByCustomerId")
+	defer span.End()
 
-	var pickupAddressToDB interface{}
-	var deliveryAddressToDB interface{}
-
-	query := `SELECT * FROM orders WHERE customer_id = $1 LIMIT $2 OFFSET $3`
+	orders := []model.Order{}
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE customer_id = $1 LIMIT $2 OFFSET $3`
 
-	rows, err := r.connection.Queryx(query, customerId, limit, offset)
+	err := r.connection.SelectContext(ctx, &orders, query, customerId, limit, offset)
 	if err != nil {
-		return nil, err
-	}
-	for rows.Next() {
-		err = rows.Scan(
-			&order.OrderID,
-			&order.CustomerID,
-			&pickupAddressToDB,
-			&deliveryAddressToDB,
-			&order.ShippingMethod,
-			&order.OrderStatus,
-			&order.ScheduledPickupDatetime,
-			&order.ScheduledDeliveryDatetime,
-			&order.TrackingNumber,
-			&order.PaymentMethod,
-			&order.InvoiceNumber,
-			&order.SpecialInstructions,
-			&order.ShippingCost,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-			&order.DeletedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		if err := pickupAddr.Scan(pickupAddressToDB); err != nil {
-			return nil, err
-		}
-		if err := deliveryAddr.Scan(deliveryAddressToDB); err != nil {
-			return nil, err
-		}
-		order.PickupAddress = *pickupAddr
-		order.DeliveryAddress = *deliveryAddr
-		orders = append(orders, order)
+		return nil, translate(err)
 	}
-
 	return orders, nil
 }
 func (r *repository) DeleteOrder(ctx context.Context, orderId string) (*model.Order, error) {
-	tx, err := r.connection.BeginTx(ctx, nil)
+	ctx, span := tracing.StartDBSpan(ctx, "DeleteOrder")
+	defer span.End()
+
+	tx, err := r.connection.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
-	defer tx.Rollback() // Rollback if there's an error
+	defer rollback(ctx, tx) // Rollback if there's an error
 
 	query := `
         DELETE FROM orders
         WHERE order_id = $1
-        RETURNING *
-    `
-	pickupAddr := &model.Address{}
-	deliveryAddr := &model.Address{}
-
-	var pickupAddressFromDB interface{}
-	var deliveryAddressFromDB interface{}
+        RETURNING ` + orderColumns
 
 	var order model.Order
-
-	// Use the transaction to execute the query and scan the result
-	err = tx.QueryRowContext(ctx, query, orderId).
-		Scan(
-			&order.OrderID,
-			&order.CustomerID,
-			&pickupAddressFromDB,
-			&deliveryAddressFromDB,
-			&order.ShippingMethod,
-			&order.OrderStatus,
-			&order.ScheduledPickupDatetime,
-			&order.ScheduledDeliveryDatetime,
-			&order.TrackingNumber,
-			&order.PaymentMethod,
-			&order.InvoiceNumber,
-			&order.SpecialInstructions,
-			&order.ShippingCost,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-			&order.DeletedAt,
-		)
-
+	err = tx.QueryRowxContext(ctx, query, orderId).StructScan(&order)
 	if err != nil {
-		// Rollback the transaction in case of an error
-		tx.Rollback()
-		return nil, err
+		return nil, translate(err)
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
-
-	if err := pickupAddr.Scan(pickupAddressFromDB); err != nil {
-		return nil, err
-	}
-	if err := deliveryAddr.Scan(deliveryAddressFromDB); err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
-	order.PickupAddress = *pickupAddr
-	order.DeliveryAddress = *deliveryAddr
 
 	return &order, nil
 }
 func (r *repository) GetOrderDetailsById(ctx context.Context, orderDetailsId string) (*model.OrderDetails, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetOrderDetailsById")
+	defer span.End()
+
 	orderDetails := model.OrderDetails{}
 	// var addressFromDB interface{}
 	query := `SELECT * FROM order_details WHERE order_details_id = $1`
 
-	err := r.connection.Get(&orderDetails, query, orderDetailsId)
+	err := r.connection.GetContext(ctx, &orderDetails, query, orderDetailsId)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 	return &orderDetails, nil
 }
 func (r *repository) GetOrderDetailsByProductId(ctx context.Context, productId string, limit, offset int) ([]model.OrderDetails, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetOrderDetailsByProductId")
+	defer span.End()
+
 	orderDetails := []model.OrderDetails{}
 	orderDetail := model.OrderDetails{}
 
 	query := `SELECT * FROM order_details WHERE product_id = $1 LIMIT $2 OFFSET $3`
 
-	rows, err := r.connection.Queryx(query, productId, limit, offset)
+	rows, err := r.connection.QueryxContext(ctx, query, productId, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
+	defer rows.Close()
 	for rows.Next() {
 		err = rows.Scan(
 			&orderDetail.OrderDetailsID,
@@ -318,27 +359,41 @@ func (r *repository) GetOrderDetailsByProductId(ctx context.Context, productId s
 			&orderDetail.CreatedAt,
 			&orderDetail.UpdatedAt,
 			&orderDetail.DeletedAt,
+			&orderDetail.UnitPrice,
+			&orderDetail.LineTotal,
+			&orderDetail.TaxRateBasisPoints,
+			&orderDetail.TaxExempt,
+			&orderDetail.Tax,
+			&orderDetail.Currency,
+			&orderDetail.Discount,
 		)
 		if err != nil {
-			return nil, err
+			return nil, translate(err)
 		}
 
 		orderDetails = append(orderDetails, orderDetail)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, translate(err)
+	}
 
 	return orderDetails, nil
 }
 
 func (r *repository) GetOrderDetailsByOrderId(ctx context.Context, orderId string, limit, offset int) ([]model.OrderDetails, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetOrderDetailsByOrderId")
+	defer span.End()
+
 	orderDetails := []model.OrderDetails{}
 	orderDetail := model.OrderDetails{}
 
 	query := `SELECT * FROM order_details WHERE order_id = $1 LIMIT $2 OFFSET $3`
 
-	rows, err := r.connection.Queryx(query, orderId, limit, offset)
+	rows, err := r.connection.QueryxContext(ctx, query, orderId, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
+	defer rows.Close()
 	for rows.Next() {
 		err = rows.Scan(
 			&orderDetail.OrderDetailsID,
@@ -348,13 +403,23 @@ func (r *repository) GetOrderDetailsByOrderId(ctx context.Context, orderId strin
 			&orderDetail.CreatedAt,
 			&orderDetail.UpdatedAt,
 			&orderDetail.DeletedAt,
+			&orderDetail.UnitPrice,
+			&orderDetail.LineTotal,
+			&orderDetail.TaxRateBasisPoints,
+			&orderDetail.TaxExempt,
+			&orderDetail.Tax,
+			&orderDetail.Currency,
+			&orderDetail.Discount,
 		)
 		if err != nil {
-			return nil, err
+			return nil, translate(err)
 		}
 
 		orderDetails = append(orderDetails, orderDetail)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, translate(err)
+	}
 
 	return orderDetails, nil
 }
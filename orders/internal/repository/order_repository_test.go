@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wathuta/technical_test/orders/internal/model"
+)
+
+func TestOrderColumns(t *testing.T) {
+	var columns []string
+	for _, column := range strings.Split(orderColumns, ",") {
+		columns = append(columns, strings.TrimSpace(column))
+	}
+
+	// every column of an order is read, a column added without its field fails the query
+	orderType := reflect.TypeOf(model.Order{})
+	var fields []string
+	for i := 0; i < orderType.NumField(); i++ {
+		if tag := orderType.Field(i).Tag.Get("db"); tag != "" && tag != "-" {
+			fields = append(fields, tag)
+		}
+	}
+	assert.ElementsMatch(t, fields, columns)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+)
+
+func (r *repository) GetShippingRate(ctx context.Context, shippingMethod string, zone model.ShippingZone) (*model.ShippingRate, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetShippingRate")
+	defer span.End()
+
+	rate := model.ShippingRate{}
+	query := `SELECT shipping_method, zone, base_fee, per_item_fee, currency FROM shipping_rates WHERE shipping_method = $1 AND zone = $2`
+
+	err := r.connection.GetContext(ctx, &rate, query, shippingMethod, zone)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &rate, nil
+}
+
+func (r *repository) GetTaxRate(ctx context.Context, category model.ProductCategory) (*model.TaxRate, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetTaxRate")
+	defer span.End()
+
+	rate := model.TaxRate{}
+	query := `SELECT category, rate_basis_points, exempt FROM tax_rates WHERE category = $1`
+
+	err := r.connection.GetContext(ctx, &rate, query, category)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &rate, nil
+}
@@ -5,50 +5,75 @@ import (
 	"strings"
 
 	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
 )
 
 func (r *repository) CreateProduct(ctx context.Context, product *model.Product) (*model.Product, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreateProduct")
+	defer span.End()
+
 	query := `
 		INSERT INTO products
-		(product_id, name, sku, category , brand , model , price , stock_quantity , is_available , created_at , updated_at, deleted_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		RETURNING product_id, name, sku, category, brand, model, price, stock_quantity, is_available, created_at, updated_at, deleted_at
+		(product_id, name, sku, category , brand , model , price , currency, stock_quantity , is_available , created_at , updated_at, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING product_id, name, sku, category, brand, model, price, currency, stock_quantity, is_available, created_at, updated_at, deleted_at, version
 	`
 	err := r.connection.QueryRowContext(
 		ctx, query,
 		product.ProductID, product.Name, product.Sku, product.Category, product.Brand,
-		product.Model, product.Price, product.StockQuantity, product.IsAvailable,
+		product.Model, product.Price, product.Currency, product.StockQuantity, product.IsAvailable,
 		product.CreatedAt, product.UpdatedAt, product.DeletedAt,
 	).Scan(
 		&product.ProductID, &product.Name, &product.Sku, &product.Category, &product.Brand,
-		&product.Model, &product.Price, &product.StockQuantity, &product.IsAvailable,
-		&product.CreatedAt, &product.UpdatedAt, &product.DeletedAt,
+		&product.Model, &product.Price, &product.Currency, &product.StockQuantity, &product.IsAvailable,
+		&product.CreatedAt, &product.UpdatedAt, &product.DeletedAt, &product.Version,
 	)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	return product, nil
 }
 func (r *repository) GetProductById(ctx context.Context, productId string) (*model.Product, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetProductById")
+	defer span.End()
+
 	product := model.Product{}
 
 	query := `SELECT * FROM products WHERE product_id = $1`
 
 	err := r.connection.GetContext(ctx, &product, query, productId)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 	return &product, nil
 }
 
-func (r *repository) UpdateProductFields(ctx context.Context, productID string, updateFields map[string]interface{}) (*model.Product, error) {
+func (r *repository) ListProducts(ctx context.Context, limit, offset int) ([]model.Product, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "ListProducts")
+	defer span.End()
+
+	products := []model.Product{}
+
+	query := `SELECT * FROM products ORDER BY created_at, product_id LIMIT $1 OFFSET $2`
+
+	err := r.connection.SelectContext(ctx, &products, query, limit, offset)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return products, nil
+}
+
+func (r *repository) UpdateProductFields(ctx context.Context, productID string, version int64, updateFields map[string]interface{}) (*model.Product, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "UpdateProductFields")
+	defer span.End()
+
 	// Start a SQL transaction
 	tx, err := r.connection.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
-	defer tx.Rollback()
+	defer rollback(ctx, tx)
 
 	// Prepare the UPDATE statement
 	query := "UPDATE products SET "
@@ -60,37 +85,47 @@ func (r *repository) UpdateProductFields(ctx context.Context, productID string,
 		setClauses = append(setClauses, field+"=:"+field) // Use named placeholders
 		namedArgs[field] = value
 	}
-	query += strings.Join(setClauses, ",") + " WHERE product_id = :product_id"
+	query += strings.Join(setClauses, ",") + ", version = version + 1 WHERE product_id = :product_id"
 	namedArgs["product_id"] = productID
+	if version != 0 {
+		query += " AND version = :version"
+		namedArgs["version"] = version
+	}
 
 	// Execute the UPDATE statement
-	_, err = tx.NamedExec(query, namedArgs)
+	result, err := tx.NamedExecContext(ctx, query, namedArgs)
 	if err != nil {
+		return nil, translate(err)
+	}
+	if err := checkUpdated(ctx, tx, result, "products", "product_id", productID); err != nil {
 		return nil, err
 	}
 
 	// Commit the transaction
 	err = tx.Commit()
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	// Return the updated product (you may need to fetch it from the database again)
 	updatedProduct, err := r.GetProductById(ctx, productID)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	return updatedProduct, nil
 }
 
 func (r *repository) DeleteProduct(ctx context.Context, productId string) (*model.Product, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "DeleteProduct")
+	defer span.End()
+
 	// Start a transaction
 	tx, err := r.connection.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
-	defer tx.Rollback() // Rollback if there's an error
+	defer rollback(ctx, tx) // Rollback if there's an error
 
 	query := `
         DELETE FROM products
@@ -102,17 +137,17 @@ func (r *repository) DeleteProduct(ctx context.Context, productId string) (*mode
 
 	// Use the transaction to execute the query and scan the result
 	err = tx.QueryRowContext(ctx, query, productId).
-		Scan(&product.ProductID, &product.Name, &product.Sku, &product.Category, &product.Brand, &product.Model, &product.Price, &product.StockQuantity, &product.IsAvailable, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
+		Scan(&product.ProductID, &product.Name, &product.Sku, &product.Category, &product.Brand, &product.Model, &product.Price, &product.StockQuantity, &product.IsAvailable, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt, &product.Currency, &product.Version)
 
 	if err != nil {
 		// Rollback the transaction in case of an error
-		tx.Rollback()
-		return nil, err
+		rollback(ctx, tx)
+		return nil, translate(err)
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 
 	return &product, nil
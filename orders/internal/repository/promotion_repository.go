@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/wathuta/technical_test/orders/internal/common"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+)
+
+const promotionColumns = `promotion_id, code, description, discount_type, percent_off_basis_points, amount_off, currency,
+        starts_at, ends_at, max_redemptions, max_redemptions_per_customer, redemption_count, restrictions,
+        created_at, updated_at`
+
+func (r *repository) CreatePromotion(ctx context.Context, promotion *model.Promotion) (*model.Promotion, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreatePromotion")
+	defer span.End()
+
+	restrictionsToDB, err := common.MarshalToBytes(promotion.Restrictions)
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	query := `
+        INSERT INTO promotions (` + promotionColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+        RETURNING ` + promotionColumns
+
+	created := model.Promotion{}
+	err = r.connection.QueryRowxContext(
+		ctx, query,
+		promotion.PromotionID,
+		promotion.Code,
+		promotion.Description,
+		promotion.DiscountType,
+		promotion.PercentOffBasisPoints,
+		promotion.AmountOff,
+		promotion.Currency,
+		promotion.StartsAt,
+		promotion.EndsAt,
+		promotion.MaxRedemptions,
+		promotion.MaxRedemptionsPerCustomer,
+		promotion.RedemptionCount,
+		restrictionsToDB,
+		promotion.CreatedAt,
+		promotion.UpdatedAt,
+	).StructScan(&created)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &created, nil
+}
+
+func (r *repository) GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetPromotionByCode")
+	defer span.End()
+
+	promotion := model.Promotion{}
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1`
+
+	err := r.connection.GetContext(ctx, &promotion, query, code)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &promotion, nil
+}
+
+func (r *repository) CountPromotionRedemptions(ctx context.Context, promotionId, customerId string) (int64, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CountPromotionRedemptions")
+	defer span.End()
+
+	var count int64
+	query := `SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND customer_id = $2`
+
+	err := r.connection.GetContext(ctx, &count, query, promotionId, customerId)
+	if err != nil {
+		return 0, translate(err)
+	}
+	return count, nil
+}
+
+// redeemPromotion records a redemption in the transaction creating its order. Updating the promotion
+// row locks it until the transaction ends so concurrent orders cannot redeem it past its limits.
+func redeemPromotion(ctx context.Context, tx *sqlx.Tx, redemption *model.PromotionRedemption) error {
+	var maxPerCustomer int64
+	err := tx.QueryRowContext(ctx, `
+        UPDATE promotions SET redemption_count = redemption_count + 1, updated_at = $2
+        WHERE promotion_id = $1 AND (max_redemptions = 0 OR redemption_count < max_redemptions)
+        RETURNING max_redemptions_per_customer`,
+		redemption.PromotionID, redemption.CreatedAt,
+	).Scan(&maxPerCustomer)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrPromotionExhausted
+		}
+		return err
+	}
+
+	if maxPerCustomer > 0 {
+		var count int64
+		err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND customer_id = $2`,
+			redemption.PromotionID, redemption.CustomerID,
+		).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count >= maxPerCustomer {
+			return model.ErrPromotionCustomerLimitReached
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO promotion_redemptions (redemption_id, promotion_id, order_id, customer_id, discount, currency, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		redemption.RedemptionID,
+		redemption.PromotionID,
+		redemption.OrderID,
+		redemption.CustomerID,
+		redemption.Discount,
+		redemption.Currency,
+		redemption.CreatedAt,
+	)
+	return err
+}
@@ -2,14 +2,20 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/wathuta/technical_test/orders/internal/logging"
 	"github.com/wathuta/technical_test/orders/internal/model"
 )
 
+// Repository stores the orders, customers and products. The update methods only apply to the
+// given version of a row, or to any version when it is 0, and increment it.
 type Repository interface {
-	CreateOrder(ctx context.Context, order *model.Order, order_details *model.OrderDetails) (*model.Order, *model.OrderDetails, error)
-	UpdateOrder(ctx context.Context, orderId string, updateFields map[string]interface{}) (*model.Order, error)
+	CreateOrder(ctx context.Context, order *model.Order, order_details *model.OrderDetails, redemption *model.PromotionRedemption, slot *model.DeliveryWindow) (*model.Order, *model.OrderDetails, error)
+	UpdateOrder(ctx context.Context, orderId string, version int64, updateFields map[string]interface{}, slot *model.DeliveryWindow) (*model.Order, error)
 	GetOrderById(ctx context.Context, orderId string) (*model.Order, error)
 	GetOrdersByCustomerId(ctx context.Context, customerId string, limit, offset int) ([]model.Order, error)
 	DeleteOrder(ctx context.Context, orderId string) (*model.Order, error)
@@ -19,13 +25,38 @@ type Repository interface {
 
 	CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error)
 	GetCustomerById(ctx context.Context, customerID string) (*model.Customer, error)
-	UpdateCustomerFields(ctx context.Context, customerID string, updateFields map[string]interface{}) (*model.Customer, error)
+	ListCustomers(ctx context.Context, limit, offset int) ([]model.Customer, error)
+	UpdateCustomerFields(ctx context.Context, customerID string, version int64, updateFields map[string]interface{}) (*model.Customer, error)
 	DeleteCustomer(ctx context.Context, customerID string) (*model.Customer, error)
 
+	CreateCustomerAddress(ctx context.Context, address *model.CustomerAddress) (*model.CustomerAddress, error)
+	GetCustomerAddress(ctx context.Context, customerId, addressId string) (*model.CustomerAddress, error)
+	GetDefaultShippingAddress(ctx context.Context, customerId string) (*model.CustomerAddress, error)
+	ListCustomerAddresses(ctx context.Context, customerId string, limit, offset int) ([]model.CustomerAddress, error)
+	UpdateCustomerAddress(ctx context.Context, customerId, addressId string, updateFields map[string]interface{}) (*model.CustomerAddress, error)
+	DeleteCustomerAddress(ctx context.Context, customerId, addressId string) (*model.CustomerAddress, error)
+
 	CreateProduct(ctx context.Context, product *model.Product) (*model.Product, error)
 	GetProductById(ctx context.Context, productId string) (*model.Product, error)
+	ListProducts(ctx context.Context, limit, offset int) ([]model.Product, error)
 	DeleteProduct(ctx context.Context, productId string) (*model.Product, error)
-	UpdateProductFields(ctx context.Context, productId string, updateFields map[string]interface{}) (*model.Product, error)
+	UpdateProductFields(ctx context.Context, productId string, version int64, updateFields map[string]interface{}) (*model.Product, error)
+
+	GetShippingRate(ctx context.Context, shippingMethod string, zone model.ShippingZone) (*model.ShippingRate, error)
+	GetTaxRate(ctx context.Context, category model.ProductCategory) (*model.TaxRate, error)
+	ListDeliverySlots(ctx context.Context, city, shippingMethod string) ([]model.DeliverySlot, error)
+	ListDeliveryBookings(ctx context.Context, city, shippingMethod string, from, to time.Time) ([]model.DeliveryBooking, error)
+
+	CreateInvoice(ctx context.Context, invoice *model.Invoice) (*model.Invoice, error)
+	GetInvoiceByOrderId(ctx context.Context, orderId string) (*model.Invoice, error)
+
+	CreatePromotion(ctx context.Context, promotion *model.Promotion) (*model.Promotion, error)
+	GetPromotionByCode(ctx context.Context, code string) (*model.Promotion, error)
+	CountPromotionRedemptions(ctx context.Context, promotionId, customerId string) (int64, error)
+
+	CreateShipment(ctx context.Context, shipment *model.Shipment) (*model.Shipment, error)
+	GetShipmentByTrackingNumber(ctx context.Context, trackingNumber string) (*model.Shipment, error)
+	RecordShipmentEvent(ctx context.Context, shipment *model.Shipment, event *model.ShipmentEvent) (*model.Shipment, bool, error)
 }
 
 type repository struct {
@@ -37,3 +68,42 @@ func NewRepository(connection *sqlx.DB) Repository {
 		connection: connection,
 	}
 }
+
+// rollback aborts tx. Failures are only logged as the caller returns the error that made it roll
+// back, and rolling back a committed transaction is expected when it is deferred.
+func rollback(ctx context.Context, tx interface{ Rollback() error }) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		logging.FromContext(ctx).Error("failed to roll back transaction", "error", err)
+	}
+}
+
+// setClause returns the SET clause updating field to the named parameter of the same name. A
+// model.JSONMerge is merged into the stored JSON object, which may be null.
+func setClause(field string, value interface{}) string {
+	if _, ok := value.(model.JSONMerge); ok {
+		return field + "=COALESCE(" + field + ", CAST('{}' AS jsonb)) || CAST(:" + field + " AS jsonb)"
+	}
+	return field + "=:" + field // Use named placeholders
+}
+
+// checkUpdated returns ErrVersionMismatch when an update of the row with the given id matched
+// no row because its version changed, and ErrNotFound when the row does not exist.
+func checkUpdated(ctx context.Context, tx *sqlx.Tx, result sql.Result, table, idColumn, id string) error {
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return translate(err)
+	}
+	if updated > 0 {
+		return nil
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM " + table + " WHERE " + idColumn + " = $1)"
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return translate(err)
+	}
+	if !exists {
+		return translate(sql.ErrNoRows)
+	}
+	return ErrVersionMismatch
+}
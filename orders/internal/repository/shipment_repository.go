@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
+)
+
+const (
+	shipmentColumns      = `shipment_id, order_id, carrier, tracking_number, status, created_at, updated_at`
+	shipmentEventColumns = `event_id, shipment_id, event_type, location, description, occurred_at, recorded_at`
+)
+
+// CreateShipment stores the shipment of an order and sets the tracking number of the order to the
+// one of the shipment.
+func (r *repository) CreateShipment(ctx context.Context, shipment *model.Shipment) (*model.Shipment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreateShipment")
+	defer span.End()
+
+	tx, err := r.connection.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, translate(err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `
+        INSERT INTO shipments (` + shipmentColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING ` + shipmentColumns
+
+	created := model.Shipment{}
+	err = tx.QueryRowxContext(
+		ctx, query,
+		shipment.ShipmentID,
+		shipment.OrderID,
+		shipment.Carrier,
+		shipment.TrackingNumber,
+		shipment.Status,
+		shipment.CreatedAt,
+		shipment.UpdatedAt,
+	).StructScan(&created)
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	query = `UPDATE orders SET tracking_number = $2, updated_at = $3, version = version + 1 WHERE order_id = $1`
+	if _, err := tx.ExecContext(ctx, query, shipment.OrderID, shipment.TrackingNumber, shipment.UpdatedAt); err != nil {
+		return nil, translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, translate(err)
+	}
+	created.Events = []model.ShipmentEvent{}
+	return &created, nil
+}
+
+// GetShipmentByTrackingNumber returns the shipment with the tracking number and its events.
+func (r *repository) GetShipmentByTrackingNumber(ctx context.Context, trackingNumber string) (*model.Shipment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetShipmentByTrackingNumber")
+	defer span.End()
+
+	shipment := model.Shipment{}
+	query := `SELECT ` + shipmentColumns + ` FROM shipments WHERE tracking_number = $1`
+	if err := r.connection.GetContext(ctx, &shipment, query, trackingNumber); err != nil {
+		return nil, translate(err)
+	}
+
+	shipment.Events = []model.ShipmentEvent{}
+	query = `SELECT ` + shipmentEventColumns + ` FROM shipment_events WHERE shipment_id = $1 ORDER BY occurred_at, recorded_at`
+	if err := r.connection.SelectContext(ctx, &shipment.Events, query, shipment.ShipmentID); err != nil {
+		return nil, translate(err)
+	}
+	return &shipment, nil
+}
+
+// RecordShipmentEvent stores a tracking event of the shipment and reports whether it changed the
+// status of the order. The shipment takes the status of its latest event, so events reported out
+// of order do not move it back, and the order moves to event.Type.OrderStatus() unless it is
+// delivered or cancelled. An event that was already recorded changes nothing.
+func (r *repository) RecordShipmentEvent(ctx context.Context, shipment *model.Shipment, event *model.ShipmentEvent) (*model.Shipment, bool, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "RecordShipmentEvent")
+	defer span.End()
+
+	tx, err := r.connection.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, translate(err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `
+        INSERT INTO shipment_events (` + shipmentEventColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (shipment_id, event_type, occurred_at) DO NOTHING`
+	result, err := tx.ExecContext(
+		ctx, query,
+		event.EventID,
+		shipment.ShipmentID,
+		event.Type,
+		event.Location,
+		event.Description,
+		event.OccurredAt,
+		event.RecordedAt,
+	)
+	if err != nil {
+		return nil, false, translate(err)
+	}
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, translate(err)
+	}
+
+	var orderUpdated bool
+	if recorded > 0 {
+		query = `
+            UPDATE shipments SET status = $2, last_event_at = $3, updated_at = $4
+            WHERE shipment_id = $1 AND (last_event_at IS NULL OR last_event_at <= $3)`
+		_, err = tx.ExecContext(ctx, query, shipment.ShipmentID, event.Type.ShipmentStatus(), event.OccurredAt, event.RecordedAt)
+		if err != nil {
+			return nil, false, translate(err)
+		}
+
+		query = `
+            UPDATE orders SET order_status = $2, updated_at = $3, version = version + 1
+            WHERE order_id = $1 AND order_status NOT IN ($2, $4, $5)`
+		result, err = tx.ExecContext(ctx, query, shipment.OrderID, event.Type.OrderStatus(), event.RecordedAt,
+			model.OrderStatusDelivered, model.OrderStatusCanceled)
+		if err != nil {
+			return nil, false, translate(err)
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return nil, false, translate(err)
+		}
+		orderUpdated = updated > 0
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, translate(err)
+	}
+
+	recordedShipment, err := r.GetShipmentByTrackingNumber(ctx, shipment.TrackingNumber)
+	if err != nil {
+		return nil, false, err
+	}
+	return recordedShipment, orderUpdated, nil
+}
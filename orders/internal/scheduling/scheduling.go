@@ -0,0 +1,201 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+)
+
+var (
+	ErrPickupInPast         = errors.New("pickup is scheduled in the past")
+	ErrDeliveryBeforePickup = errors.New("delivery is scheduled before the pickup")
+	ErrNoDeliverySlot       = errors.New("delivery is not scheduled in a delivery slot")
+)
+
+const (
+	// maxClockSkew is how far in the past a pickup can be scheduled as clocks of clients drift.
+	maxClockSkew = 5 * time.Minute
+
+	DefaultDays = 7
+	MaxDays     = 31
+)
+
+// SlotRepository looks up the delivery slots of a city and the orders booked in them.
+type SlotRepository interface {
+	ListDeliverySlots(ctx context.Context, city, shippingMethod string) ([]model.DeliverySlot, error)
+	ListDeliveryBookings(ctx context.Context, city, shippingMethod string, from, to time.Time) ([]model.DeliveryBooking, error)
+}
+
+// Scheduler checks the pickup and delivery times of orders against the delivery slots of the city
+// they are delivered to. Orders to cities without delivery slots can be delivered at any time.
+// The capacity of a slot is checked again when the order is stored as another order may have
+// booked its last place in the meantime.
+type Scheduler struct {
+	slots SlotRepository
+	now   func() time.Time
+}
+
+func NewScheduler(slots SlotRepository) *Scheduler {
+	return &Scheduler{slots: slots, now: time.Now}
+}
+
+// Schedule returns the delivery window an order picked up at pickup and delivered at delivery to
+// address is delivered in, nil when the city of address has no delivery slots. When the window is fully booked
+// it is returned with ErrDeliverySlotFull.
+func (s *Scheduler) Schedule(ctx context.Context, address model.Address, shippingMethod string, pickup, delivery time.Time) (*model.DeliveryWindow, error) {
+	if err := s.checkPickup(pickup); err != nil {
+		return nil, err
+	}
+	return s.schedule(ctx, address, shippingMethod, pickup, delivery, nil)
+}
+
+// Reschedule returns the delivery window of order, the previous order changed, as Schedule does.
+// The previous order does not take a place of its own window and its pickup may have passed when
+// it is not moved.
+func (s *Scheduler) Reschedule(ctx context.Context, previous, order model.Order) (*model.DeliveryWindow, error) {
+	if !order.ScheduledPickupDatetime.Equal(previous.ScheduledPickupDatetime) {
+		if err := s.checkPickup(order.ScheduledPickupDatetime); err != nil {
+			return nil, err
+		}
+	}
+	return s.schedule(ctx, order.DeliveryAddress, order.ShippingMethod, order.ScheduledPickupDatetime, order.ScheduledDeliveryDatetime, &previous)
+}
+
+func (s *Scheduler) checkPickup(pickup time.Time) error {
+	if pickup.Before(s.now().Add(-maxClockSkew)) {
+		return fmt.Errorf("%w: %s", ErrPickupInPast, pickup.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// schedule finds the delivery window of an order, previous is the order before it was changed
+// and nil for new orders.
+func (s *Scheduler) schedule(ctx context.Context, address model.Address, shippingMethod string, pickup, delivery time.Time, previous *model.Order) (*model.DeliveryWindow, error) {
+	if !delivery.After(pickup) {
+		return nil, fmt.Errorf("%w: %s is not after %s", ErrDeliveryBeforePickup,
+			delivery.Format(time.RFC3339), pickup.Format(time.RFC3339))
+	}
+
+	city, shippingMethod := normalize(address, shippingMethod)
+	slots, err := s.slots.ListDeliverySlots(ctx, city, shippingMethod)
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, nil
+	}
+
+	for _, slot := range slots {
+		window, err := slot.On(delivery)
+		if err != nil {
+			return nil, err
+		}
+		if !window.Contains(delivery) {
+			continue
+		}
+
+		bookings, err := s.slots.ListDeliveryBookings(ctx, city, shippingMethod, window.Start, window.End)
+		if err != nil {
+			return nil, err
+		}
+		book(&window, bookings)
+		if previous != nil && bookedIn(*previous, window) {
+			window.Booked--
+		}
+		if window.Remaining() == 0 {
+			return &window, fmt.Errorf("%w: %s on %s", model.ErrDeliverySlotFull, slot.SlotID, window.Start.Format("2006-01-02"))
+		}
+		return &window, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoDeliverySlot, delivery.Format(time.RFC3339))
+}
+
+// Available returns the delivery windows orders delivered to address can still be booked in,
+// starting from from for days days sorted by start.
+func (s *Scheduler) Available(ctx context.Context, address model.Address, shippingMethod string, from time.Time, days int) ([]model.DeliveryWindow, error) {
+	if now := s.now(); from.Before(now) {
+		from = now
+	}
+	if days <= 0 {
+		days = DefaultDays
+	} else if days > MaxDays {
+		days = MaxDays
+	}
+
+	city, shippingMethod := normalize(address, shippingMethod)
+	slots, err := s.slots.ListDeliverySlots(ctx, city, shippingMethod)
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return []model.DeliveryWindow{}, nil
+	}
+
+	windows := []model.DeliveryWindow{}
+	for _, slot := range slots {
+		for day := 0; day < days; day++ {
+			window, err := slot.On(from.AddDate(0, 0, day))
+			if err != nil {
+				return nil, err
+			}
+			if window.Start.Before(from) {
+				continue
+			}
+			windows = append(windows, window)
+		}
+	}
+	if len(windows) == 0 {
+		return windows, nil
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		if windows[i].Start.Equal(windows[j].Start) {
+			return windows[i].Slot.SlotID < windows[j].Slot.SlotID
+		}
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	to := windows[0].End
+	for _, window := range windows {
+		if window.End.After(to) {
+			to = window.End
+		}
+	}
+	bookings, err := s.slots.ListDeliveryBookings(ctx, city, shippingMethod, windows[0].Start, to)
+	if err != nil {
+		return nil, err
+	}
+
+	available := []model.DeliveryWindow{}
+	for _, window := range windows {
+		book(&window, bookings)
+		if window.Remaining() > 0 {
+			available = append(available, window)
+		}
+	}
+	return available, nil
+}
+
+// book counts the bookings delivered in window.
+func book(window *model.DeliveryWindow, bookings []model.DeliveryBooking) {
+	for _, booking := range bookings {
+		if booking.SlotID == window.Slot.SlotID && window.Contains(booking.DeliveryAt) {
+			window.Booked++
+		}
+	}
+}
+
+// bookedIn reports whether order is one of the bookings of window.
+func bookedIn(order model.Order, window model.DeliveryWindow) bool {
+	return order.OrderStatus != model.OrderStatusCanceled && order.DeliverySlotID == window.Slot.SlotID &&
+		window.Contains(order.ScheduledDeliveryDatetime) && window.Booked > 0
+}
+
+// normalize returns the city of address and shippingMethod as the delivery slots store them.
+func normalize(address model.Address, shippingMethod string) (string, string) {
+	return model.DeliveryCity(address), strings.ToUpper(strings.TrimSpace(shippingMethod))
+}
@@ -0,0 +1,144 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/mocks"
+	"github.com/wathuta/technical_test/orders/internal/model"
+)
+
+// now is 12:00 in Nairobi
+var now = time.Date(2023, 12, 10, 9, 0, 0, 0, time.UTC)
+
+var mombasa = model.Address{City: "Mombasa"}
+
+var slots = []model.DeliverySlot{
+	{SlotID: "morning", City: "mombasa", ShippingMethod: "EXPRESS", StartTime: "08:00:00", EndTime: "13:00:00", TimeZone: "Africa/Nairobi", Capacity: 1},
+	{SlotID: "afternoon", City: "mombasa", ShippingMethod: "EXPRESS", StartTime: "13:00:00", EndTime: "18:00:00", TimeZone: "Africa/Nairobi", Capacity: 2},
+}
+
+func testScheduler(t *testing.T, slots []model.DeliverySlot) (*Scheduler, *mocks.SlotRepository) {
+	repo := mocks.NewSlotRepository(t)
+	if slots != nil {
+		repo.On("ListDeliverySlots", mock.Anything, "mombasa", "EXPRESS").Return(slots, nil)
+	}
+	scheduler := NewScheduler(repo)
+	scheduler.now = func() time.Time { return now }
+	return scheduler, repo
+}
+
+func TestSchedule(t *testing.T) {
+	scheduler, repo := testScheduler(t, slots)
+	delivery := now.Add(26 * time.Hour) // 14:00 tomorrow
+	repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return(
+		[]model.DeliveryBooking{{SlotID: "afternoon", DeliveryAt: delivery}}, nil)
+
+	window, err := scheduler.Schedule(context.Background(), mombasa, " express", now.Add(time.Hour), delivery)
+
+	require.NoError(t, err)
+	assert.Equal(t, "afternoon", window.Slot.SlotID)
+	assert.True(t, time.Date(2023, 12, 11, 10, 0, 0, 0, time.UTC).Equal(window.Start))
+	assert.Equal(t, int64(1), window.Booked)
+}
+
+func TestSchedule_Full(t *testing.T) {
+	scheduler, repo := testScheduler(t, slots)
+	delivery := now.Add(22 * time.Hour) // 10:00 tomorrow
+	repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return(
+		[]model.DeliveryBooking{{SlotID: "morning", DeliveryAt: delivery.Add(time.Hour)}}, nil)
+
+	window, err := scheduler.Schedule(context.Background(), mombasa, "EXPRESS", now, delivery)
+
+	assert.True(t, errors.Is(err, model.ErrDeliverySlotFull))
+	assert.Equal(t, "morning", window.Slot.SlotID)
+}
+
+func TestSchedule_NoDeliverySlot(t *testing.T) {
+	scheduler, _ := testScheduler(t, slots)
+
+	_, err := scheduler.Schedule(context.Background(), mombasa, "EXPRESS", now, now.Add(30*time.Hour)) // 18:00 tomorrow
+	assert.True(t, errors.Is(err, ErrNoDeliverySlot))
+}
+
+func TestSchedule_CityWithoutSlots(t *testing.T) {
+	scheduler, _ := testScheduler(t, []model.DeliverySlot{})
+
+	window, err := scheduler.Schedule(context.Background(), mombasa, "EXPRESS", now, now.Add(30*time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, window)
+}
+
+func TestSchedule_InvalidTimes(t *testing.T) {
+	scheduler, _ := testScheduler(t, nil)
+
+	_, err := scheduler.Schedule(context.Background(), mombasa, "EXPRESS", now.Add(-time.Hour), now.Add(time.Hour))
+	assert.True(t, errors.Is(err, ErrPickupInPast))
+
+	// a zero pickup is in the past
+	_, err = scheduler.Schedule(context.Background(), mombasa, "EXPRESS", time.Time{}, now.Add(time.Hour))
+	assert.True(t, errors.Is(err, ErrPickupInPast))
+
+	_, err = scheduler.Schedule(context.Background(), mombasa, "EXPRESS", now.Add(time.Hour), now.Add(time.Hour))
+	assert.True(t, errors.Is(err, ErrDeliveryBeforePickup))
+}
+
+func TestReschedule(t *testing.T) {
+	scheduler, repo := testScheduler(t, slots)
+	delivery := now.Add(22 * time.Hour) // 10:00 tomorrow
+	repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return(
+		[]model.DeliveryBooking{{SlotID: "morning", DeliveryAt: delivery}}, nil)
+	previous := model.Order{
+		DeliveryAddress:           mombasa,
+		ShippingMethod:            "EXPRESS",
+		OrderStatus:               model.OrderStatusProcessing,
+		ScheduledPickupDatetime:   now.Add(-2 * time.Hour),
+		ScheduledDeliveryDatetime: delivery,
+		DeliverySlotID:            "morning",
+	}
+
+	// the order keeps its place in the full window and its pickup may have passed
+	order := previous
+	order.ScheduledDeliveryDatetime = delivery.Add(time.Hour)
+	window, err := scheduler.Reschedule(context.Background(), previous, order)
+	require.NoError(t, err)
+	assert.Equal(t, "morning", window.Slot.SlotID)
+	assert.Equal(t, int64(0), window.Booked)
+
+	// another order cannot take its place
+	other := previous
+	other.DeliverySlotID = "afternoon"
+	_, err = scheduler.Reschedule(context.Background(), other, order)
+	assert.True(t, errors.Is(err, model.ErrDeliverySlotFull))
+
+	// a moved pickup is checked
+	order.ScheduledPickupDatetime = now.Add(-time.Hour)
+	_, err = scheduler.Reschedule(context.Background(), previous, order)
+	assert.True(t, errors.Is(err, ErrPickupInPast))
+}
+
+func TestAvailable(t *testing.T) {
+	scheduler, repo := testScheduler(t, slots)
+	tomorrow := time.Date(2023, 12, 11, 5, 0, 0, 0, time.UTC) // 08:00 tomorrow
+	repo.On("ListDeliveryBookings", mock.Anything, "mombasa", "EXPRESS", mock.Anything, mock.Anything).Return(
+		[]model.DeliveryBooking{
+			{SlotID: "morning", DeliveryAt: tomorrow.Add(time.Hour)},
+			{SlotID: "afternoon", DeliveryAt: tomorrow.Add(6 * time.Hour)},
+		}, nil)
+
+	// listing from the past starts now, after the morning slot of today started
+	windows, err := scheduler.Available(context.Background(), mombasa, "EXPRESS", now.Add(-48*time.Hour), 2)
+
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "afternoon", windows[0].Slot.SlotID)
+	assert.True(t, now.Add(time.Hour).Equal(windows[0].Start))
+	assert.Equal(t, int64(2), windows[0].Remaining())
+	assert.Equal(t, "afternoon", windows[1].Slot.SlotID)
+	assert.Equal(t, int64(1), windows[1].Remaining())
+}
@@ -0,0 +1,48 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+)
+
+var ErrNoTaxRate = errors.New("no tax rate configured for product category")
+
+// RateRepository looks up the tax rate of a product category.
+type RateRepository interface {
+	GetTaxRate(ctx context.Context, category model.ProductCategory) (*model.TaxRate, error)
+}
+
+// Rules applies the per category VAT rates kept in the database.
+// Prices are exclusive of tax so the tax is charged on top of the line total.
+type Rules struct {
+	rates RateRepository
+}
+
+func NewRules(rates RateRepository) *Rules {
+	return &Rules{rates: rates}
+}
+
+// Rate returns the tax rate for a product category.
+func (r *Rules) Rate(ctx context.Context, category model.ProductCategory) (*model.TaxRate, error) {
+	rate, err := r.rates.GetTaxRate(ctx, category)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrNoTaxRate, category)
+		}
+		return nil, err
+	}
+	return rate, nil
+}
+
+// TaxLine returns the rate and the tax charged on a line total of a product.
+func (r *Rules) TaxLine(ctx context.Context, product *model.Product, lineTotal model.Money) (*model.TaxRate, model.Money, error) {
+	rate, err := r.Rate(ctx, product.Category)
+	if err != nil {
+		return nil, model.Money{}, err
+	}
+	return rate, rate.Tax(lineTotal), nil
+}
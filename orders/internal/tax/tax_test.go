@@ -0,0 +1,40 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/orders/internal/mocks"
+	"github.com/wathuta/technical_test/orders/internal/model"
+	"github.com/wathuta/technical_test/orders/internal/repository"
+)
+
+func TestRules_TaxLine(t *testing.T) {
+	rates := mocks.NewRateRepository(t)
+	rates.On("GetTaxRate", mock.Anything, model.Electronics).Return(&model.TaxRate{Category: model.Electronics, RateBasisPoints: 1600}, nil)
+	rates.On("GetTaxRate", mock.Anything, model.Food).Return(&model.TaxRate{Category: model.Food, Exempt: true}, nil)
+
+	rules := NewRules(rates)
+
+	rate, tax, err := rules.TaxLine(context.Background(), &model.Product{Category: model.Electronics}, model.NewMoney(25000, model.KES))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1600), rate.RateBasisPoints)
+	assert.Equal(t, model.NewMoney(4000, model.KES), tax)
+
+	rate, tax, err = rules.TaxLine(context.Background(), &model.Product{Category: model.Food}, model.NewMoney(25000, model.KES))
+	require.NoError(t, err)
+	assert.True(t, rate.Exempt)
+	assert.Equal(t, model.NewMoney(0, model.KES), tax)
+}
+
+func TestRules_MissingRate(t *testing.T) {
+	rates := mocks.NewRateRepository(t)
+	rates.On("GetTaxRate", mock.Anything, model.Toys).Return(nil, &repository.Error{Kind: repository.ErrNotFound})
+
+	_, err := NewRules(rates).Rate(context.Background(), model.Toys)
+	assert.True(t, errors.Is(err, ErrNoTaxRate))
+}
@@ -0,0 +1,108 @@
+// Package tracing sets up OpenTelemetry tracing for the service.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const instrumentationName = "github.com/wathuta/technical_test/orders"
+
+const (
+	// ExporterOTLP sends spans to the collector set by the standard OTEL_EXPORTER_OTLP_* env vars.
+	ExporterOTLP = "otlp"
+	// ExporterStdout prints spans to stdout, for local debugging.
+	ExporterStdout = "stdout"
+)
+
+// Setup installs the W3C trace context propagator and, unless exporter is empty, a tracer
+// provider exporting the spans of serviceName. Without an exporter spans are not recorded but
+// incoming trace context is still passed on to the services we call. The returned function
+// flushes the spans that have not been exported yet.
+func Setup(ctx context.Context, serviceName, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the service.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartDBSpan starts the span of a repository query.
+func StartDBSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(operation)),
+	)
+}
+
+// health checks are made every few seconds and would drown the traces of real requests
+func grpcOptions() []otelgrpc.Option {
+	return []otelgrpc.Option{otelgrpc.WithInterceptorFilter(filters.Not(filters.HealthCheck()))}
+}
+
+// UnaryServerInterceptor continues the trace of the caller in the span of a unary RPC.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return otelgrpc.UnaryServerInterceptor(grpcOptions()...)
+}
+
+// StreamServerInterceptor continues the trace of the caller in the span of a streaming RPC.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return otelgrpc.StreamServerInterceptor(grpcOptions()...)
+}
+
+// UnaryClientInterceptor sends the trace context with the unary RPCs made to other services.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return otelgrpc.UnaryClientInterceptor(grpcOptions()...)
+}
+
+// HTTPHandler traces the requests served by h under the name operation.
+func HTTPHandler(h http.Handler, operation string) http.Handler {
+	return otelhttp.NewHandler(h, operation)
+}
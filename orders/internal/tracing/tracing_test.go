@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// recordSpans installs a tracer provider keeping the spans in memory for the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	previous := otel.GetTracerProvider()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "orders", "")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+
+	_, err = Setup(context.Background(), "orders", "zipkin")
+	assert.Error(t, err)
+}
+
+func TestStartDBSpan(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, parent := Tracer().Start(context.Background(), "CreateOrder")
+	_, span := StartDBSpan(ctx, "GetOrderById")
+	span.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "repository.GetOrderById", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), semconv.DBSystemPostgreSQL)
+}
+
+func TestGRPCPropagation(t *testing.T) {
+	recorder := recordSpans(t)
+	_, err := Setup(context.Background(), "orders", "")
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial("passthrough:///payment", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the client interceptor writes the trace context to the outgoing metadata
+	ctx, checkout := Tracer().Start(context.Background(), "CreateOrder")
+	var sent metadata.MD
+	err = UnaryClientInterceptor()(ctx, "/ecommerce.PaymentService/CreatePayment", nil, nil, conn,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			sent, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	require.NoError(t, err)
+	checkout.End()
+	require.NotEmpty(t, sent.Get("traceparent"))
+
+	// and the server interceptor of the other service continues it
+	var received trace.SpanContext
+	_, err = UnaryServerInterceptor()(metadata.NewIncomingContext(context.Background(), sent), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/ecommerce.PaymentService/CreatePayment"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			received = trace.SpanContextFromContext(ctx)
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	assert.Equal(t, checkout.SpanContext().TraceID(), received.TraceID())
+	assert.Len(t, recorder.Ended(), 3)
+}
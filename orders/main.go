@@ -5,18 +5,23 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/wathuta/technical_test/orders/internal"
+	"github.com/wathuta/technical_test/orders/internal/carriers"
 	"github.com/wathuta/technical_test/orders/internal/config"
+	"github.com/wathuta/technical_test/orders/internal/logging"
 	database "github.com/wathuta/technical_test/orders/internal/platform/postgres"
+	"github.com/wathuta/technical_test/orders/internal/tracing"
 	"golang.org/x/exp/slog"
 )
 
 func main() {
 	var err error
 	var programLevel = new(slog.LevelVar) // Info by default
-	h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel, AddSource: true})
+	// phone numbers, passwords and tokens are redacted from every log line
+	h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel, AddSource: true, ReplaceAttr: logging.Redact})
 	slog.SetDefault(slog.New(h))
 	programLevel.Set(slog.LevelDebug)
 
@@ -38,13 +43,42 @@ func main() {
 		}
 	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), "orders", os.Getenv(config.TracesExporterEnvVar))
+	if err != nil {
+		slog.Error("unable to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
 	db, err := database.OpenDBConnection()
 	if err != nil {
 		slog.Error("failed connect to DB", "error", err)
 		os.Exit(1)
 	}
 
-	service, err := internal.NewService(context.Background(), db, internal.Options{ListenAddress: os.Getenv(config.ListenAddressEnvVar)})
+	shutdownTimeout, err := config.DurationFromEnv(config.GracefulShutdownTimeoutEnvVar, 30*time.Second)
+	if err != nil {
+		slog.Error("invalid service settings", "error", err)
+		os.Exit(1)
+	}
+	carrierSecrets, err := carriers.ParseSecrets(os.Getenv(config.CarrierWebhookSecretsEnvVar))
+	if err != nil {
+		slog.Error("invalid service settings", "error", err)
+		os.Exit(1)
+	}
+	service, err := internal.NewService(context.Background(), db, internal.Options{
+		ListenAddress:           os.Getenv(config.ListenAddressEnvVar),
+		MetricsListenAddress:    os.Getenv(config.MetricsListenAddressEnvVar),
+		GracefulShutdownTimeout: shutdownTimeout,
+		GatewayListenAddress:    os.Getenv(config.GatewayListenAddressEnvVar),
+		Reflection:              os.Getenv(config.GRPCReflectionEnvVar) == "true",
+		WebhookListenAddress:    os.Getenv(config.WebhookListenAddressEnvVar),
+		CarrierAdapters:         carriers.GenericJSONAdapters(carrierSecrets),
+	})
 	if err != nil {
 		slog.Error("failed to start service", "error", err)
 		os.Exit(1)
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoRoles           = errors.New("token does not grant any known role")
+	ErrCustomerNoSubject = errors.New("customer token without a subject")
+)
+
+// Role is what a caller is allowed to do.
+type Role string
+
+const (
+	// RoleCustomer can read the payments of their own orders.
+	RoleCustomer Role = "customer"
+	// RoleStaff can read every payment.
+	RoleStaff Role = "staff"
+	// RoleAdmin can also start payments.
+	RoleAdmin Role = "admin"
+	// RoleService is another service of the platform, the order service starting payments.
+	RoleService Role = "service"
+)
+
+// Claims are the claims read from an access token. Roles may be sent as a list in "roles"
+// or as a single "role".
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+	Role  string   `json:"role,omitempty"`
+}
+
+// Principal is the authenticated caller of an RPC.
+type Principal struct {
+	// Subject is the id of the caller, the customer id for customers.
+	Subject string
+	Roles   []Role
+}
+
+func (p *Principal) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomerOnly reports whether the caller is a customer without any other role and may only
+// see their own resources.
+func (p *Principal) CustomerOnly() bool {
+	return p.HasRole(RoleCustomer) && !p.HasRole(RoleStaff) && !p.HasRole(RoleAdmin) && !p.HasRole(RoleService)
+}
+
+type principalKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller set by the interceptors.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// CanAccessCustomer reports whether the caller may read or change the resources of customerId.
+// Requests without a principal are calls made inside the service as every RPC is authenticated
+// by the interceptors before it reaches a handler.
+func CanAccessCustomer(ctx context.Context, customerId string) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || !principal.CustomerOnly() {
+		return true
+	}
+	return principal.Subject == customerId
+}
+
+// Authenticator verifies access tokens signed with a key from the keyset.
+type Authenticator struct {
+	keyset *Keyset
+	parser *jwt.Parser
+}
+
+// NewAuthenticator returns an authenticator for the keyset. The issuer and audience are only
+// checked when they are not empty.
+func NewAuthenticator(keyset *Keyset, issuer, audience string) *Authenticator {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return &Authenticator{keyset: keyset, parser: jwt.NewParser(options...)}
+}
+
+// Authenticate verifies a token and returns the caller it was issued to.
+func (a *Authenticator) Authenticate(token string) (*Principal, error) {
+	claims := &Claims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keyset.Key(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{Subject: claims.Subject}
+	names := claims.Roles
+	if claims.Role != "" {
+		names = append(names, claims.Role)
+	}
+	for _, name := range names {
+		switch role := Role(strings.ToLower(strings.TrimSpace(name))); role {
+		case RoleCustomer, RoleStaff, RoleAdmin, RoleService:
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+	if len(principal.Roles) == 0 {
+		return nil, ErrNoRoles
+	}
+	if principal.HasRole(RoleCustomer) && principal.Subject == "" {
+		return nil, ErrCustomerNoSubject
+	}
+	return principal, nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeys are signing keys with the JWK set of their public keys.
+type testKeys struct {
+	ed25519 ed25519.PrivateKey
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	keyset  []byte
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	keyset, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": encode(edPublic)},
+		{"kty": "RSA", "kid": "rsa", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X.Bytes()), "y": encode(ecKey.Y.Bytes())},
+	}})
+	require.NoError(t, err)
+	return &testKeys{ed25519: edPrivate, rsa: rsaKey, ecdsa: ecKey, keyset: keyset}
+}
+
+func (k *testKeys) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	var key interface{}
+	switch method {
+	case jwt.SigningMethodEdDSA:
+		key = k.ed25519
+	case jwt.SigningMethodRS256:
+		key = k.rsa
+	case jwt.SigningMethodES256:
+		key = k.ecdsa
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (k *testKeys) authenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	keyset, err := ParseKeyset(k.keyset)
+	require.NoError(t, err)
+	return NewAuthenticator(keyset, "https://auth.example.com", "payment")
+}
+
+func testClaims(roles ...string) *Claims {
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "0b5c6bf4-6f39-4b6e-9c55-1a0c3ad0e3a1",
+			Issuer:    "https://auth.example.com",
+			Audience:  jwt.ClaimStrings{"payment"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: roles,
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := newTestKeys(t)
+	authenticator := keys.authenticator(t)
+
+	for _, test := range []struct {
+		method jwt.SigningMethod
+		kid    string
+	}{
+		{jwt.SigningMethodEdDSA, "ed"},
+		{jwt.SigningMethodRS256, "rsa"},
+		{jwt.SigningMethodES256, "ec"},
+	} {
+		principal, err := authenticator.Authenticate(keys.sign(t, test.method, test.kid, testClaims("Customer", "unknown")))
+		require.NoError(t, err, test.kid)
+		assert.Equal(t, "0b5c6bf4-6f39-4b6e-9c55-1a0c3ad0e3a1", principal.Subject)
+		assert.Equal(t, []Role{RoleCustomer}, principal.Roles)
+	}
+
+	claims := testClaims()
+	claims.Role = "admin"
+	principal, err := authenticator.Authenticate(keys.sign(t, jwt.SigningMethodEdDSA, "ed", claims))
+	require.NoError(t, err)
+	assert.True(t, principal.HasRole(RoleAdmin))
+}
+
+func TestAuthenticate_Invalid(t *testing.T) {
+	keys := newTestKeys(t)
+	authenticator := keys.authenticator(t)
+
+	expired := testClaims("staff")
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	_, err := authenticator.Authenticate(keys.sign(t, jwt.SigningMethodEdDSA, "ed", expired))
+	assert.True(t, errors.Is(err, jwt.ErrTokenExpired))
+
+	noExpiry := testClaims("staff")
+	noExpiry.ExpiresAt = nil
+	_, err = authenticator.Authenticate(keys.sign(t, jwt.SigningMethodEdDSA, "ed", noExpiry))
+	assert.Error(t, err)
+
+	wrongAudience := testClaims("staff")
+	wrongAudience.Audience = jwt.ClaimStrings{"orders"}
+	_, err = authenticator.Authenticate(keys.sign(t, jwt.SigningMethodEdDSA, "ed", wrongAudience))
+	assert.True(t, errors.Is(err, jwt.ErrTokenInvalidAudience))
+
+	// signed with the RSA key but claiming to be the Ed25519 key
+	_, err = authenticator.Authenticate(keys.sign(t, jwt.SigningMethodRS256, "ed", testClaims("staff")))
+	assert.Error(t, err)
+
+	_, err = authenticator.Authenticate(keys.sign(t, jwt.SigningMethodEdDSA, "unknown", testClaims("staff")))
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+
+	// a key from another keyset
+	other := newTestKeys(t)
+	_, err = authenticator.Authenticate(other.sign(t, jwt.SigningMethodEdDSA, "ed", testClaims("staff")))
+	assert.Error(t, err)
+
+	_, err = authenticator.Authenticate(keys.sign(t, jwt.SigningMethodEdDSA, "ed", testClaims("superuser")))
+	assert.True(t, errors.Is(err, ErrNoRoles))
+
+	noSubject := testClaims("customer")
+	noSubject.Subject = ""
+	_, err = authenticator.Authenticate(keys.sign(t, jwt.SigningMethodEdDSA, "ed", noSubject))
+	assert.True(t, errors.Is(err, ErrCustomerNoSubject))
+
+	// unsigned tokens are never accepted
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims("admin")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = authenticator.Authenticate(unsigned)
+	assert.Error(t, err)
+}
+
+func TestParseKeyset_Invalid(t *testing.T) {
+	for _, keyset := range []string{
+		`not json`,
+		`{"keys": []}`,
+		`{"keys": [{"kty": "oct", "kid": "secret", "k": "c2VjcmV0"}]}`,
+		`{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`,
+		`{"keys": [{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AQ"}]}`,
+	} {
+		_, err := ParseKeyset([]byte(keyset))
+		assert.Error(t, err, keyset)
+	}
+}
+
+func TestKeyset_Key(t *testing.T) {
+	keys := newTestKeys(t)
+	keyset, err := ParseKeyset(keys.keyset)
+	require.NoError(t, err)
+
+	// a token without a key id is only accepted when there is a single key
+	_, err = keyset.Key("")
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+
+	single := &Keyset{keys: map[string]crypto.PublicKey{"only": keys.ed25519.Public()}}
+	key, err := single.Key("")
+	require.NoError(t, err)
+	assert.Equal(t, keys.ed25519.Public(), key)
+}
+
+func TestCanAccessCustomer(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, CanAccessCustomer(ctx, "customer"))
+
+	customer := ContextWithPrincipal(ctx, &Principal{Subject: "customer", Roles: []Role{RoleCustomer}})
+	assert.True(t, CanAccessCustomer(customer, "customer"))
+	assert.False(t, CanAccessCustomer(customer, "someone-else"))
+
+	staff := ContextWithPrincipal(ctx, &Principal{Subject: "staff", Roles: []Role{RoleCustomer, RoleStaff}})
+	assert.True(t, CanAccessCustomer(staff, "someone-else"))
+}
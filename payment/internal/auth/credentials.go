@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TokenCredentials sends a service access token with every RPC made by a client.
+type TokenCredentials struct {
+	token string
+	// secure is true when the token must only be sent over TLS.
+	secure bool
+}
+
+var _ credentials.PerRPCCredentials = (*TokenCredentials)(nil)
+
+func NewTokenCredentials(token string, secure bool) *TokenCredentials {
+	return &TokenCredentials{token: token, secure: secure}
+}
+
+func (c *TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c *TokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
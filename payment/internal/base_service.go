@@ -2,32 +2,61 @@ package internal
 
 import (
 	"context"
-	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/wathuta/technical_test/payment/internal/auth"
 	"github.com/wathuta/technical_test/payment/internal/config"
+	"github.com/wathuta/technical_test/payment/internal/gateway"
+	grpcclients "github.com/wathuta/technical_test/payment/internal/grpc_clients"
 	orderclient "github.com/wathuta/technical_test/payment/internal/grpc_clients/order_client"
 	"github.com/wathuta/technical_test/payment/internal/handler"
+	"github.com/wathuta/technical_test/payment/internal/healthcheck"
+	"github.com/wathuta/technical_test/payment/internal/logging"
+	"github.com/wathuta/technical_test/payment/internal/metrics"
 	"github.com/wathuta/technical_test/payment/internal/platform/mpesa"
 	"github.com/wathuta/technical_test/payment/internal/repository"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
 	"golang.org/x/exp/slog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 type Service struct {
 	grpcSrv                 *grpc.Server
+	httpSrv                 *http.Server
 	GracefulShutdownTimeout time.Duration
 
+	// the JSON gateway is nil when it is not enabled
+	gatewaySrv  *http.Server
+	gatewayConn *grpc.ClientConn
+
+	health     *healthcheck.Monitor
+	stopHealth context.CancelFunc
+	healthDone chan struct{}
+	metricsSrv *http.Server
+
 	db *sqlx.DB
 }
 type Options struct {
 	ListenAddress           string
+	HTTPListenAddress       string
+	MetricsListenAddress    string
 	GracefulShutdownTimeout time.Duration
+	// limits of the callback server, defaults are used for the ones not set
+	HTTPReadTimeout  time.Duration
+	HTTPWriteTimeout time.Duration
+	HTTPMaxBodyBytes int64
+	// Reflection registers grpc server reflection so tools such as grpcurl can list the services.
+	Reflection bool
+	// GatewayListenAddress serves the payment service as JSON over HTTP when set.
+	GatewayListenAddress string
 }
 
 func NewService(ctx context.Context, db *sqlx.DB, opts Options) (*Service, error) {
@@ -35,19 +64,79 @@ func NewService(ctx context.Context, db *sqlx.DB, opts Options) (*Service, error
 	if err != nil {
 		return nil, err
 	}
+	httpListener, err := net.Listen("tcp", opts.HTTPListenAddress)
+	if err != nil {
+		return nil, err
+	}
 
-	// Set up gRPC server
-	grpcSrv := grpc.NewServer(grpc.EmptyServerOption{})
+	// every RPC is authenticated with a token signed by a key in the local keyset
+	keyset, err := auth.LoadKeyset(os.Getenv(config.AuthKeysetFileEnvVar))
 	if err != nil {
 		return nil, err
 	}
+	authenticator := auth.NewAuthenticator(keyset, os.Getenv(config.AuthIssuerEnvVar), os.Getenv(config.AuthAudienceEnvVar))
+
+	// TLS is optional, the certificate files are reloaded when they change
+	tlsFiles := config.TLSFilesFromEnv()
+	serverCreds, err := config.ServerCredentials(tlsFiles)
+	if err != nil {
+		return nil, err
+	}
+	clientCreds, err := config.ClientCredentials(tlsFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up gRPC server
+	grpcSrv := grpc.NewServer(
+		grpc.Creds(serverCreds),
+		grpc.ChainUnaryInterceptor(
+			tracing.UnaryServerInterceptor(),
+			logging.UnaryServerInterceptor(),
+			metrics.UnaryServerInterceptor(),
+			auth.UnaryServerInterceptor(authenticator, handler.Permissions()),
+		),
+		grpc.ChainStreamInterceptor(
+			tracing.StreamServerInterceptor(),
+			logging.StreamServerInterceptor(),
+			metrics.StreamServerInterceptor(),
+			auth.StreamServerInterceptor(authenticator, handler.Permissions()),
+		),
+	)
+	if err := metrics.RegisterDB(db.DB, "payment"); err != nil {
+		return nil, err
+	}
 
 	repo := repository.NewRepository(db)
+	mpesaTimeout, err := config.DurationFromEnv(config.MpesaRequestTimeoutEnvVar, 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	// updating an order is quick, give up sooner than on the default timeout
+	orderDefaults := grpcclients.DefaultOptions()
+	orderDefaults.Timeout = 5 * time.Second
+	orderOpts, err := grpcclients.OptionsFromEnv(grpcclients.EnvVars{
+		Timeout:            config.OrderServiceTimeoutEnvVar,
+		MaxAttempts:        config.OrderServiceMaxAttemptsEnvVar,
+		RetryBackoff:       config.OrderServiceRetryBackoffEnvVar,
+		BreakerThreshold:   config.OrderServiceBreakerThresholdEnvVar,
+		BreakerCooldown:    config.OrderServiceBreakerCooldownEnvVar,
+		MaxConcurrentCalls: config.OrderServiceMaxConcurrentCallsEnvVar,
+	}, orderDefaults)
+	if err != nil {
+		return nil, err
+	}
 	mpesaService := mpesa.NewMpesa(&mpesa.MpesaOpts{
 		ConsumerKey:    os.Getenv(config.MpesaConsumerKeyEnvVar),
 		ConsumerSecret: os.Getenv(config.MpesaConsumerSecreteEnvVar),
+		Timeout:        mpesaTimeout,
 	})
-	clients, err := orderclient.NewOrderClient(os.Getenv(config.OrderServiceListenAddressEnvVar))
+	clients, err := orderclient.NewOrderClient(
+		os.Getenv(config.OrderServiceListenAddressEnvVar),
+		clientCreds,
+		auth.NewTokenCredentials(os.Getenv(config.ServiceTokenEnvVar), tlsFiles.ClientTLS()),
+		orderOpts,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -55,50 +144,162 @@ func NewService(ctx context.Context, db *sqlx.DB, opts Options) (*Service, error
 
 	paymentpb.RegisterPaymentServiceServer(grpcSrv, handler)
 
+	if opts.Reflection {
+		reflection.Register(grpcSrv)
+	}
+
+	var gatewaySrv *http.Server
+	var gatewayConn *grpc.ClientConn
+	if opts.GatewayListenAddress != "" {
+		if gatewaySrv, gatewayConn, err = newGatewayServer(ctx, opts, tlsFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	// the health of every service follows the database and, for the services calling it, the
+	// orders service. Only the status of the orders server as a whole is checked so the services
+	// do not take each other out of rotation.
+	healthSrv := health.NewServer()
+	monitor := healthcheck.NewMonitor(healthSrv, healthcheck.DefaultInterval, healthcheck.DefaultTimeout)
+	monitor.AddCheck("postgres", db.PingContext)
+	monitor.AddCheck("orders", clients.CheckHealth)
+	monitor.AddService("", "postgres")
+	monitor.AddService(paymentpb.PaymentService_ServiceDesc.ServiceName, "postgres", "orders")
+	healthpb.RegisterHealthServer(grpcSrv, healthSrv)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	healthDone := make(chan struct{})
 	go func() {
-		serveHTTP(handler)
+		defer close(healthDone)
+		monitor.Run(healthCtx)
 	}()
+
+	httpSrv := newCallbackServer(opts, handler.CallbackHandler)
 	go func() {
-		fmt.Println("GRPC Server is running on:", listener.Addr())
+		slog.Info("callback server is running", "address", httpListener.Addr().String())
+		if err := httpSrv.Serve(httpListener); err != nil && err != http.ErrServerClosed {
+			slog.Error("callback server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+	if gatewaySrv != nil {
+		go func() {
+			slog.Info("gateway server is running", "address", opts.GatewayListenAddress)
+			if err := gatewaySrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("gateway server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	metricsSrv := &http.Server{
+		Addr:              opts.MetricsListenAddress,
+		Handler:           metrics.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		slog.Info("metrics server is running", "address", opts.MetricsListenAddress)
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+	go func() {
+		slog.Info("grpc server is running", "address", listener.Addr().String())
 		if err := grpcSrv.Serve(listener); err != nil {
-			slog.Error("error", err)
+			slog.Error("grpc server failed", "error", err)
 			os.Exit(1)
 		}
 	}()
 
-	return &Service{db: db, grpcSrv: grpcSrv}, nil
+	return &Service{
+		db:                      db,
+		grpcSrv:                 grpcSrv,
+		httpSrv:                 httpSrv,
+		GracefulShutdownTimeout: opts.GracefulShutdownTimeout,
+		gatewaySrv:              gatewaySrv,
+		gatewayConn:             gatewayConn,
+		health:                  monitor,
+		stopHealth:              stopHealth,
+		healthDone:              healthDone,
+		metricsSrv:              metricsSrv,
+	}, nil
 }
 
-func serveHTTP(h *handler.Handler) {
-	mux := gin.Default()
-	mux.POST("/callback", h.CallbackHandler)
-	mux.Run(os.Getenv(config.HTTPListenAddressEnvVar))
-
-	fmt.Println("REST server is running on localhost:5002")
+// newGatewayServer returns the server of the JSON gateway and its connection to the grpc server.
+func newGatewayServer(ctx context.Context, opts Options, tlsFiles config.TLSFiles) (*http.Server, *grpc.ClientConn, error) {
+	// the gateway dials the grpc server over TLS whenever it is served over TLS
+	loopbackCreds, err := config.LoopbackCredentials(tlsFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := gateway.Dial(opts.ListenAddress, loopbackCreds)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := gateway.NewHandler(ctx, conn, paymentpb.RegisterPaymentServiceHandler)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return &http.Server{
+		Addr:              opts.GatewayListenAddress,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}, conn, nil
 }
 
+// Shutdown lets pending RPCs and callbacks finish, for GracefulShutdownTimeout at most, before
+// closing the database pool. It reports whether they all finished in time.
 func (s *Service) Shutdown() bool {
-	c := make(chan struct{})
+	// report NOT_SERVING first so load balancers stop sending requests while pending ones finish
+	s.health.Shutdown()
+	s.stopHealth()
+	// metrics stay available while the servers drain
+	defer s.metricsSrv.Close()
 
-	go func() {
-		defer close(c)
+	ctx, cancel := context.WithTimeout(context.Background(), s.GracefulShutdownTimeout)
+	defer cancel()
 
+	// the gateway stops first, its requests are made to the grpc server
+	completed := shutdownHTTP(ctx, s.gatewaySrv, "gateway")
+	grpcDone := make(chan struct{})
+	go func() {
+		defer close(grpcDone)
 		// Block until all pending RPCs are finished
 		s.grpcSrv.GracefulStop()
 	}()
-
+	if !shutdownHTTP(ctx, s.httpSrv, "callback") {
+		completed = false
+	}
 	select {
-	case <-time.After(s.GracefulShutdownTimeout):
-		// Timeout
+	case <-grpcDone:
+	case <-ctx.Done():
 		s.grpcSrv.Stop()
-		<-c
-		return false
+		<-grpcDone
+		completed = false
+	}
+	if s.gatewayConn != nil {
+		s.gatewayConn.Close()
+	}
+	<-s.healthDone
+
+	// the handlers are done with the database once the servers have stopped
+	s.Close()
+	return completed
+}
 
-	case <-c:
-		// Shutdown completed within the timeout
+// shutdownHTTP shuts srv down, closing it when its requests do not finish before ctx is done.
+// It reports whether they did, servers that are not enabled are nil.
+func shutdownHTTP(ctx context.Context, srv *http.Server, name string) bool {
+	if srv == nil {
 		return true
 	}
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error(name+" server did not shut down in time", "error", err)
+		srv.Close()
+		return false
+	}
+	return true
 }
+
 func (s *Service) Close() {
 	s.db.Close()
 }
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wathuta/technical_test/payment/internal/logging"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
+)
+
+// Limits of the callback server used when Options leaves them unset. Daraja callbacks are a few
+// kilobytes and are answered straight away.
+const (
+	defaultHTTPReadTimeout  = 10 * time.Second
+	defaultHTTPWriteTimeout = 30 * time.Second
+	defaultHTTPMaxBodyBytes = 64 << 10
+)
+
+// newCallbackServer returns the server receiving the M-Pesa callbacks on opts.HTTPListenAddress.
+func newCallbackServer(opts Options, callback gin.HandlerFunc) *http.Server {
+	readTimeout, writeTimeout, maxBodyBytes := opts.HTTPReadTimeout, opts.HTTPWriteTimeout, opts.HTTPMaxBodyBytes
+	if readTimeout <= 0 {
+		readTimeout = defaultHTTPReadTimeout
+	}
+	if writeTimeout <= 0 {
+		writeTimeout = defaultHTTPWriteTimeout
+	}
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultHTTPMaxBodyBytes
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	mux := gin.New()
+	mux.Use(recoverPanics, limitBody(maxBodyBytes))
+	mux.POST("/callback", callback)
+
+	return &http.Server{
+		Addr: opts.HTTPListenAddress,
+		// the logging middleware runs inside the span so the request logger carries the trace id
+		Handler:           tracing.HTTPHandler(logging.HTTPMiddleware(mux), "mpesa-callback"),
+		ReadHeaderTimeout: readTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       2 * writeTimeout,
+	}
+}
+
+// recoverPanics answers requests whose handler panicked with an error instead of dropping the
+// connection, Daraja then sends the callback again.
+func recoverPanics(c *gin.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(c.Request.Context()).Error("callback handler panicked", "panic", r, "stack", string(debug.Stack()))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, map[string]string{"message": "Internal error"})
+		}
+	}()
+	c.Next()
+}
+
+// limitBody stops reading request bodies after maxBytes.
+func limitBody(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/payment/internal/healthcheck"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+)
+
+func TestCallbackServer_Middleware(t *testing.T) {
+	srv := newCallbackServer(Options{HTTPMaxBodyBytes: 16}, func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if body["panic"] == true {
+			panic("handler bug")
+		}
+		c.Status(http.StatusOK)
+	})
+	assert.Equal(t, defaultHTTPReadTimeout, srv.ReadTimeout)
+	assert.Equal(t, defaultHTTPWriteTimeout, srv.WriteTimeout)
+
+	post := func(body string) int {
+		recorder := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(body)))
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, post(`{}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(`{"padding":"more than sixteen bytes"}`))
+	assert.Equal(t, http.StatusInternalServerError, post(`{"panic":true}`))
+	assert.Equal(t, http.StatusOK, post(`{}`), "the server keeps serving after a panic")
+}
+
+// newTestService serves callback on a callback server owned by a Service whose other parts are idle.
+func newTestService(t *testing.T, timeout time.Duration, callback gin.HandlerFunc) (*Service, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	httpSrv := newCallbackServer(Options{}, callback)
+	go func() { _ = httpSrv.Serve(listener) }()
+
+	db, err := sqlx.Open("pgx", "postgres://localhost/payments")
+	require.NoError(t, err)
+	healthDone := make(chan struct{})
+	close(healthDone)
+	return &Service{
+		grpcSrv:                 grpc.NewServer(),
+		httpSrv:                 httpSrv,
+		GracefulShutdownTimeout: timeout,
+		health:                  healthcheck.NewMonitor(health.NewServer(), healthcheck.DefaultInterval, healthcheck.DefaultTimeout),
+		stopHealth:              func() {},
+		healthDone:              healthDone,
+		metricsSrv:              &http.Server{},
+		db:                      db,
+	}, "http://" + listener.Addr().String() + "/callback"
+}
+
+func TestShutdown_WaitsForCallbacks(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	svc, url := newTestService(t, time.Second, func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url, "application/json", strings.NewReader(`{}`))
+		if err != nil {
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+	<-started
+
+	completed := make(chan bool, 1)
+	go func() { completed <- svc.Shutdown() }()
+	select {
+	case <-completed:
+		t.Fatal("shutdown did not wait for the pending callback")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-responses)
+	assert.True(t, <-completed)
+	assert.EqualError(t, svc.db.Ping(), "sql: database is closed")
+}
+
+func TestShutdown_Timeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	svc, url := newTestService(t, 50*time.Millisecond, func(c *gin.Context) {
+		close(started)
+		select {
+		case <-release:
+		case <-c.Request.Context().Done():
+		}
+	})
+
+	go func() {
+		resp, err := http.Post(url, "application/json", strings.NewReader(`{}`))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	start := time.Now()
+	assert.False(t, svc.Shutdown())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.EqualError(t, svc.db.PingContext(context.Background()), "sql: database is closed")
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"golang.org/x/exp/slog"
 )
@@ -25,6 +28,26 @@ const (
 	MpesaPassKeyEnv                 = "MPESA_PASSKEY"
 	CallBackBaseURL                 = "CALLBACK_BASEURL"
 	OrderServiceListenAddressEnvVar = "ORDER_SERVICE_LISTEN_ADDRESS"
+	AuthKeysetFileEnvVar            = "AUTH_KEYSET_FILE"
+	AuthIssuerEnvVar                = "AUTH_ISSUER"   // optional
+	AuthAudienceEnvVar              = "AUTH_AUDIENCE" // optional
+	ServiceTokenEnvVar              = "SERVICE_TOKEN"
+	MetricsListenAddressEnvVar      = "METRICS_LISTEN_ADDRESS"
+	TracesExporterEnvVar            = "TRACES_EXPORTER"           // optional, "otlp" or "stdout"
+	MpesaRequestTimeoutEnvVar       = "MPESA_REQUEST_TIMEOUT"     // optional, defaults to 15s
+	GracefulShutdownTimeoutEnvVar   = "GRACEFUL_SHUTDOWN_TIMEOUT" // optional, defaults to 30s
+	GatewayListenAddressEnvVar      = "GATEWAY_LISTEN_ADDRESS"    // optional, the JSON gateway is only served when set
+	GRPCReflectionEnvVar            = "GRPC_REFLECTION"           // optional, "true" registers grpc server reflection
+	HTTPReadTimeoutEnvVar           = "HTTP_READ_TIMEOUT"         // optional, defaults to 10s
+	HTTPWriteTimeoutEnvVar          = "HTTP_WRITE_TIMEOUT"        // optional, defaults to 30s
+	HTTPMaxBodyBytesEnvVar          = "HTTP_MAX_BODY_BYTES"       // optional, defaults to 64KiB
+	// optional settings of the calls to the order service, see grpcclients.Options
+	OrderServiceTimeoutEnvVar            = "ORDER_SERVICE_TIMEOUT"
+	OrderServiceMaxAttemptsEnvVar        = "ORDER_SERVICE_MAX_ATTEMPTS"
+	OrderServiceRetryBackoffEnvVar       = "ORDER_SERVICE_RETRY_BACKOFF"
+	OrderServiceBreakerThresholdEnvVar   = "ORDER_SERVICE_BREAKER_THRESHOLD"
+	OrderServiceBreakerCooldownEnvVar    = "ORDER_SERVICE_BREAKER_COOLDOWN"
+	OrderServiceMaxConcurrentCallsEnvVar = "ORDER_SERVICE_MAX_CONCURRENT_CALLS"
 )
 
 func HasAllEnvVariables() bool {
@@ -46,6 +69,9 @@ func HasAllEnvVariables() bool {
 		MpesaConsumerSecreteEnvVar,
 		MpesaPassKeyEnv,
 		OrderServiceListenAddressEnvVar,
+		AuthKeysetFileEnvVar,
+		ServiceTokenEnvVar,
+		MetricsListenAddressEnvVar,
 	}
 	for _, v := range requiredEnvVars {
 		value, ok := os.LookupEnv(v)
@@ -57,3 +83,37 @@ func HasAllEnvVariables() bool {
 	return true
 
 }
+
+// DurationFromEnv parses the duration, such as "5s", in the env variable key. fallback is
+// returned when the variable is not set.
+func DurationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || len(value) < 1 {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %s: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration", key)
+	}
+	return d, nil
+}
+
+// IntFromEnv parses the positive number in the env variable key. fallback is returned when the
+// variable is not set.
+func IntFromEnv(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || len(value) < 1 {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %s: %w", key, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number", key)
+	}
+	return n, nil
+}
@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/payment/internal/config"
+	"github.com/wathuta/technical_test/payment/internal/config/tlstest"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakePaymentService keeps the metadata and requests of the calls it answers.
+type fakePaymentService struct {
+	paymentpb.UnimplementedPaymentServiceServer
+	md     metadata.MD
+	create *paymentpb.CreatePaymentRequest
+}
+
+func (s *fakePaymentService) CreatePayment(ctx context.Context, req *paymentpb.CreatePaymentRequest) (*paymentpb.CreatePaymentResponse, error) {
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	s.create = req
+	if req.CustomerPhone == "" {
+		return nil, status.Error(codes.InvalidArgument, "customer phone is required")
+	}
+	return &paymentpb.CreatePaymentResponse{Payment: &paymentpb.Payment{OrderId: req.OrderId}}, nil
+}
+
+func (s *fakePaymentService) GetPaymentByOrderId(ctx context.Context, req *paymentpb.GetPaymentByOrderIdRequest) (*paymentpb.GetPaymentByOrderIdResponse, error) {
+	return nil, status.Error(codes.NotFound, "payment not found")
+}
+
+// newTestGateway serves fake over grpc, secured by files, and returns the URL of a gateway in
+// front of it.
+func newTestGateway(t *testing.T, fake *fakePaymentService, files config.TLSFiles) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serverCreds, err := config.ServerCredentials(files)
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.Creds(serverCreds))
+	paymentpb.RegisterPaymentServiceServer(srv, fake)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	loopbackCreds, err := config.LoopbackCredentials(files)
+	require.NoError(t, err)
+	conn, err := Dial(listener.Addr().String(), loopbackCreds)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	handler, err := NewHandler(context.Background(), conn, paymentpb.RegisterPaymentServiceHandler)
+	require.NoError(t, err)
+	gateway := httptest.NewServer(handler)
+	t.Cleanup(gateway.Close)
+	return gateway.URL
+}
+
+func TestGateway_ForwardsRequests(t *testing.T) {
+	fake := &fakePaymentService{}
+	url := newTestGateway(t, fake, config.TLSFiles{})
+
+	req, err := http.NewRequest(http.MethodPost, url+"/v1/payments", strings.NewReader(`{"orderId":"order-1","customerPhone":"254700000000","paymentMethod":"MPESA"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Request-Id", "request-1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotNil(t, fake.create)
+	assert.Equal(t, "order-1", fake.create.OrderId)
+	assert.Equal(t, paymentpb.PaymentMethod_MPESA, fake.create.PaymentMethod)
+
+	// the grpc server authenticates the caller and logs the request as usual
+	assert.Equal(t, []string{"Bearer token"}, fake.md.Get("authorization"))
+	assert.Equal(t, []string{"request-1"}, fake.md.Get("x-request-id"))
+	assert.Equal(t, "request-1", resp.Header.Get("X-Request-Id"))
+}
+
+func TestGateway_ServerOnlyTLS(t *testing.T) {
+	// the certificate is not valid for the loopback address and no CA file is configured
+	dir := t.TempDir()
+	certFile, keyFile := tlstest.NewCA(t).Issue(t, dir, "payment")
+	url := newTestGateway(t, &fakePaymentService{}, config.TLSFiles{CertFile: certFile, KeyFile: keyFile})
+
+	resp, err := http.Get(url + "/v1/orders/order-1/payment")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// the grpc server answered through the TLS connection
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGateway_MapsErrors(t *testing.T) {
+	url := newTestGateway(t, &fakePaymentService{}, config.TLSFiles{})
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		code               codes.Code
+	}{
+		{http.MethodPost, "/v1/payments", `{"orderId":"order-1"}`, http.StatusBadRequest, codes.InvalidArgument},
+		{http.MethodPost, "/v1/payments", `{"orderId":`, http.StatusBadRequest, codes.InvalidArgument},
+		{http.MethodGet, "/v1/orders/order-1/payment", "", http.StatusNotFound, codes.NotFound},
+	} {
+		req, err := http.NewRequest(tc.method, url+tc.path, strings.NewReader(tc.body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		var body struct {
+			Code codes.Code `json:"code"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+		assert.Equal(t, tc.status, resp.StatusCode, tc.body)
+		assert.Equal(t, tc.code, body.Code, tc.body)
+	}
+}
+
+func TestLoopback(t *testing.T) {
+	for addr, want := range map[string]string{
+		":5001":          "localhost:5001",
+		"0.0.0.0:5001":   "localhost:5001",
+		"[::]:5001":      "localhost:5001",
+		"10.0.0.1:5001":  "10.0.0.1:5001",
+		"payment:5001":   "payment:5001",
+		"not an address": "not an address",
+	} {
+		assert.Equal(t, want, loopback(addr), addr)
+	}
+}
@@ -1,6 +1,10 @@
 package grpcclients
 
-import "github.com/wathuta/technical_test/protos_gen/orders"
+import (
+	"context"
+
+	"github.com/wathuta/technical_test/protos_gen/orders"
+)
 
 // ServiceResult
 type ServiceResult struct {
@@ -9,5 +13,8 @@ type ServiceResult struct {
 }
 
 type OrderServiceClient interface {
-	UpdateOrderDetails(orderId string, status orders.OrderStatus) chan ServiceResult
+	UpdateOrderDetails(ctx context.Context, orderId string, status orders.OrderStatus) chan ServiceResult
+	// CheckHealth reports an error when the service cannot be reached, is not serving or calls to it
+	// are failing fast because its circuit breaker is open.
+	CheckHealth(ctx context.Context) error
 }
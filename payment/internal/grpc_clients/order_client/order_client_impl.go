@@ -5,28 +5,49 @@ import (
 	"fmt"
 
 	grpcclients "github.com/wathuta/technical_test/payment/internal/grpc_clients"
+	"github.com/wathuta/technical_test/payment/internal/logging"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
+// idempotentMethods are retried when the order service is unavailable. Orders are only updated to
+// set their status, which has the same effect however often it is done.
+var idempotentMethods = []string{
+	"/orders.OrderService/UpdateOrder",
+}
+
 type orderClient struct {
 	client orderspb.OrderServiceClient
+	health healthpb.HealthClient
+	// resilience holds the circuit breaker reported by CheckHealth
+	resilience *grpcclients.Resilience
 }
 
-func NewOrderClient(host string) (grpcclients.OrderServiceClient, error) {
-	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewOrderClient connects to the order service over transportCreds. Every call is made with the service token in creds
+// and is retried, broken and limited as opts say.
+func NewOrderClient(host string, transportCreds credentials.TransportCredentials, creds credentials.PerRPCCredentials, opts grpcclients.Options) (grpcclients.OrderServiceClient, error) {
+	resilience := grpcclients.NewResilience(opts, idempotentMethods...)
+	conn, err := grpc.Dial(host,
+		grpc.WithTransportCredentials(transportCreds),
+		grpc.WithPerRPCCredentials(creds),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), logging.UnaryClientInterceptor(), resilience.UnaryClientInterceptor()),
+	)
 	if err != nil {
 		return nil, err
 	}
 
 	client := orderspb.NewOrderServiceClient(conn)
 	return &orderClient{
-		client: client,
+		client:     client,
+		health:     healthpb.NewHealthClient(conn),
+		resilience: resilience,
 	}, nil
 }
-func (oc *orderClient) UpdateOrderDetails(orderId string, status orderspb.OrderStatus) chan grpcclients.ServiceResult {
+func (oc *orderClient) UpdateOrderDetails(ctx context.Context, orderId string, status orderspb.OrderStatus) chan grpcclients.ServiceResult {
 	output := make(chan grpcclients.ServiceResult)
 
 	go func() {
@@ -40,7 +61,7 @@ func (oc *orderClient) UpdateOrderDetails(orderId string, status orderspb.OrderS
 				Paths: []string{"order_status"},
 			},
 		}
-		res, err := oc.client.UpdateOrder(context.Background(), args)
+		res, err := oc.client.UpdateOrder(ctx, args)
 		if err != nil {
 			output <- grpcclients.ServiceResult{Error: err}
 		} else if res.Order.OrderStatus != orderspb.OrderStatus_ORDER_STATUS_PROCESSING {
@@ -51,3 +72,17 @@ func (oc *orderClient) UpdateOrderDetails(orderId string, status orderspb.OrderS
 	}()
 	return output
 }
+
+func (oc *orderClient) CheckHealth(ctx context.Context) error {
+	if state := oc.resilience.BreakerState(); state == grpcclients.BreakerOpen {
+		return fmt.Errorf("circuit breaker is %s", state)
+	}
+	res, err := oc.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service is %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package orderclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/payment/internal/auth"
+	grpcclients "github.com/wathuta/technical_test/payment/internal/grpc_clients"
+	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// fakeOrderServer blocks UpdateOrder until the call is abandoned and reports how it ended.
+type fakeOrderServer struct {
+	orderspb.UnimplementedOrderServiceServer
+
+	calls     chan time.Time
+	abandoned chan error
+}
+
+func (s *fakeOrderServer) UpdateOrder(ctx context.Context, req *orderspb.UpdateOrderRequest) (*orderspb.UpdateOrderResponse, error) {
+	deadline, _ := ctx.Deadline()
+	s.calls <- deadline
+	select {
+	case <-ctx.Done():
+		s.abandoned <- ctx.Err()
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return &orderspb.UpdateOrderResponse{Order: req.Order}, nil
+	}
+}
+
+func withTimeout(timeout time.Duration) grpcclients.Options {
+	opts := grpcclients.DefaultOptions()
+	opts.Timeout = timeout
+	return opts
+}
+
+func newTestClient(t *testing.T, opts grpcclients.Options) (*orderClient, *fakeOrderServer) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	fake := &fakeOrderServer{calls: make(chan time.Time, 1), abandoned: make(chan error, 1)}
+	srv := grpc.NewServer()
+	orderspb.RegisterOrderServiceServer(srv, fake)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	client, err := NewOrderClient(listener.Addr().String(), insecure.NewCredentials(), auth.NewTokenCredentials("token", false), opts)
+	require.NoError(t, err)
+	return client.(*orderClient), fake
+}
+
+func TestUpdateOrderDetails_CanceledDuringCall(t *testing.T) {
+	client, fake := newTestClient(t, withTimeout(time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := client.UpdateOrderDetails(ctx, "order-1", orderspb.OrderStatus_ORDER_STATUS_PROCESSING)
+	<-fake.calls
+	cancel()
+
+	assert.Equal(t, codes.Canceled, status.Code((<-results).Error))
+	select {
+	case err := <-fake.abandoned:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("the order service kept working on a cancelled request")
+	}
+}
+
+func TestUpdateOrderDetails_Deadline(t *testing.T) {
+	client, fake := newTestClient(t, withTimeout(100*time.Millisecond))
+
+	start := time.Now()
+	result := <-client.UpdateOrderDetails(context.Background(), "order-1", orderspb.OrderStatus_ORDER_STATUS_PROCESSING)
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(result.Error))
+	assert.WithinDuration(t, start.Add(100*time.Millisecond), <-fake.calls, 50*time.Millisecond, "the deadline is sent to the order service")
+	assert.Equal(t, context.DeadlineExceeded, <-fake.abandoned)
+}
+
+func TestCheckHealth_BreakerOpen(t *testing.T) {
+	opts := withTimeout(50 * time.Millisecond)
+	opts.BreakerThreshold = 1
+	client, _ := newTestClient(t, opts)
+
+	result := <-client.UpdateOrderDetails(context.Background(), "order-1", orderspb.OrderStatus_ORDER_STATUS_PROCESSING)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(result.Error))
+
+	err := client.CheckHealth(context.Background())
+	assert.EqualError(t, err, "circuit breaker is open")
+}
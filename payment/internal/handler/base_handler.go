@@ -1,20 +1,36 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/wathuta/technical_test/payment/internal/common"
 	grpcclients "github.com/wathuta/technical_test/payment/internal/grpc_clients"
 	"github.com/wathuta/technical_test/payment/internal/platform/mpesa"
 	"github.com/wathuta/technical_test/payment/internal/repository"
 	"github.com/wathuta/technical_test/protos_gen/payment"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// savePaymentTimeout bounds recording a payment once the STK push has been sent.
+const savePaymentTimeout = 5 * time.Second
+
 var (
 	errInternal                   = status.Error(codes.Internal, "internal error")
 	errNotFound                   = status.Error(codes.NotFound, "resource not found")
 	errResourceRequired           = status.Error(codes.InvalidArgument, "resource required")
 	errResourceUpdateMaskRequired = status.Error(codes.InvalidArgument, "resource update mask required")
 	errBadRequest                 = status.Error(codes.InvalidArgument, "invalid request payload")
+	errCanceled                   = status.Error(codes.Canceled, "request canceled")
+	errDeadlineExceeded           = status.Error(codes.DeadlineExceeded, "request timed out")
+	errInvalidPhoneNumber         = status.Error(codes.InvalidArgument, "invalid phone number")
+	errInsufficientFunds          = status.Error(codes.FailedPrecondition, "insufficient funds")
+	errPaymentsThrottled          = status.Error(codes.ResourceExhausted, "too many payment requests, try again later")
+	errPaymentsUnavailable        = status.Error(codes.Unavailable, "payment provider unavailable")
 )
 
 type Handler struct {
@@ -38,3 +54,42 @@ func New(
 	}
 
 }
+
+// callError is returned when a call to another service fails. Calls given up because the request
+// was cancelled or ran out of time keep that code so callers can tell them apart from failures.
+func callError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled:
+		return errCanceled
+	case errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded:
+		return errDeadlineExceeded
+	}
+	return errInternal
+}
+
+// validationError returns an InvalidArgument error with a google.rpc.BadRequest field violation
+// for every field of err, the error of a validator.
+func validationError(err error) error {
+	fields := common.ValidatorErrors(err)
+	if len(fields) == 0 {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	details := &errdetails.BadRequest{}
+	for _, path := range paths {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       path,
+			Description: fields[path],
+		})
+	}
+	detailed, detailsErr := status.New(codes.InvalidArgument, "invalid request payload").WithDetails(details)
+	if detailsErr != nil {
+		return errBadRequest
+	}
+	return detailed.Err()
+}
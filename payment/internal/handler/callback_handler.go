@@ -2,71 +2,105 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wathuta/technical_test/payment/internal/logging"
+	"github.com/wathuta/technical_test/payment/internal/metrics"
 	"github.com/wathuta/technical_test/payment/internal/model"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
-	"golang.org/x/exp/slog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (h *Handler) CallbackHandler(ctx *gin.Context) {
+	logger := logging.FromContext(ctx.Request.Context())
 	callbackResponse := model.CallbackResponse{}
-	err := ctx.BindJSON(&callbackResponse)
+	err := ctx.ShouldBindJSON(&callbackResponse)
 	if err != nil {
-		slog.Error("failed to unmarshal callback request")
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			logger.Error("callback request too large", "limit", tooLarge.Limit)
+			ctx.JSON(http.StatusRequestEntityTooLarge, map[string]string{"status": "failed"})
+			return
+		}
+		logger.Error("failed to unmarshal callback request", "error", err)
 		ctx.JSON(http.StatusBadRequest, map[string]string{"status": "failed"})
 		return
 	}
+	metrics.CallbackResults.WithLabelValues(strconv.Itoa(callbackResponse.Body.StkCallback.ResultCode)).Inc()
 
-	payment, err := h.repo.GetPaymentByMerchantRequestId(ctx, callbackResponse.Body.StkCallback.MerchantRequestID)
+	payment, err := h.repo.GetPaymentByMerchantRequestId(ctx.Request.Context(), callbackResponse.Body.StkCallback.MerchantRequestID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.Error("failed to Get payment record in db", "error", err)
+			logger.Error("failed to Get payment record in db", "error", err)
 			ctx.JSON(http.StatusNotFound, map[string]string{"message": "payment record not found"})
 			return
 		}
-		slog.Error("failed to Get payment record in db", "error", err)
+		logger.Error("failed to Get payment record in db", "error", err)
 		ctx.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal error"})
 		return
 	}
+	// M-Pesa calls back without trace context so the span is linked to the trace that started the payment
+	spanCtx, span := tracing.Tracer().Start(ctx.Request.Context(), "ProcessPaymentCallback", callbackSpanOptions(payment, callbackResponse.Body.StkCallback.ResultCode)...)
+	defer span.End()
+
 	switch callbackResponse.Body.StkCallback.ResultCode {
 	case 0:
-		result := <-h.clients.UpdateOrderDetails(payment.OrderID, orderspb.OrderStatus_ORDER_STATUS_PROCESSING)
+		result := <-h.clients.UpdateOrderDetails(spanCtx, payment.OrderID, orderspb.OrderStatus_ORDER_STATUS_PROCESSING)
 		if result.Error != nil {
-			slog.Error("failed to update order record from in order service", "error", result.Error)
+			logger.Error("failed to update order record from in order service", "error", result.Error)
 			ctx.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal error"})
 			return
 		}
-		payment, err = h.repo.UpdatePaymentStatus(ctx, model.PaymentStatus_COMPLETED, payment.PaymentID)
+		receiptNumber := callbackResponse.Body.StkCallback.CallbackMetadata.Value(model.MpesaReceiptNumberItem)
+		payment, err = h.repo.CompletePayment(spanCtx, payment.PaymentID, receiptNumber)
 		if err != nil {
-			slog.Error("failed to update payment status in db", "error", err)
+			logger.Error("failed to update payment status in db", "error", err)
 			ctx.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal error"})
 			return
 		}
+		metrics.PaymentStatusChanges.WithLabelValues(string(model.PaymentStatus_COMPLETED)).Inc()
 
-		slog.Debug("Update order status successful", "payment_id", payment.PaymentID)
+		logger.Debug("Update order status successful", "payment_id", payment.PaymentID, "mpesa_receipt_number", payment.MpesaReceiptNumber)
 	case 1032:
-		payment, err := h.repo.UpdatePaymentStatus(ctx, model.PaymentStatus_CANCELED, payment.PaymentID)
+		payment, err := h.repo.UpdatePaymentStatus(spanCtx, model.PaymentStatus_CANCELED, payment.PaymentID)
 		if err != nil {
-			slog.Error("failed to update payment status in db", "error", err)
+			logger.Error("failed to update payment status in db", "error", err)
 			ctx.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal error"})
 			return
 		}
-		slog.Debug("Transaction canceled by user", "payment", payment)
+		metrics.PaymentStatusChanges.WithLabelValues(string(model.PaymentStatus_CANCELED)).Inc()
+		logger.Debug("Transaction canceled by user", "payment", payment)
 		ctx.JSON(http.StatusPaymentRequired, map[string]string{"status": "payment canceled"})
 		return
 	default:
-		payment, err := h.repo.UpdatePaymentStatus(ctx, model.PaymentStatus_PENDING, payment.PaymentID)
+		payment, err := h.repo.UpdatePaymentStatus(spanCtx, model.PaymentStatus_PENDING, payment.PaymentID)
 		if err != nil {
-			slog.Error("failed to update payment status in db", "error", err)
+			logger.Error("failed to update payment status in db", "error", err)
 			ctx.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal error"})
 			return
 		}
-		slog.Debug("Transaction failed", "payment_id", payment.PaymentID)
+		metrics.PaymentStatusChanges.WithLabelValues(string(model.PaymentStatus_PENDING)).Inc()
+		logger.Debug("Transaction failed", "payment_id", payment.PaymentID)
 		ctx.JSON(http.StatusPaymentRequired, map[string]string{"status": "payment required"})
 		return
 
 	}
 	ctx.JSON(http.StatusOK, map[string]string{"status": "payment successful"})
 }
+
+func callbackSpanOptions(payment *model.Payment, resultCode int) []trace.SpanStartOption {
+	options := []trace.SpanStartOption{trace.WithAttributes(
+		attribute.String("payment.id", payment.PaymentID),
+		attribute.String("order.id", payment.OrderID),
+		attribute.Int("mpesa.result_code", resultCode),
+	)}
+	if link, ok := tracing.LinkTo(payment.TraceParent); ok {
+		options = append(options, trace.WithLinks(link))
+	}
+	return options
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -12,7 +13,11 @@ import (
 	"github.com/stretchr/testify/mock"
 	grpcclients "github.com/wathuta/technical_test/payment/internal/grpc_clients"
 	"github.com/wathuta/technical_test/payment/internal/model"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 	orderspb "github.com/wathuta/technical_test/protos_gen/orders"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func (st *PaymentHandlerTestSuite) TestCallbackHandler_Success() {
@@ -30,14 +35,15 @@ func (st *PaymentHandlerTestSuite) TestCallbackHandler_Success() {
 			OrderID:   st.testUUID.String(),
 		}, nil,
 	)
-	st.orderclient.On("UpdateOrderDetails", st.testUUID.String(), orderspb.OrderStatus_ORDER_STATUS_PROCESSING).Return(
+	st.orderclient.On("UpdateOrderDetails", mock.Anything, st.testUUID.String(), orderspb.OrderStatus_ORDER_STATUS_PROCESSING).Return(
 		output,
 	)
-	st.repo.On("UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything).Return(
+	st.repo.On("CompletePayment", mock.Anything, st.testUUID1.String(), "NLJ7RT61SV").Return(
 		&model.Payment{
-			PaymentID: st.testUUID1.String(),
-			OrderID:   st.testUUID.String(),
-			Status:    model.PaymentStatus_COMPLETED,
+			PaymentID:          st.testUUID1.String(),
+			OrderID:            st.testUUID.String(),
+			Status:             model.PaymentStatus_COMPLETED,
+			MpesaReceiptNumber: "NLJ7RT61SV",
 		}, nil,
 	)
 
@@ -47,6 +53,14 @@ func (st *PaymentHandlerTestSuite) TestCallbackHandler_Success() {
 			StkCallback: model.StkCallback{
 				MerchantRequestID: "123456",
 				ResultCode:        0,
+				CallbackMetadata: model.CallbackMetadata{
+					Item: []model.Item{
+						{Name: "Amount", Value: 1.00},
+						{Name: "MpesaReceiptNumber", Value: "NLJ7RT61SV"},
+						{Name: "TransactionDate", Value: 20191219102115},
+						{Name: "PhoneNumber", Value: 254708374149},
+					},
+				},
 			},
 		},
 	}
@@ -65,7 +79,7 @@ func (st *PaymentHandlerTestSuite) TestCallbackHandler_Success() {
 	st.Require().Equal(http.StatusOK, ctx.Writer.Status())
 }
 
-func (st *PaymentHandlerTestSuite) TestCallbackHandler_UpdatePaymentStatusError() {
+func (st *PaymentHandlerTestSuite) TestCallbackHandler_CompletePaymentError() {
 	output := make(chan grpcclients.ServiceResult)
 	go func() {
 		output <- grpcclients.ServiceResult{Error: nil, Result: &orderspb.Order{
@@ -80,10 +94,10 @@ func (st *PaymentHandlerTestSuite) TestCallbackHandler_UpdatePaymentStatusError(
 			OrderID:   st.testUUID.String(),
 		}, nil,
 	)
-	st.orderclient.On("UpdateOrderDetails", st.testUUID.String(), orderspb.OrderStatus_ORDER_STATUS_PROCESSING).Return(
+	st.orderclient.On("UpdateOrderDetails", mock.Anything, st.testUUID.String(), orderspb.OrderStatus_ORDER_STATUS_PROCESSING).Return(
 		output,
 	)
-	st.repo.On("UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("some error"))
+	st.repo.On("CompletePayment", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("some error"))
 
 	// Create a mock callback response
 	callbackResponse := &model.CallbackResponse{
@@ -121,7 +135,7 @@ func (st *PaymentHandlerTestSuite) TestCallbackHandler_UpdateOrderDetailsError()
 			OrderID:   st.testUUID.String(),
 		}, nil,
 	)
-	st.orderclient.On("UpdateOrderDetails", st.testUUID.String(), orderspb.OrderStatus_ORDER_STATUS_PROCESSING).Return(
+	st.orderclient.On("UpdateOrderDetails", mock.Anything, st.testUUID.String(), orderspb.OrderStatus_ORDER_STATUS_PROCESSING).Return(
 		output,
 	)
 
@@ -357,3 +371,43 @@ func (st *PaymentHandlerTestSuite) TestCallbackHandler_STKCallbackResultCodeErro
 	// Check the response status code
 	st.Require().Equal(http.StatusInternalServerError, ctx.Writer.Status())
 }
+
+func (st *PaymentHandlerTestSuite) TestCallbackHandler_LinksPaymentTrace() {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	// the trace of the CreatePayment request that started the payment
+	paymentCtx, paymentSpan := tracing.Tracer().Start(context.Background(), "CreatePayment")
+	paymentSpan.End()
+
+	st.repo.On("GetPaymentByMerchantRequestId", mock.Anything, "123456").Return(
+		&model.Payment{
+			PaymentID:   st.testUUID1.String(),
+			OrderID:     st.testUUID.String(),
+			TraceParent: tracing.TraceParent(paymentCtx),
+		}, nil,
+	)
+	st.repo.On("UpdatePaymentStatus", mock.Anything, model.PaymentStatus_CANCELED, st.testUUID1.String()).Return(
+		&model.Payment{PaymentID: st.testUUID1.String(), Status: model.PaymentStatus_CANCELED}, nil,
+	)
+
+	requestJSON, _ := json.Marshal(&model.CallbackResponse{Body: model.Body{StkCallback: model.StkCallback{
+		MerchantRequestID: "123456",
+		ResultCode:        1032,
+	}}})
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("POST", "/callback", bytes.NewReader(requestJSON))
+
+	st.handler.CallbackHandler(ctx)
+
+	st.Require().Equal(http.StatusPaymentRequired, ctx.Writer.Status())
+	spans := recorder.Ended()
+	st.Require().Len(spans, 2)
+	callbackSpan := spans[1]
+	st.Require().Equal("ProcessPaymentCallback", callbackSpan.Name())
+	st.Require().Len(callbackSpan.Links(), 1)
+	st.Require().Equal(paymentSpan.SpanContext().SpanID(), callbackSpan.Links()[0].SpanContext.SpanID())
+	st.Require().Equal(paymentSpan.SpanContext().TraceID(), callbackSpan.Links()[0].SpanContext.TraceID())
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"math"
 	"os"
 	"strings"
 	"time"
@@ -13,30 +13,36 @@ import (
 	"github.com/google/uuid"
 	"github.com/wathuta/technical_test/payment/internal/common"
 	"github.com/wathuta/technical_test/payment/internal/config"
+	"github.com/wathuta/technical_test/payment/internal/logging"
+	"github.com/wathuta/technical_test/payment/internal/metrics"
 	"github.com/wathuta/technical_test/payment/internal/model"
+	"github.com/wathuta/technical_test/payment/internal/platform/mpesa"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
-	"golang.org/x/exp/slog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 func (h *Handler) CreatePayment(ctx context.Context, req *paymentpb.CreatePaymentRequest) (*paymentpb.CreatePaymentResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.OrderId) == 0 || model.CheckNotAValidEnum(req) {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("create payment", "order_id", req.OrderId)
+	logger.Debug("create payment", "order_id", req.OrderId)
+
+	amount := model.MoneyFromProto(req.Amount)
+	shippingFee := model.MoneyFromProto(req.ShippingFee)
+	productCost := model.MoneyFromProto(req.ProductCost)
 
 	payment := &model.Payment{
 		PaymentID:     uuid.New().String(),
 		OrderID:       req.OrderId,
 		CustomerID:    req.CustomerId,
 		Description:   fmt.Sprintf("Payment for order %s", req.OrderId),
-		Currency:      string(model.KES),
+		Currency:      amount.Currency,
 		PaymentMethod: model.PaymentMethod(req.PaymentMethod.String()),
-		Amount:        req.Amount,
-		ShippingCost:  float64(req.ShippingFee),
-		ProductCost:   float64(req.ProductCost),
+		Amount:        amount.Amount,
+		ShippingCost:  shippingFee.Amount,
+		ProductCost:   productCost.Amount,
 		Status:        model.PaymentStatus_PENDING,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
@@ -45,12 +51,18 @@ func (h *Handler) CreatePayment(ctx context.Context, req *paymentpb.CreatePaymen
 	validator := common.NewValidator()
 
 	if err := validator.Struct(payment); err != nil {
-		slog.Error("failed to validate payment", "error", err)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		logger.Error("failed to validate payment", "error", err)
+		return nil, validationError(err)
 	}
 
-	if math.Ceil(payment.Amount) != math.Ceil(float64(req.ShippingFee)+payment.ProductCost) {
-		slog.Error("invalid payment values shipping cost + product price is not equal to amount")
+	total, err := productCost.Add(shippingFee)
+	if err != nil || total != amount {
+		logger.Error("invalid payment values shipping cost + product price is not equal to amount", "amount", amount, "product_cost", productCost, "shipping_fee", shippingFee)
+		return nil, errBadRequest
+	}
+	// daraja only handles payments in kenyan shillings
+	if amount.Currency != model.KES {
+		logger.Error("unsupported payment currency", "currency", amount.Currency)
 		return nil, errBadRequest
 	}
 	// Format the current time as "yyyyMMddHHmmss"
@@ -61,10 +73,9 @@ func (h *Handler) CreatePayment(ctx context.Context, req *paymentpb.CreatePaymen
 	password := base64.StdEncoding.EncodeToString([]byte(combinedValue))
 
 	callbackURL := fmt.Sprintf("%s%s", os.Getenv(config.CallBackBaseURL), "/callback")
-	fmt.Println(callbackURL)
-	resp, err := h.mpesa.InitiateSTKPushRequest(&model.STKPushRequestBody{
+	resp, err := h.mpesa.InitiateSTKPushRequest(ctx, &model.STKPushRequestBody{
 		Timestamp:         formattedTime,
-		Amount:            int(math.Ceil(req.Amount)),
+		Amount:            int(amount.WholeUnits()),
 		Password:          password,
 		TransactionType:   string(model.CustomerPayBillOnline),
 		BusinessShortCode: model.BusinessSortCode,
@@ -77,45 +88,102 @@ func (h *Handler) CreatePayment(ctx context.Context, req *paymentpb.CreatePaymen
 		AccountReference: "Technical test",
 	})
 	if err != nil {
-		slog.Error("initiating Stk push failed", "error", err)
-		return nil, errInternal
+		logger.Error("initiating Stk push failed", "error", err)
+		return nil, stkPushError(err)
 	}
 
 	payment.MerchantRequestID = resp.MerchantRequestID
+	payment.TraceParent = tracing.TraceParent(ctx)
 
-	payment, err = h.repo.CreatePayment(ctx, payment)
+	// the customer has been prompted to pay, the payment is recorded even if the caller has gone
+	// away so the callback finds it
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), savePaymentTimeout)
+	defer cancel()
+	payment, err = h.repo.CreatePayment(saveCtx, payment)
 	if err != nil {
-		slog.Error("failed to create payment in db", "error", err)
+		logger.Error("failed to create payment in db", "error", err)
 		return nil, errInternal
 	}
+	metrics.PaymentStatusChanges.WithLabelValues(string(payment.Status)).Inc()
 
-	slog.Debug("create payment successful")
+	logger.Debug("create payment successful")
 	return &paymentpb.CreatePaymentResponse{Payment: payment.Proto()}, nil
 }
 
+// stkPushError returns the status reported for a failed STK push.
+func stkPushError(err error) error {
+	switch {
+	case errors.Is(err, mpesa.ErrInvalidPhoneNumber):
+		return errInvalidPhoneNumber
+	case errors.Is(err, mpesa.ErrInsufficientFunds):
+		return errInsufficientFunds
+	case errors.Is(err, mpesa.ErrRateLimited):
+		return errPaymentsThrottled
+	case errors.Is(err, mpesa.ErrUpstreamUnavailable):
+		return errPaymentsUnavailable
+	}
+	// Daraja refusing our credentials is not something the caller can act on
+	return callError(err)
+}
+
 func (h *Handler) GetPaymentById(ctx context.Context, req *paymentpb.GetPaymentByIdRequest) (*paymentpb.GetPaymentByIdResponse, error) {
+	logger := logging.FromContext(ctx)
 	if req == nil || len(req.Id) == 0 {
-		slog.Error("invalid request", "error", errResourceRequired)
+		logger.Error("invalid request", "error", errResourceRequired)
 		return nil, errResourceRequired
 	}
-	slog.Debug("get payment by id", "payment_id", req.Id)
+	logger.Debug("get payment by id", "payment_id", req.Id)
 
 	paymentUUID, err := uuid.Parse(req.Id)
 	if err != nil {
-		slog.Error("invalid payment uuid value", "error", err)
+		logger.Error("invalid payment uuid value", "error", err)
 		return nil, errBadRequest
 	}
 
 	resource, err := h.repo.GetPaymentById(ctx, req.Id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.Error("payment with the given payment_id not found", "payment_id", paymentUUID, "error", err)
+			logger.Error("payment with the given payment_id not found", "payment_id", paymentUUID, "error", err)
 			return nil, errNotFound
 		}
-		slog.Error("failed to get payment from db", "error", err)
+		logger.Error("failed to get payment from db", "error", err)
 		return nil, errInternal
 	}
+	if err := authorizePayment(ctx, resource); err != nil {
+		return nil, err
+	}
 
-	slog.Debug("get payment successful")
+	logger.Debug("get payment successful")
 	return &paymentpb.GetPaymentByIdResponse{Payment: resource.Proto()}, nil
 }
+
+func (h *Handler) GetPaymentByOrderId(ctx context.Context, req *paymentpb.GetPaymentByOrderIdRequest) (*paymentpb.GetPaymentByOrderIdResponse, error) {
+	logger := logging.FromContext(ctx)
+	if req == nil || len(req.OrderId) == 0 {
+		logger.Error("invalid request", "error", errResourceRequired)
+		return nil, errResourceRequired
+	}
+	logger.Debug("get payment by order id", "order_id", req.OrderId)
+
+	orderUUID, err := uuid.Parse(req.OrderId)
+	if err != nil {
+		logger.Error("invalid order uuid value", "error", err)
+		return nil, errBadRequest
+	}
+
+	resource, err := h.repo.GetPaymentByOrderId(ctx, orderUUID.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Error("payment for the given order_id not found", "order_id", orderUUID, "error", err)
+			return nil, errNotFound
+		}
+		logger.Error("failed to get payment from db", "error", err)
+		return nil, errInternal
+	}
+	if err := authorizePayment(ctx, resource); err != nil {
+		return nil, err
+	}
+
+	logger.Debug("get payment by order id successful")
+	return &paymentpb.GetPaymentByOrderIdResponse{Payment: resource.Proto()}, nil
+}
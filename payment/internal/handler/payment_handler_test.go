@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -14,8 +15,13 @@ import (
 	"github.com/wathuta/technical_test/payment/internal/config"
 	"github.com/wathuta/technical_test/payment/internal/mocks"
 	"github.com/wathuta/technical_test/payment/internal/model"
+	"github.com/wathuta/technical_test/payment/internal/platform/mpesa"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
 	"golang.org/x/exp/slog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type PaymentHandlerTestSuite struct {
@@ -61,12 +67,12 @@ func (st *PaymentHandlerTestSuite) TestCreatePayment_Success() {
 		OrderId:       st.testUUID.String(),
 		CustomerId:    st.testUUID1.String(),
 		PaymentMethod: 2,
-		Amount:        10,
+		Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		CustomerPhone: "+254724396746",
-		ProductCost:   5,
-		ShippingFee:   5,
+		ProductCost:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+		ShippingFee:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
 	}
-	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything).Return(&model.STKPushRequestResponse{
+	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything, mock.Anything).Return(&model.STKPushRequestResponse{
 		MerchantRequestID:   "29115-34620561-1",
 		CheckoutRequestID:   "ws_CO_191220191020363925",
 		ResponseCode:        "0",
@@ -77,12 +83,12 @@ func (st *PaymentHandlerTestSuite) TestCreatePayment_Success() {
 		OrderID:           st.testUUID.String(),
 		CustomerID:        st.testUUID1.String(),
 		PaymentMethod:     model.PaymentMethod_MPESA,
-		Amount:            10,
-		ProductCost:       5,
-		ShippingCost:      5,
+		Amount:            1000,
+		ProductCost:       500,
+		ShippingCost:      500,
 		PaymentID:         st.testUUID1.String(),
 		MerchantRequestID: "29115-34620561-1",
-		Currency:          "KES",
+		Currency:          model.KES,
 		Status:            model.PaymentStatus_COMPLETED,
 		Description:       "payment for order qerty-erty-cvbn-yh9ik",
 		CreatedAt:         time.Now(),
@@ -99,12 +105,12 @@ func (st *PaymentHandlerTestSuite) TestCreatePayment_CreatePaymentError() {
 		OrderId:       st.testUUID.String(),
 		CustomerId:    st.testUUID1.String(),
 		PaymentMethod: 2,
-		Amount:        10,
+		Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		CustomerPhone: "+254724396746",
-		ProductCost:   5,
-		ShippingFee:   5,
+		ProductCost:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+		ShippingFee:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
 	}
-	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything).Return(&model.STKPushRequestResponse{
+	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything, mock.Anything).Return(&model.STKPushRequestResponse{
 		MerchantRequestID:   "29115-34620561-1",
 		CheckoutRequestID:   "ws_CO_191220191020363925",
 		ResponseCode:        "0",
@@ -123,30 +129,121 @@ func (st *PaymentHandlerTestSuite) TestCreatePayment_ErrorInitiateSTKPushFailed(
 		OrderId:       st.testUUID.String(),
 		CustomerId:    st.testUUID1.String(),
 		PaymentMethod: 2,
-		Amount:        10,
+		Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		CustomerPhone: "+254724396746",
-		ProductCost:   5,
-		ShippingFee:   5,
+		ProductCost:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+		ShippingFee:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
 	}
-	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything).Return(nil, errors.New("some error"))
+	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything, mock.Anything).Return(nil, errors.New("some error"))
 
 	resp, err := st.handler.CreatePayment(context.Background(), payment)
 	st.Require().NotNil(err)
 	st.Require().Nil(resp)
 }
 
+func (st *PaymentHandlerTestSuite) TestCreatePayment_STKPushTimedOut() {
+	payment := &paymentpb.CreatePaymentRequest{
+		OrderId:       st.testUUID.String(),
+		CustomerId:    st.testUUID1.String(),
+		PaymentMethod: 2,
+		Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
+		CustomerPhone: "+254724396746",
+		ProductCost:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+		ShippingFee:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+	}
+	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("stk push request failed with error: %w", context.DeadlineExceeded))
+
+	resp, err := st.handler.CreatePayment(context.Background(), payment)
+	st.Require().Nil(resp)
+	st.Equal(codes.DeadlineExceeded, status.Code(err))
+	st.repo.AssertNotCalled(st.T(), "CreatePayment", mock.Anything, mock.Anything)
+}
+
+func (st *PaymentHandlerTestSuite) TestCreatePayment_STKPushErrors() {
+	payment := &paymentpb.CreatePaymentRequest{
+		OrderId:       st.testUUID.String(),
+		CustomerId:    st.testUUID1.String(),
+		PaymentMethod: 2,
+		Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
+		CustomerPhone: "+254724396746",
+		ProductCost:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+		ShippingFee:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+	}
+	tests := map[error]codes.Code{
+		mpesa.ErrInvalidPhoneNumber:  codes.InvalidArgument,
+		mpesa.ErrInsufficientFunds:   codes.FailedPrecondition,
+		mpesa.ErrRateLimited:         codes.ResourceExhausted,
+		mpesa.ErrUpstreamUnavailable: codes.Unavailable,
+		mpesa.ErrAuthFailed:          codes.Internal,
+	}
+	for kind, code := range tests {
+		st.mpesaService.ExpectedCalls = nil
+		st.mpesaService.On("InitiateSTKPushRequest", mock.Anything, mock.Anything).Return(nil, &mpesa.Error{Kind: kind, Code: "code", Message: kind.Error()})
+
+		resp, err := st.handler.CreatePayment(context.Background(), payment)
+		st.Require().Nil(resp)
+		st.Equal(code, status.Code(err), kind.Error())
+	}
+	st.repo.AssertNotCalled(st.T(), "CreatePayment", mock.Anything, mock.Anything)
+}
+
+func (st *PaymentHandlerTestSuite) TestCreatePayment_CanceledAfterSTKPush() {
+	payment := &paymentpb.CreatePaymentRequest{
+		OrderId:       st.testUUID.String(),
+		CustomerId:    st.testUUID1.String(),
+		PaymentMethod: 2,
+		Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
+		CustomerPhone: "+254724396746",
+		ProductCost:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+		ShippingFee:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// the caller goes away while Daraja prompts the customer
+	st.mpesaService.On("InitiateSTKPushRequest", mock.Anything, mock.Anything).Run(func(mock.Arguments) { cancel() }).Return(&model.STKPushRequestResponse{
+		MerchantRequestID: "29115-34620561-1",
+		ResponseCode:      "0",
+	}, nil)
+	st.repo.On("CreatePayment", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), mock.Anything).Return(&model.Payment{
+		OrderID:           st.testUUID.String(),
+		CustomerID:        st.testUUID1.String(),
+		PaymentMethod:     model.PaymentMethod_MPESA,
+		Amount:            1000,
+		PaymentID:         st.testUUID1.String(),
+		MerchantRequestID: "29115-34620561-1",
+		Currency:          model.KES,
+		Status:            model.PaymentStatus_PENDING,
+	}, nil)
+
+	_, err := st.handler.CreatePayment(ctx, payment)
+	st.Require().Nil(err)
+	st.repo.AssertCalled(st.T(), "CreatePayment", mock.Anything, mock.Anything)
+}
+
 func (st *PaymentHandlerTestSuite) TestCreatePayment_StructValidationError() {
 	payment := &paymentpb.CreatePaymentRequest{
+		OrderId:       "not-a-uuid",
 		PaymentMethod: 2,
-		Amount:        10,
+		Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 1000},
 		CustomerPhone: "+254724396746",
-		ProductCost:   5,
-		ShippingFee:   5,
+		ProductCost:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
+		ShippingFee:   &moneypb.Money{CurrencyCode: "KES", Amount: 500},
 	}
 
 	resp, err := st.handler.CreatePayment(context.Background(), payment)
 	st.Require().NotNil(err)
 	st.Require().Nil(resp)
+	st.Require().Equal(codes.InvalidArgument, status.Code(err))
+
+	// every invalid field is reported
+	details := status.Convert(err).Details()
+	st.Require().Len(details, 1)
+	badRequest, ok := details[0].(*errdetails.BadRequest)
+	st.Require().True(ok)
+	var fields []string
+	for _, violation := range badRequest.FieldViolations {
+		fields = append(fields, violation.Field)
+	}
+	st.Require().Equal([]string{"customer_id", "order_id"}, fields)
 }
 
 func (st *PaymentHandlerTestSuite) TestCreatePayment_NilRequestError() {
@@ -159,12 +256,12 @@ func (st *PaymentHandlerTestSuite) TestGetPaymentById_Success() {
 		OrderID:           st.testUUID.String(),
 		CustomerID:        st.testUUID1.String(),
 		PaymentMethod:     model.PaymentMethod_MPESA,
-		Amount:            10,
-		ProductCost:       5,
-		ShippingCost:      5,
+		Amount:            1000,
+		ProductCost:       500,
+		ShippingCost:      500,
 		PaymentID:         st.testUUID1.String(),
 		MerchantRequestID: "29115-34620561-1",
-		Currency:          "KES",
+		Currency:          model.KES,
 		Status:            model.PaymentStatus_COMPLETED,
 		Description:       "payment for order qerty-erty-cvbn-yh9ik",
 		CreatedAt:         time.Now(),
@@ -221,3 +318,47 @@ func (st *PaymentHandlerTestSuite) TestGetPaymentById_NilRequest() {
 	st.Require().Nil(resp)
 	st.Require().NotNil(err)
 }
+
+func (st *PaymentHandlerTestSuite) TestGetPaymentByOrderId_Success() {
+	payment := &model.Payment{
+		OrderID:            st.testUUID.String(),
+		CustomerID:         st.testUUID1.String(),
+		PaymentMethod:      model.PaymentMethod_MPESA,
+		Amount:             1000,
+		ProductCost:        500,
+		ShippingCost:       500,
+		PaymentID:          st.testUUID1.String(),
+		Currency:           model.KES,
+		Status:             model.PaymentStatus_COMPLETED,
+		MpesaReceiptNumber: "NLJ7RT61SV",
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	st.repo.On("GetPaymentByOrderId", mock.Anything, st.testUUID.String()).Return(payment, nil)
+
+	resp, err := st.handler.GetPaymentByOrderId(context.Background(), &paymentpb.GetPaymentByOrderIdRequest{
+		OrderId: st.testUUID.String(),
+	})
+	st.Require().Nil(err)
+	st.Require().Equal(st.testUUID.String(), resp.Payment.OrderId)
+	st.Require().Equal("NLJ7RT61SV", resp.Payment.MpesaReceiptNumber)
+}
+
+func (st *PaymentHandlerTestSuite) TestGetPaymentByOrderId_NotFound() {
+	st.repo.On("GetPaymentByOrderId", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows)
+
+	resp, err := st.handler.GetPaymentByOrderId(context.Background(), &paymentpb.GetPaymentByOrderIdRequest{
+		OrderId: st.testUUID.String(),
+	})
+	st.Require().Equal(errNotFound, err)
+	st.Require().Nil(resp)
+}
+
+func (st *PaymentHandlerTestSuite) TestGetPaymentByOrderId_InvalidId() {
+	resp, err := st.handler.GetPaymentByOrderId(context.Background(), &paymentpb.GetPaymentByOrderIdRequest{
+		OrderId: "not-a-uuid",
+	})
+	st.Require().Equal(errBadRequest, err)
+	st.Require().Nil(resp)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/wathuta/technical_test/payment/internal/auth"
+	"github.com/wathuta/technical_test/payment/internal/logging"
+	"github.com/wathuta/technical_test/payment/internal/model"
+)
+
+var (
+	allowEveryone = auth.Allow(auth.RoleCustomer, auth.RoleStaff, auth.RoleAdmin, auth.RoleService)
+	allowServices = auth.Allow(auth.RoleService, auth.RoleAdmin)
+	allowStaff    = auth.Allow(auth.RoleStaff, auth.RoleAdmin)
+)
+
+// Permissions declares who may call each RPC served by the handler. Customers are also limited
+// to the payments of their own orders by the handlers.
+func Permissions() auth.Policy {
+	return auth.Policy{
+		"/ecommerce.PaymentService/CreatePayment":       allowServices,
+		"/ecommerce.PaymentService/GetPaymentById":      allowEveryone,
+		"/ecommerce.PaymentService/GetPaymentByOrderId": allowEveryone,
+
+		// probes and load balancers check health without a token
+		"/grpc.health.v1.Health/Check": auth.Public(),
+		"/grpc.health.v1.Health/Watch": auth.Public(),
+
+		// the schema is only described to operators, when reflection is enabled
+		"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      allowStaff,
+		"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": allowStaff,
+	}
+}
+
+// authorizePayment checks that a customer is reading one of their own payments. Payments of
+// other customers are reported as not found so customers cannot find out which ids exist.
+func authorizePayment(ctx context.Context, payment *model.Payment) error {
+	logger := logging.FromContext(ctx)
+	if !auth.CanAccessCustomer(ctx, payment.CustomerID) {
+		logger.Error("customer is not allowed to read payment", "payment_id", payment.PaymentID)
+		return errNotFound
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wathuta/technical_test/payment/internal/auth"
+	"github.com/wathuta/technical_test/payment/internal/model"
+	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionalphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+func (st *PaymentHandlerTestSuite) TestPermissions_EveryMethodDeclared() {
+	policy := Permissions()
+	for _, service := range []grpc.ServiceDesc{
+		paymentpb.PaymentService_ServiceDesc,
+		reflectionpb.ServerReflection_ServiceDesc,
+		reflectionalphapb.ServerReflection_ServiceDesc,
+	} {
+		for _, method := range service.Methods {
+			fullMethod := "/" + service.ServiceName + "/" + method.MethodName
+			_, ok := policy[fullMethod]
+			st.Require().True(ok, "no permission declared for %s", fullMethod)
+		}
+		for _, stream := range service.Streams {
+			fullMethod := "/" + service.ServiceName + "/" + stream.StreamName
+			_, ok := policy[fullMethod]
+			st.Require().True(ok, "no permission declared for %s", fullMethod)
+		}
+	}
+}
+
+func (st *PaymentHandlerTestSuite) customerContext(customerId string) context.Context {
+	return auth.ContextWithPrincipal(context.Background(), &auth.Principal{
+		Subject: customerId,
+		Roles:   []auth.Role{auth.RoleCustomer},
+	})
+}
+
+func (st *PaymentHandlerTestSuite) TestGetPaymentById_OtherCustomer() {
+	st.repo.On("GetPaymentById", mock.Anything, st.testUUID.String()).Return(
+		&model.Payment{PaymentID: st.testUUID.String(), CustomerID: st.testUUID1.String()},
+		nil,
+	)
+
+	resp, err := st.handler.GetPaymentById(st.customerContext("another-customer"), &paymentpb.GetPaymentByIdRequest{
+		Id: st.testUUID.String(),
+	})
+	st.Require().Nil(resp)
+	st.Require().Equal(errNotFound, err)
+}
+
+func (st *PaymentHandlerTestSuite) TestGetPaymentByOrderId_OwnPayment() {
+	st.repo.On("GetPaymentByOrderId", mock.Anything, st.testUUID.String()).Return(
+		&model.Payment{PaymentID: st.testUUID1.String(), OrderID: st.testUUID.String(), CustomerID: st.testUUID1.String()},
+		nil,
+	)
+
+	resp, err := st.handler.GetPaymentByOrderId(st.customerContext(st.testUUID1.String()), &paymentpb.GetPaymentByOrderIdRequest{
+		OrderId: st.testUUID.String(),
+	})
+	st.Require().Nil(err)
+	st.Require().Equal(st.testUUID1.String(), resp.Payment.Id)
+}
+
+func (st *PaymentHandlerTestSuite) TestGetPaymentByOrderId_OtherCustomer() {
+	st.repo.On("GetPaymentByOrderId", mock.Anything, st.testUUID.String()).Return(
+		&model.Payment{PaymentID: st.testUUID1.String(), OrderID: st.testUUID.String(), CustomerID: st.testUUID1.String()},
+		nil,
+	)
+
+	resp, err := st.handler.GetPaymentByOrderId(st.customerContext("another-customer"), &paymentpb.GetPaymentByOrderIdRequest{
+		OrderId: st.testUUID.String(),
+	})
+	st.Require().Nil(resp)
+	st.Require().Equal(errNotFound, err)
+}
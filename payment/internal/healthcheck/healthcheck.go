@@ -0,0 +1,131 @@
+// Package healthcheck drives the grpc health service from periodic checks of the dependencies
+// of the service.
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// DefaultInterval is how often the dependencies are checked.
+	DefaultInterval = 10 * time.Second
+	// DefaultTimeout bounds a single check.
+	DefaultTimeout = 2 * time.Second
+)
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+// Monitor runs the checks and sets the status of every service from the checks it depends on.
+type Monitor struct {
+	server   *health.Server
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	checks   map[string]Check
+	services map[string][]string
+	failing  map[string]bool
+}
+
+func NewMonitor(server *health.Server, interval, timeout time.Duration) *Monitor {
+	return &Monitor{
+		server:   server,
+		interval: interval,
+		timeout:  timeout,
+		checks:   map[string]Check{},
+		services: map[string][]string{},
+		failing:  map[string]bool{},
+	}
+}
+
+// AddCheck registers a named dependency check.
+func (m *Monitor) AddCheck(name string, check Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks[name] = check
+}
+
+// AddService declares that service, "" being the whole server, is only serving while the checks
+// it depends on pass. Services are NOT_SERVING until the first round of checks.
+func (m *Monitor) AddService(service string, dependsOn ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.services[service] = dependsOn
+	m.server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Run checks the dependencies until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.CheckNow(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckNow runs every check once and updates the status of the services.
+func (m *Monitor) CheckNow(ctx context.Context) {
+	m.mu.Lock()
+	checks := make(map[string]Check, len(m.checks))
+	for name, check := range m.checks {
+		checks[name] = check
+	}
+	m.mu.Unlock()
+
+	failing := make(map[string]bool, len(checks))
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, m.timeout)
+			defer cancel()
+			err := check(checkCtx)
+
+			resultMu.Lock()
+			defer resultMu.Unlock()
+			failing[name] = err != nil
+			if err != nil {
+				slog.Error("health check failed", "check", name, "error", err)
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, failed := range failing {
+		if !failed && m.failing[name] {
+			slog.Info("health check recovered", "check", name)
+		}
+	}
+	m.failing = failing
+	for service, dependsOn := range m.services {
+		status := healthpb.HealthCheckResponse_SERVING
+		for _, name := range dependsOn {
+			if failed, ok := failing[name]; !ok || failed {
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+		}
+		m.server.SetServingStatus(service, status)
+	}
+}
+
+// Shutdown sets every service to NOT_SERVING for good so load balancers stop sending new
+// requests while the server drains.
+func (m *Monitor) Shutdown() {
+	m.server.Shutdown()
+}
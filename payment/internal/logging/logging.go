@@ -0,0 +1,175 @@
+// Package logging gives every request a logger carrying its request id and redacts sensitive
+// values from the logs.
+package logging
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDKey is the grpc metadata key and HTTP header carrying the request id between services.
+const RequestIDKey = "x-request-id"
+
+// maxRequestIDLength bounds the request ids accepted from callers.
+const maxRequestIDLength = 128
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// WithLogger returns a context carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the request, the default logger outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID returns the id of the request ctx belongs to.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// withRequest stores the request id and a logger annotated with it and the trace of the request.
+func withRequest(ctx context.Context, requestID string, attrs ...any) context.Context {
+	attrs = append(attrs, "request_id", requestID)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		attrs = append(attrs, "trace_id", spanContext.TraceID().String())
+	}
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return WithLogger(ctx, slog.Default().With(attrs...))
+}
+
+// validRequestID reports whether an id sent by a caller can be logged as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// incomingRequestID returns the request id sent by the caller or a new one.
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, id := range md.Get(RequestIDKey) {
+			if validRequestID(id) {
+				return id
+			}
+		}
+	}
+	return uuid.NewString()
+}
+
+// health checks are made every few seconds and would drown the logs of real requests
+func quiet(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/")
+}
+
+func logFinished(ctx context.Context, fullMethod string, start time.Time, err error) {
+	if quiet(fullMethod) {
+		return
+	}
+	logger := FromContext(ctx)
+	code := status.Code(err)
+	if err != nil {
+		logger.Info("finished call", "code", code.String(), "duration", time.Since(start), "error", err)
+		return
+	}
+	logger.Info("finished call", "code", code.String(), "duration", time.Since(start))
+}
+
+// UnaryServerInterceptor takes the request id from the incoming metadata, or assigns one, sends
+// it back in the response header and stores the logger of the request in the context.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := incomingRequestID(ctx)
+		ctx = withRequest(ctx, requestID, "method", info.FullMethod)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, requestID))
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logFinished(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming RPCs.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		requestID := incomingRequestID(ss.Context())
+		ctx := withRequest(ss.Context(), requestID, "method", info.FullMethod)
+		_ = ss.SetHeader(metadata.Pairs(RequestIDKey, requestID))
+
+		start := time.Now()
+		err := handler(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+		logFinished(ctx, info.FullMethod, start, err)
+		return err
+	}
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryClientInterceptor passes the request id on to the services we call.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if requestID := RequestID(ctx); requestID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDKey, requestID)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// HTTPMiddleware gives HTTP requests a request id, from the X-Request-Id header when the caller
+// sent one, and a logger, and logs them once they are answered.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDKey)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDKey, requestID)
+		ctx := withRequest(r.Context(), requestID, "method", r.Method, "path", r.URL.Path)
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		FromContext(ctx).Info("finished request", "status", recorder.status, "duration", time.Since(start))
+	})
+}
+
+// statusRecorder keeps the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
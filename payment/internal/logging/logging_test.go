@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// captureLogs makes the default logger write JSON lines, with redaction, to the returned buffer.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	previous := slog.Default()
+	buf := &bytes.Buffer{}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{ReplaceAttr: Redact})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return buf
+}
+
+func lastLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	line := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &line))
+	return line
+}
+
+func serve(t *testing.T, ctx context.Context) (context.Context, string) {
+	t.Helper()
+	var handlerCtx context.Context
+	_, err := UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/ecommerce.PaymentService/GetPaymentById"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			handlerCtx = ctx
+			FromContext(ctx).Debug("get order by id")
+			return nil, nil
+		})
+	require.NoError(t, err)
+	return handlerCtx, RequestID(handlerCtx)
+}
+
+func TestUnaryServerInterceptor_AssignsRequestID(t *testing.T) {
+	buf := captureLogs(t)
+
+	_, requestID := serve(t, context.Background())
+
+	require.NotEmpty(t, requestID)
+	line := lastLine(t, buf)
+	assert.Equal(t, "finished call", line["msg"])
+	assert.Equal(t, requestID, line["request_id"])
+	assert.Equal(t, "/ecommerce.PaymentService/GetPaymentById", line["method"])
+	assert.Equal(t, "OK", line["code"])
+}
+
+func TestUnaryServerInterceptor_PropagatesRequestID(t *testing.T) {
+	captureLogs(t)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDKey, "checkout-42"))
+
+	_, requestID := serve(t, ctx)
+	assert.Equal(t, "checkout-42", requestID)
+
+	// ids that could forge log lines are replaced
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDKey, "bad\"id\n"))
+	_, requestID = serve(t, ctx)
+	assert.NotEqual(t, "bad\"id\n", requestID)
+	assert.NotEmpty(t, requestID)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	captureLogs(t)
+	ctx, requestID := serve(t, context.Background())
+
+	var sent metadata.MD
+	err := UnaryClientInterceptor()(ctx, "/orders.OrderService/UpdateOrderDetails", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			sent, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{requestID}, sent.Get(RequestIDKey))
+}
+
+func TestFromContext_Default(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+	assert.Empty(t, RequestID(context.Background()))
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	buf := captureLogs(t)
+	var requestID string
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = RequestID(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/callback", nil)
+	req.Header.Set(RequestIDKey, "callback-1")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, "callback-1", requestID)
+	assert.Equal(t, "callback-1", recorder.Header().Get(RequestIDKey))
+	line := lastLine(t, buf)
+	assert.Equal(t, "finished request", line["msg"])
+	assert.Equal(t, "callback-1", line["request_id"])
+	assert.Equal(t, float64(http.StatusAccepted), line["status"])
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/callback", nil))
+	assert.NotEmpty(t, requestID)
+	assert.Equal(t, requestID, recorder.Header().Get(RequestIDKey))
+}
+
+func TestRedact(t *testing.T) {
+	buf := captureLogs(t)
+
+	slog.Info("create payment",
+		"customer_phone", "254708374149",
+		"PhoneNumber", "254708374149",
+		"Password", "c2VjcmV0",
+		"customer_email", "jane@example.com",
+		slog.Group("stk_push", "PartyA", "254708374149", "Amount", 10),
+		"order_id", "6b3c2f6e",
+	)
+
+	line := lastLine(t, buf)
+	assert.Equal(t, Redacted, line["customer_phone"])
+	assert.Equal(t, Redacted, line["PhoneNumber"])
+	assert.Equal(t, Redacted, line["Password"])
+	assert.Equal(t, Redacted, line["customer_email"])
+	assert.Equal(t, map[string]interface{}{"PartyA": Redacted, "Amount": float64(10)}, line["stk_push"])
+	assert.Equal(t, "6b3c2f6e", line["order_id"])
+	assert.NotContains(t, buf.String(), "254708374149")
+}
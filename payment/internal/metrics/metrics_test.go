@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ecommerce.PaymentService/GetPaymentById"}
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "payment", nil }
+	notFound := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "resource not found")
+	}
+
+	resp, err := interceptor(context.Background(), nil, info, ok)
+	require.NoError(t, err)
+	assert.Equal(t, "payment", resp)
+	_, err = interceptor(context.Background(), nil, info, notFound)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, _ = interceptor(context.Background(), nil, info, notFound)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(grpcRequests.WithLabelValues(info.FullMethod, "OK")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(grpcRequests.WithLabelValues(info.FullMethod, "NotFound")))
+	assert.Equal(t, 2, testutil.CollectAndCount(grpcDuration, "payment_grpc_request_duration_seconds"))
+}
+
+func TestHandler(t *testing.T) {
+	CallbackResults.WithLabelValues("1032").Inc()
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(recorder.Result().Body)
+	require.NoError(t, err)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, string(body), `payment_mpesa_callbacks_total{result_code="1032"}`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
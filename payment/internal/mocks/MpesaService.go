@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 	model "github.com/wathuta/technical_test/payment/internal/model"
 )
@@ -12,25 +14,25 @@ type MpesaService struct {
 	mock.Mock
 }
 
-// InitiateSTKPushRequest provides a mock function with given fields: body
-func (_m *MpesaService) InitiateSTKPushRequest(body *model.STKPushRequestBody) (*model.STKPushRequestResponse, error) {
-	ret := _m.Called(body)
+// InitiateSTKPushRequest provides a mock function with given fields: ctx, body
+func (_m *MpesaService) InitiateSTKPushRequest(ctx context.Context, body *model.STKPushRequestBody) (*model.STKPushRequestResponse, error) {
+	ret := _m.Called(ctx, body)
 
 	var r0 *model.STKPushRequestResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*model.STKPushRequestBody) (*model.STKPushRequestResponse, error)); ok {
-		return rf(body)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.STKPushRequestBody) (*model.STKPushRequestResponse, error)); ok {
+		return rf(ctx, body)
 	}
-	if rf, ok := ret.Get(0).(func(*model.STKPushRequestBody) *model.STKPushRequestResponse); ok {
-		r0 = rf(body)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.STKPushRequestBody) *model.STKPushRequestResponse); ok {
+		r0 = rf(ctx, body)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.STKPushRequestResponse)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*model.STKPushRequestBody) error); ok {
-		r1 = rf(body)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.STKPushRequestBody) error); ok {
+		r1 = rf(ctx, body)
 	} else {
 		r1 = ret.Error(1)
 	}
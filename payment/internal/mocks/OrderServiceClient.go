@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 	grpcclients "github.com/wathuta/technical_test/payment/internal/grpc_clients"
 
@@ -14,13 +16,27 @@ type OrderServiceClient struct {
 	mock.Mock
 }
 
-// UpdateOrderDetails provides a mock function with given fields: orderId, status
-func (_m *OrderServiceClient) UpdateOrderDetails(orderId string, status orders.OrderStatus) chan grpcclients.ServiceResult {
-	ret := _m.Called(orderId, status)
+// CheckHealth provides a mock function with given fields: ctx
+func (_m *OrderServiceClient) CheckHealth(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateOrderDetails provides a mock function with given fields: ctx, orderId, status
+func (_m *OrderServiceClient) UpdateOrderDetails(ctx context.Context, orderId string, status orders.OrderStatus) chan grpcclients.ServiceResult {
+	ret := _m.Called(ctx, orderId, status)
 
 	var r0 chan grpcclients.ServiceResult
-	if rf, ok := ret.Get(0).(func(string, orders.OrderStatus) chan grpcclients.ServiceResult); ok {
-		r0 = rf(orderId, status)
+	if rf, ok := ret.Get(0).(func(context.Context, string, orders.OrderStatus) chan grpcclients.ServiceResult); ok {
+		r0 = rf(ctx, orderId, status)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(chan grpcclients.ServiceResult)
@@ -14,6 +14,32 @@ type Repository struct {
 	mock.Mock
 }
 
+// CompletePayment provides a mock function with given fields: ctx, paymentId, mpesaReceiptNumber
+func (_m *Repository) CompletePayment(ctx context.Context, paymentId string, mpesaReceiptNumber string) (*model.Payment, error) {
+	ret := _m.Called(ctx, paymentId, mpesaReceiptNumber)
+
+	var r0 *model.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.Payment, error)); ok {
+		return rf(ctx, paymentId, mpesaReceiptNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Payment); ok {
+		r0 = rf(ctx, paymentId, mpesaReceiptNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, paymentId, mpesaReceiptNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreatePayment provides a mock function with given fields: ctx, payment
 func (_m *Repository) CreatePayment(ctx context.Context, payment *model.Payment) (*model.Payment, error) {
 	ret := _m.Called(ctx, payment)
@@ -92,6 +118,32 @@ func (_m *Repository) GetPaymentByMerchantRequestId(ctx context.Context, merchnt
 	return r0, r1
 }
 
+// GetPaymentByOrderId provides a mock function with given fields: ctx, orderId
+func (_m *Repository) GetPaymentByOrderId(ctx context.Context, orderId string) (*model.Payment, error) {
+	ret := _m.Called(ctx, orderId)
+
+	var r0 *model.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Payment, error)); ok {
+		return rf(ctx, orderId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Payment); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdatePaymentStatus provides a mock function with given fields: ctx, paymentStatus, paymentId
 func (_m *Repository) UpdatePaymentStatus(ctx context.Context, paymentStatus model.PaymentStatus, paymentId string) (*model.Payment, error) {
 	ret := _m.Called(ctx, paymentStatus, paymentId)
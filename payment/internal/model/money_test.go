@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
+)
+
+func TestMoneyFromProto(t *testing.T) {
+	m := MoneyFromProto(&moneypb.Money{CurrencyCode: "USD", Amount: 1050})
+	assert.Equal(t, Money{Amount: 1050, Currency: USD}, m)
+
+	// a missing value is zero in the default currency
+	m = MoneyFromProto(nil)
+	assert.Equal(t, Money{Amount: 0, Currency: DefaultCurrency}, m)
+}
+
+func TestMoneyAdd(t *testing.T) {
+	sum, err := NewMoney(10, KES).Add(NewMoney(20, KES))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(30, KES), sum)
+
+	_, err = NewMoney(10, KES).Add(NewMoney(20, USD))
+	assert.True(t, errors.Is(err, ErrCurrencyMismatch))
+}
+
+func TestMoneyWholeUnits(t *testing.T) {
+	assert.Equal(t, int64(10), NewMoney(1000, KES).WholeUnits())
+	assert.Equal(t, int64(11), NewMoney(1050, KES).WholeUnits())
+	assert.Equal(t, int64(1), NewMoney(1, KES).WholeUnits())
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "KES 10.50", NewMoney(1050, KES).String())
+	assert.Equal(t, "USD -1.25", NewMoney(-125, USD).String())
+}
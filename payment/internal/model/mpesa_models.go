@@ -1,16 +1,17 @@
 package model
 
-type TransactionType string
+import (
+	"fmt"
 
-const (
-	CustomerPayBillOnline TransactionType = "CustomerPayBillOnline"
+	"golang.org/x/exp/slog"
 )
 
-type Currency string
+type TransactionType string
 
 const (
-	KES Currency = "KES"
+	CustomerPayBillOnline TransactionType = "CustomerPayBillOnline"
 )
+
 const BaseURL = "https://sandbox.safaricom.co.ke"
 const BusinessSortCode = "174379"
 
@@ -28,6 +29,20 @@ type STKPushRequestBody struct {
 	TransactionDesc   string `json:"TransactionDesc"`
 }
 
+// LogValue keeps the password and the customer's phone number out of the logs.
+func (b STKPushRequestBody) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("BusinessShortCode", b.BusinessShortCode),
+		slog.String("Timestamp", b.Timestamp),
+		slog.String("TransactionType", b.TransactionType),
+		slog.Int("Amount", b.Amount),
+		slog.String("PartyB", b.PartyB),
+		slog.String("CallBackURL", b.CallBackURL),
+		slog.String("AccountReference", b.AccountReference),
+		slog.String("TransactionDesc", b.TransactionDesc),
+	)
+}
+
 // STKPushRequestResponse is the response sent back after initiating an STK push request.
 type STKPushRequestResponse struct {
 	MerchantRequestID   string `json:"MerchantRequestID"`
@@ -58,6 +73,19 @@ type CallbackMetadata struct {
 	Item []Item `json:"Item"`
 }
 
+// MpesaReceiptNumberItem is the name of the callback metadata item holding the M-Pesa receipt number.
+const MpesaReceiptNumberItem = "MpesaReceiptNumber"
+
+// Value returns the value of the item with the given name or an empty string if it is not present.
+func (m CallbackMetadata) Value(name string) string {
+	for _, item := range m.Item {
+		if item.Name == name && item.Value != nil {
+			return fmt.Sprint(item.Value)
+		}
+	}
+	return ""
+}
+
 type StkCallback struct {
 	MerchantRequestID string           `json:"MerchantRequestID"`
 	CheckoutRequestID string           `json:"CheckoutRequestID"`
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
+)
+
+func TestSTKPushRequestBodyLogValue(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+
+	logger.Info("initiating stk push request", "body", STKPushRequestBody{
+		BusinessShortCode: BusinessSortCode,
+		Password:          "c2VjcmV0",
+		Amount:            10,
+		PartyA:            "254708374149",
+		PhoneNumber:       "254708374149",
+		AccountReference:  "order-1",
+	})
+
+	assert.Contains(t, buf.String(), "body.Amount=10")
+	assert.Contains(t, buf.String(), "body.AccountReference=order-1")
+	assert.NotContains(t, buf.String(), "c2VjcmV0")
+	assert.NotContains(t, buf.String(), "254708374149")
+}
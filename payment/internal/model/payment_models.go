@@ -1,7 +1,6 @@
 package model
 
 import (
-	"fmt"
 	"time"
 
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
@@ -27,45 +26,47 @@ const (
 )
 
 type Payment struct {
-	PaymentID         string        `validate:"required,uuid" db:"id"`
-	OrderID           string        `validate:"required,uuid" db:"order_id"`
-	CustomerID        string        `validate:"required,uuid" db:"customer_id"`
-	PaymentMethod     PaymentMethod `validate:"required" db:"payment_method"`
-	MerchantRequestID string        `validate:"omitempty" db:"merchant_request_id"`
-	Amount            float64       `validate:"required" db:"amount"`
-	Currency          string        `validate:"required" db:"currency"`
-	Status            PaymentStatus `validate:"required" db:"status"`
-	Description       string        `validate:"required" db:"description"`
-	ShippingCost      float64       `validate:"required" db:"shipping_cost"`
-	ProductCost       float64       `validate:"required" db:"product_cost"`
-	CreatedAt         time.Time     `db:"created_at"`
-	UpdatedAt         time.Time     `db:"updated_at"`
+	PaymentID          string        `validate:"required,uuid" db:"id"`
+	OrderID            string        `validate:"required,uuid" db:"order_id"`
+	CustomerID         string        `validate:"required,uuid" db:"customer_id"`
+	PaymentMethod      PaymentMethod `validate:"required" db:"payment_method"`
+	MerchantRequestID  string        `validate:"omitempty" db:"merchant_request_id"`
+	Amount             int64         `validate:"required,gt=0" db:"amount"` // in the minor unit of Currency e.g cents
+	Currency           Currency      `validate:"required,len=3" db:"currency"`
+	Status             PaymentStatus `validate:"required" db:"status"`
+	Description        string        `validate:"required" db:"description"`
+	ShippingCost       int64         `validate:"required" db:"shipping_cost"` // in the minor unit of Currency e.g cents
+	ProductCost        int64         `validate:"required" db:"product_cost"`  // in the minor unit of Currency e.g cents
+	MpesaReceiptNumber string        `db:"mpesa_receipt_number"`
+	TraceParent        string        `db:"trace_parent"` // links the M-Pesa callback to the trace that started the payment
+	CreatedAt          time.Time     `db:"created_at"`
+	UpdatedAt          time.Time     `db:"updated_at"`
 }
 
 func PaymentFromProto(e *paymentpb.Payment) *Payment {
+	amount := MoneyFromProto(e.Amount)
 	return &Payment{
 		OrderID:       e.OrderId,
 		PaymentMethod: PaymentMethod(e.PaymentMethod.String()),
-		Amount:        e.Amount,
-		Currency:      e.Currency,
+		Amount:        amount.Amount,
+		Currency:      amount.Currency,
 		Status:        PaymentStatus(e.Status.String()),
 	}
 }
 
 func (p *Payment) Proto() *paymentpb.Payment {
-	fmt.Println(p.Status)
 	return &paymentpb.Payment{
-		Id:            p.PaymentID,
-		OrderId:       p.OrderID,
-		PaymentMethod: paymentpb.PaymentMethod(paymentpb.PaymentMethod_value[string(p.PaymentMethod)]),
-		Amount:        p.Amount,
-		Status:        paymentpb.PaymentStatus(paymentpb.PaymentStatus_value[string(p.Status)]),
-		ProductCost:   int64(p.ProductCost),
-		CustomerId:    p.CustomerID,
-		ShippingFee:   int64(p.ShippingCost),
-		Currency:      p.Currency,
-		CreatedAt:     timestamppb.New(p.CreatedAt),
-		UpdatedAt:     timestamppb.New(p.UpdatedAt),
+		Id:                 p.PaymentID,
+		OrderId:            p.OrderID,
+		PaymentMethod:      paymentpb.PaymentMethod(paymentpb.PaymentMethod_value[string(p.PaymentMethod)]),
+		Amount:             NewMoney(p.Amount, p.Currency).Proto(),
+		Status:             paymentpb.PaymentStatus(paymentpb.PaymentStatus_value[string(p.Status)]),
+		ProductCost:        NewMoney(p.ProductCost, p.Currency).Proto(),
+		CustomerId:         p.CustomerID,
+		ShippingFee:        NewMoney(p.ShippingCost, p.Currency).Proto(),
+		CreatedAt:          timestamppb.New(p.CreatedAt),
+		UpdatedAt:          timestamppb.New(p.UpdatedAt),
+		MpesaReceiptNumber: p.MpesaReceiptNumber,
 	}
 
 }
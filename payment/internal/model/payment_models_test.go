@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	moneypb "github.com/wathuta/technical_test/protos_gen/money"
 	paymentpb "github.com/wathuta/technical_test/protos_gen/payment"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -21,15 +22,14 @@ func TestPaymentFromProto(t *testing.T) {
 			input: &paymentpb.Payment{
 				OrderId:       "order123",
 				PaymentMethod: paymentpb.PaymentMethod_CREDIT_CARD,
-				Amount:        100.0,
-				Currency:      "KES",
+				Amount:        &moneypb.Money{CurrencyCode: "KES", Amount: 10000},
 				Status:        paymentpb.PaymentStatus_COMPLETED,
 			},
 			expected: &Payment{
 				OrderID:       "order123",
 				PaymentMethod: PaymentMethod_CREDIT_CARD,
-				Amount:        100.0,
-				Currency:      "KES",
+				Amount:        10000,
+				Currency:      KES,
 				Status:        PaymentStatus_COMPLETED,
 			},
 		},
@@ -59,12 +59,12 @@ func TestPayment_Proto(t *testing.T) {
 				PaymentID:     "payment123",
 				OrderID:       "order456",
 				PaymentMethod: PaymentMethod_CREDIT_CARD,
-				Amount:        100.0,
+				Amount:        10000,
 				Status:        PaymentStatus_COMPLETED,
-				ProductCost:   80.0,
+				ProductCost:   8000,
 				CustomerID:    "customer789",
-				ShippingCost:  20.0,
-				Currency:      "USD",
+				ShippingCost:  2000,
+				Currency:      USD,
 				CreatedAt:     createdAt,
 				UpdatedAt:     updatedAt,
 			},
@@ -72,12 +72,11 @@ func TestPayment_Proto(t *testing.T) {
 				Id:            "payment123",
 				OrderId:       "order456",
 				PaymentMethod: paymentpb.PaymentMethod_CREDIT_CARD,
-				Amount:        100.0,
+				Amount:        &moneypb.Money{CurrencyCode: "USD", Amount: 10000},
 				Status:        paymentpb.PaymentStatus_COMPLETED,
-				ProductCost:   80,
+				ProductCost:   &moneypb.Money{CurrencyCode: "USD", Amount: 8000},
 				CustomerId:    "customer789",
-				ShippingFee:   20,
-				Currency:      "USD",
+				ShippingFee:   &moneypb.Money{CurrencyCode: "USD", Amount: 2000},
 				CreatedAt:     timestamppb.New(createdAt),
 				UpdatedAt:     timestamppb.New(updatedAt),
 			},
@@ -123,3 +122,18 @@ func TestCheckEnums(t *testing.T) {
 		})
 	}
 }
+
+func TestCallbackMetadata_Value(t *testing.T) {
+	metadata := CallbackMetadata{
+		Item: []Item{
+			{Name: "Amount", Value: 1.00},
+			{Name: "MpesaReceiptNumber", Value: "NLJ7RT61SV"},
+			{Name: "Balance"},
+		},
+	}
+
+	assert.Equal(t, "NLJ7RT61SV", metadata.Value(MpesaReceiptNumberItem))
+	assert.Equal(t, "1", metadata.Value("Amount"))
+	assert.Equal(t, "", metadata.Value("Balance"))
+	assert.Equal(t, "", metadata.Value("PhoneNumber"))
+}
@@ -0,0 +1,5 @@
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / 100.0,
+    ALTER COLUMN shipping_cost TYPE DOUBLE PRECISION USING shipping_cost / 100.0,
+    ALTER COLUMN product_cost TYPE DOUBLE PRECISION USING product_cost / 100.0,
+    ALTER COLUMN currency TYPE VARCHAR(255);
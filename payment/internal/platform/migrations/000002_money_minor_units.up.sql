@@ -0,0 +1,6 @@
+-- amounts are stored in the minor unit of the currency (e.g cents) to avoid floating point rounding
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT,
+    ALTER COLUMN shipping_cost TYPE BIGINT USING ROUND(shipping_cost * 100)::BIGINT,
+    ALTER COLUMN product_cost TYPE BIGINT USING ROUND(product_cost * 100)::BIGINT,
+    ALTER COLUMN currency TYPE CHAR(3);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS payments_order_id_idx;
+
+ALTER TABLE payments DROP COLUMN mpesa_receipt_number;
@@ -0,0 +1,4 @@
+-- The receipt number M-Pesa sends in the callback of a successful payment.
+ALTER TABLE payments ADD COLUMN mpesa_receipt_number VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX payments_order_id_idx ON payments (order_id);
@@ -0,0 +1,2 @@
+-- The W3C traceparent of the request that started the payment, the M-Pesa callback is linked to it.
+ALTER TABLE payments ADD COLUMN trace_parent VARCHAR(55) NOT NULL DEFAULT '';
@@ -0,0 +1,126 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Kinds of errors returned by the Daraja API, test for them with errors.Is.
+var (
+	ErrAuthFailed          = errors.New("mpesa authentication failed")
+	ErrInvalidPhoneNumber  = errors.New("invalid phone number")
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+	ErrRateLimited         = errors.New("mpesa rate limit exceeded")
+	ErrUpstreamUnavailable = errors.New("mpesa unavailable")
+)
+
+// errorCodes maps the errorCode values documented by Daraja to the kinds of errors.
+var errorCodes = map[string]error{
+	"400.008.01": ErrAuthFailed,          // Invalid Authentication passed
+	"400.008.02": ErrAuthFailed,          // Invalid grant type passed
+	"401.002.01": ErrAuthFailed,          // Invalid Access Token
+	"404.001.03": ErrAuthFailed,          // Invalid Access Token
+	"404.001.04": ErrAuthFailed,          // Invalid Authentication Header
+	"500.003.02": ErrRateLimited,         // Spike Arrest Violation
+	"500.003.03": ErrRateLimited,         // Quota Violation
+	"500.003.01": ErrUpstreamUnavailable, // Internal Server Error
+	"503.001.01": ErrUpstreamUnavailable, // Service is currently unreachable
+}
+
+// Error is a failed request to the Daraja API.
+type Error struct {
+	// Kind is one of the Err values, nil when the failure is not one of them.
+	Kind       error
+	StatusCode int
+	Code       string
+	Message    string
+
+	cause error
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("mpesa error %s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("mpesa error: %s", e.Message)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.cause}
+}
+
+// errorResponse is the body of Daraja error responses.
+type errorResponse struct {
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// responseError returns the error of a response with statusCode and body, nil for a success.
+func responseError(statusCode int, body []byte) error {
+	var resp errorResponse
+	// the body of gateway errors is not always json, the status code still tells what happened
+	_ = json.Unmarshal(body, &resp)
+	if statusCode < http.StatusBadRequest && resp.ErrorCode == "" {
+		return nil
+	}
+	if resp.ErrorMessage == "" {
+		resp.ErrorMessage = http.StatusText(statusCode)
+	}
+	return &Error{
+		Kind:       classify(statusCode, resp.ErrorCode, resp.ErrorMessage),
+		StatusCode: statusCode,
+		Code:       resp.ErrorCode,
+		Message:    resp.ErrorMessage,
+	}
+}
+
+// classify returns the kind of a Daraja error from its errorCode, falling back on the message and
+// the status code for the codes Daraja uses for several errors.
+func classify(statusCode int, code, message string) error {
+	if kind, ok := errorCodes[code]; ok {
+		return kind
+	}
+	message = strings.ToLower(message)
+	switch {
+	case strings.Contains(message, "insufficient"):
+		return ErrInsufficientFunds
+	case strings.HasPrefix(code, "400.002.") && (strings.Contains(message, "phonenumber") || strings.Contains(message, "partya")):
+		return ErrInvalidPhoneNumber
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrAuthFailed
+	// 500.001.1001 is used for rejected transactions rather than for failures of Daraja
+	case statusCode >= http.StatusInternalServerError && code != "500.001.1001":
+		return ErrUpstreamUnavailable
+	}
+	return nil
+}
+
+// transportError wraps a request that failed before a response was received.
+func transportError(err error) error {
+	return &Error{Kind: ErrUpstreamUnavailable, Message: err.Error(), cause: err}
+}
+
+// retryable reports whether a failed request can be made again. Requests that may have been
+// processed by Daraja are only repeated when they are idempotent, a repeated STK push would prompt
+// the customer twice.
+func retryable(err error, idempotent bool) bool {
+	if errors.Is(err, ErrRateLimited) {
+		// throttled requests are rejected before they are processed
+		return true
+	}
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		// the request was never sent
+		return true
+	}
+	return idempotent
+}
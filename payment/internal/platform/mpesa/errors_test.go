@@ -0,0 +1,57 @@
+package mpesa
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+	}{
+		{"expired token", http.StatusUnauthorized, `{"errorCode":"404.001.03","errorMessage":"Invalid Access Token"}`, ErrAuthFailed},
+		{"forbidden", http.StatusForbidden, ``, ErrAuthFailed},
+		{"invalid phone number", http.StatusBadRequest, `{"errorCode":"400.002.02","errorMessage":"Bad Request - Invalid PhoneNumber"}`, ErrInvalidPhoneNumber},
+		{"invalid party", http.StatusBadRequest, `{"errorCode":"400.002.02","errorMessage":"Bad Request - Invalid PartyA"}`, ErrInvalidPhoneNumber},
+		{"insufficient funds", http.StatusInternalServerError, `{"errorCode":"500.001.1001","errorMessage":"The balance is insufficient for the transaction"}`, ErrInsufficientFunds},
+		{"spike arrest", http.StatusInternalServerError, `{"errorCode":"500.003.02","errorMessage":"Error Occurred: Spike Arrest Violation"}`, ErrRateLimited},
+		{"too many requests", http.StatusTooManyRequests, ``, ErrRateLimited},
+		{"gateway error", http.StatusBadGateway, `<html>bad gateway</html>`, ErrUpstreamUnavailable},
+		{"unreachable", http.StatusServiceUnavailable, `{"errorCode":"503.001.01","errorMessage":"Service is currently unreachable"}`, ErrUpstreamUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := responseError(tt.status, []byte(tt.body))
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+
+	// transactions rejected for other reasons are not failures of Daraja
+	err := responseError(http.StatusInternalServerError, []byte(`{"errorCode":"500.001.1001","errorMessage":"Unable to lock subscriber, a transaction is already in process for the current subscriber"}`))
+	var mpesaErr *Error
+	assert.True(t, errors.As(err, &mpesaErr))
+	assert.Nil(t, mpesaErr.Kind)
+	assert.Equal(t, "500.001.1001", mpesaErr.Code)
+
+	assert.NoError(t, responseError(http.StatusOK, []byte(`{"ResponseCode":"0"}`)))
+}
+
+func TestRetryable(t *testing.T) {
+	refused := transportError(&net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	reset := transportError(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")})
+	throttled := responseError(http.StatusTooManyRequests, nil)
+	invalid := responseError(http.StatusBadRequest, []byte(`{"errorCode":"400.002.02","errorMessage":"Bad Request - Invalid PhoneNumber"}`))
+
+	assert.True(t, retryable(refused, false), "a request that was never sent can be sent again")
+	assert.True(t, retryable(throttled, false))
+	assert.True(t, retryable(reset, true))
+	assert.False(t, retryable(reset, false), "Daraja may have processed the request")
+	assert.False(t, retryable(invalid, true))
+}
@@ -2,17 +2,22 @@ package mpesa
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"time"
 
+	"github.com/wathuta/technical_test/payment/internal/logging"
+	"github.com/wathuta/technical_test/payment/internal/metrics"
 	"github.com/wathuta/technical_test/payment/internal/model"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 )
 
 type MpesaService interface {
-	InitiateSTKPushRequest(body *model.STKPushRequestBody) (*model.STKPushRequestResponse, error)
+	InitiateSTKPushRequest(ctx context.Context, body *model.STKPushRequestBody) (*model.STKPushRequestResponse, error)
 }
 
 // Mpesa is an application that will be making a transaction
@@ -21,18 +26,24 @@ type Mpesa struct {
 	consumerSecret string
 	baseURL        string
 	client         *http.Client
+	// maxAttempts and retryBackoff bound the retries of requests failing with transient errors
+	maxAttempts  int
+	retryBackoff time.Duration
 }
 
 // MpesaOpts stores all the configuration keys we need to set up a Mpesa app,
 type MpesaOpts struct {
 	ConsumerKey    string
 	ConsumerSecret string
+	// Timeout bounds each request to the Daraja API
+	Timeout time.Duration
 }
 
 // NewMpesa sets up and returns an instance of Mpesa
 func NewMpesa(m *MpesaOpts) MpesaService {
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   m.Timeout,
+		Transport: tracing.HTTPTransport(http.DefaultTransport),
 	}
 
 	return &Mpesa{
@@ -40,50 +51,104 @@ func NewMpesa(m *MpesaOpts) MpesaService {
 		consumerSecret: m.ConsumerSecret,
 		baseURL:        model.BaseURL,
 		client:         client,
+		maxAttempts:    3,
+		retryBackoff:   500 * time.Millisecond,
 	}
 }
 
-// makeRequest performs all the http requests for the specific app
+// makeRequest performs all the http requests for the specific app. Failures are returned as *Error
+// unless the caller gave up on the request.
 func (m *Mpesa) makeRequest(req *http.Request) ([]byte, error) {
+	endpoint := req.URL.Path
+	start := time.Now()
+	defer func() {
+		metrics.MpesaRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+	}()
+
+	logger := logging.FromContext(req.Context())
 	resp, err := m.client.Do(req)
 	if err != nil {
-		return nil, err
+		metrics.MpesaRequestErrors.WithLabelValues(endpoint, "transport").Inc()
+		logger.Error("mpesa request failed", "endpoint", endpoint, "error", err)
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		return nil, transportError(err)
 	}
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		metrics.MpesaRequestErrors.WithLabelValues(endpoint, fmt.Sprintf("%dxx", resp.StatusCode/100)).Inc()
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		metrics.MpesaRequestErrors.WithLabelValues(endpoint, "read_body").Inc()
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		return nil, transportError(err)
+	}
+	if err := responseError(resp.StatusCode, body); err != nil {
+		logger.Error("mpesa request failed", "endpoint", endpoint, "status", resp.StatusCode, "error", err)
 		return nil, err
 	}
+	logger.Debug("mpesa request finished", "endpoint", endpoint, "status", resp.StatusCode, "duration", time.Since(start))
 
 	return body, nil
 }
 
+// do makes the request built by newRequest, making it again after a jittered backoff while it
+// fails with an error that is safe to retry.
+func (m *Mpesa) do(ctx context.Context, idempotent bool, newRequest func() (*http.Request, error)) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		body, err := m.makeRequest(req)
+		if err == nil || attempt >= m.maxAttempts || !retryable(err, idempotent) {
+			return body, err
+		}
+
+		backoff := m.retryBackoff << (attempt - 1)
+		backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		logging.FromContext(ctx).Warn("retrying mpesa request", "endpoint", req.URL.Path, "attempt", attempt, "backoff", backoff, "error", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
 // initiateSTKPushRequest makes a http request performing an STK push request
-func (m *Mpesa) InitiateSTKPushRequest(body *model.STKPushRequestBody) (*model.STKPushRequestResponse, error) {
+func (m *Mpesa) InitiateSTKPushRequest(ctx context.Context, body *model.STKPushRequestBody) (*model.STKPushRequestResponse, error) {
 	url := fmt.Sprintf("%s/mpesa/stkpush/v1/processrequest", m.baseURL)
 
+	logging.FromContext(ctx).Debug("initiating stk push request", "body", body)
 	requestBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal stk push request json with error: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stk push request with error: %w", err)
-	}
-
-	accessTokenResponse, err := m.generateAccessToken()
+	accessTokenResponse, err := m.generateAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessTokenResponse.AccessToken))
-
-	resp, err := m.makeRequest(req)
+	resp, err := m.do(ctx, false, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(requestBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stk push request with error: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessTokenResponse.AccessToken))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("stk push request failed with error: %w", err)
 	}
@@ -93,26 +158,31 @@ func (m *Mpesa) InitiateSTKPushRequest(body *model.STKPushRequestBody) (*model.S
 		return nil, fmt.Errorf("failed to unmarshal stkpush response error: %w", err)
 	}
 
-	if stkPushResponse.ErrorCode != "0" && stkPushResponse.ErrorCode != "" {
-		return nil, fmt.Errorf("initiate stkpush request failed with error: %s", stkPushResponse.ErrorMessage)
+	if stkPushResponse.ResponseCode != "0" {
+		return nil, &Error{
+			Kind:       classify(http.StatusOK, stkPushResponse.ResponseCode, stkPushResponse.ResponseDescription),
+			StatusCode: http.StatusOK,
+			Code:       stkPushResponse.ResponseCode,
+			Message:    stkPushResponse.ResponseDescription,
+		}
 	}
 
 	return stkPushResponse, nil
 }
 
 // generateAccessToken sends a http request to generate new access token
-func (m *Mpesa) generateAccessToken() (*model.MpesaAccessTokenResponse, error) {
+func (m *Mpesa) generateAccessToken(ctx context.Context) (*model.MpesaAccessTokenResponse, error) {
 	url := fmt.Sprintf("%s/oauth/v1/generate?grant_type=client_credentials", m.baseURL)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to ucreate generate access token request with error: %w", err)
-	}
-
-	req.SetBasicAuth(m.consumerKey, m.consumerSecret)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := m.makeRequest(req)
+	resp, err := m.do(ctx, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ucreate generate access token request with error: %w", err)
+		}
+		req.SetBasicAuth(m.consumerKey, m.consumerSecret)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("generate access token request failed with error: %w", err)
 	}
@@ -121,6 +191,9 @@ func (m *Mpesa) generateAccessToken() (*model.MpesaAccessTokenResponse, error) {
 	if err := json.Unmarshal(resp, &accessTokenResponse); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal generate access token response error: %w", err)
 	}
+	if accessTokenResponse.AccessToken == "" {
+		return nil, &Error{Kind: ErrAuthFailed, StatusCode: http.StatusOK, Message: "no access token in response"}
+	}
 
 	return accessTokenResponse, nil
 }
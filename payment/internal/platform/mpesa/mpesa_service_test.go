@@ -0,0 +1,236 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wathuta/technical_test/payment/internal/metrics"
+	"github.com/wathuta/technical_test/payment/internal/model"
+)
+
+func TestMakeRequest_Metrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	m := &Mpesa{baseURL: server.URL, client: server.Client()}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/ok", nil)
+	require.NoError(t, err)
+	body, err := m.makeRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(body))
+
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/unavailable", nil)
+	require.NoError(t, err)
+	_, err = m.makeRequest(req)
+	assert.True(t, errors.Is(err, ErrUpstreamUnavailable))
+
+	server.Close()
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/closed", nil)
+	require.NoError(t, err)
+	_, err = m.makeRequest(req)
+	assert.True(t, errors.Is(err, ErrUpstreamUnavailable))
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.MpesaRequestErrors.WithLabelValues("/ok", "5xx")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.MpesaRequestErrors.WithLabelValues("/unavailable", "5xx")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.MpesaRequestErrors.WithLabelValues("/closed", "transport")))
+	assert.Equal(t, 3, testutil.CollectAndCount(metrics.MpesaRequestDuration))
+}
+
+// darajaServer answers token requests and blocks STK push requests until the request is abandoned.
+func darajaServer(t *testing.T, pushes *atomic.Int32, abandoned chan<- error) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/v1/generate" {
+			_, _ = w.Write([]byte(`{"access_token":"token","expires_in":"3599"}`))
+			return
+		}
+		pushes.Add(1)
+		// the server only notices the client went away once the body has been read
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+			abandoned <- r.Context().Err()
+		case <-time.After(5 * time.Second):
+			_, _ = w.Write([]byte(`{"ResponseCode":"0"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInitiateSTKPushRequest_CanceledBeforeSending(t *testing.T) {
+	var pushes atomic.Int32
+	server := darajaServer(t, &pushes, make(chan error, 1))
+	m := &Mpesa{baseURL: server.URL, client: server.Client()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := m.InitiateSTKPushRequest(ctx, &model.STKPushRequestBody{})
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, int32(0), pushes.Load(), "no stk push is sent for a cancelled request")
+}
+
+func TestInitiateSTKPushRequest_Deadline(t *testing.T) {
+	var pushes atomic.Int32
+	abandoned := make(chan error, 1)
+	server := darajaServer(t, &pushes, abandoned)
+	m := &Mpesa{baseURL: server.URL, client: server.Client()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := m.InitiateSTKPushRequest(ctx, &model.STKPushRequestBody{})
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	select {
+	case <-abandoned:
+	case <-time.After(time.Second):
+		t.Fatal("the stk push request was not abandoned at the deadline")
+	}
+}
+
+// scriptedServer answers each request to a path with the next of its responses, then with the last one.
+type scriptedServer struct {
+	mu        sync.Mutex
+	responses map[string][]scriptedResponse
+	calls     map[string]int
+}
+
+type scriptedResponse struct {
+	status int
+	body   string
+}
+
+func newScriptedServer(t *testing.T, responses map[string][]scriptedResponse) (*scriptedServer, *Mpesa) {
+	s := &scriptedServer{responses: responses, calls: map[string]int{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		script := s.responses[r.URL.Path]
+		resp := script[min(s.calls[r.URL.Path], len(script)-1)]
+		s.calls[r.URL.Path]++
+		w.WriteHeader(resp.status)
+		_, _ = w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(server.Close)
+	return s, &Mpesa{baseURL: server.URL, client: server.Client(), maxAttempts: 3, retryBackoff: time.Millisecond}
+}
+
+func (s *scriptedServer) callCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[path]
+}
+
+const (
+	tokenPath = "/oauth/v1/generate"
+	pushPath  = "/mpesa/stkpush/v1/processrequest"
+)
+
+var (
+	tokenOK     = scriptedResponse{http.StatusOK, `{"access_token":"token","expires_in":"3599"}`}
+	pushOK      = scriptedResponse{http.StatusOK, `{"MerchantRequestID":"29115-34620561-1","ResponseCode":"0"}`}
+	unavailable = scriptedResponse{http.StatusServiceUnavailable, `upstream connect error`}
+	throttled   = scriptedResponse{http.StatusTooManyRequests, `{"requestId":"1","errorCode":"500.003.02","errorMessage":"Error Occurred: Spike Arrest Violation"}`}
+)
+
+func TestInitiateSTKPushRequest_RetriesTokenRequests(t *testing.T) {
+	server, m := newScriptedServer(t, map[string][]scriptedResponse{
+		tokenPath: {unavailable, unavailable, tokenOK},
+		pushPath:  {pushOK},
+	})
+
+	resp, err := m.InitiateSTKPushRequest(context.Background(), &model.STKPushRequestBody{})
+	require.NoError(t, err)
+	assert.Equal(t, "29115-34620561-1", resp.MerchantRequestID)
+	assert.Equal(t, 3, server.callCount(tokenPath))
+}
+
+func TestInitiateSTKPushRequest_RetriesThrottledPushes(t *testing.T) {
+	server, m := newScriptedServer(t, map[string][]scriptedResponse{
+		tokenPath: {tokenOK},
+		pushPath:  {throttled, pushOK},
+	})
+
+	_, err := m.InitiateSTKPushRequest(context.Background(), &model.STKPushRequestBody{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.callCount(pushPath))
+}
+
+func TestInitiateSTKPushRequest_DoesNotRepeatPushes(t *testing.T) {
+	// Daraja may have prompted the customer before failing
+	server, m := newScriptedServer(t, map[string][]scriptedResponse{
+		tokenPath: {tokenOK},
+		pushPath:  {unavailable, pushOK},
+	})
+
+	_, err := m.InitiateSTKPushRequest(context.Background(), &model.STKPushRequestBody{})
+	assert.True(t, errors.Is(err, ErrUpstreamUnavailable))
+	assert.Equal(t, 1, server.callCount(pushPath))
+}
+
+func TestInitiateSTKPushRequest_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		token scriptedResponse
+		push  scriptedResponse
+		kind  error
+	}{
+		{
+			name:  "bad credentials",
+			token: scriptedResponse{http.StatusBadRequest, `{"errorCode":"400.008.01","errorMessage":"Invalid Authentication passed"}`},
+			kind:  ErrAuthFailed,
+		},
+		{
+			name:  "invalid phone number",
+			token: tokenOK,
+			push:  scriptedResponse{http.StatusBadRequest, `{"requestId":"1","errorCode":"400.002.02","errorMessage":"Bad Request - Invalid PhoneNumber"}`},
+			kind:  ErrInvalidPhoneNumber,
+		},
+		{
+			name:  "insufficient funds",
+			token: tokenOK,
+			push:  scriptedResponse{http.StatusInternalServerError, `{"requestId":"1","errorCode":"500.001.1001","errorMessage":"The balance is insufficient for the transaction"}`},
+			kind:  ErrInsufficientFunds,
+		},
+		{
+			name:  "rejected push",
+			token: tokenOK,
+			push:  scriptedResponse{http.StatusOK, `{"ResponseCode":"1","ResponseDescription":"Insufficient balance"}`},
+			kind:  ErrInsufficientFunds,
+		},
+		{
+			name:  "rate limited",
+			token: tokenOK,
+			push:  throttled,
+			kind:  ErrRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, m := newScriptedServer(t, map[string][]scriptedResponse{
+				tokenPath: {tt.token},
+				pushPath:  {tt.push},
+			})
+
+			_, err := m.InitiateSTKPushRequest(context.Background(), &model.STKPushRequestBody{})
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+			var mpesaErr *Error
+			assert.True(t, errors.As(err, &mpesaErr))
+		})
+	}
+}
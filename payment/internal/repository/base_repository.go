@@ -12,6 +12,8 @@ type Repository interface {
 	GetPaymentById(ctx context.Context, payment_id string) (*model.Payment, error)
 	GetPaymentByMerchantRequestId(ctx context.Context, merchnt_request_id string) (*model.Payment, error)
 	UpdatePaymentStatus(ctx context.Context, paymentStatus model.PaymentStatus, paymentId string) (*model.Payment, error)
+	CompletePayment(ctx context.Context, paymentId string, mpesaReceiptNumber string) (*model.Payment, error)
+	GetPaymentByOrderId(ctx context.Context, orderId string) (*model.Payment, error)
 }
 
 type repository struct {
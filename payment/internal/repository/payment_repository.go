@@ -4,15 +4,19 @@ import (
 	"context"
 
 	"github.com/wathuta/technical_test/payment/internal/model"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 )
 
 func (r *repository) CreatePayment(ctx context.Context, payment *model.Payment) (*model.Payment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CreatePayment")
+	defer span.End()
+
 	// Define the SQL query with the RETURNING clause
 	query := `
 		INSERT INTO payments
-		(id, order_id, customer_id, payment_method, merchant_request_id, amount, currency, status, description, shipping_cost, product_cost, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		RETURNING id, order_id, customer_id, payment_method, merchant_request_id, amount, currency, status, description, shipping_cost, product_cost, created_at, updated_at
+		(id, order_id, customer_id, payment_method, merchant_request_id, amount, currency, status, description, shipping_cost, product_cost, created_at, updated_at, trace_parent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, order_id, customer_id, payment_method, merchant_request_id, amount, currency, status, description, shipping_cost, product_cost, created_at, updated_at, trace_parent
 	`
 
 	// Execute the SQL query and scan the result into the createdPayment struct
@@ -21,12 +25,12 @@ func (r *repository) CreatePayment(ctx context.Context, payment *model.Payment)
 		payment.PaymentID, payment.OrderID, payment.CustomerID, payment.PaymentMethod,
 		payment.MerchantRequestID, payment.Amount, payment.Currency, payment.Status,
 		payment.Description, payment.ShippingCost, payment.ProductCost,
-		payment.CreatedAt, payment.UpdatedAt,
+		payment.CreatedAt, payment.UpdatedAt, payment.TraceParent,
 	).Scan(
 		&payment.PaymentID, &payment.OrderID, &payment.CustomerID, &payment.PaymentMethod,
 		&payment.MerchantRequestID, &payment.Amount, &payment.Currency, &payment.Status,
 		&payment.Description, &payment.ShippingCost, &payment.ProductCost,
-		&payment.CreatedAt, &payment.UpdatedAt,
+		&payment.CreatedAt, &payment.UpdatedAt, &payment.TraceParent,
 	)
 	if err != nil {
 		return nil, err
@@ -36,11 +40,14 @@ func (r *repository) CreatePayment(ctx context.Context, payment *model.Payment)
 }
 
 func (r *repository) GetPaymentById(ctx context.Context, paymentID string) (*model.Payment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetPaymentById")
+	defer span.End()
+
 	payment := model.Payment{}
 
 	query := `SELECT * FROM payments WHERE id = $1`
 
-	err := r.connection.Get(&payment, query, paymentID)
+	err := r.connection.GetContext(ctx, &payment, query, paymentID)
 	if err != nil {
 		return nil, err
 	}
@@ -50,11 +57,14 @@ func (r *repository) GetPaymentById(ctx context.Context, paymentID string) (*mod
 }
 
 func (r *repository) GetPaymentByMerchantRequestId(ctx context.Context, merchantRequestID string) (*model.Payment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetPaymentByMerchantRequestId")
+	defer span.End()
+
 	payment := model.Payment{}
 
 	query := `SELECT * FROM payments WHERE merchant_request_id = $1`
 
-	err := r.connection.Get(&payment, query, merchantRequestID)
+	err := r.connection.GetContext(ctx, &payment, query, merchantRequestID)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +74,9 @@ func (r *repository) GetPaymentByMerchantRequestId(ctx context.Context, merchant
 }
 
 func (r *repository) UpdatePaymentStatus(ctx context.Context, paymentStatus model.PaymentStatus, paymentId string) (*model.Payment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "UpdatePaymentStatus")
+	defer span.End()
+
 	// Define the SQL query to update the payment status
 	query := `
 		UPDATE payments
@@ -81,3 +94,40 @@ func (r *repository) UpdatePaymentStatus(ctx context.Context, paymentStatus mode
 
 	return &payment, nil
 }
+
+func (r *repository) CompletePayment(ctx context.Context, paymentId string, mpesaReceiptNumber string) (*model.Payment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "CompletePayment")
+	defer span.End()
+
+	query := `
+		UPDATE payments
+		SET status = $1, mpesa_receipt_number = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING id, order_id, payment_method, amount, currency, status, mpesa_receipt_number, created_at, updated_at
+	`
+
+	payment := model.Payment{}
+	err := r.connection.GetContext(ctx, &payment, query, model.PaymentStatus_COMPLETED, mpesaReceiptNumber, paymentId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *repository) GetPaymentByOrderId(ctx context.Context, orderId string) (*model.Payment, error) {
+	ctx, span := tracing.StartDBSpan(ctx, "GetPaymentByOrderId")
+	defer span.End()
+
+	payment := model.Payment{}
+
+	// an order can have several payment attempts, the completed one is preferred over the latest attempt
+	query := `SELECT * FROM payments WHERE order_id = $1 ORDER BY status = $2 DESC, created_at DESC LIMIT 1`
+
+	err := r.connection.GetContext(ctx, &payment, query, orderId, model.PaymentStatus_COMPLETED)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
@@ -5,18 +5,22 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/wathuta/technical_test/payment/internal"
 	"github.com/wathuta/technical_test/payment/internal/config"
+	"github.com/wathuta/technical_test/payment/internal/logging"
 	database "github.com/wathuta/technical_test/payment/internal/platform/postgres"
+	"github.com/wathuta/technical_test/payment/internal/tracing"
 	"golang.org/x/exp/slog"
 )
 
 func main() {
 	var err error
 	var programLevel = new(slog.LevelVar) // Info by default
-	h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel, AddSource: true})
+	// phone numbers, passwords and tokens are redacted from every log line
+	h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel, AddSource: true, ReplaceAttr: logging.Redact})
 	slog.SetDefault(slog.New(h))
 	programLevel.Set(slog.LevelDebug)
 
@@ -38,19 +42,60 @@ func main() {
 		}
 	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), "payment", os.Getenv(config.TracesExporterEnvVar))
+	if err != nil {
+		slog.Error("unable to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
 	db, err := database.OpenDBConnection()
 	if err != nil {
 		slog.Error("failed connect to DB", "error", err)
 		os.Exit(1)
 	}
 
-	service, err := internal.NewService(context.Background(), db, internal.Options{ListenAddress: os.Getenv(config.GRPCListenAddressEnvVar)})
+	opts, err := serviceOptions()
+	if err != nil {
+		slog.Error("invalid service settings", "error", err)
+		os.Exit(1)
+	}
+	service, err := internal.NewService(context.Background(), db, opts)
 	if err != nil {
 		slog.Error("failed to start service", "error", err)
 		os.Exit(1)
 	}
 	shutdownOnSignal(service)
 }
+
+// serviceOptions reads the settings of the servers from the environment.
+func serviceOptions() (internal.Options, error) {
+	opts := internal.Options{
+		ListenAddress:        os.Getenv(config.GRPCListenAddressEnvVar),
+		HTTPListenAddress:    os.Getenv(config.HTTPListenAddressEnvVar),
+		MetricsListenAddress: os.Getenv(config.MetricsListenAddressEnvVar),
+		GatewayListenAddress: os.Getenv(config.GatewayListenAddressEnvVar),
+		Reflection:           os.Getenv(config.GRPCReflectionEnvVar) == "true",
+	}
+	var err error
+	if opts.GracefulShutdownTimeout, err = config.DurationFromEnv(config.GracefulShutdownTimeoutEnvVar, 30*time.Second); err != nil {
+		return opts, err
+	}
+	if opts.HTTPReadTimeout, err = config.DurationFromEnv(config.HTTPReadTimeoutEnvVar, 0); err != nil {
+		return opts, err
+	}
+	if opts.HTTPWriteTimeout, err = config.DurationFromEnv(config.HTTPWriteTimeoutEnvVar, 0); err != nil {
+		return opts, err
+	}
+	maxBodyBytes, err := config.IntFromEnv(config.HTTPMaxBodyBytesEnvVar, 0)
+	opts.HTTPMaxBodyBytes = int64(maxBodyBytes)
+	return opts, err
+}
+
 func waitForShutdownSignal() string {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
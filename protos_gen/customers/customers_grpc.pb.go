@@ -20,8 +20,14 @@ const _ = grpc.SupportPackageIsVersion7
 type CustomerServiceClient interface {
 	CreateCustomer(ctx context.Context, in *CreateCustomerRequest, opts ...grpc.CallOption) (*CreateCustomerResponse, error)
 	GetCustomerById(ctx context.Context, in *GetCustomerByIdRequest, opts ...grpc.CallOption) (*GetCustomerByIdResponse, error)
+	ListCustomers(ctx context.Context, in *ListCustomersRequest, opts ...grpc.CallOption) (*ListCustomersResponse, error)
 	UpdateCustomer(ctx context.Context, in *UpdateCustomerRequest, opts ...grpc.CallOption) (*UpdateCustomerResponse, error)
 	DeleteCustomer(ctx context.Context, in *DeleteCustomerRequest, opts ...grpc.CallOption) (*DeleteCustomerResponse, error)
+	CreateCustomerAddress(ctx context.Context, in *CreateCustomerAddressRequest, opts ...grpc.CallOption) (*CreateCustomerAddressResponse, error)
+	GetCustomerAddress(ctx context.Context, in *GetCustomerAddressRequest, opts ...grpc.CallOption) (*GetCustomerAddressResponse, error)
+	ListCustomerAddresses(ctx context.Context, in *ListCustomerAddressesRequest, opts ...grpc.CallOption) (*ListCustomerAddressesResponse, error)
+	UpdateCustomerAddress(ctx context.Context, in *UpdateCustomerAddressRequest, opts ...grpc.CallOption) (*UpdateCustomerAddressResponse, error)
+	DeleteCustomerAddress(ctx context.Context, in *DeleteCustomerAddressRequest, opts ...grpc.CallOption) (*DeleteCustomerAddressResponse, error)
 }
 
 type customerServiceClient struct {
@@ -50,6 +56,15 @@ func (c *customerServiceClient) GetCustomerById(ctx context.Context, in *GetCust
 	return out, nil
 }
 
+func (c *customerServiceClient) ListCustomers(ctx context.Context, in *ListCustomersRequest, opts ...grpc.CallOption) (*ListCustomersResponse, error) {
+	out := new(ListCustomersResponse)
+	err := c.cc.Invoke(ctx, "/customers.CustomerService/ListCustomers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *customerServiceClient) UpdateCustomer(ctx context.Context, in *UpdateCustomerRequest, opts ...grpc.CallOption) (*UpdateCustomerResponse, error) {
 	out := new(UpdateCustomerResponse)
 	err := c.cc.Invoke(ctx, "/customers.CustomerService/UpdateCustomer", in, out, opts...)
@@ -68,14 +83,65 @@ func (c *customerServiceClient) DeleteCustomer(ctx context.Context, in *DeleteCu
 	return out, nil
 }
 
+func (c *customerServiceClient) CreateCustomerAddress(ctx context.Context, in *CreateCustomerAddressRequest, opts ...grpc.CallOption) (*CreateCustomerAddressResponse, error) {
+	out := new(CreateCustomerAddressResponse)
+	err := c.cc.Invoke(ctx, "/customers.CustomerService/CreateCustomerAddress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *customerServiceClient) GetCustomerAddress(ctx context.Context, in *GetCustomerAddressRequest, opts ...grpc.CallOption) (*GetCustomerAddressResponse, error) {
+	out := new(GetCustomerAddressResponse)
+	err := c.cc.Invoke(ctx, "/customers.CustomerService/GetCustomerAddress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *customerServiceClient) ListCustomerAddresses(ctx context.Context, in *ListCustomerAddressesRequest, opts ...grpc.CallOption) (*ListCustomerAddressesResponse, error) {
+	out := new(ListCustomerAddressesResponse)
+	err := c.cc.Invoke(ctx, "/customers.CustomerService/ListCustomerAddresses", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *customerServiceClient) UpdateCustomerAddress(ctx context.Context, in *UpdateCustomerAddressRequest, opts ...grpc.CallOption) (*UpdateCustomerAddressResponse, error) {
+	out := new(UpdateCustomerAddressResponse)
+	err := c.cc.Invoke(ctx, "/customers.CustomerService/UpdateCustomerAddress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *customerServiceClient) DeleteCustomerAddress(ctx context.Context, in *DeleteCustomerAddressRequest, opts ...grpc.CallOption) (*DeleteCustomerAddressResponse, error) {
+	out := new(DeleteCustomerAddressResponse)
+	err := c.cc.Invoke(ctx, "/customers.CustomerService/DeleteCustomerAddress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CustomerServiceServer is the server API for CustomerService service.
 // All implementations must embed UnimplementedCustomerServiceServer
 // for forward compatibility
 type CustomerServiceServer interface {
 	CreateCustomer(context.Context, *CreateCustomerRequest) (*CreateCustomerResponse, error)
 	GetCustomerById(context.Context, *GetCustomerByIdRequest) (*GetCustomerByIdResponse, error)
+	ListCustomers(context.Context, *ListCustomersRequest) (*ListCustomersResponse, error)
 	UpdateCustomer(context.Context, *UpdateCustomerRequest) (*UpdateCustomerResponse, error)
 	DeleteCustomer(context.Context, *DeleteCustomerRequest) (*DeleteCustomerResponse, error)
+	CreateCustomerAddress(context.Context, *CreateCustomerAddressRequest) (*CreateCustomerAddressResponse, error)
+	GetCustomerAddress(context.Context, *GetCustomerAddressRequest) (*GetCustomerAddressResponse, error)
+	ListCustomerAddresses(context.Context, *ListCustomerAddressesRequest) (*ListCustomerAddressesResponse, error)
+	UpdateCustomerAddress(context.Context, *UpdateCustomerAddressRequest) (*UpdateCustomerAddressResponse, error)
+	DeleteCustomerAddress(context.Context, *DeleteCustomerAddressRequest) (*DeleteCustomerAddressResponse, error)
 	mustEmbedUnimplementedCustomerServiceServer()
 }
 
@@ -89,12 +155,30 @@ func (UnimplementedCustomerServiceServer) CreateCustomer(context.Context, *Creat
 func (UnimplementedCustomerServiceServer) GetCustomerById(context.Context, *GetCustomerByIdRequest) (*GetCustomerByIdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCustomerById not implemented")
 }
+func (UnimplementedCustomerServiceServer) ListCustomers(context.Context, *ListCustomersRequest) (*ListCustomersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCustomers not implemented")
+}
 func (UnimplementedCustomerServiceServer) UpdateCustomer(context.Context, *UpdateCustomerRequest) (*UpdateCustomerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateCustomer not implemented")
 }
 func (UnimplementedCustomerServiceServer) DeleteCustomer(context.Context, *DeleteCustomerRequest) (*DeleteCustomerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteCustomer not implemented")
 }
+func (UnimplementedCustomerServiceServer) CreateCustomerAddress(context.Context, *CreateCustomerAddressRequest) (*CreateCustomerAddressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCustomerAddress not implemented")
+}
+func (UnimplementedCustomerServiceServer) GetCustomerAddress(context.Context, *GetCustomerAddressRequest) (*GetCustomerAddressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCustomerAddress not implemented")
+}
+func (UnimplementedCustomerServiceServer) ListCustomerAddresses(context.Context, *ListCustomerAddressesRequest) (*ListCustomerAddressesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCustomerAddresses not implemented")
+}
+func (UnimplementedCustomerServiceServer) UpdateCustomerAddress(context.Context, *UpdateCustomerAddressRequest) (*UpdateCustomerAddressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCustomerAddress not implemented")
+}
+func (UnimplementedCustomerServiceServer) DeleteCustomerAddress(context.Context, *DeleteCustomerAddressRequest) (*DeleteCustomerAddressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCustomerAddress not implemented")
+}
 func (UnimplementedCustomerServiceServer) mustEmbedUnimplementedCustomerServiceServer() {}
 
 // UnsafeCustomerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -144,6 +228,24 @@ func _CustomerService_GetCustomerById_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CustomerService_ListCustomers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCustomersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomerServiceServer).ListCustomers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/customers.CustomerService/ListCustomers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomerServiceServer).ListCustomers(ctx, req.(*ListCustomersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CustomerService_UpdateCustomer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateCustomerRequest)
 	if err := dec(in); err != nil {
@@ -180,6 +282,96 @@ func _CustomerService_DeleteCustomer_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CustomerService_CreateCustomerAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCustomerAddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomerServiceServer).CreateCustomerAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/customers.CustomerService/CreateCustomerAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomerServiceServer).CreateCustomerAddress(ctx, req.(*CreateCustomerAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CustomerService_GetCustomerAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCustomerAddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomerServiceServer).GetCustomerAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/customers.CustomerService/GetCustomerAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomerServiceServer).GetCustomerAddress(ctx, req.(*GetCustomerAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CustomerService_ListCustomerAddresses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCustomerAddressesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomerServiceServer).ListCustomerAddresses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/customers.CustomerService/ListCustomerAddresses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomerServiceServer).ListCustomerAddresses(ctx, req.(*ListCustomerAddressesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CustomerService_UpdateCustomerAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCustomerAddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomerServiceServer).UpdateCustomerAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/customers.CustomerService/UpdateCustomerAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomerServiceServer).UpdateCustomerAddress(ctx, req.(*UpdateCustomerAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CustomerService_DeleteCustomerAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCustomerAddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomerServiceServer).DeleteCustomerAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/customers.CustomerService/DeleteCustomerAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomerServiceServer).DeleteCustomerAddress(ctx, req.(*DeleteCustomerAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CustomerService_ServiceDesc is the grpc.ServiceDesc for CustomerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -195,6 +387,10 @@ var CustomerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetCustomerById",
 			Handler:    _CustomerService_GetCustomerById_Handler,
 		},
+		{
+			MethodName: "ListCustomers",
+			Handler:    _CustomerService_ListCustomers_Handler,
+		},
 		{
 			MethodName: "UpdateCustomer",
 			Handler:    _CustomerService_UpdateCustomer_Handler,
@@ -203,6 +399,26 @@ var CustomerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteCustomer",
 			Handler:    _CustomerService_DeleteCustomer_Handler,
 		},
+		{
+			MethodName: "CreateCustomerAddress",
+			Handler:    _CustomerService_CreateCustomerAddress_Handler,
+		},
+		{
+			MethodName: "GetCustomerAddress",
+			Handler:    _CustomerService_GetCustomerAddress_Handler,
+		},
+		{
+			MethodName: "ListCustomerAddresses",
+			Handler:    _CustomerService_ListCustomerAddresses_Handler,
+		},
+		{
+			MethodName: "UpdateCustomerAddress",
+			Handler:    _CustomerService_UpdateCustomerAddress_Handler,
+		},
+		{
+			MethodName: "DeleteCustomerAddress",
+			Handler:    _CustomerService_DeleteCustomerAddress_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "protos/orders/customers.proto",
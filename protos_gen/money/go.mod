@@ -0,0 +1,5 @@
+module github.com/wathuta/technical_test/protos_gen/money
+
+go 1.21.1
+
+require google.golang.org/protobuf v1.31.0
@@ -34,6 +34,10 @@ type OrderServiceClient interface {
 	GetOrderDetailsById(ctx context.Context, in *GetOrderDetailByIdRequest, opts ...grpc.CallOption) (*GetOrderDetailByIdResponse, error)
 	// Get Order details by UserID
 	ListOrderDetailsByOrderId(ctx context.Context, in *ListOrderDetailsByOrderIdRequest, opts ...grpc.CallOption) (*ListOrderDetailsByOrderIdResponse, error)
+	// Get the invoice of an order rendered as HTML and PDF
+	GetInvoice(ctx context.Context, in *GetInvoiceRequest, opts ...grpc.CallOption) (*GetInvoiceResponse, error)
+	// List the delivery slots with capacity left for a delivery address and shipping method
+	ListAvailableDeliverySlots(ctx context.Context, in *ListAvailableDeliverySlotsRequest, opts ...grpc.CallOption) (*ListAvailableDeliverySlotsResponse, error)
 }
 
 type orderServiceClient struct {
@@ -116,6 +120,24 @@ func (c *orderServiceClient) ListOrderDetailsByOrderId(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *orderServiceClient) GetInvoice(ctx context.Context, in *GetInvoiceRequest, opts ...grpc.CallOption) (*GetInvoiceResponse, error) {
+	out := new(GetInvoiceResponse)
+	err := c.cc.Invoke(ctx, "/orders.OrderService/GetInvoice", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) ListAvailableDeliverySlots(ctx context.Context, in *ListAvailableDeliverySlotsRequest, opts ...grpc.CallOption) (*ListAvailableDeliverySlotsResponse, error) {
+	out := new(ListAvailableDeliverySlotsResponse)
+	err := c.cc.Invoke(ctx, "/orders.OrderService/ListAvailableDeliverySlots", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // OrderServiceServer is the server API for OrderService service.
 // All implementations must embed UnimplementedOrderServiceServer
 // for forward compatibility
@@ -136,6 +158,10 @@ type OrderServiceServer interface {
 	GetOrderDetailsById(context.Context, *GetOrderDetailByIdRequest) (*GetOrderDetailByIdResponse, error)
 	// Get Order details by UserID
 	ListOrderDetailsByOrderId(context.Context, *ListOrderDetailsByOrderIdRequest) (*ListOrderDetailsByOrderIdResponse, error)
+	// Get the invoice of an order rendered as HTML and PDF
+	GetInvoice(context.Context, *GetInvoiceRequest) (*GetInvoiceResponse, error)
+	// List the delivery slots with capacity left for a delivery address and shipping method
+	ListAvailableDeliverySlots(context.Context, *ListAvailableDeliverySlotsRequest) (*ListAvailableDeliverySlotsResponse, error)
 	mustEmbedUnimplementedOrderServiceServer()
 }
 
@@ -167,6 +193,12 @@ func (UnimplementedOrderServiceServer) GetOrderDetailsById(context.Context, *Get
 func (UnimplementedOrderServiceServer) ListOrderDetailsByOrderId(context.Context, *ListOrderDetailsByOrderIdRequest) (*ListOrderDetailsByOrderIdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListOrderDetailsByOrderId not implemented")
 }
+func (UnimplementedOrderServiceServer) GetInvoice(context.Context, *GetInvoiceRequest) (*GetInvoiceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInvoice not implemented")
+}
+func (UnimplementedOrderServiceServer) ListAvailableDeliverySlots(context.Context, *ListAvailableDeliverySlotsRequest) (*ListAvailableDeliverySlotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAvailableDeliverySlots not implemented")
+}
 func (UnimplementedOrderServiceServer) mustEmbedUnimplementedOrderServiceServer() {}
 
 // UnsafeOrderServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -324,6 +356,42 @@ func _OrderService_ListOrderDetailsByOrderId_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderService_GetInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).GetInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.OrderService/GetInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).GetInvoice(ctx, req.(*GetInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_ListAvailableDeliverySlots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAvailableDeliverySlotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).ListAvailableDeliverySlots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.OrderService/ListAvailableDeliverySlots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).ListAvailableDeliverySlots(ctx, req.(*ListAvailableDeliverySlotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // OrderService_ServiceDesc is the grpc.ServiceDesc for OrderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -363,6 +431,300 @@ var OrderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListOrderDetailsByOrderId",
 			Handler:    _OrderService_ListOrderDetailsByOrderId_Handler,
 		},
+		{
+			MethodName: "GetInvoice",
+			Handler:    _OrderService_GetInvoice_Handler,
+		},
+		{
+			MethodName: "ListAvailableDeliverySlots",
+			Handler:    _OrderService_ListAvailableDeliverySlots_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "protos/orders/orders.proto",
+}
+
+// PromotionServiceClient is the client API for PromotionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PromotionServiceClient interface {
+	CreatePromotion(ctx context.Context, in *CreatePromotionRequest, opts ...grpc.CallOption) (*CreatePromotionResponse, error)
+	GetPromotionByCode(ctx context.Context, in *GetPromotionByCodeRequest, opts ...grpc.CallOption) (*GetPromotionByCodeResponse, error)
+}
+
+type promotionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPromotionServiceClient(cc grpc.ClientConnInterface) PromotionServiceClient {
+	return &promotionServiceClient{cc}
+}
+
+func (c *promotionServiceClient) CreatePromotion(ctx context.Context, in *CreatePromotionRequest, opts ...grpc.CallOption) (*CreatePromotionResponse, error) {
+	out := new(CreatePromotionResponse)
+	err := c.cc.Invoke(ctx, "/orders.PromotionService/CreatePromotion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *promotionServiceClient) GetPromotionByCode(ctx context.Context, in *GetPromotionByCodeRequest, opts ...grpc.CallOption) (*GetPromotionByCodeResponse, error) {
+	out := new(GetPromotionByCodeResponse)
+	err := c.cc.Invoke(ctx, "/orders.PromotionService/GetPromotionByCode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PromotionServiceServer is the server API for PromotionService service.
+// All implementations must embed UnimplementedPromotionServiceServer
+// for forward compatibility
+type PromotionServiceServer interface {
+	CreatePromotion(context.Context, *CreatePromotionRequest) (*CreatePromotionResponse, error)
+	GetPromotionByCode(context.Context, *GetPromotionByCodeRequest) (*GetPromotionByCodeResponse, error)
+	mustEmbedUnimplementedPromotionServiceServer()
+}
+
+// UnimplementedPromotionServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPromotionServiceServer struct {
+}
+
+func (UnimplementedPromotionServiceServer) CreatePromotion(context.Context, *CreatePromotionRequest) (*CreatePromotionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePromotion not implemented")
+}
+func (UnimplementedPromotionServiceServer) GetPromotionByCode(context.Context, *GetPromotionByCodeRequest) (*GetPromotionByCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPromotionByCode not implemented")
+}
+func (UnimplementedPromotionServiceServer) mustEmbedUnimplementedPromotionServiceServer() {}
+
+// UnsafePromotionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PromotionServiceServer will
+// result in compilation errors.
+type UnsafePromotionServiceServer interface {
+	mustEmbedUnimplementedPromotionServiceServer()
+}
+
+func RegisterPromotionServiceServer(s grpc.ServiceRegistrar, srv PromotionServiceServer) {
+	s.RegisterService(&PromotionService_ServiceDesc, srv)
+}
+
+func _PromotionService_CreatePromotion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePromotionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PromotionServiceServer).CreatePromotion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.PromotionService/CreatePromotion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PromotionServiceServer).CreatePromotion(ctx, req.(*CreatePromotionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PromotionService_GetPromotionByCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPromotionByCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PromotionServiceServer).GetPromotionByCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.PromotionService/GetPromotionByCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PromotionServiceServer).GetPromotionByCode(ctx, req.(*GetPromotionByCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PromotionService_ServiceDesc is the grpc.ServiceDesc for PromotionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PromotionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orders.PromotionService",
+	HandlerType: (*PromotionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePromotion",
+			Handler:    _PromotionService_CreatePromotion_Handler,
+		},
+		{
+			MethodName: "GetPromotionByCode",
+			Handler:    _PromotionService_GetPromotionByCode_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "protos/orders/orders.proto",
+}
+
+// ShipmentServiceClient is the client API for ShipmentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ShipmentServiceClient interface {
+	// Hand an order to a carrier
+	CreateShipment(ctx context.Context, in *CreateShipmentRequest, opts ...grpc.CallOption) (*CreateShipmentResponse, error)
+	// Record a tracking event reported by the carrier. Events already recorded are ignored.
+	RecordShipmentEvent(ctx context.Context, in *RecordShipmentEventRequest, opts ...grpc.CallOption) (*RecordShipmentEventResponse, error)
+	// Track a shipment by its tracking number, it can be called without a token
+	TrackShipment(ctx context.Context, in *TrackShipmentRequest, opts ...grpc.CallOption) (*TrackShipmentResponse, error)
+}
+
+type shipmentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewShipmentServiceClient(cc grpc.ClientConnInterface) ShipmentServiceClient {
+	return &shipmentServiceClient{cc}
+}
+
+func (c *shipmentServiceClient) CreateShipment(ctx context.Context, in *CreateShipmentRequest, opts ...grpc.CallOption) (*CreateShipmentResponse, error) {
+	out := new(CreateShipmentResponse)
+	err := c.cc.Invoke(ctx, "/orders.ShipmentService/CreateShipment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shipmentServiceClient) RecordShipmentEvent(ctx context.Context, in *RecordShipmentEventRequest, opts ...grpc.CallOption) (*RecordShipmentEventResponse, error) {
+	out := new(RecordShipmentEventResponse)
+	err := c.cc.Invoke(ctx, "/orders.ShipmentService/RecordShipmentEvent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shipmentServiceClient) TrackShipment(ctx context.Context, in *TrackShipmentRequest, opts ...grpc.CallOption) (*TrackShipmentResponse, error) {
+	out := new(TrackShipmentResponse)
+	err := c.cc.Invoke(ctx, "/orders.ShipmentService/TrackShipment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ShipmentServiceServer is the server API for ShipmentService service.
+// All implementations must embed UnimplementedShipmentServiceServer
+// for forward compatibility
+type ShipmentServiceServer interface {
+	// Hand an order to a carrier
+	CreateShipment(context.Context, *CreateShipmentRequest) (*CreateShipmentResponse, error)
+	// Record a tracking event reported by the carrier. Events already recorded are ignored.
+	RecordShipmentEvent(context.Context, *RecordShipmentEventRequest) (*RecordShipmentEventResponse, error)
+	// Track a shipment by its tracking number, it can be called without a token
+	TrackShipment(context.Context, *TrackShipmentRequest) (*TrackShipmentResponse, error)
+	mustEmbedUnimplementedShipmentServiceServer()
+}
+
+// UnimplementedShipmentServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedShipmentServiceServer struct {
+}
+
+func (UnimplementedShipmentServiceServer) CreateShipment(context.Context, *CreateShipmentRequest) (*CreateShipmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateShipment not implemented")
+}
+func (UnimplementedShipmentServiceServer) RecordShipmentEvent(context.Context, *RecordShipmentEventRequest) (*RecordShipmentEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordShipmentEvent not implemented")
+}
+func (UnimplementedShipmentServiceServer) TrackShipment(context.Context, *TrackShipmentRequest) (*TrackShipmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TrackShipment not implemented")
+}
+func (UnimplementedShipmentServiceServer) mustEmbedUnimplementedShipmentServiceServer() {}
+
+// UnsafeShipmentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ShipmentServiceServer will
+// result in compilation errors.
+type UnsafeShipmentServiceServer interface {
+	mustEmbedUnimplementedShipmentServiceServer()
+}
+
+func RegisterShipmentServiceServer(s grpc.ServiceRegistrar, srv ShipmentServiceServer) {
+	s.RegisterService(&ShipmentService_ServiceDesc, srv)
+}
+
+func _ShipmentService_CreateShipment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateShipmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShipmentServiceServer).CreateShipment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.ShipmentService/CreateShipment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShipmentServiceServer).CreateShipment(ctx, req.(*CreateShipmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShipmentService_RecordShipmentEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordShipmentEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShipmentServiceServer).RecordShipmentEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.ShipmentService/RecordShipmentEvent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShipmentServiceServer).RecordShipmentEvent(ctx, req.(*RecordShipmentEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShipmentService_TrackShipment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrackShipmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShipmentServiceServer).TrackShipment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.ShipmentService/TrackShipment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShipmentServiceServer).TrackShipment(ctx, req.(*TrackShipmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ShipmentService_ServiceDesc is the grpc.ServiceDesc for ShipmentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ShipmentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orders.ShipmentService",
+	HandlerType: (*ShipmentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateShipment",
+			Handler:    _ShipmentService_CreateShipment_Handler,
+		},
+		{
+			MethodName: "RecordShipmentEvent",
+			Handler:    _ShipmentService_RecordShipmentEvent_Handler,
+		},
+		{
+			MethodName: "TrackShipment",
+			Handler:    _ShipmentService_TrackShipment_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "protos/orders/orders.proto",
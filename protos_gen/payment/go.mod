@@ -0,0 +1,22 @@
+module github.com/wathuta/technical_test/protos_gen/payment
+
+go 1.21.1
+
+require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.16.0
+	github.com/wathuta/technical_test/protos_gen/money v0.0.0-00010101000000-000000000000
+	google.golang.org/genproto/googleapis/api v0.0.0-20230711160842-782d3b101e98
+	google.golang.org/grpc v1.58.2
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.12.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/text v0.11.0 // indirect
+	google.golang.org/genproto v0.0.0-20230711160842-782d3b101e98 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230711160842-782d3b101e98 // indirect
+)
+
+replace github.com/wathuta/technical_test/protos_gen/money => ../money
@@ -22,6 +22,9 @@ type PaymentServiceClient interface {
 	CreatePayment(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*CreatePaymentResponse, error)
 	// GetPayment retrieves a payment by ID.
 	GetPaymentById(ctx context.Context, in *GetPaymentByIdRequest, opts ...grpc.CallOption) (*GetPaymentByIdResponse, error)
+	// GetPaymentByOrderId retrieves the payment of an order. When an order has been paid for more than once
+	// the completed payment is returned, otherwise the latest attempt.
+	GetPaymentByOrderId(ctx context.Context, in *GetPaymentByOrderIdRequest, opts ...grpc.CallOption) (*GetPaymentByOrderIdResponse, error)
 }
 
 type paymentServiceClient struct {
@@ -50,6 +53,15 @@ func (c *paymentServiceClient) GetPaymentById(ctx context.Context, in *GetPaymen
 	return out, nil
 }
 
+func (c *paymentServiceClient) GetPaymentByOrderId(ctx context.Context, in *GetPaymentByOrderIdRequest, opts ...grpc.CallOption) (*GetPaymentByOrderIdResponse, error) {
+	out := new(GetPaymentByOrderIdResponse)
+	err := c.cc.Invoke(ctx, "/ecommerce.PaymentService/GetPaymentByOrderId", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility
@@ -58,6 +70,9 @@ type PaymentServiceServer interface {
 	CreatePayment(context.Context, *CreatePaymentRequest) (*CreatePaymentResponse, error)
 	// GetPayment retrieves a payment by ID.
 	GetPaymentById(context.Context, *GetPaymentByIdRequest) (*GetPaymentByIdResponse, error)
+	// GetPaymentByOrderId retrieves the payment of an order. When an order has been paid for more than once
+	// the completed payment is returned, otherwise the latest attempt.
+	GetPaymentByOrderId(context.Context, *GetPaymentByOrderIdRequest) (*GetPaymentByOrderIdResponse, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -71,6 +86,9 @@ func (UnimplementedPaymentServiceServer) CreatePayment(context.Context, *CreateP
 func (UnimplementedPaymentServiceServer) GetPaymentById(context.Context, *GetPaymentByIdRequest) (*GetPaymentByIdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentById not implemented")
 }
+func (UnimplementedPaymentServiceServer) GetPaymentByOrderId(context.Context, *GetPaymentByOrderIdRequest) (*GetPaymentByOrderIdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentByOrderId not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 
 // UnsafePaymentServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -120,6 +138,24 @@ func _PaymentService_GetPaymentById_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_GetPaymentByOrderId_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentByOrderIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetPaymentByOrderId(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.PaymentService/GetPaymentByOrderId",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetPaymentByOrderId(ctx, req.(*GetPaymentByOrderIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -135,6 +171,10 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPaymentById",
 			Handler:    _PaymentService_GetPaymentById_Handler,
 		},
+		{
+			MethodName: "GetPaymentByOrderId",
+			Handler:    _PaymentService_GetPaymentByOrderId_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "protos/payment/payment.proto",